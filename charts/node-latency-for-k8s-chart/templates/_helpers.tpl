@@ -60,3 +60,11 @@ Create the name of the service account to use
 {{- default "default" .Values.serviceAccount.name }}
 {{- end }}
 {{- end }}
+
+{{/*
+Selector labels of the aggregation server, which are distinct from the DaemonSet's
+*/}}
+{{- define "node-latency-for-k8s.serverSelectorLabels" -}}
+app.kubernetes.io/name: {{ include "node-latency-for-k8s.name" . }}-server
+app.kubernetes.io/instance: {{ .Release.Name }}
+{{- end }}
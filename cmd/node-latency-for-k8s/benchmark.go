@@ -0,0 +1,107 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+)
+
+// benchmarkCommand is the subcommand that launches instances and compares the measurements they report
+const benchmarkCommand = "benchmark"
+
+type BenchmarkOptions struct {
+	LaunchTemplate string
+	InstanceTypes  string
+	AMIs           string
+	Count          int
+	SubnetID       string
+	S3URI          string
+	TimeoutSeconds int
+	KeepInstances  bool
+	Output         string
+}
+
+// runBenchmark launches instances of each instance type and AMI, waits for their measurements in S3, and prints the comparison
+func runBenchmark(args []string) {
+	f := flag.NewFlagSet(fmt.Sprintf("%s %s", path.Base(os.Args[0]), benchmarkCommand), flag.ExitOnError)
+	f.Usage = HelpFunc(f)
+	options := BenchmarkOptions{}
+	f.StringVar(&options.LaunchTemplate, "launch-template", strEnv("LAUNCH_TEMPLATE", ""), "name of the launch template to launch the instances with, its user data must join the instances to a cluster running the DaemonSet with --s3-bucket")
+	f.StringVar(&options.InstanceTypes, "instance-types", strEnv("INSTANCE_TYPES", ""), "comma separated instance types to benchmark, i.e. m5.large,m6i.large")
+	f.StringVar(&options.AMIs, "amis", strEnv("AMIS", ""), "(optional) comma separated AMI IDs to benchmark, default: the launch template's AMI")
+	f.IntVar(&options.Count, "count", intEnv("COUNT", 3), "Number of instances to launch of each instance type and AMI, default: 3")
+	f.StringVar(&options.SubnetID, "subnet-id", strEnv("SUBNET_ID", ""), "(optional) subnet to launch the instances in, default: the launch template's subnet")
+	f.StringVar(&options.S3URI, "s3-uri", strEnv("S3_URI", ""), "s3://<s3-bucket>/<s3-prefix>/<cluster-name> the DaemonSet uploads the measurements to")
+	f.IntVar(&options.TimeoutSeconds, "timeout", intEnv("TIMEOUT", 900), "Timeout in seconds to wait for every instance to report a measurement, default: 900")
+	f.BoolVar(&options.KeepInstances, "keep-instances", boolEnv("KEEP_INSTANCES", false), "Do not terminate the instances after the benchmark, default: false")
+	f.StringVar(&options.Output, "output", strEnv("OUTPUT", "markdown"), "output type (markdown or json), default: markdown")
+	lo.Must0(f.Parse(args))
+
+	instanceTypes := lo.Filter(strings.Split(options.InstanceTypes, ","), func(t string, _ int) bool { return t != "" })
+	if options.LaunchTemplate == "" || len(instanceTypes) == 0 || !strings.HasPrefix(options.S3URI, "s3://") {
+		log.Fatalf("--launch-template, --instance-types, and an s3:// --s3-uri are required")
+	}
+	if options.Count < 1 {
+		log.Fatalf("--count must be at least 1")
+	}
+
+	ctx := context.Background()
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		log.Fatalf("unable to load AWS SDK config, %s", err)
+	}
+	result, err := latency.Benchmark(ctx, ec2.NewFromConfig(cfg), s3.NewFromConfig(cfg), latency.BenchmarkOptions{
+		LaunchTemplate: options.LaunchTemplate,
+		InstanceTypes:  instanceTypes,
+		AMIIDs:         lo.Filter(strings.Split(options.AMIs, ","), func(a string, _ int) bool { return a != "" }),
+		Count:          options.Count,
+		SubnetID:       options.SubnetID,
+		S3URI:          options.S3URI,
+		Timeout:        time.Duration(options.TimeoutSeconds) * time.Second,
+		KeepInstances:  options.KeepInstances,
+	})
+	if err != nil {
+		log.Printf("Benchmark %s: %s\n", result.ID, err)
+	}
+	log.Printf("Benchmark %s launched %d instances, %d reported a measurement\n", result.ID, len(result.InstanceIDs), len(result.Measurements))
+	for _, instanceID := range result.Missing {
+		log.Printf("Instance %s did not report a measurement\n", instanceID)
+	}
+	switch options.Output {
+	case "json":
+		resultJSON, err := json.MarshalIndent(result, "", "    ")
+		if err != nil {
+			log.Fatalf("unable to marshal json output: %v", err)
+		}
+		fmt.Println(string(resultJSON))
+	default:
+		latency.ChartComparison(os.Stdout, result.Comparison)
+	}
+}
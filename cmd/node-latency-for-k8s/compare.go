@@ -0,0 +1,88 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+)
+
+// compareCommand is the subcommand that compares stored measurements across AMIs and instance types
+const compareCommand = "compare"
+
+type CompareOptions struct {
+	Output string
+}
+
+// runCompare prints the p50, p90, and p99 of each event by AMI ID and instance type of the measurements in the files, directories, or s3:// URIs
+func runCompare(args []string) {
+	f := flag.NewFlagSet(fmt.Sprintf("%s %s", path.Base(os.Args[0]), compareCommand), flag.ExitOnError)
+	f.Usage = HelpFunc(f)
+	options := CompareOptions{}
+	f.StringVar(&options.Output, "output", strEnv("OUTPUT", "markdown"), "output type (markdown or json), default: markdown")
+	lo.Must0(f.Parse(args))
+	if f.NArg() == 0 {
+		log.Fatalf("At least one measurement file, directory, or s3://<bucket>/<prefix> is required")
+	}
+
+	ctx := context.Background()
+	var s3Client *s3.Client
+	var measurements []*latency.Measurement
+	for _, location := range f.Args() {
+		var locationMeasurements []*latency.Measurement
+		var err error
+		if strings.HasPrefix(location, "s3://") {
+			if s3Client == nil {
+				cfg, err := config.LoadDefaultConfig(ctx)
+				if err != nil {
+					log.Fatalf("unable to load AWS SDK config, %s", err)
+				}
+				s3Client = s3.NewFromConfig(cfg)
+			}
+			locationMeasurements, err = latency.ReadMeasurementsS3(ctx, s3Client, location)
+		} else {
+			locationMeasurements, err = latency.ReadMeasurementFiles(location)
+		}
+		if err != nil {
+			log.Fatalf("Unable to read measurements: %s", err)
+		}
+		measurements = append(measurements, locationMeasurements...)
+	}
+	log.Printf("Comparing %d measurements\n", len(measurements))
+
+	rows := latency.Compare(measurements)
+	switch options.Output {
+	case "json":
+		rowsJSON, err := json.MarshalIndent(rows, "", "    ")
+		if err != nil {
+			log.Fatalf("unable to marshal json output: %v", err)
+		}
+		fmt.Println(string(rowsJSON))
+	default:
+		latency.ChartComparison(os.Stdout, rows)
+	}
+}
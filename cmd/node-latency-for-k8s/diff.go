@@ -0,0 +1,67 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+)
+
+// diffCommand is the subcommand that diffs two stored measurements
+const diffCommand = "diff"
+
+type DiffOptions struct {
+	Output string
+}
+
+// runDiff prints the per event deltas from the base measurement (the first arg) to the new measurement (the second arg)
+func runDiff(args []string) {
+	f := flag.NewFlagSet(fmt.Sprintf("%s %s", path.Base(os.Args[0]), diffCommand), flag.ExitOnError)
+	f.Usage = HelpFunc(f)
+	options := DiffOptions{}
+	f.StringVar(&options.Output, "output", strEnv("OUTPUT", "markdown"), "output type (markdown or json), default: markdown")
+	lo.Must0(f.Parse(args))
+	if f.NArg() != 2 {
+		log.Fatalf("Usage: %s [flags] <base.json> <new.json>", f.Name())
+	}
+	base, err := latency.LoadMeasurement(f.Arg(0))
+	if err != nil {
+		log.Fatalf("Unable to load base measurement: %s", err)
+	}
+	other, err := latency.LoadMeasurement(f.Arg(1))
+	if err != nil {
+		log.Fatalf("Unable to load new measurement: %s", err)
+	}
+
+	diff := base.Diff(other)
+	switch options.Output {
+	case "json":
+		diffJSON, err := json.MarshalIndent(diff, "", "    ")
+		if err != nil {
+			log.Fatalf("unable to marshal json output: %v", err)
+		}
+		fmt.Println(string(diffJSON))
+	default:
+		fmt.Print(diff.Markdown())
+	}
+}
@@ -15,8 +15,8 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
@@ -25,22 +25,43 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"runtime"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"google.golang.org/grpc"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
 
 	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+	"github.com/awslabs/node-latency-for-k8s/pkg/rpc"
+	"github.com/awslabs/node-latency-for-k8s/pkg/serve"
+	"github.com/awslabs/node-latency-for-k8s/pkg/server"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	azuresrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/azure"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/dnsprobe"
+	gcesrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/gce"
+	imdssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/imds"
+	talossrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/talos"
 )
 
 var (
@@ -48,25 +69,143 @@ var (
 	commit  string
 )
 
+// Exit code consts for gating i.e. CI pipelines on the measurement
+const (
+	// exitCodeRegression is the exit code when an event regressed against the --baseline measurement
+	exitCodeRegression = 3
+	// exitCodeSLOViolation is the exit code when an event exceeded its max latency
+	exitCodeSLOViolation = 4
+)
+
+// Cloud provider consts for the --cloud-provider flag
+const (
+	cloudProviderAWS   = "aws"
+	cloudProviderGCE   = "gce"
+	cloudProviderAzure = "azure"
+)
+
 type Options struct {
 	CloudWatch          bool
+	CloudWatchEMF       bool
+	EMFLogGroup         string
+	EMFLogStream        string
+	AggregationServer   string
+	Baseline            string
+	BaselineTolerance   int
+	BaselineSlack       int
+	Datadog             bool
+	DatadogSite         string
+	DogStatsDAddr       string
+	AMPRemoteWriteURL   string
 	Prometheus          bool
+	OTLPTraces          bool
+	OTLPMetrics         bool
 	ExperimentDimension string
+	ClusterName         string
+	S3Bucket            string
+	S3Prefix            string
+	S3IncludeLogLines   bool
+	EventBridgeBus      string
+	SNSTopicARN         string
+	SQSQueueURL         string
+	NotifyThresholds    string
+	WebhookURL          string
+	WebhookHeaders      string
+	WebhookTemplate     string
 	TimeoutSeconds      int
 	RetryDelaySeconds   int
+	Runs                int
+	Stream              bool
+	CheckpointFile      string
+	RunInterval         int
+	Concurrency         int
+	SourceTimeout       int
+	TerminalEvents      string
+	ClockStepCorrection bool
+	MeasureInterval     int
+	MeasurementHistory  int
 	MetricsPort         int
+	MetricsHistograms   bool
+	HistogramBuckets    string
+	MetricsNamespace    string
+	MetricsConstLabels  string
+	GRPCPort            int
 	IMDSEndpoint        string
+	IMDSCacheFile       string
+	MetadataRegion      string
+	MetadataZone        string
+	MetadataType        string
+	MetadataNodeGroup   string
+	IMDSTokenTTL        int
+	IMDSMaxAttempts     int
+	IMDSV2Only          bool
+	CloudProvider       string
+	GCEMetadataEndpoint string
+	TalosNode           string
+	DNSProbeName        string
+	DNSProbeServer      string
 	Kubeconfig          string
 	PodNamespace        string
 	NodeName            string
 	NoIMDS              bool
+	ASGActivity         bool
+	SpotEvents          bool
 	Output              string
+	OutputFile          string
 	NoComments          bool
+	NoCSVHeader         bool
+	NodeAnnotations     bool
+	NodeEvents          bool
+	MeasurementResource bool
+	Config              string
+	Profiles            string
+	Replay              string
+	Synthetic           bool
+	SyntheticDelays     string
 	Version             bool
+	// CloudWatch namespace, dimensions, and resolution
+	CloudWatchNamespace         string
+	CloudWatchDimensions        string
+	CloudWatchExcludeDimensions string
+	CloudWatchHighResolution    bool
+	NodeLabelDimensions         string
+	VersionDimensions           string
+	BootSegmentation            bool
+	ChartColumns                string
+	ChartTimestampFormat        string
+	Precision                   string
+	ZeroEvents                  string
+	GRPCAddress                 string
+	GRPCTLSCert                 string
+	GRPCTLSKey                  string
+	GRPCTLSClientCA             string
+	GRPCAllowedPaths            string
 }
 
 //nolint:gocyclo
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case serverCommand:
+			runServer(os.Args[2:])
+			return
+		case compareCommand:
+			runCompare(os.Args[2:])
+			return
+		case diffCommand:
+			runDiff(os.Args[2:])
+			return
+		case recordCommand:
+			runRecord(os.Args[2:])
+			return
+		case remoteCommand:
+			runRemote(os.Args[2:])
+			return
+		case benchmarkCommand:
+			runBenchmark(os.Args[2:])
+			return
+		}
+	}
 	root := flag.NewFlagSet(path.Base(os.Args[0]), flag.ExitOnError)
 	root.Usage = HelpFunc(root)
 	options := MustParseFlags(root)
@@ -75,70 +214,301 @@ func main() {
 		fmt.Printf("Git Commit: %s\n", commit)
 		os.Exit(0)
 	}
+	histogramBuckets, err := latency.ParseHistogramBuckets(options.HistogramBuckets)
+	if err != nil {
+		log.Fatalf("Unable to parse histogram buckets: %s", err)
+	}
+	metricsConstLabels, err := latency.ParseConstLabels(options.MetricsConstLabels)
+	if err != nil {
+		log.Fatalf("Unable to parse metrics const labels: %s", err)
+	}
+	cloudWatchDimensions, err := latency.ParseDimensions(options.CloudWatchDimensions)
+	if err != nil {
+		log.Fatalf("Unable to parse CloudWatch dimensions: %s", err)
+	}
+	nodeLabelDimensions, err := latency.ParseNodeLabelDimensions(options.NodeLabelDimensions)
+	if err != nil {
+		log.Fatalf("Unable to parse node label dimensions: %s", err)
+	}
+	versionDimensions, err := latency.ParseVersionDimensions(options.VersionDimensions)
+	if err != nil {
+		log.Fatalf("Unable to parse version dimensions: %s", err)
+	}
+	if _, err := latency.ParseChartColumns(options.ChartColumns); err != nil {
+		log.Fatalf("Unable to parse chart columns: %s", err)
+	}
+	precision, err := parsePrecision(options.Precision)
+	if err != nil {
+		log.Fatalf("Unable to parse precision: %s", err)
+	}
+	cloudWatchOptions := latency.CloudWatchOptions{
+		Namespace:         options.CloudWatchNamespace,
+		Dimensions:        cloudWatchDimensions,
+		ExcludeDimensions: lo.Filter(strings.Split(options.CloudWatchExcludeDimensions, ","), func(d string, _ int) bool { return d != "" }),
+		HighResolution:    options.CloudWatchHighResolution,
+	}
 	ctx := context.Background()
-	var err error
 	latencyClient := latency.New()
 
+	// Measure a recording, or a directory of log files, offline instead of the node, so the node's APIs are not used
+	// A synthetic boot is measured like a recording, so the outputs and sinks can be tried without an EC2 instance
+	var replay *latency.Replay
+	if options.Replay != "" || options.Synthetic {
+		if options.Stream || options.MeasureInterval > 0 {
+			log.Fatalf("--replay and --synthetic are mutually exclusive with --stream and --measure-interval")
+		}
+		if options.Synthetic {
+			delays, err := latency.ParseThresholds(options.SyntheticDelays)
+			if err != nil {
+				log.Fatalf("Unable to parse synthetic delays: %s", err)
+			}
+			replay, err = latency.OpenSynthetic(delays)
+			if err != nil {
+				log.Fatalf("Unable to fabricate a synthetic boot: %s", err)
+			}
+		} else {
+			replay, err = latency.OpenReplay(options.Replay)
+			if err != nil {
+				log.Fatalf("Unable to open replay: %s", err)
+			}
+		}
+		defer replay.Close()
+		latencyClient = latencyClient.WithRoot(replay.Root)
+		if replay.IMDS != nil {
+			latencyClient = latencyClient.WithIMDS(replay.IMDS)
+		}
+	}
+
 	// Setup K8s clientset
 	var k8sConfig *rest.Config
-	if options.Kubeconfig != "" {
-		k8sConfig, err = clientcmd.BuildConfigFromFlags("", options.Kubeconfig)
-		if err != nil {
-			log.Fatalf("Unable to create K8s clientset from kubeconfig: %s", err)
+	var clientset *kubernetes.Clientset
+	var dynamicClient dynamic.Interface
+	if replay == nil {
+		if options.Kubeconfig != "" {
+			k8sConfig, err = clientcmd.BuildConfigFromFlags("", options.Kubeconfig)
+			if err != nil {
+				log.Fatalf("Unable to create K8s clientset from kubeconfig: %s", err)
+			}
+		} else {
+			k8sConfig, err = rest.InClusterConfig()
 		}
-	} else {
-		k8sConfig, err = rest.InClusterConfig()
+		if err == nil {
+			clientset, err = kubernetes.NewForConfig(k8sConfig)
+			if err != nil {
+				log.Fatalf("Unable to create K8s clientset: %s", err)
+			}
+			dynamicClient, err = dynamic.NewForConfig(k8sConfig)
+			if err != nil {
+				log.Fatalf("Unable to create K8s dynamic client: %s", err)
+			}
+			latencyClient = latencyClient.WithK8sClientset(clientset).WithPodNamespace(options.PodNamespace).WithNodeName(options.NodeName)
+		} else {
+			log.Printf("Unable to find in-cluster K8s config: %s\n", err)
+		}
+	}
+
+	latencyClient = latencyClient.WithConcurrency(options.Concurrency).WithSourceTimeout(time.Duration(options.SourceTimeout) * time.Second)
+	if options.DNSProbeName != "" && replay == nil {
+		latencyClient = latencyClient.WithDNSProbe(dnsprobe.New(options.DNSProbeName, options.DNSProbeServer))
+	}
+	if options.TalosNode != "" && replay == nil {
+		latencyClient = latencyClient.WithTalos(talossrc.New(options.TalosNode))
 	}
-	if err == nil {
-		clientset, err := kubernetes.NewForConfig(k8sConfig)
+
+	// Fargate and other microVMs have no IMDS, EC2 instance, or host system log, so their reduced event set is measured
+	microVM := ""
+	if replay == nil {
+		if microVM = latency.DetectMicroVM(options.NodeName); microVM != "" {
+			log.Printf("Detected a %s microVM, the %s profile is selected and IMDS is not used\n", microVM, latency.ProfileMicroVM)
+		}
+	}
+
+	// Setup Cloud Provider Clients
+	if replay == nil {
+		switch options.CloudProvider {
+		case cloudProviderGCE:
+			latencyClient = latencyClient.WithGCEMetadata(gcesrc.New(options.GCEMetadataEndpoint))
+		case cloudProviderAzure:
+			if !options.NoIMDS && microVM == "" {
+				latencyClient = latencyClient.WithAzureIMDS(azuresrc.New(options.IMDSEndpoint))
+			}
+		case cloudProviderAWS:
+			cfg, err := config.LoadDefaultConfig(ctx, withIMDSEndpoint(options.IMDSEndpoint))
+			if err != nil {
+				log.Fatalf("unable to load AWS SDK config, %s", err)
+			}
+			// microVMs are not EC2 instances, so there is no instance to find the fleet or scaling activity of
+			if microVM == "" {
+				if !options.NoIMDS {
+					imdsClient := imdssrc.NewClient(cfg, imdssrc.ClientOptions{
+						TokenTTL:    time.Duration(options.IMDSTokenTTL) * time.Second,
+						MaxAttempts: options.IMDSMaxAttempts,
+						V2Only:      options.IMDSV2Only,
+					})
+					var imdsCache *imdssrc.Cache
+					if options.IMDSCacheFile != "" {
+						if imdsCache, err = imdssrc.LoadCache(options.IMDSCacheFile); err != nil {
+							log.Printf("Unable to load the IMDS cache, IMDS responses are not cached: %s\n", err)
+						}
+					}
+					// an unreachable IMDS would fail every IMDS event and leave the measurement without metadata, so it is
+					// not used and the measurement reports why, unless its responses were cached before a restart
+					var probeErr error
+					if imdsCache == nil || imdsCache.Len() == 0 {
+						probeErr = imdssrc.Probe(ctx, imdsClient)
+					}
+					if probeErr != nil {
+						log.Printf("EC2 IMDS is unavailable, measuring in metadata unavailable mode: %s\n", probeErr)
+						latencyClient = latencyClient.WithMetadataUnavailable(probeErr.Error())
+					} else {
+						latencyClient = latencyClient.WithIMDS(imdsClient).WithIMDSCache(imdsCache)
+					}
+				}
+				latencyClient = latencyClient.WithEC2Client(ec2.NewFromConfig(cfg))
+				// the scaling activity needs autoscaling:Describe* permissions, so it is only read when enabled
+				if options.ASGActivity {
+					latencyClient = latencyClient.WithASGClient(autoscaling.NewFromConfig(cfg))
+				}
+			}
+		default:
+			log.Fatalf("unknown cloud provider \"%s\"", options.CloudProvider)
+		}
+	}
+
+	// Metadata supplied by env vars, i.e. from the downward API or the helm chart, populates the metric dimensions when the
+	// metadata service is unreachable, along with the node's well-known labels
+	fallbackMetadata := latency.Metadata{
+		Region:           options.MetadataRegion,
+		AvailabilityZone: options.MetadataZone,
+		InstanceType:     options.MetadataType,
+		NodeGroup:        options.MetadataNodeGroup,
+	}
+	if lo.SomeBy([]string{fallbackMetadata.Region, fallbackMetadata.AvailabilityZone, fallbackMetadata.InstanceType, fallbackMetadata.NodeGroup}, func(v string) bool { return v != "" }) {
+		latencyClient = latencyClient.WithFallbackMetadata(&fallbackMetadata)
+	}
+	if len(nodeLabelDimensions) > 0 {
+		latencyClient = latencyClient.WithNodeLabelDimensions(nodeLabelDimensions)
+	}
+	latencyClient = latencyClient.WithVersionDimensions(versionDimensions)
+
+	// Load the config file of custom sources and events
+	var latencyConfig *latency.Config
+	if options.Config != "" {
+		latencyConfig, err = latency.LoadConfig(options.Config)
 		if err != nil {
-			log.Fatalf("Unable to create K8s clientset: %s", err)
+			log.Fatalf("Unable to load config: %s", err)
 		}
-		latencyClient = latencyClient.WithK8sClientset(clientset).WithPodNamespace(options.PodNamespace).WithNodeName(options.NodeName)
-	} else {
-		log.Printf("Unable to find in-cluster K8s config: %s\n", err)
 	}
 
-	// Setup AWS Config and Clients
-	cfg, err := config.LoadDefaultConfig(ctx, withIMDSEndpoint(options.IMDSEndpoint))
-	if err != nil {
-		log.Fatalf("unable to load AWS SDK config, %s", err)
+	// Select profiles which customize the default sources and events
+	profiles := lo.Filter(strings.Split(options.Profiles, ","), func(p string, _ int) bool { return p != "" })
+	if latencyConfig != nil {
+		profiles = append(profiles, latencyConfig.Profiles...)
+	}
+	// Windows nodes do not log like Linux nodes, so the windows profile is always selected
+	if runtime.GOOS == "windows" {
+		profiles = append(profiles, latency.ProfileWindows)
 	}
-	if !options.NoIMDS {
-		latencyClient = latencyClient.WithIMDS(imds.NewFromConfig(cfg))
+	if microVM != "" {
+		profiles = append(profiles, latency.ProfileMicroVM)
+	}
+	latencyClient, err = latencyClient.WithProfiles(lo.Uniq(profiles)...)
+	if err != nil {
+		log.Fatalf("Unable to select profiles: %s", err)
 	}
-	latencyClient = latencyClient.WithEC2Client(ec2.NewFromConfig(cfg))
 
 	// Register the Default Sources and Events
-	latencyClient, err = latencyClient.RegisterDefaultSources().RegisterDefaultEvents()
-	if err != nil {
-		log.Println("Unable to instantiate the latency timing client: ")
-		log.Printf("    %s", err)
+	if latencyConfig != nil {
+		latencyClient = latencyClient.WithDefaultEventParams(latencyConfig.DefaultEvents)
+	}
+	latencyClient = latencyClient.RegisterDefaultSources().WithSpotEvents(options.SpotEvents)
+	if latencyConfig == nil || !latencyConfig.DisableDefaultEvents {
+		latencyClient, err = latencyClient.RegisterDefaultEvents()
+		if err != nil {
+			log.Println("Unable to instantiate the latency timing client: ")
+			log.Printf("    %s", err)
+		}
+	}
+
+	// Register the Custom Sources and Events from the config file
+	if latencyConfig != nil {
+		latencyClient, err = latencyClient.RegisterConfig(latencyConfig)
+		if err != nil {
+			log.Println("Unable to register sources and events from config: ")
+			log.Printf("    %s", err)
+		}
+	}
+	if terminalEvents := lo.Filter(strings.Split(options.TerminalEvents, ","), func(e string, _ int) bool { return e != "" }); len(terminalEvents) > 0 {
+		latencyClient = latencyClient.WithTerminalEvents(terminalEvents...)
 	}
+	if zeroEvents := lo.Filter(strings.Split(options.ZeroEvents, ","), func(e string, _ int) bool { return e != "" }); len(zeroEvents) > 0 {
+		latencyClient = latencyClient.WithZeroEvents(zeroEvents...)
+	}
+	latencyClient = latencyClient.WithClockStepCorrection(options.ClockStepCorrection)
+	latencyClient = latencyClient.WithBootSegmentation(options.BootSegmentation)
+	latencyClient = latencyClient.WithPrecision(precision)
 
-	// Take measurements
-	measurement, err := latencyClient.MeasureUntil(ctx, time.Duration(options.TimeoutSeconds)*time.Second, time.Duration(options.RetryDelaySeconds)*time.Second)
+	// Restore the log sources from the checkpoint file, and persist their checkpoints after every measurement, if a file is set
+	if options.CheckpointFile != "" {
+		latencyClient, err = latencyClient.WithCheckpoints(options.CheckpointFile)
+		if err != nil {
+			log.Printf("Unable to restore checkpoints, the sources will be re-read: %s\n", err)
+		}
+	}
+
+	// Take measurements, the sinks receive the last measurement when runs are repeated
+	if options.Runs < 1 {
+		log.Fatalf("--runs must be at least 1")
+	}
+	var measurements []*latency.Measurement
+	if options.Stream {
+		if options.Runs > 1 {
+			log.Fatalf("--stream and --runs are mutually exclusive")
+		}
+		// log each timing as soon as it is measured, the outputs are emitted once the measurement completes
+		var streamed *latency.Measurement
+		streamed, err = latencyClient.MeasureStream(ctx, time.Duration(options.TimeoutSeconds)*time.Second, time.Duration(options.RetryDelaySeconds)*time.Second, func(t *sources.Timing) {
+			log.Printf("Measured %s at %s\n", t.Event.Name, t.Timestamp.Format(time.RFC3339))
+		})
+		measurements = append(measurements, streamed)
+	} else if replay != nil {
+		// a recording does not change, so it is measured once
+		measurement := latencyClient.Measure(ctx)
+		for _, t := range measurement.Timings {
+			if t.Error != nil {
+				log.Printf("Unable to retrieve timing for Event \"%s\": %v\n", t.Event.Name, t.Error)
+			}
+		}
+		measurements = append(measurements, measurement)
+	} else {
+		measurements, err = latencyClient.MeasureRuns(ctx, options.Runs, time.Duration(options.RunInterval)*time.Second, time.Duration(options.TimeoutSeconds)*time.Second, time.Duration(options.RetryDelaySeconds)*time.Second)
+	}
 	if err != nil {
 		log.Println(err)
 	}
+	measurement := measurements[len(measurements)-1]
 
-	// Emit Measurement to stdout based on output type
-	switch options.Output {
-	case "json":
-		jsonMeasurement, err := json.MarshalIndent(measurement, "", "    ")
+	// Export an OpenTelemetry trace of the node boot first if flag is enabled, so the outputs and metrics carry its trace ID
+	if options.OTLPTraces {
+		exporter, err := otlptracehttp.New(ctx)
 		if err != nil {
-			log.Printf("unable to marshal json output: %v", err)
+			log.Printf("Unable to create OTLP trace exporter: %s\n", err)
+		} else if err := measurement.EmitTraces(ctx, exporter); err != nil {
+			log.Printf("Error exporting OTLP traces: %s\n", err)
 		} else {
-			fmt.Println(string(jsonMeasurement))
+			log.Println("Successfully exported OTLP traces")
 		}
-	default:
-		fallthrough
-	case "markdown":
-		var hiddenColumns []string
-		if options.NoComments {
-			hiddenColumns = append(hiddenColumns, latency.ChartColumnComment)
+	}
+
+	// Write the statistics of repeated runs, or the Measurement, to stdout or the output file based on output type
+	if options.OutputFile == "" {
+		writeOutput(os.Stdout, options, measurements)
+	} else {
+		var output bytes.Buffer
+		writeOutput(&output, options, measurements)
+		if err := writeOutputFile(options.OutputFile, output.Bytes()); err != nil {
+			log.Printf("Unable to write output: %s\n", err)
 		}
-		measurement.Chart(latency.ChartOptions{HiddenColumns: hiddenColumns})
 	}
 
 	// Emit CloudWatch Metrics if flag is enabled
@@ -148,20 +518,306 @@ func main() {
 			log.Fatalf("unable to load AWS SDK config, %s", err)
 		}
 		cw := cloudwatch.NewFromConfig(cfg)
-		if err := measurement.EmitCloudWatchMetrics(ctx, cw, options.ExperimentDimension); err != nil {
+		if err := measurement.EmitCloudWatchMetrics(ctx, cw, options.ExperimentDimension, cloudWatchOptions); err != nil {
 			log.Printf("Error emitting CloudWatch metrics: %s\n", err)
 		} else {
 			log.Println("Successfully emitted CloudWatch metrics")
 		}
 	}
 
+	// Emit CloudWatch Embedded Metric Format to a log stream, or to stdout when no log group is set, if flag is enabled
+	if options.CloudWatchEMF {
+		if options.EMFLogGroup == "" {
+			emf, err := measurement.EMF(options.ExperimentDimension, cloudWatchOptions, time.Now())
+			if err != nil {
+				log.Printf("Error marshaling CloudWatch embedded metric format: %s\n", err)
+			} else {
+				fmt.Println(string(emf))
+			}
+		} else {
+			cfg, err := config.LoadDefaultConfig(ctx)
+			if err != nil {
+				log.Fatalf("unable to load AWS SDK config, %s", err)
+			}
+			if err := measurement.EmitEMF(ctx, cloudwatchlogs.NewFromConfig(cfg), options.EMFLogGroup, options.EMFLogStream, options.ExperimentDimension, cloudWatchOptions); err != nil {
+				log.Printf("Error emitting CloudWatch embedded metric format: %s\n", err)
+			} else {
+				log.Println("Successfully emitted CloudWatch embedded metric format")
+			}
+		}
+	}
+
+	// Emit Datadog Metrics if flag is enabled
+	if options.Datadog {
+		if err := measurement.EmitDatadogMetrics(ctx, latency.DatadogOptions{
+			Site:                options.DatadogSite,
+			APIKey:              os.Getenv("DD_API_KEY"),
+			StatsdAddr:          options.DogStatsDAddr,
+			ExperimentDimension: options.ExperimentDimension,
+		}); err != nil {
+			log.Printf("Error emitting Datadog metrics: %s\n", err)
+		} else {
+			log.Println("Successfully emitted Datadog metrics")
+		}
+	}
+
+	// Write metrics to Amazon Managed Prometheus if a remote write URL is set
+	if options.AMPRemoteWriteURL != "" {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			log.Fatalf("unable to load AWS SDK config, %s", err)
+		}
+		if err := measurement.EmitRemoteWrite(ctx, cfg, options.AMPRemoteWriteURL, options.ExperimentDimension, options.MetricsNamespace, metricsConstLabels); err != nil {
+			log.Printf("Error writing metrics to Amazon Managed Prometheus: %s\n", err)
+		} else {
+			log.Println("Successfully wrote metrics to Amazon Managed Prometheus")
+		}
+	}
+
+	// Upload the measurement to S3 if a bucket is set
+	if options.S3Bucket != "" {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			log.Fatalf("unable to load AWS SDK config, %s", err)
+		}
+		key, err := measurement.EmitS3(ctx, s3.NewFromConfig(cfg), latency.S3Options{
+			Bucket:          options.S3Bucket,
+			Prefix:          options.S3Prefix,
+			ClusterName:     options.ClusterName,
+			IncludeLogLines: options.S3IncludeLogLines,
+		})
+		if err != nil {
+			log.Printf("Error uploading measurement to S3: %s\n", err)
+		} else {
+			log.Printf("Successfully uploaded measurement to s3://%s/%s\n", options.S3Bucket, key)
+		}
+	}
+
+	// Publish the measurement to EventBridge if an event bus is set
+	if options.EventBridgeBus != "" {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			log.Fatalf("unable to load AWS SDK config, %s", err)
+		}
+		if err := measurement.EmitEventBridge(ctx, eventbridge.NewFromConfig(cfg), options.EventBridgeBus); err != nil {
+			log.Printf("Error publishing measurement to EventBridge: %s\n", err)
+		} else {
+			log.Println("Successfully published measurement to EventBridge")
+		}
+	}
+
+	// Send a summary of the measurement to SNS and/or SQS if a topic or queue is set
+	if options.SNSTopicARN != "" || options.SQSQueueURL != "" {
+		thresholds, err := latency.ParseThresholds(options.NotifyThresholds)
+		if err != nil {
+			log.Fatalf("Unable to parse notify thresholds: %s", err)
+		}
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			log.Fatalf("unable to load AWS SDK config, %s", err)
+		}
+		if options.SNSTopicARN != "" {
+			if err := measurement.EmitSNS(ctx, sns.NewFromConfig(cfg), options.SNSTopicARN, thresholds); err != nil {
+				log.Printf("Error publishing summary to SNS: %s\n", err)
+			} else {
+				log.Println("Successfully published summary to SNS")
+			}
+		}
+		if options.SQSQueueURL != "" {
+			if err := measurement.EmitSQS(ctx, sqs.NewFromConfig(cfg), options.SQSQueueURL, thresholds); err != nil {
+				log.Printf("Error sending summary to SQS: %s\n", err)
+			} else {
+				log.Println("Successfully sent summary to SQS")
+			}
+		}
+	}
+
+	// Write the timings back to the Node as annotations if flag is enabled
+	if options.NodeAnnotations {
+		if clientset == nil {
+			log.Println("Unable to annotate the node without a K8s clientset")
+		} else if err := measurement.EmitNodeAnnotations(ctx, clientset, latencyClient.NodeName()); err != nil {
+			log.Printf("Error annotating node: %s\n", err)
+		} else {
+			log.Println("Successfully annotated node")
+		}
+	}
+
+	// Create Kubernetes Events on the Node for terminal events and threshold breaches if flag is enabled
+	if options.NodeEvents {
+		thresholds, err := latency.ParseThresholds(options.NotifyThresholds)
+		if err != nil {
+			log.Fatalf("Unable to parse notify thresholds: %s", err)
+		}
+		if clientset == nil {
+			log.Println("Unable to create node events without a K8s clientset")
+		} else if err := measurement.EmitNodeEvents(ctx, clientset, latencyClient.NodeName(), thresholds); err != nil {
+			log.Printf("Error creating node events: %s\n", err)
+		} else {
+			log.Println("Successfully created node events")
+		}
+	}
+
+	// Persist the measurement as a NodeLatencyMeasurement custom resource if flag is enabled
+	if options.MeasurementResource {
+		if clientset == nil {
+			log.Printf("Unable to create a %s without a K8s clientset\n", latency.MeasurementResourceKind)
+		} else if name, err := measurement.EmitMeasurementResource(ctx, clientset, dynamicClient, latencyClient.NodeName()); err != nil {
+			log.Printf("Error creating %s: %s\n", latency.MeasurementResourceKind, err)
+		} else {
+			log.Printf("Successfully created %s %s\n", latency.MeasurementResourceKind, name)
+		}
+	}
+
+	// Push the measurement to the central aggregation server if a URL is set
+	if options.AggregationServer != "" {
+		if err := server.Push(ctx, options.AggregationServer, measurement); err != nil {
+			log.Printf("Error pushing measurement to the aggregation server: %s\n", err)
+		} else {
+			log.Println("Successfully pushed measurement to the aggregation server")
+		}
+	}
+
+	// POST the measurement, or a templated summary, to a webhook if a URL is set
+	if options.WebhookURL != "" {
+		thresholds, err := latency.ParseThresholds(options.NotifyThresholds)
+		if err != nil {
+			log.Fatalf("Unable to parse notify thresholds: %s", err)
+		}
+		headers, err := latency.ParseHeaders(options.WebhookHeaders)
+		if err != nil {
+			log.Fatalf("Unable to parse webhook headers: %s", err)
+		}
+		var payloadTemplate []byte
+		if options.WebhookTemplate != "" {
+			payloadTemplate, err = os.ReadFile(options.WebhookTemplate)
+			if err != nil {
+				log.Fatalf("Unable to read webhook template: %s", err)
+			}
+		}
+		if err := measurement.EmitWebhook(ctx, latency.WebhookOptions{
+			URL:        options.WebhookURL,
+			Headers:    headers,
+			Template:   string(payloadTemplate),
+			Thresholds: thresholds,
+		}); err != nil {
+			log.Printf("Error posting measurement to webhook: %s\n", err)
+		} else {
+			log.Println("Successfully posted measurement to webhook")
+		}
+	}
+
+	// Export OpenTelemetry metrics if flag is enabled
+	if options.OTLPMetrics {
+		exporter, err := otlpmetrichttp.New(ctx)
+		if err != nil {
+			log.Printf("Unable to create OTLP metric exporter: %s\n", err)
+		} else if err := measurement.EmitOTLPMetrics(ctx, exporter, options.ExperimentDimension); err != nil {
+			log.Printf("Error exporting OTLP metrics: %s\n", err)
+		} else {
+			log.Println("Successfully exported OTLP metrics")
+		}
+	}
+
+	// Check the SLOs of events which declare a max latency, violations exit non-zero after all outputs are emitted
+	// unless the measurement is served, i.e. by a DaemonSet pod which would otherwise crash-loop
+	exitCode := 0
+	oneShot := options.GRPCPort == 0 && options.MeasureInterval == 0 && !options.Prometheus
+	if violations := measurement.SLOViolations(); len(violations) > 0 {
+		for _, t := range violations {
+			log.Printf("SLO violation of %s: %s, max latency %s\n", t.Event.Name, latency.FormatSeconds(t.T, precision), t.Event.MaxLatency)
+		}
+		if oneShot {
+			exitCode = exitCodeSLOViolation
+		}
+	}
+
+	// Compare the measurement against a baseline measurement if one is set, regressions of one-shot runs exit non-zero to gate
+	// i.e. AMI pipelines
+	if options.Baseline != "" {
+		baseline, err := latency.LoadMeasurement(options.Baseline)
+		if err != nil {
+			log.Fatalf("Unable to load baseline measurement: %s", err)
+		}
+		regressions := measurement.Regressions(baseline, latency.Tolerance{
+			Percent:  float64(options.BaselineTolerance),
+			Duration: time.Duration(options.BaselineSlack) * time.Second,
+		})
+		seconds := func(s float64) string { return latency.FormatSeconds(time.Duration(s*float64(time.Second)), precision) }
+		for _, r := range regressions {
+			log.Printf("Regression of %s: %s, baseline %s, allowed %s\n", r.Event, seconds(r.Seconds), seconds(r.BaselineSeconds), seconds(r.AllowedSeconds))
+		}
+		if options.CloudWatch {
+			cfg, err := config.LoadDefaultConfig(ctx)
+			if err != nil {
+				log.Fatalf("unable to load AWS SDK config, %s", err)
+			}
+			if err := measurement.EmitRegressionsMetric(ctx, cloudwatch.NewFromConfig(cfg), regressions, options.ExperimentDimension, cloudWatchOptions); err != nil {
+				log.Printf("Error emitting CloudWatch regressions metric: %s\n", err)
+			} else {
+				log.Println("Successfully emitted CloudWatch regressions metric")
+			}
+		}
+		if len(regressions) > 0 {
+			log.Printf("%d events regressed against the baseline measurement\n", len(regressions))
+			if oneShot {
+				exitCode = exitCodeRegression
+			}
+		} else {
+			log.Println("No events regressed against the baseline measurement")
+		}
+	}
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+
+	// Serve the gRPC API around the Measurer if a port is set
+	if options.GRPCPort > 0 {
+		if options.MeasureInterval > 0 {
+			log.Fatalf("--grpc-port and --measure-interval are mutually exclusive since both re-measure with the same Measurer")
+		}
+		var serverOpts []grpc.ServerOption
+		if options.GRPCTLSCert != "" || options.GRPCTLSKey != "" {
+			tlsOpt, err := rpc.TLS(options.GRPCTLSCert, options.GRPCTLSKey, options.GRPCTLSClientCA)
+			if err != nil {
+				log.Fatalf("Unable to configure gRPC TLS: %s", err)
+			}
+			serverOpts = append(serverOpts, tlsOpt)
+		} else if ip := net.ParseIP(options.GRPCAddress); ip == nil || !ip.IsLoopback() {
+			log.Printf("Warning: the gRPC API is served on %s without TLS, any client which can reach it can trigger measurements", options.GRPCAddress)
+		}
+		grpcAllowedPaths := lo.Filter(strings.Split(options.GRPCAllowedPaths, ","), func(p string, _ int) bool { return p != "" })
+		addr := net.JoinHostPort(options.GRPCAddress, strconv.Itoa(options.GRPCPort))
+		log.Printf("Serving the %s gRPC API on %s", rpc.ServiceName, addr)
+		lo.Must0(rpc.NewServer(latencyClient, measurement).WithAllowedPaths(grpcAllowedPaths...).ListenAndServe(ctx, addr, serverOpts...))
+		return
+	}
+
+	// Periodically re-measure and serve metrics, health, and the latest measurement if an interval is set
+	if options.MeasureInterval > 0 {
+		daemon := serve.New(latencyClient, measurement, time.Duration(options.MeasureInterval)*time.Second, options.ExperimentDimension).
+			WithHistory(options.MeasurementHistory).
+			WithMetricsNamespace(options.MetricsNamespace, metricsConstLabels)
+		if options.MetricsHistograms {
+			daemon = daemon.WithHistograms(histogramBuckets)
+		}
+		log.Printf("Re-measuring every %ds and serving /metrics, /healthz, /measurement, and /measurements on :%d", options.MeasureInterval, options.MetricsPort)
+		lo.Must0(daemon.ListenAndServe(ctx, fmt.Sprintf(":%d", options.MetricsPort)))
+		return
+	}
+
 	// Serve Prometheus Metrics if flag is enabled
 	if options.Prometheus {
 		registry := prometheus.NewRegistry()
-		measurement.RegisterMetrics(registry, options.ExperimentDimension)
+		register := latency.WrapRegisterer(registry, options.MetricsNamespace, metricsConstLabels)
+		if options.MetricsHistograms {
+			latency.NewHistograms(register, options.ExperimentDimension, histogramBuckets).Observe(measurement)
+		} else {
+			measurement.RegisterMetrics(register, options.ExperimentDimension)
+		}
 		http.Handle("/metrics", promhttp.HandlerFor(
 			registry,
-			promhttp.HandlerOpts{EnableOpenMetrics: false},
+			promhttp.HandlerOpts{EnableOpenMetrics: options.MetricsHistograms},
 		))
 		log.Printf("Serving Prometheus metrics on :%d", options.MetricsPort)
 		srv := &http.Server{
@@ -177,18 +833,98 @@ func main() {
 
 func MustParseFlags(f *flag.FlagSet) Options {
 	options := Options{}
+	f.StringVar(&options.AggregationServer, "aggregation-server-url", strEnv("AGGREGATION_SERVER_URL", ""), "(optional) base URL of the central aggregation server (node-latency-for-k8s server) to push the measurement to, i.e. http://node-latency-for-k8s-server:8080")
+	f.StringVar(&options.Baseline, "baseline", strEnv("BASELINE", ""), fmt.Sprintf("(optional) path to a baseline JSON measurement to compare each event against, exits with code %d if any event regressed", exitCodeRegression))
+	f.IntVar(&options.BaselineTolerance, "baseline-tolerance", intEnv("BASELINE_TOLERANCE", 10), "Percent an event may be slower than the baseline before it is a regression, default: 10")
+	f.IntVar(&options.BaselineSlack, "baseline-slack", intEnv("BASELINE_SLACK", 0), "Seconds an event may be slower than the baseline, in addition to the tolerance, before it is a regression, default: 0")
 	f.BoolVar(&options.CloudWatch, "cloudwatch-metrics", boolEnv("CLOUDWATCH_METRICS", false), "Emit metrics to CloudWatch, default: false")
+	f.StringVar(&options.CloudWatchNamespace, "cloudwatch-namespace", strEnv("CLOUDWATCH_NAMESPACE", latency.CloudWatchNamespace), fmt.Sprintf("Namespace of the CloudWatch and Embedded Metric Format metrics, default: %s", latency.CloudWatchNamespace))
+	f.StringVar(&options.CloudWatchDimensions, "cloudwatch-dimensions", strEnv("CLOUDWATCH_DIMENSIONS", ""), "(optional) comma separated dimensions to add to the CloudWatch metrics, i.e. cluster=prod-1,env=prod")
+	f.StringVar(&options.CloudWatchExcludeDimensions, "cloudwatch-exclude-dimensions", strEnv("CLOUDWATCH_EXCLUDE_DIMENSIONS", ""), "(optional) comma separated dimensions to drop from the CloudWatch metrics, i.e. amiID,availabilityZone to reduce their cardinality")
+	f.BoolVar(&options.CloudWatchHighResolution, "cloudwatch-high-resolution", boolEnv("CLOUDWATCH_HIGH_RESOLUTION", false), "Store the CloudWatch metrics at 1 second resolution instead of 1 minute, default: false")
+	f.BoolVar(&options.CloudWatchEMF, "cloudwatch-emf", boolEnv("CLOUDWATCH_EMF", false), "Emit metrics as a CloudWatch Embedded Metric Format log line to stdout, or to a log stream when a log group is set, default: false")
+	f.StringVar(&options.EMFLogGroup, "cloudwatch-emf-log-group", strEnv("CLOUDWATCH_EMF_LOG_GROUP", ""), "(optional) existing CloudWatch Logs log group to put the Embedded Metric Format log line to instead of stdout")
+	f.StringVar(&options.EMFLogStream, "cloudwatch-emf-log-stream", strEnv("CLOUDWATCH_EMF_LOG_STREAM", ""), "CloudWatch Logs log stream to put the Embedded Metric Format log line to, default: <instance-id or hostname>")
+	f.BoolVar(&options.Datadog, "datadog-metrics", boolEnv("DATADOG_METRICS", false), "Emit metrics to Datadog, to the API if the DD_API_KEY env var is set or otherwise to DogStatsD, default: false")
+	f.StringVar(&options.DatadogSite, "datadog-site", strEnv("DD_SITE", "datadoghq.com"), "Datadog site to submit metrics to when DD_API_KEY is set, default: datadoghq.com")
+	f.StringVar(&options.DogStatsDAddr, "dogstatsd-addr", strEnv("DOGSTATSD_ADDR", fmt.Sprintf("%s:8125", strEnv("DD_AGENT_HOST", "localhost"))), "DogStatsD address to send metrics to when DD_API_KEY is not set, default: $DD_AGENT_HOST:8125 or localhost:8125")
+	f.StringVar(&options.AMPRemoteWriteURL, "amp-remote-write-url", strEnv("AMP_REMOTE_WRITE_URL", ""), "(optional) sigv4 signed Prometheus remote write URL to write metrics to, i.e. https://aps-workspaces.<region>.amazonaws.com/workspaces/<workspace-id>/api/v1/remote_write")
 	f.BoolVar(&options.Prometheus, "prometheus-metrics", boolEnv("PROMETHEUS_METRICS", false), "Expose a Prometheus metrics endpoint (this runs as a daemon), default: false")
+	f.BoolVar(&options.OTLPMetrics, "otlp-metrics", boolEnv("OTLP_METRICS", false), "Export metrics as OpenTelemetry gauges via OTLP/HTTP (configured with the standard OTEL_EXPORTER_OTLP_* env vars), default: false")
+	f.BoolVar(&options.OTLPTraces, "otlp-traces", boolEnv("OTLP_TRACES", false), "Export the measurement as an OpenTelemetry trace via OTLP/HTTP (configured with the standard OTEL_EXPORTER_OTLP_* env vars), its trace ID is attached as an exemplar to the --metrics-histograms, default: false")
+	f.StringVar(&options.GRPCAddress, "grpc-address", strEnv("GRPC_ADDRESS", "127.0.0.1"), "The address to serve the gRPC API on, set it to 0.0.0.0 with --grpc-tls-cert and --grpc-tls-client-ca to serve other hosts, default: 127.0.0.1")
+	f.StringVar(&options.GRPCAllowedPaths, "grpc-allowed-paths", strEnv("GRPC_ALLOWED_PATHS", strings.Join(latency.DefaultUntrustedPaths, ",")), "Comma separated directories the sources of configs registered over the gRPC API may read logs from, default: "+strings.Join(latency.DefaultUntrustedPaths, ","))
+	f.StringVar(&options.GRPCTLSCert, "grpc-tls-cert", strEnv("GRPC_TLS_CERT", ""), "(optional) path to the TLS certificate to serve the gRPC API with")
+	f.StringVar(&options.GRPCTLSKey, "grpc-tls-key", strEnv("GRPC_TLS_KEY", ""), "(optional) path to the TLS key of the gRPC API's certificate")
+	f.StringVar(&options.GRPCTLSClientCA, "grpc-tls-client-ca", strEnv("GRPC_TLS_CLIENT_CA", ""), "(optional) path to the CA which must sign the certificates of gRPC clients (mTLS)")
+	f.IntVar(&options.GRPCPort, "grpc-port", intEnv("GRPC_PORT", 0), "The port to serve the gRPC API around the Measurer on to trigger and retrieve measurements (this runs as a daemon), default: 0 (disabled)")
+	f.BoolVar(&options.MetricsHistograms, "metrics-histograms", boolEnv("METRICS_HISTOGRAMS", false), "Record the event latencies into prometheus histograms instead of gauges of the latest measurement, so percentiles can be computed across nodes and over time, each timing is only observed once when re-measuring, default: false")
+	f.StringVar(&options.HistogramBuckets, "histogram-buckets", strEnv("HISTOGRAM_BUCKETS", ""), "(optional) comma separated upper bounds of the prometheus histogram buckets with --metrics-histograms, i.e. 10s,30s,1m,5m, default: 5s,10s,15s,20s,30s,45s,1m,1m30s,2m,3m,5m,10m")
+	f.StringVar(&options.MetricsNamespace, "metrics-namespace", strEnv("METRICS_NAMESPACE", ""), "(optional) namespace to prefix the prometheus metric names with, i.e. nlk for nlk_node_ready, to avoid collisions with other metrics")
+	f.StringVar(&options.MetricsConstLabels, "metrics-const-labels", strEnv("METRICS_CONST_LABELS", ""), "(optional) comma separated const labels to add to all prometheus metrics, i.e. cluster=prod-1,env=prod")
 	f.IntVar(&options.MetricsPort, "metrics-port", intEnv("METRICS_PORT", 2112), "The port to serve prometheus metrics from, default: 2112")
+	f.BoolVar(&options.ClockStepCorrection, "clock-step-correction", boolEnv("CLOCK_STEP_CORRECTION", false), "Correct the timestamps logged before chronyd stepped the clock by the step's offset, timestamps of IMDS and the cloud APIs are not corrected, default: false")
+	f.BoolVar(&options.BootSegmentation, "boot-segmentation", boolEnv("BOOT_SEGMENTATION", false), "Only time the events the node's clock logged since the current boot, so the events of previous boots in persistent logs are not mixed into the timeline, default: false")
+	f.StringVar(&options.CheckpointFile, "checkpoint-file", strEnv("CHECKPOINT_FILE", ""), "(optional) path to persist the read positions and matches of the log and journal sources to, so a restarted process only reads appended logs and does not re-stream timings")
+	f.StringVar(&options.ClusterName, "cluster-name", strEnv("CLUSTER_NAME", ""), "(optional) name of the cluster the node belongs to which is used to key uploaded measurements")
+	f.StringVar(&options.S3Bucket, "s3-bucket", strEnv("S3_BUCKET", ""), "(optional) S3 bucket to upload the JSON measurement to, keyed by <s3-prefix>/<cluster-name>/<instance-id>/<timestamp>.json")
+	f.StringVar(&options.S3Prefix, "s3-prefix", strEnv("S3_PREFIX", ""), "(optional) key prefix of measurements uploaded to S3")
+	f.BoolVar(&options.S3IncludeLogLines, "s3-include-log-lines", boolEnv("S3_INCLUDE_LOG_LINES", false), "Upload the raw matched log lines alongside the JSON measurement to S3, default: false")
+	f.StringVar(&options.EventBridgeBus, "eventbridge-bus", strEnv("EVENTBRIDGE_BUS", ""), "(optional) name or ARN of an EventBridge event bus to publish the measurement to as a CloudEvent when the measurement completes or times out")
+	f.StringVar(&options.SNSTopicARN, "sns-topic-arn", strEnv("SNS_TOPIC_ARN", ""), "(optional) SNS topic ARN to publish a summary of the measurement (node metadata, terminal event latencies, and threshold breaches) to")
+	f.StringVar(&options.SQSQueueURL, "sqs-queue-url", strEnv("SQS_QUEUE_URL", ""), "(optional) SQS queue URL to send a summary of the measurement (node metadata, terminal event latencies, and threshold breaches) to")
+	f.StringVar(&options.NotifyThresholds, "notify-thresholds", strEnv("NOTIFY_THRESHOLDS", ""), "(optional) comma separated metric latency thresholds that are reported as breaches in SNS, SQS, and webhook summaries and node events, i.e. node_ready=60s,pod_ready=90s")
+	f.StringVar(&options.WebhookURL, "webhook-url", strEnv("WEBHOOK_URL", ""), "(optional) HTTP webhook URL to POST the JSON measurement, or the rendered webhook template, to")
+	f.StringVar(&options.WebhookHeaders, "webhook-headers", strEnv("WEBHOOK_HEADERS", ""), "(optional) comma separated headers of webhook requests, i.e. Authorization=Bearer token,Content-Type=text/plain")
+	f.StringVar(&options.WebhookTemplate, "webhook-template", strEnv("WEBHOOK_TEMPLATE", ""), "(optional) path to a Go template of the webhook payload rendered with .Measurement and .Summary")
 	f.StringVar(&options.ExperimentDimension, "experiment-dimension", strEnv("EXPERIMENT_DIMENSION", "none"), "Custom dimension to add to experiment metrics, default: none")
 	f.IntVar(&options.TimeoutSeconds, "timeout", intEnv("TIMEOUT", 600), "Timeout in seconds for how long event timings will try to be retrieved, default: 600")
 	f.IntVar(&options.RetryDelaySeconds, "retry-delay", intEnv("RETRY_DELAY", 5), "Delay in seconds in-between timing retrievals, default: 5")
-	f.StringVar(&options.IMDSEndpoint, "imds-endpoint", strEnv("IMDS_ENDPOINT", "http://169.254.169.254"), "IMDS endpoint for testing, default: http://169.254.169.254")
-	f.BoolVar(&options.NoIMDS, "no-imds", boolEnv("NO_IMDS", false), "Do not use EC2 Instance Metadata Service (IMDS), default: false")
+	f.BoolVar(&options.Stream, "stream", boolEnv("STREAM", false), "Tail the log sources with inotify and match appended lines as they are written instead of re-reading the logs every retry delay, the retry delay only polls API sources, default: false")
+	f.IntVar(&options.Runs, "runs", intEnv("RUNS", 1), "Number of measurement cycles to run, more than 1 outputs the min, p50, p90, p99, and max of each event (markdown or json) instead of a single measurement, default: 1")
+	f.IntVar(&options.Concurrency, "concurrency", intEnv("CONCURRENCY", latency.DefaultConcurrency), fmt.Sprintf("Number of events searched concurrently, events of the same source are searched one at a time, default: %d", latency.DefaultConcurrency))
+	f.StringVar(&options.ZeroEvents, "zero-events", strEnv("ZERO_EVENTS", ""), "(optional) comma separated metric or event names of the events the timings are relative to in order of preference, overriding the config file, i.e. instance_requested,vm_initialized, default: the first timing")
+	f.StringVar(&options.TerminalEvents, "terminal-events", strEnv("TERMINAL_EVENTS", ""), "(optional) comma separated metric or event names of the events which complete a measurement, overriding the default terminal events and the config file, i.e. node_ready")
+	f.IntVar(&options.SourceTimeout, "source-timeout", intEnv("SOURCE_TIMEOUT", 0), "Timeout in seconds for searching each source in a measurement, the events of a source that times out are reported as errored, default: 0 (no timeout)")
+	f.IntVar(&options.RunInterval, "run-interval", intEnv("RUN_INTERVAL", 0), "Delay in seconds in-between measurement cycles when --runs is more than 1, default: 0")
+	f.IntVar(&options.MeasureInterval, "measure-interval", intEnv("MEASURE_INTERVAL", 0), "Interval in seconds to periodically re-measure and serve /metrics, /healthz, /measurement, and /measurements on the metrics port (this runs as a daemon), default: 0 (disabled)")
+	f.IntVar(&options.MeasurementHistory, "measurement-history", intEnv("MEASUREMENT_HISTORY", serve.DefaultHistory), fmt.Sprintf("Number of the most recent measurements served on /measurements when re-measuring on an interval, default: %d", serve.DefaultHistory))
+	f.StringVar(&options.IMDSCacheFile, "imds-cache-file", strEnv("IMDS_CACHE_FILE", ""), "(optional) path to persist the instance-identity document and IMDS event times to, so they are reused across restarts and measurements instead of queried from IMDS again")
+	f.StringVar(&options.IMDSEndpoint, "imds-endpoint", strEnv("IMDS_ENDPOINT", "http://169.254.169.254"), "IMDS endpoint (EC2 or Azure) for testing, default: http://169.254.169.254")
+	f.IntVar(&options.IMDSMaxAttempts, "imds-max-attempts", intEnv("IMDS_MAX_ATTEMPTS", 3), "Number of attempts of each EC2 IMDS request, the SDK backs off with jitter of up to 1s between attempts, default: 3")
+	f.IntVar(&options.IMDSTokenTTL, "imds-token-ttl", intEnv("IMDS_TOKEN_TTL", 21600), "TTL in seconds requested for EC2 IMDSv2 session tokens, default: 21600")
+	f.BoolVar(&options.IMDSV2Only, "imds-v2-only", boolEnv("IMDS_V2_ONLY", false), "Only use EC2 IMDSv2, rather than falling back to IMDSv1 when a session token cannot be retrieved, default: false")
+	f.StringVar(&options.CloudProvider, "cloud-provider", strEnv("CLOUD_PROVIDER", cloudProviderAWS), "cloud provider the node runs on which determines the metadata and API sources (aws, gce, or azure), default: aws")
+	f.StringVar(&options.DNSProbeName, "dns-probe-name", strEnv("DNS_PROBE_NAME", ""), fmt.Sprintf("(optional) cluster DNS name to actively resolve every retry delay to time when DNS is first reachable from the node, i.e. %s", dnsprobe.DefaultName))
+	f.StringVar(&options.DNSProbeServer, "dns-probe-server", strEnv("DNS_PROBE_SERVER", ""), "(optional) cluster DNS service address the DNS probe queries, i.e. 10.100.0.10, default: the system resolver")
+	f.StringVar(&options.GCEMetadataEndpoint, "gce-metadata-endpoint", strEnv("GCE_METADATA_ENDPOINT", gcesrc.DefaultEndpoint), "GCE metadata server endpoint when the cloud provider is gce, default: http://metadata.google.internal")
+	f.StringVar(&options.TalosNode, "talos-node", strEnv("TALOS_NODE", ""), "(optional) address of the node's Talos machine API the talos profile reads the kernel log from with talosctl, i.e. $HOST_IP, default: the talosconfig's nodes")
+	f.BoolVar(&options.ASGActivity, "asg-activity", boolEnv("ASG_ACTIVITY", false), "Time the scale-out decision, launch, and warm pool exit of the instance's Auto Scaling Group from its scaling activity, requires the autoscaling:DescribeAutoScalingInstances and autoscaling:DescribeScalingActivities permissions, default: false")
+	f.BoolVar(&options.SpotEvents, "spot-events", boolEnv("SPOT_EVENTS", false), "Time the spot interruption and rebalance recommendation notices from IMDS, which are only issued to spot instances, default: false")
+	f.BoolVar(&options.NoIMDS, "no-imds", boolEnv("NO_IMDS", false), "Do not use the EC2 or Azure Instance Metadata Service (IMDS), default: false")
 	f.StringVar(&options.PodNamespace, "pod-namespace", strEnv("POD_NAMESPACE", "default"), "namespace of the pods that will be measured from creation to running, default: default")
+	f.BoolVar(&options.MeasurementResource, "measurement-resource", boolEnv("MEASUREMENT_RESOURCE", false), "Persist the measurement as a NodeLatencyMeasurement custom resource owned by the Node (the CRD is installed by the helm chart with measurementResource.enabled), default: false")
+	f.BoolVar(&options.NodeAnnotations, "node-annotations", boolEnv("NODE_ANNOTATIONS", false), "Patch the Node with the timings as annotations, i.e. node-latency.aws/pod_ready=42s, default: false")
+	f.StringVar(&options.NodeLabelDimensions, "node-label-dimensions", strEnv("NODE_LABEL_DIMENSIONS", ""), "(optional) comma separated Node labels to add as metric dimensions, optionally named, i.e. nodepool=karpenter.sh/nodepool,eks.amazonaws.com/nodegroup, unnamed labels are named after the label without its prefix")
+	f.BoolVar(&options.NodeEvents, "node-events", boolEnv("NODE_EVENTS", false), "Create Kubernetes Events on the Node for terminal events and notify threshold breaches, default: false")
+	f.StringVar(&options.MetadataRegion, "metadata-region", strEnv("METADATA_REGION", ""), "(optional) region of the node's metadata when the metadata service (IMDS) is unreachable, the node's topology.kubernetes.io/region label is used if it is not set")
+	f.StringVar(&options.MetadataZone, "metadata-availability-zone", strEnv("METADATA_AVAILABILITY_ZONE", ""), "(optional) availability zone of the node's metadata when the metadata service (IMDS) is unreachable, the node's topology.kubernetes.io/zone label is used if it is not set")
+	f.StringVar(&options.MetadataType, "metadata-instance-type", strEnv("METADATA_INSTANCE_TYPE", ""), "(optional) instance type of the node's metadata when the metadata service (IMDS) is unreachable, the node's node.kubernetes.io/instance-type label is used if it is not set")
+	f.StringVar(&options.MetadataNodeGroup, "metadata-node-group", strEnv("METADATA_NODE_GROUP", ""), "(optional) node group of the node's metadata when the metadata service (IMDS) is unreachable, the node's eks.amazonaws.com/nodegroup (or Karpenter node pool) label is used if it is not set")
 	f.StringVar(&options.NodeName, "node-name", strEnv("NODE_NAME", ""), "node name to query for the first pod creation time in the pod namespace, default: <auto-discovered via IMDS>")
-	f.StringVar(&options.Output, "output", strEnv("OUTPUT", "markdown"), "output type (markdown or json), default: markdown")
+	f.StringVar(&options.Output, "output", strEnv("OUTPUT", "markdown"), "output type (markdown, json, csv, html, mermaid, svg, folded, or speedscope), default: markdown")
+	f.StringVar(&options.OutputFile, "output-file", strEnv("OUTPUT_FILE", ""), "(optional) file to write the output to instead of stdout, i.e. on a hostPath or emptyDir, the file is replaced so readers never see a partial report")
+	f.StringVar(&options.Precision, "precision", strEnv("PRECISION", latency.DefaultPrecision.String()), "Precision of the event timings in the chart, JSON, and metrics, i.e. 1s for whole seconds, default: "+latency.DefaultPrecision.String())
+	f.StringVar(&options.ChartColumns, "chart-columns", strEnv("CHART_COLUMNS", ""), "(optional) comma separated columns of the markdown chart in order (event, timestamp, t, delta, comment, slo, slack), default: all columns")
+	f.StringVar(&options.ChartTimestampFormat, "chart-timestamp-format", strEnv("CHART_TIMESTAMP_FORMAT", latency.DefaultChartTimestampFormat), "Go time layout of the timestamp column of the markdown chart, i.e. 15:04:05.000, default: "+latency.DefaultChartTimestampFormat)
 	f.BoolVar(&options.NoComments, "no-comments", boolEnv("NO_COMMENTS", false), "Hide the comments column in the markdown chart output, default: false")
+	f.BoolVar(&options.NoCSVHeader, "no-csv-header", boolEnv("NO_CSV_HEADER", false), "Omit the header row of the csv output so outputs from many nodes can be concatenated, default: false")
+	f.StringVar(&options.Config, "config", strEnv("CONFIG", ""), "(optional) path to a YAML or JSON config file declaring custom sources and events")
+	f.StringVar(&options.Profiles, "profiles", strEnv("PROFILES", ""), fmt.Sprintf("(optional) comma separated profiles that customize the default sources and events for a node OS, runtime, or add-on %v", latency.ProfileNames()))
+	f.StringVar(&options.Replay, "replay", strEnv("REPLAY", ""), "(optional) recording tar.gz or directory written by the record subcommand, or a directory of log files laid out like the node's filesystem, to measure offline instead of the node")
+	f.BoolVar(&options.Synthetic, "synthetic", boolEnv("SYNTHETIC", false), "Measure a fabricated EKS node boot instead of the node to try the outputs, dashboards, and sinks without an EC2 instance, default: false")
+	f.StringVar(&options.SyntheticDelays, "synthetic-delays", strEnv("SYNTHETIC_DELAYS", ""), "(optional) comma separated delays of the synthetic events after the event before them, overriding the typical delays, i.e. kubelet_start=10s,node_ready=1m")
+	f.StringVar(&options.VersionDimensions, "version-dimensions", strEnv("VERSION_DIMENSIONS", ""), "(optional) comma separated versions of the node's OS and components to add as metric dimensions: osImage, kernelVersion, kubeletVersion, and containerRuntimeVersion")
 	f.BoolVar(&options.Version, "version", false, "version information")
 	f.StringVar(&options.Kubeconfig, "kubeconfig", defaultKubeconfig(), "(optional) absolute path to the kubeconfig file")
 	lo.Must0(f.Parse(os.Args[1:]))
@@ -219,6 +955,18 @@ func defaultKubeconfig() string {
 	return ""
 }
 
+// parsePrecision parses the precision of the event timings, which must be positive
+func parsePrecision(precisionStr string) (time.Duration, error) {
+	precision, err := time.ParseDuration(precisionStr)
+	if err != nil {
+		return 0, err
+	}
+	if precision <= 0 {
+		return 0, fmt.Errorf("precision %s must be positive", precisionStr)
+	}
+	return precision, nil
+}
+
 // strEnv retrieves the env var key or defaults to fallback value
 func strEnv(key string, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
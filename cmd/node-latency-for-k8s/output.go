@@ -0,0 +1,110 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+)
+
+// writeOutput writes the statistics of repeated runs, or the last Measurement, to w based on the output type
+func writeOutput(w io.Writer, options Options, measurements []*latency.Measurement) {
+	measurement := measurements[len(measurements)-1]
+	if options.Runs > 1 {
+		stats := latency.Statistics(measurements)
+		if options.Output == "json" {
+			jsonStats, err := json.MarshalIndent(stats, "", "    ")
+			if err != nil {
+				log.Printf("unable to marshal json output: %v", err)
+			} else {
+				fmt.Fprintln(w, string(jsonStats))
+			}
+		} else {
+			latency.ChartStatistics(w, stats)
+		}
+		return
+	}
+	switch options.Output {
+	case "json":
+		jsonMeasurement, err := measurement.JSON()
+		if err != nil {
+			log.Printf("unable to marshal json output: %v", err)
+		} else {
+			fmt.Fprintln(w, string(jsonMeasurement))
+		}
+	case "html":
+		if err := measurement.HTML(w); err != nil {
+			log.Printf("unable to render html output: %v", err)
+		}
+	case "csv":
+		if err := measurement.CSV(w, latency.CSVOptions{NoHeader: options.NoCSVHeader}); err != nil {
+			log.Printf("unable to write csv output: %v", err)
+		}
+	case "svg":
+		fmt.Fprint(w, measurement.SVG())
+	case "mermaid":
+		fmt.Fprintf(w, "```mermaid\n%s```\n", measurement.Mermaid())
+	case "folded":
+		fmt.Fprint(w, measurement.Folded())
+	case "speedscope":
+		speedscope, err := measurement.Speedscope()
+		if err != nil {
+			log.Printf("unable to marshal speedscope output: %v", err)
+		} else {
+			fmt.Fprintln(w, string(speedscope))
+		}
+	default:
+		fallthrough
+	case "markdown":
+		var hiddenColumns []string
+		if options.NoComments {
+			hiddenColumns = append(hiddenColumns, latency.ChartColumnComment)
+		}
+		// the columns and precision are validated on startup
+		columns, _ := latency.ParseChartColumns(options.ChartColumns)
+		precision, _ := parsePrecision(options.Precision)
+		measurement.Chart(w, latency.ChartOptions{HiddenColumns: hiddenColumns, Columns: columns, TimestampFormat: options.ChartTimestampFormat, Precision: precision})
+	}
+}
+
+// writeOutputFile replaces the file with the output, it is written to a temp file in the same directory and renamed
+// so a reader of the file, i.e. on a hostPath, never sees a partially written report
+func writeOutputFile(file string, output []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+".*")
+	if err != nil {
+		return fmt.Errorf("unable to create output file %s: %w", file, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(output); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to write output file %s: %w", file, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to write output file %s: %w", file, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("unable to set the mode of output file %s: %w", file, err)
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return fmt.Errorf("unable to replace output file %s: %w", file, err)
+	}
+	return nil
+}
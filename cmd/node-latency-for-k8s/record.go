@@ -0,0 +1,84 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+)
+
+// recordCommand is the subcommand that bundles the raw inputs of a measurement for offline analysis
+const recordCommand = "record"
+
+type RecordOptions struct {
+	OutputFile   string
+	Paths        string
+	IMDSEndpoint string
+	NoIMDS       bool
+}
+
+// runRecord writes the logs and IMDS responses of the node to a tar.gz with a manifest, so the boot can be analyzed off of the node
+func runRecord(args []string) {
+	f := flag.NewFlagSet(fmt.Sprintf("%s %s", path.Base(os.Args[0]), recordCommand), flag.ExitOnError)
+	f.Usage = HelpFunc(f)
+	options := RecordOptions{}
+	hostname, _ := os.Hostname()
+	defaultOutputFile := fmt.Sprintf("node-latency-%s-%d.tar.gz", lo.Ternary(hostname == "", "node", hostname), time.Now().Unix())
+	f.StringVar(&options.OutputFile, "output-file", strEnv("OUTPUT_FILE", defaultOutputFile), "path of the tar.gz to write, default: node-latency-<hostname>-<unix time>.tar.gz")
+	f.StringVar(&options.Paths, "paths", strEnv("PATHS", ""), fmt.Sprintf("(optional) comma separated log files or globs to record in addition to %v", latency.RecordPaths))
+	f.StringVar(&options.IMDSEndpoint, "imds-endpoint", strEnv("IMDS_ENDPOINT", "http://169.254.169.254"), "IMDS endpoint for testing, default: http://169.254.169.254")
+	f.BoolVar(&options.NoIMDS, "no-imds", boolEnv("NO_IMDS", false), "Do not record the EC2 Instance Metadata Service (IMDS) responses, default: false")
+	lo.Must0(f.Parse(args))
+
+	ctx := context.Background()
+	opts := latency.RecordOptions{
+		Paths:   lo.Filter(strings.Split(options.Paths, ","), func(p string, _ int) bool { return p != "" }),
+		Version: version,
+	}
+	if !options.NoIMDS {
+		cfg, err := config.LoadDefaultConfig(ctx, withIMDSEndpoint(options.IMDSEndpoint))
+		if err != nil {
+			log.Fatalf("unable to load AWS SDK config, %s", err)
+		}
+		opts.IMDS = imds.NewFromConfig(cfg)
+	}
+	file, err := os.Create(options.OutputFile)
+	if err != nil {
+		log.Fatalf("Unable to create %s: %s", options.OutputFile, err)
+	}
+	defer file.Close()
+	manifest, err := latency.Record(ctx, file, opts)
+	if err != nil {
+		log.Printf("Unable to record all inputs: %s\n", err)
+	}
+	if manifest != nil {
+		for _, skipped := range manifest.Skipped {
+			log.Printf("Skipped %s\n", skipped)
+		}
+		log.Printf("Recorded %d inputs to %s\n", len(manifest.Files), options.OutputFile)
+	}
+}
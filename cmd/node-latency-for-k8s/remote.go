@@ -0,0 +1,160 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+)
+
+// remoteCommand is the subcommand that records nodes over SSM or SSH and measures the recordings locally
+const remoteCommand = "remote"
+
+type RemoteOptions struct {
+	InstanceIDs    string
+	SSHHosts       string
+	SSHArgs        string
+	S3Bucket       string
+	S3Prefix       string
+	OutputDir      string
+	Output         string
+	Profiles       string
+	TimeoutSeconds int
+}
+
+// runRemote records each instance with SSM Run Command, or each host over SSH, and measures the recordings locally,
+// so a fleet can be spot-checked without deploying the DaemonSet
+func runRemote(args []string) {
+	f := flag.NewFlagSet(fmt.Sprintf("%s %s", path.Base(os.Args[0]), remoteCommand), flag.ExitOnError)
+	f.Usage = HelpFunc(f)
+	options := RemoteOptions{}
+	f.StringVar(&options.InstanceIDs, "instance-ids", strEnv("INSTANCE_IDS", ""), "(optional) comma separated EC2 instance IDs to record with SSM Run Command, --s3-bucket is required")
+	f.StringVar(&options.SSHHosts, "ssh-hosts", strEnv("SSH_HOSTS", ""), "(optional) comma separated hosts to record over SSH with sudo, i.e. ec2-user@10.0.0.1")
+	f.StringVar(&options.SSHArgs, "ssh-args", strEnv("SSH_ARGS", ""), "(optional) space separated args passed to the ssh client before the host, i.e. \"-i key.pem -o StrictHostKeyChecking=no\"")
+	f.StringVar(&options.S3Bucket, "s3-bucket", strEnv("S3_BUCKET", ""), "S3 bucket the SSM agent uploads the recordings to, since SSM truncates the command output")
+	f.StringVar(&options.S3Prefix, "s3-prefix", strEnv("S3_PREFIX", "node-latency-for-k8s/remote"), "S3 key prefix of the uploaded recordings, default: node-latency-for-k8s/remote")
+	f.StringVar(&options.OutputDir, "output-dir", strEnv("OUTPUT_DIR", ""), "(optional) directory to keep the recordings in as <instance ID or host>.tar.gz to --replay later, default: the recordings are removed")
+	f.StringVar(&options.Output, "output", strEnv("OUTPUT", "markdown"), "output type of each measurement (markdown, json, csv, html, mermaid, or svg), default: markdown")
+	f.StringVar(&options.Profiles, "profiles", strEnv("PROFILES", ""), fmt.Sprintf("(optional) comma separated profiles that customize the default sources and events for a node OS, runtime, or add-on %v", latency.ProfileNames()))
+	f.IntVar(&options.TimeoutSeconds, "timeout", intEnv("TIMEOUT", 300), "Timeout in seconds to record all nodes, default: 300")
+	lo.Must0(f.Parse(args))
+
+	instanceIDs := lo.Filter(strings.Split(options.InstanceIDs, ","), func(id string, _ int) bool { return id != "" })
+	sshHosts := lo.Filter(strings.Split(options.SSHHosts, ","), func(h string, _ int) bool { return h != "" })
+	if len(instanceIDs)+len(sshHosts) == 0 {
+		log.Fatalf("--instance-ids or --ssh-hosts is required")
+	}
+	if len(instanceIDs) > 0 && options.S3Bucket == "" {
+		log.Fatalf("--s3-bucket is required to record instances with SSM")
+	}
+	outputDir := options.OutputDir
+	if outputDir == "" {
+		tempDir, err := os.MkdirTemp("", "node-latency-remote-")
+		if err != nil {
+			log.Fatalf("Unable to create recordings directory: %s", err)
+		}
+		defer os.RemoveAll(tempDir)
+		outputDir = tempDir
+	} else if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		log.Fatalf("Unable to create recordings directory: %s", err)
+	}
+
+	timeout := time.Duration(options.TimeoutSeconds) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var recordings []latency.RemoteRecording
+	if len(instanceIDs) > 0 {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			log.Fatalf("unable to load AWS SDK config, %s", err)
+		}
+		ssmRecordings, err := latency.CollectSSM(ctx, ssm.NewFromConfig(cfg), s3.NewFromConfig(cfg), latency.SSMCollectOptions{
+			InstanceIDs: instanceIDs,
+			Bucket:      options.S3Bucket,
+			Prefix:      options.S3Prefix,
+			Timeout:     timeout,
+		})
+		if err != nil {
+			log.Printf("Unable to record instances with SSM: %s\n", err)
+		}
+		recordings = append(recordings, ssmRecordings...)
+	}
+	for _, host := range sshHosts {
+		recording, err := latency.CollectSSH(ctx, host, strings.Fields(options.SSHArgs)...)
+		recordings = append(recordings, latency.RemoteRecording{Target: host, Recording: recording, Err: err})
+	}
+
+	profiles := lo.Filter(strings.Split(options.Profiles, ","), func(p string, _ int) bool { return p != "" })
+	for _, recording := range recordings {
+		if recording.Err != nil {
+			log.Printf("Unable to record %s: %s\n", recording.Target, recording.Err)
+			continue
+		}
+		file := filepath.Join(outputDir, recording.Target+".tar.gz")
+		if err := os.WriteFile(file, recording.Recording, 0o600); err != nil {
+			log.Printf("Unable to write the recording of %s: %s\n", recording.Target, err)
+			continue
+		}
+		log.Printf("Recorded %s\n", recording.Target)
+		measurement, err := measureRecording(file, profiles)
+		if err != nil {
+			log.Printf("Unable to measure the recording of %s: %s\n", recording.Target, err)
+			continue
+		}
+		writeOutput(os.Stdout, Options{Output: options.Output, Runs: 1}, []*latency.Measurement{measurement})
+	}
+}
+
+// measureRecording measures the recording with the default sources and events of the profiles
+func measureRecording(file string, profiles []string) (*latency.Measurement, error) {
+	replay, err := latency.OpenReplay(file)
+	if err != nil {
+		return nil, err
+	}
+	defer replay.Close()
+	measurer := latency.New().WithRoot(replay.Root)
+	if replay.IMDS != nil {
+		measurer = measurer.WithIMDS(replay.IMDS)
+	}
+	measurer, err = measurer.WithProfiles(profiles...)
+	if err != nil {
+		return nil, err
+	}
+	measurer, err = measurer.RegisterDefaultSources().RegisterDefaultEvents()
+	if err != nil {
+		log.Printf("Unable to register all default events: %s\n", err)
+	}
+	measurement := measurer.Measure(context.Background())
+	for _, t := range measurement.Timings {
+		if t.Error != nil {
+			log.Printf("Unable to retrieve timing for Event \"%s\": %v\n", t.Event.Name, t.Error)
+		}
+	}
+	return measurement, nil
+}
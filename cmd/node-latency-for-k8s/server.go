@@ -0,0 +1,66 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+	"github.com/awslabs/node-latency-for-k8s/pkg/server"
+)
+
+// serverCommand is the subcommand that runs the central aggregation server, i.e. as a Deployment
+const serverCommand = "server"
+
+type ServerOptions struct {
+	Port             int
+	WindowSeconds    int
+	Histograms       bool
+	HistogramBuckets string
+}
+
+// runServer runs the aggregation server that agents push measurements to with --aggregation-server-url
+func runServer(args []string) {
+	f := flag.NewFlagSet(fmt.Sprintf("%s %s", path.Base(os.Args[0]), serverCommand), flag.ExitOnError)
+	f.Usage = HelpFunc(f)
+	options := ServerOptions{}
+	f.IntVar(&options.Port, "port", intEnv("SERVER_PORT", 8080), "The port to receive measurements on and serve aggregated prometheus metrics from, default: 8080")
+	f.IntVar(&options.WindowSeconds, "window", intEnv("SERVER_WINDOW", 86400), "Window in seconds of the measurements that fleet-wide percentiles are aggregated over, default: 86400")
+	f.BoolVar(&options.Histograms, "histograms", boolEnv("SERVER_HISTOGRAMS", false), "Aggregate the timings into prometheus histograms instead of summaries, so percentiles can be computed over any time range and across servers, default: false")
+	f.StringVar(&options.HistogramBuckets, "histogram-buckets", strEnv("SERVER_HISTOGRAM_BUCKETS", ""), "(optional) comma separated upper bounds of the histogram buckets with --histograms, i.e. 10s,30s,1m,5m, default: 5s,10s,15s,20s,30s,45s,1m,1m30s,2m,3m,5m,10m")
+	lo.Must0(f.Parse(args))
+	buckets, err := latency.ParseHistogramBuckets(options.HistogramBuckets)
+	if err != nil {
+		log.Fatalf("Unable to parse histogram buckets: %s", err)
+	}
+
+	addr := fmt.Sprintf(":%d", options.Port)
+	log.Printf("Receiving measurements on %s%s and serving aggregated metrics on %s/metrics\n", addr, server.MeasurementsPath, addr)
+	aggregator := server.New(time.Duration(options.WindowSeconds) * time.Second)
+	if options.Histograms {
+		aggregator = aggregator.WithHistograms(buckets)
+	}
+	if err := aggregator.ListenAndServe(context.Background(), addr); err != nil {
+		log.Fatalf("Unable to run the aggregation server: %s", err)
+	}
+}
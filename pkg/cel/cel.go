@@ -0,0 +1,161 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cel evaluates a small subset of Common Expression Language (CEL) expressions over log lines and JSON log
+// entries, i.e. to match the lines of an event or extract its comment, without a dependency on cel-go. The subset is
+// string, number, bool, and null literals, the declared variables and the selection of their fields, the !, -, &&, ||,
+// comparison, and + operators, parentheses, has(), string(), and the contains, startsWith, endsWith, and matches
+// functions of strings. All numbers are doubles, and lists, maps, indexing, macros, and the other functions of CEL are
+// not supported.
+package cel
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Variables the expressions of events may reference
+const (
+	// VarLine is the raw log line
+	VarLine = "line"
+	// VarEntry is the decoded JSON entry of the log line, only for JSON log sources
+	VarEntry = "entry"
+)
+
+// Program is a compiled expression
+type Program struct {
+	expression string
+	root       node
+}
+
+// Compile parses the expression, which may only reference the declared variables
+func Compile(expression string, vars ...string) (*Program, error) {
+	p := &parser{vars: vars}
+	if err := p.lex(expression); err != nil {
+		return nil, fmt.Errorf("unable to parse expression \"%s\": %w", expression, err)
+	}
+	root, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse expression \"%s\": %w", expression, err)
+	}
+	return &Program{expression: expression, root: root}, nil
+}
+
+// String is the source of the expression
+func (p *Program) String() string {
+	return p.expression
+}
+
+// Eval evaluates the expression with the values of the variables
+func (p *Program) Eval(vars map[string]any) (any, error) {
+	return p.root.eval(vars)
+}
+
+// Matches checks if the expression evaluates to true, an expression which fails to evaluate does not match
+func (p *Program) Matches(vars map[string]any) bool {
+	value, err := p.Eval(vars)
+	return err == nil && value == true
+}
+
+// Prefilter returns a regex of the lines which may match the expression, so that only those are decoded and evaluated, or
+// nil if every line may match. A line can only match if it holds a literal string the expression requires, i.e. the
+// "Started" of line.contains("Started") && entry.level == "info", as long as the literal is not escaped in JSON.
+func (p *Program) Prefilter() *regexp.Regexp {
+	var longest string
+	for _, literal := range requiredLiterals(p.root) {
+		if len(literal) > len(longest) && verbatimInJSON(literal) {
+			longest = literal
+		}
+	}
+	if longest == "" {
+		return nil
+	}
+	return regexp.MustCompile(fmt.Sprintf(`.*%s.*`, regexp.QuoteMeta(longest)))
+}
+
+// requiredLiterals are the string literals which the value of a variable, or of one of its fields, is compared to or
+// searched for in every conjunct of the expression, so the log line must hold them for the expression to be true
+func requiredLiterals(n node) []string {
+	switch n := n.(type) {
+	case *binaryNode:
+		switch n.op {
+		case "&&":
+			return append(requiredLiterals(n.left), requiredLiterals(n.right)...)
+		case "==":
+			if s, ok := stringLiteral(n.right); ok && isPath(n.left) {
+				return []string{s}
+			}
+			if s, ok := stringLiteral(n.left); ok && isPath(n.right) {
+				return []string{s}
+			}
+		}
+	case *callNode:
+		switch n.function {
+		case "contains", "startsWith", "endsWith":
+			if s, ok := stringLiteral(n.arg); ok && isPath(n.target) {
+				return []string{s}
+			}
+		}
+	}
+	return nil
+}
+
+// isPath checks if the node is a variable or the selection of its fields, whose string values are held verbatim by the line
+func isPath(n node) bool {
+	switch n := n.(type) {
+	case *identNode:
+		return true
+	case *selectNode:
+		return isPath(n.operand)
+	}
+	return false
+}
+
+// stringLiteral returns the value of a string literal node
+func stringLiteral(n node) (string, bool) {
+	if l, ok := n.(*literalNode); ok {
+		s, ok := l.value.(string)
+		return s, ok
+	}
+	return "", false
+}
+
+// verbatimInJSON checks if the string is written as is in JSON, i.e. it has no characters which are escaped
+func verbatimInJSON(s string) bool {
+	encoded, err := json.Marshal(s)
+	return err == nil && string(encoded) == strconv.Quote(s) && !strings.ContainsAny(s, `"\`)
+}
+
+// Format formats the value of an expression as a string, i.e. for a timing comment
+// Strings are returned as is, null is empty, and lists and maps are formatted as JSON.
+func Format(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
@@ -0,0 +1,218 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cel
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// entry is a decoded kubelet JSON log entry, numbers are json.Number like the entries of JSON log sources
+func entry(t *testing.T) map[string]any {
+	t.Helper()
+	decoder := json.NewDecoder(strings.NewReader(`{"msg": "Successfully pulled image", "image": "nginx:1.25", "duration": 12.5, "attempts": 2, "ready": true, "pod": {"name": "web-0", "namespace": "default"}, "reason": null}`))
+	decoder.UseNumber()
+	var e map[string]any
+	if err := decoder.Decode(&e); err != nil {
+		t.Fatalf("unable to decode the entry: %v", err)
+	}
+	return e
+}
+
+func TestCompile(t *testing.T) {
+	for _, tc := range []struct {
+		expression string
+		err        string
+	}{
+		{expression: `line.contains("Started kubelet")`},
+		{expression: `entry.pod.name == 'web-0' && !(entry.attempts > 1 || has(entry.reason))`},
+		{expression: `-entry.duration < -1.5`},
+		{expression: `string(entry.duration) + "s"`},
+		{expression: `"a \"quoted\" \\ \n\t 'string'"`},
+		{expression: `line.matches("^Jan [0-9]+")`},
+		{expression: ``, err: "unexpected end of expression at 0, expected a value"},
+		{expression: `line.contains("a"`, err: "unexpected end of expression at 17, expected \")\""},
+		{expression: `line contains`, err: "unexpected contains at 5, expected an operator"},
+		{expression: `line.`, err: "expected a field name"},
+		{expression: `line.size()`, err: "unsupported function size at 5"},
+		{expression: `lines.contains("a")`, err: "undeclared variable lines at 0"},
+		{expression: `size(line) > 1`, err: "undeclared variable size at 0"},
+		{expression: `has(entry)`, err: "has at 0 requires a field selection"},
+		{expression: `line.matches("(")`, err: "invalid regex at 5"},
+		{expression: `line == "unterminated`, err: "unterminated string at 8"},
+		{expression: `line == "\x41"`, err: "unsupported escape \\x at 9"},
+		{expression: `line[0] == "J"`, err: "unexpected character '[' at 4"},
+		{expression: `line == 1.2.3`, err: "invalid number 1.2.3 at 8"},
+	} {
+		t.Run(tc.expression, func(t *testing.T) {
+			program, err := Compile(tc.expression, VarLine, VarEntry)
+			if tc.err == "" {
+				if err != nil {
+					t.Errorf("unable to compile: %v", err)
+				} else if program.String() != tc.expression {
+					t.Errorf("expected the program of %s, got %s", tc.expression, program)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected an error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestEval(t *testing.T) {
+	vars := map[string]any{
+		VarLine:  `Jan 14 10:00:06 ip-192-168-23-248 kubelet[2046]: {"msg": "Successfully pulled image"}`,
+		VarEntry: entry(t),
+	}
+	for _, tc := range []struct {
+		expression string
+		value      any
+		err        string
+	}{
+		// literals
+		{expression: `"kubelet"`, value: "kubelet"},
+		{expression: `'it\'s'`, value: "it's"},
+		{expression: `12.5`, value: 12.5},
+		{expression: `true`, value: true},
+		{expression: `null`, value: nil},
+		// variables and fields
+		{expression: `entry.image`, value: "nginx:1.25"},
+		{expression: `entry.attempts`, value: 2.0},
+		{expression: `entry.pod.namespace`, value: "default"},
+		{expression: `entry.reason`, value: nil},
+		{expression: `entry.node`, err: "no such key: node"},
+		{expression: `entry.image.tag`, err: "unable to select a field of string"},
+		{expression: `has(entry.duration)`, value: true},
+		{expression: `has(entry.pod.uid)`, value: false},
+		{expression: `has(entry.node.name)`, err: "no such key: node"},
+		// operators
+		{expression: `!entry.ready`, value: false},
+		{expression: `-entry.duration`, value: -12.5},
+		{expression: `!entry.image`, err: "no such overload: !(string)"},
+		{expression: `entry.duration > 10`, value: true},
+		{expression: `entry.attempts <= 1`, value: false},
+		{expression: `entry.image >= "nginx"`, value: true},
+		{expression: `entry.image < 1`, err: "no such overload: <(string, double)"},
+		{expression: `entry.attempts == 2`, value: true},
+		{expression: `entry.attempts == "2"`, value: false},
+		{expression: `entry.reason == null`, value: true},
+		{expression: `entry.pod != null`, err: "no such overload: ==(map, null)"},
+		{expression: `entry.image + " in " + string(entry.duration) + "s"`, value: "nginx:1.25 in 12.5s"},
+		{expression: `entry.attempts + 1`, value: 3.0},
+		{expression: `entry.image + 1`, err: "no such overload: +(string, double)"},
+		{expression: `string(entry.ready)`, value: "true"},
+		{expression: `string(entry.pod)`, err: "no such overload: string(map)"},
+		{expression: `1 + 2 == 3 && "a" + "b" == "ab"`, value: true},
+		// errors are absorbed by the side which decides && and ||
+		{expression: `has(entry.node) && entry.node.ready`, value: false},
+		{expression: `entry.node.ready && false`, value: false},
+		{expression: `entry.node.ready || true`, value: true},
+		{expression: `entry.node.ready || false`, err: "no such key: node"},
+		{expression: `entry.image && true`, err: "no such overload: &&(string)"},
+		// string functions
+		{expression: `line.contains("Successfully pulled")`, value: true},
+		{expression: `line.startsWith("Jan 14")`, value: true},
+		{expression: `line.endsWith("}")`, value: true},
+		{expression: `line.matches("kubelet\\[[0-9]+\\]")`, value: true},
+		{expression: `entry.image.matches(entry.pod.name)`, value: false},
+		{expression: `entry.image.matches(entry.msg + "(")`, err: "invalid regex"},
+		{expression: `entry.duration.contains("1")`, err: "no such overload: contains(double, string)"},
+	} {
+		t.Run(tc.expression, func(t *testing.T) {
+			program, err := Compile(tc.expression, VarLine, VarEntry)
+			if err != nil {
+				t.Fatalf("unable to compile: %v", err)
+			}
+			value, err := program.Eval(vars)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("expected an error containing %q, got %v (%v)", tc.err, err, value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to evaluate: %v", err)
+			}
+			if !reflect.DeepEqual(value, tc.value) {
+				t.Errorf("expected %#v, got %#v", tc.value, value)
+			}
+			if matches := program.Matches(vars); matches != (tc.value == true) {
+				t.Errorf("expected Matches to be %t", tc.value == true)
+			}
+		})
+	}
+	program, err := Compile(`line.contains("kubelet")`, VarLine)
+	if err != nil {
+		t.Fatalf("unable to compile: %v", err)
+	}
+	if _, err := program.Eval(map[string]any{}); err == nil {
+		t.Error("expected an error without a value for the line")
+	}
+}
+
+func TestPrefilter(t *testing.T) {
+	for _, tc := range []struct {
+		expression string
+		prefilter  string
+	}{
+		{expression: `line.contains("Started kubelet")`, prefilter: `.*Started kubelet.*`},
+		{expression: `entry.msg == "pulled" && entry.image.startsWith("public.ecr.aws/")`, prefilter: `.*public\.ecr\.aws/.*`},
+		{expression: `"ready" == entry.state`, prefilter: `.*ready.*`},
+		{expression: `line.contains("Started") || line.contains("Stopped")`},
+		{expression: `!line.contains("Started")`},
+		{expression: `entry.msg == "say \"hi\""`},
+		{expression: `entry.attempts > 1`},
+	} {
+		t.Run(tc.expression, func(t *testing.T) {
+			program, err := Compile(tc.expression, VarLine, VarEntry)
+			if err != nil {
+				t.Fatalf("unable to compile: %v", err)
+			}
+			prefilter := program.Prefilter()
+			if tc.prefilter == "" {
+				if prefilter != nil {
+					t.Errorf("expected every line to be evaluated, got prefilter %s", prefilter)
+				}
+				return
+			}
+			if prefilter == nil || prefilter.String() != tc.prefilter {
+				t.Errorf("expected prefilter %s, got %v", tc.prefilter, prefilter)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for _, tc := range []struct {
+		value     any
+		formatted string
+	}{
+		{value: nil, formatted: ""},
+		{value: "nginx", formatted: "nginx"},
+		{value: 12.5, formatted: "12.5"},
+		{value: 1e21, formatted: "1000000000000000000000"},
+		{value: false, formatted: "false"},
+		{value: map[string]any{"name": "web-0"}, formatted: `{"name":"web-0"}`},
+		{value: []any{"a", json.Number("1")}, formatted: `["a",1]`},
+	} {
+		if formatted := Format(tc.value); formatted != tc.formatted {
+			t.Errorf("Format(%#v) = %s, expected %s", tc.value, formatted, tc.formatted)
+		}
+	}
+}
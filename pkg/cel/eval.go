@@ -0,0 +1,332 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// node is a node of the expression's syntax tree
+// Values are strings, numbers as float64, bools, nil, and the maps and lists of decoded JSON.
+type node interface {
+	eval(vars map[string]any) (any, error)
+}
+
+type literalNode struct{ value any }
+
+type identNode struct{ name string }
+
+// selectNode selects the field of a map
+type selectNode struct {
+	operand node
+	field   string
+}
+
+// hasNode tests whether the field of a map is set
+type hasNode struct {
+	operand node
+	field   string
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+type binaryNode struct {
+	op    string
+	left  node
+	right node
+}
+
+// callNode calls the string function, or a member function on the target
+type callNode struct {
+	function string
+	target   node
+	arg      node
+	re       *regexp.Regexp
+}
+
+func (n *literalNode) eval(map[string]any) (any, error) {
+	return n.value, nil
+}
+
+func (n *identNode) eval(vars map[string]any) (any, error) {
+	value, ok := vars[n.name]
+	if !ok {
+		return nil, fmt.Errorf("no value for variable %s", n.name)
+	}
+	return normalize(value), nil
+}
+
+func (n *selectNode) eval(vars map[string]any) (any, error) {
+	fields, err := fieldsOf(n.operand, vars)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := fields[n.field]
+	if !ok {
+		return nil, fmt.Errorf("no such key: %s", n.field)
+	}
+	return normalize(value), nil
+}
+
+func (n *hasNode) eval(vars map[string]any) (any, error) {
+	fields, err := fieldsOf(n.operand, vars)
+	if err != nil {
+		return nil, err
+	}
+	_, ok := fields[n.field]
+	return ok, nil
+}
+
+// fieldsOf evaluates the operand of a field selection, which must be a map
+func fieldsOf(operand node, vars map[string]any) (map[string]any, error) {
+	value, err := operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	fields, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unable to select a field of %s", typeName(value))
+	}
+	return fields, nil
+}
+
+func (n *unaryNode) eval(vars map[string]any) (any, error) {
+	value, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case bool:
+		if n.op == "!" {
+			return !v, nil
+		}
+	case float64:
+		if n.op == "-" {
+			return -v, nil
+		}
+	}
+	return nil, noOverload(n.op, value)
+}
+
+func (n *binaryNode) eval(vars map[string]any) (any, error) {
+	if n.op == "&&" || n.op == "||" {
+		return n.logical(vars)
+	}
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==", "!=":
+		eq, err := equal(left, right)
+		if err != nil {
+			return nil, err
+		}
+		return eq == (n.op == "=="), nil
+	case "+":
+		switch l := left.(type) {
+		case string:
+			if r, ok := right.(string); ok {
+				return l + r, nil
+			}
+		case float64:
+			if r, ok := right.(float64); ok {
+				return l + r, nil
+			}
+		}
+		return nil, noOverload(n.op, left, right)
+	}
+	cmp, err := compare(n.op, left, right)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+// logical evaluates && and || commutatively like CEL, an error of one side is absorbed if the other side decides the result
+func (n *binaryNode) logical(vars map[string]any) (any, error) {
+	// the value which decides the result, false for && and true for ||
+	decisive := n.op == "||"
+	left, leftErr := n.left.eval(vars)
+	if leftErr == nil {
+		l, ok := left.(bool)
+		if !ok {
+			return nil, noOverload(n.op, left)
+		}
+		if l == decisive {
+			return decisive, nil
+		}
+	}
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := right.(bool)
+	if !ok {
+		return nil, noOverload(n.op, right)
+	}
+	if r == decisive || leftErr == nil {
+		return r, nil
+	}
+	return nil, leftErr
+}
+
+func (n *callNode) eval(vars map[string]any) (any, error) {
+	arg, err := n.arg.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	if n.target == nil {
+		return toString(arg)
+	}
+	target, err := n.target.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	s, sOK := target.(string)
+	a, aOK := arg.(string)
+	if !sOK || !aOK {
+		return nil, noOverload(n.function, target, arg)
+	}
+	switch n.function {
+	case "contains":
+		return strings.Contains(s, a), nil
+	case "startsWith":
+		return strings.HasPrefix(s, a), nil
+	case "endsWith":
+		return strings.HasSuffix(s, a), nil
+	}
+	re := n.re
+	if re == nil {
+		if re, err = regexp.Compile(a); err != nil {
+			return nil, fmt.Errorf("invalid regex: %w", err)
+		}
+	}
+	return re.MatchString(s), nil
+}
+
+// normalize converts the numbers of decoded JSON to float64
+func normalize(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	}
+	return value
+}
+
+// equal compares strings, numbers, bools, and null, values of different types are not equal
+func equal(left any, right any) (bool, error) {
+	switch left.(type) {
+	case nil, string, float64, bool:
+	default:
+		return false, noOverload("==", left, right)
+	}
+	switch right.(type) {
+	case nil, string, float64, bool:
+	default:
+		return false, noOverload("==", left, right)
+	}
+	return left == right, nil
+}
+
+// compare orders a pair of numbers or strings
+func compare(op string, left any, right any) (int, error) {
+	switch l := left.(type) {
+	case float64:
+		if r, ok := right.(float64); ok {
+			switch {
+			case l < r:
+				return -1, nil
+			case l > r:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case string:
+		if r, ok := right.(string); ok {
+			return strings.Compare(l, r), nil
+		}
+	}
+	return 0, noOverload(op, left, right)
+}
+
+// toString converts a string, number, or bool to a string
+func toString(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return "", noOverload("string", value)
+}
+
+// noOverload is the error of an operator or function which does not apply to the types of its arguments
+func noOverload(function string, args ...any) error {
+	types := make([]string, len(args))
+	for i, arg := range args {
+		types[i] = typeName(arg)
+	}
+	return errors.New("no such overload: " + function + "(" + strings.Join(types, ", ") + ")")
+}
+
+// typeName is the type of the value
+func typeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "double"
+	case bool:
+		return "bool"
+	case map[string]any:
+		return "map"
+	case []any:
+		return "list"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,327 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cel
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Token kinds of the lexer
+const (
+	tokenEOF = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenOp
+)
+
+type token struct {
+	kind  int
+	text  string
+	value any
+	pos   int
+}
+
+// operators are the operators and punctuation of the grammar, longest first so "==" is not lexed as "=" "="
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "+", "-", "!", ".", ",", "(", ")"}
+
+// memberFunctions are the string functions called on a string with a single string argument
+var memberFunctions = []string{"contains", "startsWith", "endsWith", "matches"}
+
+// parser is a recursive descent parser of the grammar
+type parser struct {
+	tokens []token
+	pos    int
+	vars   []string
+}
+
+// lex splits the expression into tokens
+func (p *parser) lex(expression string) error {
+	for i := 0; i < len(expression); {
+		c := expression[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(expression) && (isIdentStart(expression[j]) || isDigit(expression[j])) {
+				j++
+			}
+			p.tokens = append(p.tokens, token{kind: tokenIdent, text: expression[i:j], pos: i})
+			i = j
+		case isDigit(c):
+			j := i + 1
+			for j < len(expression) && (isDigit(expression[j]) || (expression[j] == '.' && j+1 < len(expression) && isDigit(expression[j+1]))) {
+				j++
+			}
+			value, err := strconv.ParseFloat(expression[i:j], 64)
+			if err != nil {
+				return fmt.Errorf("invalid number %s at %d", expression[i:j], i)
+			}
+			p.tokens = append(p.tokens, token{kind: tokenNumber, text: expression[i:j], value: value, pos: i})
+			i = j
+		case c == '"' || c == '\'':
+			value, end, err := lexString(expression, i)
+			if err != nil {
+				return err
+			}
+			p.tokens = append(p.tokens, token{kind: tokenString, text: expression[i:end], value: value, pos: i})
+			i = end
+		default:
+			op, ok := "", false
+			for _, candidate := range operators {
+				if strings.HasPrefix(expression[i:], candidate) {
+					op, ok = candidate, true
+					break
+				}
+			}
+			if !ok {
+				return fmt.Errorf("unexpected character %q at %d", c, i)
+			}
+			p.tokens = append(p.tokens, token{kind: tokenOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	p.tokens = append(p.tokens, token{kind: tokenEOF, text: "end of expression", pos: len(expression)})
+	return nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// lexString lexes a single or double quoted string at the start, only the quotes, backslash, \n, and \t are escaped
+func lexString(expression string, start int) (string, int, error) {
+	quote := expression[start]
+	var value strings.Builder
+	for i := start + 1; i < len(expression); i++ {
+		switch c := expression[i]; {
+		case c == quote:
+			return value.String(), i + 1, nil
+		case c == '\\' && i+1 < len(expression):
+			i++
+			switch escaped := expression[i]; escaped {
+			case '\\', '"', '\'':
+				value.WriteByte(escaped)
+			case 'n':
+				value.WriteByte('\n')
+			case 't':
+				value.WriteByte('\t')
+			default:
+				return "", 0, fmt.Errorf("unsupported escape \\%c at %d", escaped, i-1)
+			}
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string at %d", start)
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the operator if it is next
+func (p *parser) accept(op string) bool {
+	if tok := p.peek(); tok.kind == tokenOp && tok.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// expect consumes the operator or fails
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		return p.unexpected(fmt.Sprintf("\"%s\"", op))
+	}
+	return nil
+}
+
+func (p *parser) unexpected(expected string) error {
+	tok := p.peek()
+	return fmt.Errorf("unexpected %s at %d, expected %s", tok.text, tok.pos, expected)
+}
+
+// parse parses the whole expression
+func (p *parser) parse() (node, error) {
+	root, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, p.unexpected("an operator")
+	}
+	return root, nil
+}
+
+// precedences are the binary operators from the lowest to the highest precedence
+var precedences = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+"},
+}
+
+// binary parses the left associative binary operators of the precedence level and higher
+func (p *parser) binary(level int) (node, error) {
+	if level == len(precedences) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok.kind != tokenOp || !contains(precedences[level], tok.text) {
+			return left, nil
+		}
+		p.next()
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: tok.text, left: left, right: right}
+	}
+}
+
+func contains(ops []string, op string) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// unary = member | "!" unary | "-" unary
+func (p *parser) unary() (node, error) {
+	if tok := p.peek(); tok.kind == tokenOp && (tok.text == "!" || tok.text == "-") {
+		p.next()
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: tok.text, operand: operand}, nil
+	}
+	operand, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	return p.member(operand)
+}
+
+// member parses the field selections and member calls on the operand
+func (p *parser) member(operand node) (node, error) {
+	for p.accept(".") {
+		if p.peek().kind != tokenIdent {
+			return nil, p.unexpected("a field name")
+		}
+		tok := p.next()
+		if !p.accept("(") {
+			operand = &selectNode{operand: operand, field: tok.text}
+			continue
+		}
+		if !contains(memberFunctions, tok.text) {
+			return nil, fmt.Errorf("unsupported function %s at %d", tok.text, tok.pos)
+		}
+		arg, err := p.binary(0)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		call := &callNode{function: tok.text, target: operand, arg: arg}
+		// the regex of matches is compiled once if it is a literal
+		if s, ok := stringLiteral(arg); ok && tok.text == "matches" {
+			if call.re, err = regexp.Compile(s); err != nil {
+				return nil, fmt.Errorf("invalid regex at %d: %w", tok.pos, err)
+			}
+		}
+		operand = call
+	}
+	return operand, nil
+}
+
+// primary = literal | ident | "has" "(" ident "." field ")" | "string" "(" expr ")" | "(" expr ")"
+func (p *parser) primary() (node, error) {
+	start := p.pos
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber, tokenString:
+		return &literalNode{value: tok.value}, nil
+	case tokenIdent:
+		switch tok.text {
+		case "true", "false":
+			return &literalNode{value: tok.text == "true"}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		case "has", "string":
+			if !p.accept("(") {
+				break
+			}
+			arg, err := p.binary(0)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			if tok.text == "string" {
+				return &callNode{function: tok.text, arg: arg}, nil
+			}
+			// has tests whether the field is set rather than selecting it
+			sel, ok := arg.(*selectNode)
+			if !ok {
+				return nil, fmt.Errorf("has at %d requires a field selection, i.e. has(entry.field)", tok.pos)
+			}
+			return &hasNode{operand: sel.operand, field: sel.field}, nil
+		}
+		if !contains(p.vars, tok.text) {
+			return nil, fmt.Errorf("undeclared variable %s at %d", tok.text, tok.pos)
+		}
+		return &identNode{name: tok.text}, nil
+	case tokenOp:
+		if tok.text == "(" {
+			n, err := p.binary(0)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return n, nil
+		}
+	}
+	p.pos = start
+	return nil, p.unexpected("a value")
+}
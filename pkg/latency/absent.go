@@ -0,0 +1,83 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// AbsentMetricSuffix suffixes the metric of an event that was not observed by its deadline, i.e. node_ready_absent
+const AbsentMetricSuffix = "_absent"
+
+// WithDeadlines sets how long after the first timing events must be observed by metric name, i.e. node_ready: 10m
+// The deadlines apply to both registered events and events registered afterwards.
+func (m *Measurer) WithDeadlines(deadlines map[string]time.Duration) *Measurer {
+	if m.deadlines == nil {
+		m.deadlines = map[string]time.Duration{}
+	}
+	for metric, deadline := range deadlines {
+		m.deadlines[metric] = deadline
+	}
+	for _, e := range m.events {
+		if deadline, ok := m.deadlines[e.Metric]; ok {
+			e.Deadline = deadline
+		}
+	}
+	return m
+}
+
+// absentTimings returns an errored timing for each event with a deadline that has no successful timing by its deadline
+// The timing's event has the metric suffixed with AbsentMetricSuffix and the timing is how long the event has been absent
+// since the first timing, so a stuck node reports a metric rather than nothing.
+func (m *Measurer) absentTimings(timings []*sources.Timing, now time.Time) []*sources.Timing {
+	first, ok := lo.Find(timings, func(t *sources.Timing) bool { return t.Error == nil })
+	if !ok {
+		return nil
+	}
+	var absent []*sources.Timing
+	for _, e := range m.events {
+		if e.Deadline <= 0 || now.Sub(first.Timestamp) <= e.Deadline {
+			continue
+		}
+		if lo.ContainsBy(timings, func(t *sources.Timing) bool { return t.Event == e && t.Error == nil }) {
+			continue
+		}
+		absent = append(absent, &sources.Timing{
+			Event: &sources.Event{
+				Name:          fmt.Sprintf("%s Absent", e.Name),
+				Metric:        e.Metric + AbsentMetricSuffix,
+				MatchSelector: e.MatchSelector,
+				SrcName:       e.SrcName,
+				Src:           e.Src,
+			},
+			Timestamp: now,
+			T:         now.Sub(first.Timestamp).Round(m.precision),
+			Error:     fmt.Errorf("event \"%s\" was not observed within its deadline of %s", e.Name, e.Deadline),
+		})
+	}
+	return absent
+}
+
+// absent checks if the measurement has an absent timing of the event
+func absent(measurement *Measurement, e *sources.Event) bool {
+	return lo.ContainsBy(measurement.Timings, func(t *sources.Timing) bool {
+		return t.Event.Metric == e.Metric+AbsentMetricSuffix && t.Event.SrcName == e.SrcName
+	})
+}
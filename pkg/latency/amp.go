@@ -0,0 +1,117 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/golang/snappy"
+	"github.com/samber/lo"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// ampSigningName is the sigv4 service name of Amazon Managed Service for Prometheus
+const ampSigningName = "aps"
+
+// EmitRemoteWrite writes a gauge sample per metric to a Prometheus remote_write endpoint signed with sigv4, i.e. an AMP workspace
+// The samples have the same names and labels as the Prometheus endpoint, including the namespace and const labels, so the
+// last timing of a metric wins.
+func (m *Measurement) EmitRemoteWrite(ctx context.Context, cfg aws.Config, url string, experimentDimension string, namespace string, constLabels map[string]string) error {
+	body := snappy.Encode(nil, m.remoteWriteRequest(experimentDimension, namespace, constLabels, time.Now()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve AWS credentials: %w", err)
+	}
+	payloadHash := sha256.Sum256(body)
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(payloadHash[:]), ampSigningName, cfg.Region, time.Now()); err != nil {
+		return fmt.Errorf("unable to sign remote write request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send remote write request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unable to send remote write request, status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// remoteWriteRequest encodes the metrics as a prometheus.WriteRequest protobuf
+// The message is small enough to encode by hand rather than depending on the prometheus module:
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; }
+func (m *Measurement) remoteWriteRequest(experimentDimension string, namespace string, constLabels map[string]string, now time.Time) []byte {
+	dimensions := lo.Assign(constLabels, m.metricDimensions(experimentDimension))
+	latest := map[string]*sources.Timing{}
+	for _, timing := range m.Timings {
+		latest[timing.Event.Metric] = timing
+	}
+	metrics := lo.Keys(latest)
+	sort.Strings(metrics)
+
+	var writeRequest []byte
+	for _, metric := range metrics {
+		// remote_write requires labels sorted by name
+		labels := lo.Assign(dimensions, map[string]string{"__name__": metricName(namespace, metric)})
+		names := lo.Keys(labels)
+		sort.Strings(names)
+		var series []byte
+		for _, name := range names {
+			var label []byte
+			label = protowire.AppendTag(label, 1, protowire.BytesType)
+			label = protowire.AppendString(label, name)
+			label = protowire.AppendTag(label, 2, protowire.BytesType)
+			label = protowire.AppendString(label, labels[name])
+			series = protowire.AppendTag(series, 1, protowire.BytesType)
+			series = protowire.AppendBytes(series, label)
+		}
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(latest[metric].T.Seconds()))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(now.UnixMilli()))
+		series = protowire.AppendTag(series, 2, protowire.BytesType)
+		series = protowire.AppendBytes(series, sample)
+
+		writeRequest = protowire.AppendTag(writeRequest, 1, protowire.BytesType)
+		writeRequest = protowire.AppendBytes(writeRequest, series)
+	}
+	return writeRequest
+}
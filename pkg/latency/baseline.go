@@ -0,0 +1,104 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// RegressionsMetric is the metric name of the number of events that regressed against a baseline
+const RegressionsMetric = "regressions"
+
+// Tolerance is how much slower than the baseline an event may be before it is a regression
+// Both are allowed, i.e. 10% and 5s allows a 60s baseline event to take up to 71s.
+type Tolerance struct {
+	Percent  float64
+	Duration time.Duration
+}
+
+// Regression is an event whose latency exceeded its baseline latency plus the tolerance
+type Regression struct {
+	Event           string  `json:"event"`
+	Metric          string  `json:"metric"`
+	Seconds         float64 `json:"seconds"`
+	BaselineSeconds float64 `json:"baselineSeconds"`
+	AllowedSeconds  float64 `json:"allowedSeconds"`
+}
+
+// LoadMeasurement reads a JSON Measurement from a file, i.e. the --output json of a baseline run
+func LoadMeasurement(path string) (*Measurement, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	measurement := &Measurement{}
+	if err := json.Unmarshal(data, measurement); err != nil {
+		return nil, fmt.Errorf("unable to decode measurement %s: %w", path, err)
+	}
+	return measurement, nil
+}
+
+// Regressions compares the successful timings against the first successful timing of the same metric in the baseline
+// Events which are not in the baseline are not compared.
+func (m *Measurement) Regressions(baseline *Measurement, tolerance Tolerance) []Regression {
+	successful := func(t *sources.Timing, _ int) bool { return t.Error == nil }
+	baselineTimings := lo.KeyBy(lo.Reverse(lo.Filter(baseline.Timings, successful)), func(t *sources.Timing) string { return t.Event.Metric })
+	regressions := []Regression{}
+	for _, timing := range lo.Filter(m.Timings, successful) {
+		baselineTiming, ok := baselineTimings[timing.Event.Metric]
+		if !ok {
+			continue
+		}
+		allowed := time.Duration(float64(baselineTiming.T)*(1+tolerance.Percent/100)) + tolerance.Duration
+		if timing.T > allowed {
+			regressions = append(regressions, Regression{
+				Event:           timing.Event.Name,
+				Metric:          timing.Event.Metric,
+				Seconds:         timing.T.Seconds(),
+				BaselineSeconds: baselineTiming.T.Seconds(),
+				AllowedSeconds:  allowed.Seconds(),
+			})
+		}
+	}
+	return regressions
+}
+
+// EmitRegressionsMetric posts the number of regressed events to CloudWatch with the same dimensions as the Measurement's metrics
+func (m *Measurement) EmitRegressionsMetric(ctx context.Context, cw *cloudwatch.Client, regressions []Regression, experimentDimension string, opts CloudWatchOptions) error {
+	_, err := cw.PutMetricData(ctx, &cloudwatch.PutMetricDataInput{
+		Namespace: aws.String(opts.namespace()),
+		MetricData: []types.MetricDatum{
+			{
+				MetricName:        aws.String(RegressionsMetric),
+				Value:             aws.Float64(float64(len(regressions))),
+				Unit:              types.StandardUnitCount,
+				StorageResolution: opts.storageResolution(),
+				Dimensions:        cloudWatchDimensions(opts.dimensions(m.metricDimensions(experimentDimension))),
+			},
+		},
+	})
+	return err
+}
@@ -0,0 +1,157 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+)
+
+// BenchmarkTagKey tags the instances launched by a benchmark with the benchmark's ID
+const BenchmarkTagKey = "node-latency-for-k8s/benchmark"
+
+// BenchmarkOptions configures a benchmark of the node latency of instance types and AMIs
+type BenchmarkOptions struct {
+	// LaunchTemplate is the name of the launch template the instances are launched with, its user data joins the
+	// instances to a cluster that runs the DaemonSet with the S3 sink
+	LaunchTemplate string
+	InstanceTypes  []string
+	// AMIIDs override the launch template's AMI, the launch template's AMI is benchmarked if none are set
+	AMIIDs []string
+	// Count is the number of instances launched of each instance type and AMI
+	Count    int
+	SubnetID string
+	// S3URI is where the DaemonSet uploads the measurements, s3://<bucket>/<prefix>/<cluster>, they are keyed by instance ID under it
+	S3URI string
+	// Timeout is how long to wait for all instances to report a measurement, default: 15 minutes
+	Timeout time.Duration
+	// PollInterval is the delay in-between reads of the reported measurements, default: 15 seconds
+	PollInterval time.Duration
+	// KeepInstances skips terminating the instances after the benchmark
+	KeepInstances bool
+}
+
+// BenchmarkResult is the measurements reported by the instances launched by a benchmark and their comparison
+type BenchmarkResult struct {
+	ID           string          `json:"id"`
+	InstanceIDs  []string        `json:"instanceIDs"`
+	Measurements []*Measurement  `json:"-"`
+	Comparison   []ComparisonRow `json:"comparison"`
+	// Missing are the instances which did not report a measurement before the timeout
+	Missing []string `json:"missing,omitempty"`
+}
+
+// Benchmark launches Count instances of every instance type and AMI, waits for each instance to report its measurement
+// to S3, and compares the measurements by AMI and instance type, automating an AMI bake-off. The instances are
+// terminated afterwards unless KeepInstances is set. Launch errors, i.e. insufficient capacity of an instance type, are
+// returned with the result of the instances which were launched.
+func Benchmark(ctx context.Context, ec2Client *ec2.Client, s3Client *s3.Client, opts BenchmarkOptions) (_ *BenchmarkResult, errs error) {
+	timeout := lo.Ternary(opts.Timeout > 0, opts.Timeout, 15*time.Minute)
+	pollInterval := lo.Ternary(opts.PollInterval > 0, opts.PollInterval, 15*time.Second)
+	result := &BenchmarkResult{ID: fmt.Sprintf("nlk-%d", time.Now().Unix())}
+	amiIDs := lo.Ternary(len(opts.AMIIDs) > 0, opts.AMIIDs, []string{""})
+	for _, amiID := range amiIDs {
+		for _, instanceType := range opts.InstanceTypes {
+			instanceIDs, err := launchBenchmarkInstances(ctx, ec2Client, opts, result.ID, instanceType, amiID)
+			errs = multierr.Append(errs, err)
+			result.InstanceIDs = append(result.InstanceIDs, instanceIDs...)
+		}
+	}
+	if len(result.InstanceIDs) == 0 {
+		return result, errs
+	}
+	if !opts.KeepInstances {
+		defer func() {
+			// the instances are terminated even if the benchmark was canceled
+			if _, err := ec2Client.TerminateInstances(context.Background(), &ec2.TerminateInstancesInput{InstanceIds: result.InstanceIDs}); err != nil {
+				errs = multierr.Append(errs, fmt.Errorf("unable to terminate benchmark instances %v: %w", result.InstanceIDs, err))
+			}
+		}()
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	measurements, err := waitForBenchmarkMeasurements(waitCtx, s3Client, opts.S3URI, result.InstanceIDs, pollInterval)
+	errs = multierr.Append(errs, err)
+	for _, instanceID := range result.InstanceIDs {
+		if m, ok := measurements[instanceID]; ok {
+			result.Measurements = append(result.Measurements, m)
+		} else {
+			result.Missing = append(result.Missing, instanceID)
+		}
+	}
+	result.Comparison = Compare(result.Measurements)
+	return result, errs
+}
+
+// launchBenchmarkInstances launches the instances of the instance type and AMI tagged with the benchmark ID
+func launchBenchmarkInstances(ctx context.Context, ec2Client *ec2.Client, opts BenchmarkOptions, id string, instanceType string, amiID string) ([]string, error) {
+	input := &ec2.RunInstancesInput{
+		MinCount:       aws.Int32(int32(opts.Count)),
+		MaxCount:       aws.Int32(int32(opts.Count)),
+		InstanceType:   ec2types.InstanceType(instanceType),
+		LaunchTemplate: &ec2types.LaunchTemplateSpecification{LaunchTemplateName: aws.String(opts.LaunchTemplate)},
+		TagSpecifications: []ec2types.TagSpecification{{
+			ResourceType: ec2types.ResourceTypeInstance,
+			Tags:         []ec2types.Tag{{Key: aws.String(BenchmarkTagKey), Value: aws.String(id)}},
+		}},
+	}
+	if amiID != "" {
+		input.ImageId = aws.String(amiID)
+	}
+	if opts.SubnetID != "" {
+		input.SubnetId = aws.String(opts.SubnetID)
+	}
+	out, err := ec2Client.RunInstances(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("unable to launch %d %s instances of AMI %s: %w", opts.Count, instanceType, lo.Ternary(amiID == "", "<launch template>", amiID), err)
+	}
+	return lo.Map(out.Instances, func(i ec2types.Instance, _ int) string { return aws.ToString(i.InstanceId) }), nil
+}
+
+// waitForBenchmarkMeasurements polls S3 until every instance reported a measurement, the last measurement of each instance is returned
+func waitForBenchmarkMeasurements(ctx context.Context, s3Client *s3.Client, uri string, instanceIDs []string, pollInterval time.Duration) (map[string]*Measurement, error) {
+	measurements := map[string]*Measurement{}
+	for {
+		for _, instanceID := range instanceIDs {
+			if _, ok := measurements[instanceID]; ok {
+				continue
+			}
+			reported, err := ReadMeasurementsS3(ctx, s3Client, fmt.Sprintf("%s/%s/", strings.TrimSuffix(uri, "/"), instanceID))
+			if err != nil && ctx.Err() == nil {
+				return measurements, err
+			}
+			if len(reported) > 0 {
+				measurements[instanceID] = reported[len(reported)-1]
+			}
+		}
+		if len(measurements) == len(instanceIDs) {
+			return measurements, nil
+		}
+		select {
+		case <-ctx.Done():
+			return measurements, fmt.Errorf("%d of %d instances did not report a measurement: %w", len(instanceIDs)-len(measurements), len(instanceIDs), ctx.Err())
+		case <-time.After(pollInterval):
+		}
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/kmsg"
+)
+
+var (
+	// BootIDPath is the random ID the kernel generates on every boot
+	BootIDPath = "/proc/sys/kernel/random/boot_id"
+	// BootSegmentTolerance is how long before the boot time, computed from the uptime, the node's clock may have logged
+	// events of the current boot, i.e. before chronyd stepped a clock which was behind
+	BootSegmentTolerance = 30 * time.Second
+)
+
+// WithBootSegmentation is a builder func that only times the events the node's clock logged during the current boot
+// Persistent logs, i.e. /var/log/messages, keep the events of previous boots, so a rebooted node would otherwise mix the
+// events of both boots into one timeline. Events are only segmented when enabled and measuring the live node with the real clock.
+func (m *Measurer) WithBootSegmentation(segment bool) *Measurer {
+	m.bootSegmentation = segment
+	return m
+}
+
+// BootID returns the ID of the node's current boot
+func BootID() (string, error) {
+	bootID, err := os.ReadFile(BootIDPath)
+	if err != nil {
+		return "", fmt.Errorf("unable to read boot ID: %w", err)
+	}
+	return strings.TrimSpace(string(bootID)), nil
+}
+
+// getBootID returns the ID of the node's current boot, it is empty when replaying or with a fake clock since the boot is
+// not the measured node's
+func (m *Measurer) getBootID() string {
+	if m.root != "" || m.clock != nil {
+		return ""
+	}
+	if m.bootID == "" {
+		m.bootID, _ = BootID()
+	}
+	return m.bootID
+}
+
+// bootStart returns the earliest time an event of the current boot may have been logged at by the node's clock, it is
+// zero if the boot is not segmented. The boot is not segmented when replaying or with a fake clock, since the boot time
+// is not the measured node's.
+func (m *Measurer) bootStart() time.Time {
+	if !m.bootSegmentation || m.root != "" || m.clock != nil {
+		return time.Time{}
+	}
+	if m.bootTime.IsZero() {
+		bootTime, err := kmsg.BootTime()
+		if err != nil {
+			return time.Time{}
+		}
+		m.bootTime = bootTime
+	}
+	return m.bootTime.Add(-BootSegmentTolerance)
+}
+
+// findInBoot searches the event's source for the results logged since the start of the current boot
+// The first or last match is selected by the source, which may stop at the first match, and is the current boot's unless
+// it was logged before the boot started. Otherwise all matches are searched and the results of previous boots are dropped
+// before selecting, so the nth match is the current boot's. Results of sources with a remote clock, i.e. IMDS or the K8s
+// API, are not dropped since they are not logged by the node.
+func findInBoot(ctx context.Context, event *sources.Event, start time.Time) ([]sources.FindResult, error) {
+	if _, remote := event.Src.(sources.RemoteClock); start.IsZero() || remote {
+		return event.Src.Find(ctx, event)
+	}
+	inBoot := func(r sources.FindResult) bool { return r.Err != nil || !r.Timestamp.Before(start) }
+	if event.MatchSelector == sources.EventMatchSelectorFirst || event.MatchSelector == sources.EventMatchSelectorLast {
+		results, err := event.Src.Find(ctx, event)
+		if err != nil || lo.EveryBy(results, inBoot) {
+			return results, err
+		}
+	}
+	all := *event
+	all.MatchSelector = sources.EventMatchSelectorAll
+	results, err := event.Src.Find(ctx, &all)
+	results = lo.Filter(results, func(r sources.FindResult, _ int) bool { return inBoot(r) })
+	return sources.SelectMatches(results, event.MatchSelector), err
+}
@@ -0,0 +1,167 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// resultsSource is a source returning the same results on every search, selected by the event's match selector
+type resultsSource struct {
+	results []sources.FindResult
+	finds   int
+}
+
+func (s *resultsSource) Find(_ context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	s.finds++
+	return sources.SelectMatches(s.results, event.MatchSelector), nil
+}
+
+func (s *resultsSource) Name() string   { return "results" }
+func (s *resultsSource) ClearCache()    {}
+func (s *resultsSource) String() string { return "results" }
+
+// remoteResultsSource is a resultsSource whose timestamps are from a remote clock
+type remoteResultsSource struct {
+	resultsSource
+}
+
+func (s *remoteResultsSource) RemoteClock() {}
+
+func TestFindInBoot(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	previousBoot := sources.FindResult{Line: "previous boot", Timestamp: start.Add(-time.Hour)}
+	previousBootRestart := sources.FindResult{Line: "previous boot restart", Timestamp: start.Add(-time.Minute)}
+	currentBoot := sources.FindResult{Line: "current boot", Timestamp: start.Add(time.Minute)}
+	currentBootRestart := sources.FindResult{Line: "current boot restart", Timestamp: start.Add(time.Hour)}
+	errored := sources.FindResult{Line: "errored", Err: errors.New("no timestamp")}
+	for _, tc := range []struct {
+		name          string
+		results       []sources.FindResult
+		remote        bool
+		start         time.Time
+		matchSelector string
+		expected      []sources.FindResult
+		finds         int
+	}{
+		{
+			name:          "not segmented",
+			results:       []sources.FindResult{previousBoot, currentBoot},
+			matchSelector: sources.EventMatchSelectorFirst,
+			expected:      []sources.FindResult{previousBoot},
+			finds:         1,
+		},
+		{
+			name:          "first match of the current boot stops early",
+			results:       []sources.FindResult{currentBoot, currentBootRestart},
+			start:         start,
+			matchSelector: sources.EventMatchSelectorFirst,
+			expected:      []sources.FindResult{currentBoot},
+			finds:         1,
+		},
+		{
+			name:          "first match of a previous boot",
+			results:       []sources.FindResult{previousBoot, previousBootRestart, currentBoot, currentBootRestart},
+			start:         start,
+			matchSelector: sources.EventMatchSelectorFirst,
+			expected:      []sources.FindResult{currentBoot},
+			finds:         2,
+		},
+		{
+			name:          "last match of the current boot stops early",
+			results:       []sources.FindResult{previousBoot, currentBoot, currentBootRestart},
+			start:         start,
+			matchSelector: sources.EventMatchSelectorLast,
+			expected:      []sources.FindResult{currentBootRestart},
+			finds:         1,
+		},
+		{
+			name:          "last match of a previous boot",
+			results:       []sources.FindResult{previousBoot, previousBootRestart},
+			start:         start,
+			matchSelector: sources.EventMatchSelectorLast,
+			finds:         2,
+		},
+		{
+			name:          "nth match of the current boot",
+			results:       []sources.FindResult{previousBoot, previousBootRestart, currentBoot, currentBootRestart},
+			start:         start,
+			matchSelector: sources.EventMatchSelectorNthPrefix + "2",
+			expected:      []sources.FindResult{currentBootRestart},
+			finds:         1,
+		},
+		{
+			name:          "all matches keep errored results",
+			results:       []sources.FindResult{previousBoot, errored, currentBoot},
+			start:         start,
+			matchSelector: sources.EventMatchSelectorAll,
+			expected:      []sources.FindResult{errored, currentBoot},
+			finds:         1,
+		},
+		{
+			name:          "remote clock",
+			results:       []sources.FindResult{previousBoot, currentBoot},
+			remote:        true,
+			start:         start,
+			matchSelector: sources.EventMatchSelectorFirst,
+			expected:      []sources.FindResult{previousBoot},
+			finds:         1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var src sources.Source
+			results := &resultsSource{results: tc.results}
+			if tc.remote {
+				remote := &remoteResultsSource{resultsSource: resultsSource{results: tc.results}}
+				src, results = remote, &remote.resultsSource
+			} else {
+				src = results
+			}
+			found, err := findInBoot(context.Background(), &sources.Event{Src: src, MatchSelector: tc.matchSelector}, tc.start)
+			if err != nil {
+				t.Fatalf("unable to find the event: %v", err)
+			}
+			if !reflect.DeepEqual(found, tc.expected) {
+				t.Errorf("expected results %v, got %v", tc.expected, found)
+			}
+			if results.finds != tc.finds {
+				t.Errorf("expected %d searches, got %d", tc.finds, results.finds)
+			}
+		})
+	}
+}
+
+func TestBootStart(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		measurer *Measurer
+	}{
+		{name: "default", measurer: New()},
+		{name: "fake clock", measurer: New().WithBootSegmentation(true).WithClock(time.Now)},
+		{name: "replay", measurer: New().WithBootSegmentation(true).WithRoot(t.TempDir())},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if start := tc.measurer.bootStart(); !start.IsZero() {
+				t.Errorf("expected the boot not to be segmented, got a boot start of %s", start)
+			}
+		})
+	}
+}
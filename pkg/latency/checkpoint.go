@@ -0,0 +1,130 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// checkpointFile is the persisted state of a Measurer
+type checkpointFile struct {
+	// Sources are the checkpoints of the sources keyed by source name
+	Sources map[string]*sources.Checkpoint `json:"sources"`
+	// Emitted are the keys of the timings already passed to MeasureStream's onTiming
+	Emitted []string `json:"emitted"`
+	// BootID is the ID of the node's boot the checkpoints were saved during
+	BootID string `json:"bootID,omitempty"`
+}
+
+// WithCheckpoints restores the sources from the checkpoint file, if it exists, and persists their checkpoints to it after every measurement
+// Log and journal sources are tailed so a restarted process only reads what was appended since the checkpoint,
+// and timings streamed before the checkpoint are not streamed again. Sources must be registered beforehand.
+// The sources are not restored from the checkpoints of a previous boot, i.e. the kernel ring buffer's sequence restarts.
+func (m *Measurer) WithCheckpoints(path string) (*Measurer, error) {
+	m.checkpointPath = path
+	m.emitted = map[string]struct{}{}
+	for _, src := range m.sources {
+		if tailer, ok := src.(sources.Tailer); ok {
+			tailer.Tail()
+		}
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return m, fmt.Errorf("unable to read checkpoint file %s: %w", path, err)
+	}
+	var checkpoints checkpointFile
+	if err := json.Unmarshal(data, &checkpoints); err != nil {
+		return m, fmt.Errorf("unable to decode checkpoint file %s: %w", path, err)
+	}
+	if bootID := m.getBootID(); checkpoints.BootID != "" && bootID != "" && checkpoints.BootID != bootID {
+		log.Printf("Checkpoints were saved during boot %s, the sources will be re-read\n", checkpoints.BootID)
+		checkpoints.Sources = nil
+	}
+	for name, checkpoint := range checkpoints.Sources {
+		if src, ok := m.sources[name].(sources.Checkpointer); ok && checkpoint != nil {
+			src.Restore(checkpoint)
+		}
+	}
+	m.emitted = lo.SliceToMap(checkpoints.Emitted, func(key string) (string, struct{}) { return key, struct{}{} })
+	return m, nil
+}
+
+// SaveCheckpoints writes the checkpoints of the sources and the streamed timings to the checkpoint file
+// The file is written to a temporary file and renamed so a crash mid-write does not corrupt the previous checkpoint.
+func (m *Measurer) SaveCheckpoints() error {
+	if m.checkpointPath == "" {
+		return errors.New("no checkpoint file is configured")
+	}
+	checkpoints := checkpointFile{Sources: map[string]*sources.Checkpoint{}, Emitted: lo.Keys(m.emitted), BootID: m.getBootID()}
+	for name, src := range m.sources {
+		checkpointer, ok := src.(sources.Checkpointer)
+		if !ok {
+			continue
+		}
+		// a source still being searched after it timed out is not checkpointed
+		unlock, ok := m.tryLockSource(name)
+		if !ok {
+			continue
+		}
+		if checkpoint := checkpointer.Checkpoint(); checkpoint != nil {
+			checkpoints.Sources[name] = checkpoint
+		}
+		unlock()
+	}
+	data, err := json.Marshal(checkpoints)
+	if err != nil {
+		return fmt.Errorf("unable to marshal checkpoints: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(m.checkpointPath), filepath.Base(m.checkpointPath)+".*")
+	if err != nil {
+		return fmt.Errorf("unable to create checkpoint file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to write checkpoint file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to write checkpoint file: %w", err)
+	}
+	return os.Rename(tmp.Name(), m.checkpointPath)
+}
+
+// saveCheckpoints saves the checkpoints if a checkpoint file is configured, errors are logged since the measurement is still valid
+func (m *Measurer) saveCheckpoints() {
+	if m.checkpointPath == "" {
+		return
+	}
+	if err := m.SaveCheckpoints(); err != nil {
+		log.Printf("Unable to save checkpoints: %s\n", err)
+	}
+}
+
+// timingKey identifies a timing across measurements and restarts
+func timingKey(t *sources.Timing) string {
+	return fmt.Sprintf("%s/%d", t.Event.Name, t.Timestamp.UnixNano())
+}
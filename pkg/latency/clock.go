@@ -0,0 +1,85 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// ClockSteppedMetric is the metric of the default event of chronyd stepping the node's clock
+const ClockSteppedMetric = "clock_stepped"
+
+// WithClockStepCorrection is a builder func that corrects the timestamps of the node's clock which were logged before chronyd stepped the clock
+// Timestamps of sources with a remote clock, i.e. IMDS or the EC2 API, are not corrected.
+func (m *Measurer) WithClockStepCorrection(correct bool) *Measurer {
+	m.clockStepCorrection = correct
+	return m
+}
+
+// WithClock is a builder func that sets the func returning the current time, i.e. a fake clock when testing events
+// The clock is used to decide which events are absent past their deadline.
+func (m *Measurer) WithClock(now func() time.Time) *Measurer {
+	m.clock = now
+	return m
+}
+
+// now returns the current time of the Measurer's clock
+func (m *Measurer) now() time.Time {
+	if m.clock == nil {
+		return time.Now()
+	}
+	return m.clock()
+}
+
+// clockStepEvent is the default event of chronyd stepping the node's clock, the comment is the step offset
+func (m *Measurer) clockStepEvent(syslog sources.RegexFinder) *sources.Event {
+	return &sources.Event{
+		Name:          "Clock Stepped",
+		Metric:        ClockSteppedMetric,
+		SrcName:       syslog.Name(),
+		MatchSelector: sources.EventMatchSelectorAll,
+		CommentFn:     sources.CommentCaptureGroups(clockStepped),
+		FindFn:        syslog.FindByRegex(clockStepped),
+	}
+}
+
+// correctClockSteps shifts the timestamps of the node's clock which were logged before each clock step by the step's offset
+// chronyd logs the step after the clock was stepped, so the timestamp of the step is correct and timestamps before it were
+// off by the offset. Later steps are corrected first since the timestamps of earlier steps are off by the later offsets.
+func (m *Measurer) correctClockSteps(timings []*sources.Timing) {
+	if !m.clockStepCorrection {
+		return
+	}
+	steps := lo.Filter(timings, func(t *sources.Timing, _ int) bool { return t.Event.Metric == ClockSteppedMetric && t.Error == nil })
+	sort.Slice(steps, func(i, j int) bool { return steps[i].Timestamp.After(steps[j].Timestamp) })
+	for _, step := range steps {
+		offset, err := strconv.ParseFloat(sources.CaptureGroups(clockStepped, step.Line)["offset"], 64)
+		if err != nil {
+			continue
+		}
+		for _, t := range timings {
+			if _, remote := t.Event.Src.(sources.RemoteClock); remote || t == step || t.Error != nil || !t.Timestamp.Before(step.Timestamp) {
+				continue
+			}
+			t.Timestamp = t.Timestamp.Add(time.Duration(offset * float64(time.Second)))
+		}
+	}
+}
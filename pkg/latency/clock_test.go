@@ -0,0 +1,107 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+func TestCorrectClockSteps(t *testing.T) {
+	boot := time.Date(2024, 1, 14, 10, 0, 0, 0, time.UTC)
+	local := &resultsSource{}
+	remote := &remoteResultsSource{}
+	step := func(at time.Duration, offset string) *sources.Timing {
+		return &sources.Timing{
+			Event:     &sources.Event{Metric: ClockSteppedMetric, Src: local},
+			Timestamp: boot.Add(at),
+			Line:      fmt.Sprintf("Jan 14 10:00:00 ip-192-168-23-248 chronyd[812]: System clock was stepped by %s seconds", offset),
+		}
+	}
+	timing := func(metric string, src sources.Source, at time.Duration) *sources.Timing {
+		return &sources.Timing{Event: &sources.Event{Metric: metric, Src: src}, Timestamp: boot.Add(at)}
+	}
+	for _, tc := range []struct {
+		name     string
+		correct  bool
+		timings  []*sources.Timing
+		expected []time.Duration
+	}{
+		{
+			name:     "disabled",
+			timings:  []*sources.Timing{timing("vm_initialized", local, 0), step(100*time.Second, "10")},
+			expected: []time.Duration{0, 100 * time.Second},
+		},
+		{
+			name:     "timestamps before the step",
+			correct:  true,
+			timings:  []*sources.Timing{timing("vm_initialized", local, 0), step(100*time.Second, "10"), timing("kubelet_start", local, 150*time.Second)},
+			expected: []time.Duration{10 * time.Second, 100 * time.Second, 150 * time.Second},
+		},
+		{
+			name:     "negative and fractional offset",
+			correct:  true,
+			timings:  []*sources.Timing{timing("vm_initialized", local, 0), step(100*time.Second, "-2.5")},
+			expected: []time.Duration{-2500 * time.Millisecond, 100 * time.Second},
+		},
+		{
+			name:     "remote clock",
+			correct:  true,
+			timings:  []*sources.Timing{timing("instance_pending", remote, 0), step(100*time.Second, "10")},
+			expected: []time.Duration{0, 100 * time.Second},
+		},
+		{
+			name:     "errored timing",
+			correct:  true,
+			timings:  []*sources.Timing{{Event: &sources.Event{Metric: "vm_initialized", Src: local}, Timestamp: boot, Error: errors.New("no timestamp")}, step(100*time.Second, "10")},
+			expected: []time.Duration{0, 100 * time.Second},
+		},
+		{
+			name:    "later steps first",
+			correct: true,
+			timings: []*sources.Timing{
+				timing("vm_initialized", local, 0),
+				step(100*time.Second, "5"),
+				timing("kubelet_start", local, 150*time.Second),
+				step(200*time.Second, "20"),
+			},
+			expected: []time.Duration{25 * time.Second, 120 * time.Second, 170 * time.Second, 200 * time.Second},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			New().WithClockStepCorrection(tc.correct).correctClockSteps(tc.timings)
+			for i, timing := range tc.timings {
+				if expected := boot.Add(tc.expected[i]); !timing.Timestamp.Equal(expected) {
+					t.Errorf("expected %s at %s, got %s", timing.Event.Metric, expected, timing.Timestamp)
+				}
+			}
+		})
+	}
+}
+
+func TestNow(t *testing.T) {
+	fake := time.Date(2024, 1, 14, 10, 0, 0, 0, time.UTC)
+	if now := New().WithClock(func() time.Time { return fake }).now(); !now.Equal(fake) {
+		t.Errorf("expected the fake clock's time %s, got %s", fake, now)
+	}
+	before := time.Now()
+	if now := New().now(); now.Before(before) {
+		t.Errorf("expected the real clock's time after %s, got %s", before, now)
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/olekukonko/tablewriter"
+	"github.com/samber/lo"
+)
+
+// ComparisonRow is the latency percentiles of an event across the measurements of an AMI and instance type
+type ComparisonRow struct {
+	Event        string  `json:"event"`
+	Metric       string  `json:"metric"`
+	AMIID        string  `json:"amiID"`
+	InstanceType string  `json:"instanceType"`
+	Count        int     `json:"count"`
+	P50          float64 `json:"p50"`
+	P90          float64 `json:"p90"`
+	P99          float64 `json:"p99"`
+}
+
+// Compare computes the p50, p90, and p99 of each event broken down by AMI ID and instance type
+// Rows are ordered by the first occurrence of the event, so they follow the boot order, and then by AMI ID and instance type.
+func Compare(measurements []*Measurement) []ComparisonRow {
+	type key struct{ metric, amiID, instanceType string }
+	values := map[key][]float64{}
+	events := map[string]string{}
+	// order is the index of the first occurrence of each metric
+	order := map[string]int{}
+	for _, m := range measurements {
+		amiID, instanceType := "unknown", "unknown"
+		if m.Metadata != nil {
+			amiID = lo.Ternary(m.Metadata.AMIID != "", m.Metadata.AMIID, amiID)
+			instanceType = lo.Ternary(m.Metadata.InstanceType != "", m.Metadata.InstanceType, instanceType)
+		}
+		for _, t := range m.Timings {
+			if t.Error != nil {
+				continue
+			}
+			if _, ok := events[t.Event.Metric]; !ok {
+				events[t.Event.Metric] = t.Event.Name
+				order[t.Event.Metric] = len(order)
+			}
+			k := key{metric: t.Event.Metric, amiID: amiID, instanceType: instanceType}
+			values[k] = append(values[k], t.T.Seconds())
+		}
+	}
+	keys := lo.Keys(values)
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].metric != keys[j].metric {
+			return order[keys[i].metric] < order[keys[j].metric]
+		}
+		if keys[i].amiID != keys[j].amiID {
+			return keys[i].amiID < keys[j].amiID
+		}
+		return keys[i].instanceType < keys[j].instanceType
+	})
+	return lo.Map(keys, func(k key, _ int) ComparisonRow {
+		v := values[k]
+		sort.Float64s(v)
+		return ComparisonRow{
+			Event:        events[k.metric],
+			Metric:       k.metric,
+			AMIID:        k.amiID,
+			InstanceType: k.instanceType,
+			Count:        len(v),
+			P50:          percentile(v, 50),
+			P90:          percentile(v, 90),
+			P99:          percentile(v, 99),
+		}
+	})
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[lo.Clamp(rank, 1, len(sorted))-1]
+}
+
+// ChartComparison writes the comparison rows as a markdown table to w
+func ChartComparison(w io.Writer, rows []ComparisonRow) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Event", "AMI ID", "Instance Type", "N", "P50", "P90", "P99"})
+	for _, r := range rows {
+		table.Append([]string{
+			r.Event, r.AMIID, r.InstanceType, fmt.Sprint(r.Count),
+			fmt.Sprintf("%.0fs", r.P50), fmt.Sprintf("%.0fs", r.P90), fmt.Sprintf("%.0fs", r.P99),
+		})
+	}
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	table.SetAutoWrapText(false)
+	table.Render()
+}
+
+// DecodeMeasurements decodes a JSON Measurement, or a JSON list of Measurements as served on /measurements
+func DecodeMeasurements(data []byte) ([]*Measurement, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var measurements []*Measurement
+		if err := json.Unmarshal(trimmed, &measurements); err != nil {
+			return nil, err
+		}
+		return measurements, nil
+	}
+	measurement := &Measurement{}
+	if err := json.Unmarshal(data, measurement); err != nil {
+		return nil, err
+	}
+	return []*Measurement{measurement}, nil
+}
+
+// ReadMeasurementFiles reads the JSON measurements of the paths, directories are walked for *.json files
+func ReadMeasurementFiles(paths ...string) ([]*Measurement, error) {
+	var measurements []*Measurement
+	for _, p := range paths {
+		if err := filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// only filter by extension when walking a directory, explicit files are always read
+			if d.IsDir() || (path != p && filepath.Ext(path) != ".json") {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			decoded, err := DecodeMeasurements(data)
+			if err != nil {
+				return fmt.Errorf("unable to decode measurement %s: %w", path, err)
+			}
+			measurements = append(measurements, decoded...)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return measurements, nil
+}
+
+// ReadMeasurementsS3 reads the JSON measurements under an s3://<bucket>/<prefix> URI, i.e. uploaded with EmitS3
+func ReadMeasurementsS3(ctx context.Context, client *s3.Client, uri string) ([]*Measurement, error) {
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(uri, "s3://"), "/")
+	var measurements []*Measurement
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list measurements in %s: %w", uri, err)
+		}
+		for _, obj := range page.Contents {
+			key := aws.ToString(obj.Key)
+			if !strings.HasSuffix(key, ".json") {
+				continue
+			}
+			out, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: obj.Key})
+			if err != nil {
+				return nil, fmt.Errorf("unable to get s3://%s/%s: %w", bucket, key, err)
+			}
+			data, err := io.ReadAll(out.Body)
+			out.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("unable to read s3://%s/%s: %w", bucket, key, err)
+			}
+			decoded, err := DecodeMeasurements(data)
+			if err != nil {
+				return nil, fmt.Errorf("unable to decode measurement s3://%s/%s: %w", bucket, key, err)
+			}
+			measurements = append(measurements, decoded...)
+		}
+	}
+	return measurements, nil
+}
@@ -0,0 +1,437 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/cel"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/awsnode"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/cloudinit"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/execplugin"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/journal"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/jsonlog"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/kmsg"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/logfile"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/messages"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/rpcplugin"
+)
+
+// Source type consts for a SourceConfig's Type
+const (
+	SourceTypeLog       = "log"
+	SourceTypeJSONLog   = "json-log"
+	SourceTypeMessages  = "messages"
+	SourceTypeAWSNode   = "aws-node"
+	SourceTypeJournal   = "journal"
+	SourceTypeKmsg      = "kmsg"
+	SourceTypeCloudInit = "cloud-init"
+	// SourceTypeExec and SourceTypePlugin run the config's command on the node, usually as root, so they are only
+	// registered from the trusted local config file, i.e. --config, and RegisterUntrustedConfig rejects them
+	SourceTypeExec   = "exec"
+	SourceTypePlugin = "plugin"
+)
+
+// untrustedSourceTypes are the source types an untrusted config may declare, they only read logs of the node
+var untrustedSourceTypes = []string{SourceTypeLog, SourceTypeJSONLog, SourceTypeMessages, SourceTypeAWSNode, SourceTypeJournal, SourceTypeKmsg, SourceTypeCloudInit}
+
+// DefaultUntrustedPaths are the directories the sources of untrusted configs may read from by default
+var DefaultUntrustedPaths = []string{"/var/log"}
+
+// anyLine matches every line, it is searched for the lines of an expression without a literal to prefilter them by
+var anyLine = regexp.MustCompile(`.+`)
+
+// Config declares custom sources and events to register to a Measurer
+// Config files may be YAML or JSON
+type Config struct {
+	// DisableDefaultEvents skips registering the default events so that only the configured events are timed
+	DisableDefaultEvents bool `json:"disableDefaultEvents"`
+	// DefaultEvents parameterize the default events, i.e. the namespace and name prefix of the pods that mark "Pod Ready"
+	DefaultEvents DefaultEventParams `json:"defaultEvents"`
+	// Profiles are built-in profiles that customize the default sources and events, i.e. "bottlerocket"
+	Profiles []string `json:"profiles"`
+	// SLOs are the expected max latencies of events by metric name, i.e. pod_ready: 90s
+	SLOs map[string]metav1.Duration `json:"slos"`
+	// Deadlines are how long after the first timing events must be observed by metric name before they are reported absent, i.e. node_ready: 10m
+	Deadlines map[string]metav1.Duration `json:"deadlines"`
+	// TerminalEvents are the metric or event names of the events which complete a measurement, overriding the events' own terminal
+	TerminalEvents []string `json:"terminalEvents"`
+	// ZeroEvents are the metric or event names of the events timings are relative to, the first which was timed is the zero point
+	ZeroEvents []string `json:"zeroEvents"`
+	// SourceTimeouts are how long each source may be searched by source name, i.e. imds: 5s
+	SourceTimeouts map[string]metav1.Duration `json:"sourceTimeouts"`
+	Sources        []SourceConfig             `json:"sources"`
+	Events         []EventConfig              `json:"events"`
+}
+
+// SourceConfig declares a source to register
+type SourceConfig struct {
+	// Name is only used for the "log", "json-log", "exec", and "plugin" types, the other types use their default source names
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Path string `json:"path"`
+	// Paths are more paths, or globs, of the log for the "log", "json-log", "messages", and "aws-node" types, i.e. the log's
+	// paths on other distros, the lines of every matched log are merged
+	Paths []string `json:"paths"`
+	// TimestampRegex is only used for the "log" type and TimestampLayout for the "log" and "json-log" types
+	// The "auto" TimestampLayout detects RFC 3164, RFC 5424, and ISO 8601 timestamps, the TimestampRegex is then optional
+	TimestampRegex  string `json:"timestampRegex"`
+	TimestampLayout string `json:"timestampLayout"`
+	// TimestampField is the dot separated path of the timestamp field for the "json-log" type, defaults to "ts"
+	TimestampField string `json:"timestampField"`
+	// Args are extra journalctl args for the "journal" type, or the args of the command for the "exec" and "plugin" types
+	Args []string `json:"args"`
+	// Command is the binary for the "exec" type which emits events as JSON on stdout, or for the "plugin" type which serves a Source over gRPC
+	Command string `json:"command"`
+	// Timeout is how long the command of the "exec" type may run before it is killed, defaults to 30s
+	Timeout metav1.Duration `json:"timeout"`
+}
+
+// EventConfig declares a regex, field selector, or expression event to register
+type EventConfig struct {
+	Name   string `json:"name"`
+	Metric string `json:"metric"`
+	Src    string `json:"src"`
+	Regex  string `json:"regex"`
+	// Fields select the entries of a "json-log" source instead of a regex, an entry must match all of them
+	Fields []FieldConfig `json:"fields"`
+	// Expression is a CEL expression which selects the lines instead of a regex, i.e. line.contains("Started kubelet"), the
+	// lines are the "line" variable and the JSON entries of "json-log" sources the "entry" variable
+	Expression string `json:"expression"`
+	// MatchSelector is first (default), last, all, or nth:<n> for the nth match, i.e. nth:3
+	MatchSelector string `json:"matchSelector"`
+	Terminal      bool   `json:"terminal"`
+	// CommentMatchedLine uses the matched log line as the timing comment
+	CommentMatchedLine bool `json:"commentMatchedLine"`
+	// CommentField uses the value of a field of the matched entry as the timing comment, only for "json-log" sources
+	CommentField string `json:"commentField"`
+	// CommentExpression is a CEL expression whose value is the timing comment, over the same variables as the Expression
+	CommentExpression string `json:"commentExpression"`
+	// Labels are named capture groups of the regex to add as labels to the event's metric, i.e. pod
+	// The values of the named capture groups are the timing comment unless another comment is configured.
+	Labels []string `json:"labels"`
+	// StaticLabels are labels with fixed values to add to the event's metric, i.e. component: cni and phase: bootstrap
+	StaticLabels map[string]string `json:"staticLabels"`
+	// MaxLatency is the expected max latency (SLO) of the event, i.e. 90s
+	MaxLatency metav1.Duration `json:"maxLatency"`
+	// Deadline is how long after the first timing the event must be observed by before it is reported absent, i.e. 10m
+	Deadline metav1.Duration `json:"deadline"`
+	// After are the metrics of the events this event is expected to be timed after, ordering anomalies are reported
+	After []string `json:"after"`
+}
+
+// FieldConfig declares a selector on a field of a JSON log entry, the field must exist and match every set condition
+type FieldConfig struct {
+	// Field is the dot separated path of the field, i.e. "msg" or "pod.namespace"
+	Field    string `json:"field"`
+	Equals   string `json:"equals"`
+	Contains string `json:"contains"`
+	Regex    string `json:"regex"`
+}
+
+// LoadConfig reads and parses a YAML or JSON config file
+func LoadConfig(path string) (*Config, error) {
+	configBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config file %s: %w", path, err)
+	}
+	var config Config
+	if err := yaml.UnmarshalStrict(configBytes, &config); err != nil {
+		return nil, fmt.Errorf("unable to parse config file %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// RegisterConfig registers the sources and then the events declared in the config to the Measurer
+// The config is trusted to run the commands of its exec and plugin sources, use RegisterUntrustedConfig otherwise.
+func (m *Measurer) RegisterConfig(config *Config) (*Measurer, error) {
+	var errs error
+	m.WithSLOs(lo.MapValues(config.SLOs, func(d metav1.Duration, _ string) time.Duration { return d.Duration }))
+	m.WithDeadlines(lo.MapValues(config.Deadlines, func(d metav1.Duration, _ string) time.Duration { return d.Duration }))
+	m.WithSourceTimeouts(lo.MapValues(config.SourceTimeouts, func(d metav1.Duration, _ string) time.Duration { return d.Duration }))
+	if len(config.TerminalEvents) > 0 {
+		m.WithTerminalEvents(config.TerminalEvents...)
+	}
+	if len(config.ZeroEvents) > 0 {
+		m.WithZeroEvents(config.ZeroEvents...)
+	}
+	for _, srcConfig := range config.Sources {
+		src, err := srcConfig.source()
+		if err != nil {
+			errs = multierr.Append(errs, err)
+			continue
+		}
+		m.RegisterSources(src)
+	}
+	var events []*sources.Event
+	for _, eventConfig := range config.Events {
+		event, err := eventConfig.event(m)
+		if err != nil {
+			errs = multierr.Append(errs, err)
+			continue
+		}
+		events = append(events, event)
+	}
+	_, err := m.RegisterEvents(events...)
+	return m, multierr.Append(errs, err)
+}
+
+// RegisterUntrustedConfig registers a config which is not trusted to read the node's files, i.e. a config received over
+// the network rather than read from the local config file, like RegisterConfig
+// Its sources may only read the logs under the allowed paths, or the default paths of their type, they may not run a
+// command or pass args to journalctl, and its events may not copy the matched line or entry into their comments.
+// Nothing is registered if the config is rejected.
+func (m *Measurer) RegisterUntrustedConfig(config *Config, allowedPaths ...string) (*Measurer, error) {
+	if err := config.validateUntrusted(allowedPaths); err != nil {
+		return m, err
+	}
+	return m.RegisterConfig(config)
+}
+
+// validateUntrusted checks that the sources and events of an untrusted config only read logs under the allowed paths
+func (c *Config) validateUntrusted(allowedPaths []string) error {
+	var errs error
+	for _, src := range c.Sources {
+		if !lo.Contains(untrustedSourceTypes, src.Type) {
+			errs = multierr.Append(errs, fmt.Errorf("source \"%s\" of type %s is only allowed in the local config file", src.Name, src.Type))
+			continue
+		}
+		if len(src.Args) > 0 || src.Command != "" {
+			errs = multierr.Append(errs, fmt.Errorf("source \"%s\" may not set args or a command outside of the local config file", src.Name))
+		}
+		for _, path := range append([]string{src.Path}, src.Paths...) {
+			if path != "" && !withinPaths(path, allowedPaths) {
+				errs = multierr.Append(errs, fmt.Errorf("path %s of source \"%s\" is not under the allowed paths %v", path, src.Name, allowedPaths))
+			}
+		}
+	}
+	for _, event := range c.Events {
+		if event.CommentMatchedLine || event.CommentField != "" || event.CommentExpression != "" {
+			errs = multierr.Append(errs, fmt.Errorf("event \"%s\" may only comment with the capture groups of its regex outside of the local config file", event.Name))
+		}
+	}
+	return errs
+}
+
+// withinPaths checks if the path, or glob, is one of the allowed paths or under one of them once it is cleaned, so ".."
+// elements can not escape them
+func withinPaths(path string, allowedPaths []string) bool {
+	if !filepath.IsAbs(path) {
+		return false
+	}
+	path = filepath.Clean(path)
+	return lo.SomeBy(allowedPaths, func(allowed string) bool {
+		allowed = filepath.Clean(allowed)
+		return filepath.IsAbs(allowed) && (path == allowed || strings.HasPrefix(path, strings.TrimSuffix(allowed, string(filepath.Separator))+string(filepath.Separator)))
+	})
+}
+
+// source constructs the Source declared by the SourceConfig
+func (s SourceConfig) source() (sources.Source, error) {
+	switch s.Type {
+	case SourceTypeMessages:
+		return messages.New(s.Path, s.Paths...), nil
+	case SourceTypeAWSNode:
+		return awsnode.New(s.Path, s.Paths...), nil
+	case SourceTypeJournal:
+		return journal.New(s.Args...), nil
+	case SourceTypeKmsg:
+		return kmsg.New(lo.Ternary(s.Path == "", kmsg.DefaultPath, s.Path)), nil
+	case SourceTypeCloudInit:
+		return cloudinit.New(lo.Ternary(s.Path == "", cloudinit.DefaultPath, s.Path), cloudinit.DefaultStatusPath), nil
+	case SourceTypeExec:
+		if s.Name == "" || s.Command == "" {
+			return nil, fmt.Errorf("source \"%s\" of type %s requires a name and command", s.Name, s.Type)
+		}
+		src := execplugin.New(s.Name, s.Command, s.Args...)
+		if s.Timeout.Duration > 0 {
+			src.WithTimeout(s.Timeout.Duration)
+		}
+		return src, nil
+	case SourceTypePlugin:
+		if s.Name == "" || s.Command == "" {
+			return nil, fmt.Errorf("source \"%s\" of type %s requires a name and command", s.Name, s.Type)
+		}
+		return rpcplugin.New(s.Name, s.Command, s.Args...), nil
+	case SourceTypeLog:
+		if s.Name == "" || s.Path == "" || s.TimestampLayout == "" || (s.TimestampRegex == "" && s.TimestampLayout != sources.TimestampLayoutAuto) {
+			return nil, fmt.Errorf("source \"%s\" of type %s requires a name, path, timestampRegex, and timestampLayout", s.Name, s.Type)
+		}
+		// the auto layout defaults to matching the timestamps it detects
+		tsRegex := sources.SyslogTimestampFormat
+		if s.TimestampRegex != "" {
+			var err error
+			if tsRegex, err = regexp.Compile(s.TimestampRegex); err != nil {
+				return nil, fmt.Errorf("invalid timestampRegex for source \"%s\": %w", s.Name, err)
+			}
+		}
+		return logfile.New(s.Name, s.Path, tsRegex, s.TimestampLayout, s.Paths...), nil
+	case SourceTypeJSONLog:
+		if s.Name == "" || s.Path == "" {
+			return nil, fmt.Errorf("source \"%s\" of type %s requires a name and path", s.Name, s.Type)
+		}
+		return jsonlog.New(s.Name, s.Path, s.TimestampField, s.TimestampLayout, s.Paths...), nil
+	}
+	return nil, fmt.Errorf("unknown type \"%s\" for source \"%s\"", s.Type, s.Name)
+}
+
+// event constructs the Event declared by the EventConfig, the event's source must already be registered to the Measurer
+func (e EventConfig) event(m *Measurer) (*sources.Event, error) {
+	if e.Name == "" || e.Metric == "" || lo.Count([]bool{e.Regex != "", len(e.Fields) > 0, e.Expression != ""}, true) != 1 {
+		return nil, fmt.Errorf("event \"%s\" requires a name, metric, and either a regex, fields, or an expression", e.Name)
+	}
+	matchSelector := e.MatchSelector
+	if matchSelector == "" {
+		matchSelector = sources.EventMatchSelectorFirst
+	} else if !sources.ValidMatchSelector(matchSelector) {
+		return nil, fmt.Errorf("invalid matchSelector \"%s\" for event \"%s\", expected first, last, all, or nth:<n>", e.MatchSelector, e.Name)
+	}
+	src, ok := m.GetSource(e.Src)
+	if !ok {
+		return nil, fmt.Errorf("unable to register event \"%s\" because source \"%s\" is not registered", e.Name, e.Src)
+	}
+	event := &sources.Event{
+		Name:          e.Name,
+		Metric:        e.Metric,
+		SrcName:       e.Src,
+		MatchSelector: matchSelector,
+		Terminal:      e.Terminal,
+		MaxLatency:    e.MaxLatency.Duration,
+		Deadline:      e.Deadline.Duration,
+		After:         e.After,
+	}
+	jsonSrc, isJSON := src.(*jsonlog.Source)
+	if (len(e.Fields) > 0 || e.CommentField != "") && !isJSON {
+		return nil, fmt.Errorf("unable to register event \"%s\" because source \"%s\" does not support field selectors", e.Name, e.Src)
+	}
+	if len(e.Labels) > 0 && e.Regex == "" {
+		return nil, fmt.Errorf("event \"%s\" requires a regex with named capture groups for labels", e.Name)
+	}
+	// expressions are evaluated over the line, and the JSON entry of JSON log sources
+	vars := []string{cel.VarLine}
+	if isJSON {
+		vars = append(vars, cel.VarEntry)
+	}
+	activation := func(line string) map[string]any {
+		values := map[string]any{cel.VarLine: line}
+		if isJSON {
+			if entry, err := jsonlog.Decode(line); err == nil {
+				values[cel.VarEntry] = entry
+			}
+		}
+		return values
+	}
+	var re *regexp.Regexp
+	switch {
+	case len(e.Fields) > 0:
+		selectors, err := e.selectors()
+		if err != nil {
+			return nil, err
+		}
+		event.FindFn = jsonSrc.FindBySelectors(selectors...)
+	case e.Expression != "":
+		finder, ok := src.(sources.RegexFinder)
+		if !ok {
+			return nil, fmt.Errorf("unable to register event \"%s\" because source \"%s\" does not support expression matching", e.Name, e.Src)
+		}
+		program, err := cel.Compile(e.Expression, vars...)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression for event \"%s\": %w", e.Name, err)
+		}
+		prefilter := program.Prefilter()
+		if prefilter == nil {
+			prefilter = anyLine
+		}
+		event.FindFn = sources.FindByLine(finder, prefilter, func(line string) bool { return program.Matches(activation(line)) })
+	default:
+		finder, ok := src.(sources.RegexFinder)
+		if !ok {
+			return nil, fmt.Errorf("unable to register event \"%s\" because source \"%s\" does not support regex matching", e.Name, e.Src)
+		}
+		var err error
+		if re, err = regexp.Compile(e.Regex); err != nil {
+			return nil, fmt.Errorf("invalid regex for event \"%s\": %w", e.Name, err)
+		}
+		event.FindFn = finder.FindByRegex(re)
+	}
+	if len(e.Labels) > 0 {
+		if missing, _ := lo.Difference(e.Labels, re.SubexpNames()); len(missing) > 0 {
+			return nil, fmt.Errorf("labels %v of event \"%s\" are not named capture groups of its regex", missing, e.Name)
+		}
+		event.Labels = lo.Uniq(e.Labels)
+		event.LabelFn = sources.LabelCaptureGroups(re, event.Labels...)
+	}
+	if len(e.StaticLabels) > 0 {
+		for name := range e.StaticLabels {
+			if !model.LabelName(name).IsValid() || lo.Contains(e.Labels, name) {
+				return nil, fmt.Errorf("static label \"%s\" of event \"%s\" is not a valid label name or is also a captured label", name, e.Name)
+			}
+		}
+		event.StaticLabels = e.StaticLabels
+	}
+	switch {
+	case e.CommentExpression != "":
+		program, err := cel.Compile(e.CommentExpression, vars...)
+		if err != nil {
+			return nil, fmt.Errorf("invalid commentExpression for event \"%s\": %w", e.Name, err)
+		}
+		event.CommentFn = func(matchedLine string) string {
+			value, err := program.Eval(activation(matchedLine))
+			if err != nil {
+				return ""
+			}
+			return cel.Format(value)
+		}
+	case e.CommentField != "":
+		event.CommentFn = jsonlog.CommentField(e.CommentField)
+	case e.CommentMatchedLine:
+		event.CommentFn = sources.CommentMatchedLine()
+	case re != nil && lo.SomeBy(re.SubexpNames(), func(name string) bool { return name != "" }):
+		event.CommentFn = sources.CommentCaptureGroups(re)
+	}
+	return event, nil
+}
+
+// selectors constructs the field selectors declared by the EventConfig
+func (e EventConfig) selectors() ([]jsonlog.Selector, error) {
+	var selectors []jsonlog.Selector
+	for _, f := range e.Fields {
+		if f.Field == "" {
+			return nil, fmt.Errorf("field selector of event \"%s\" requires a field", e.Name)
+		}
+		selector := jsonlog.Selector{Field: f.Field, Equals: f.Equals, Contains: f.Contains}
+		if f.Regex != "" {
+			re, err := regexp.Compile(f.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex for field \"%s\" of event \"%s\": %w", f.Field, e.Name, err)
+			}
+			selector.Regex = re
+		}
+		selectors = append(selectors, selector)
+	}
+	return selectors, nil
+}
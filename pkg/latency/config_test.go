@@ -0,0 +1,111 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"testing"
+)
+
+func TestWithinPaths(t *testing.T) {
+	for _, tc := range []struct {
+		path    string
+		allowed []string
+		within  bool
+	}{
+		{path: "/var/log", allowed: []string{"/var/log"}, within: true},
+		{path: "/var/log/messages", allowed: []string{"/var/log"}, within: true},
+		{path: "/var/log/pods/*/aws-node/*.log", allowed: []string{"/var/log/"}, within: true},
+		{path: "/opt/agent/agent.log", allowed: []string{"/var/log", "/opt/agent"}, within: true},
+		{path: "/var/logs/messages", allowed: []string{"/var/log"}},
+		{path: "/var/log*/messages", allowed: []string{"/var/log"}},
+		{path: "/var/log/../../etc/shadow", allowed: []string{"/var/log"}},
+		{path: "/var/log/*/../../../etc/*", allowed: []string{"/var/log"}},
+		{path: "var/log/messages", allowed: []string{"/var/log"}},
+		{path: "/var/log/messages", allowed: []string{"var/log"}},
+		{path: "/var/log/messages"},
+		{path: "/etc/shadow", allowed: []string{"/"}, within: true},
+	} {
+		if within := withinPaths(tc.path, tc.allowed); within != tc.within {
+			t.Errorf("withinPaths(%s, %v) = %t, expected %t", tc.path, tc.allowed, within, tc.within)
+		}
+	}
+}
+
+func TestValidateUntrusted(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config Config
+		valid  bool
+	}{
+		{
+			name: "default paths",
+			config: Config{
+				Sources: []SourceConfig{{Type: SourceTypeMessages}, {Type: SourceTypeJournal}, {Type: SourceTypeKmsg}},
+				Events:  []EventConfig{{Name: "Ready", Metric: "ready", Src: "Messages", Regex: `agent (?P<state>ready)`}},
+			},
+			valid: true,
+		},
+		{
+			name: "allowed paths",
+			config: Config{Sources: []SourceConfig{
+				{Name: "agent", Type: SourceTypeLog, Path: "/var/log/agent.log", Paths: []string{"/var/log/agent/*.log"}},
+				{Name: "kubelet", Type: SourceTypeJSONLog, Path: "/var/log/kubelet.json.log"},
+			}},
+			valid: true,
+		},
+		{
+			name:   "path outside of the allowed paths",
+			config: Config{Sources: []SourceConfig{{Name: "shadow", Type: SourceTypeLog, Path: "/etc/shadow"}}},
+		},
+		{
+			name:   "more paths outside of the allowed paths",
+			config: Config{Sources: []SourceConfig{{Type: SourceTypeMessages, Paths: []string{"/var/log/../lib/kubelet/kubeconfig"}}}},
+		},
+		{
+			name:   "exec source",
+			config: Config{Sources: []SourceConfig{{Name: "exec", Type: SourceTypeExec, Command: "/bin/sh"}}},
+		},
+		{
+			name:   "plugin source",
+			config: Config{Sources: []SourceConfig{{Name: "plugin", Type: SourceTypePlugin, Command: "/var/log/plugin"}}},
+		},
+		{
+			name:   "journal args",
+			config: Config{Sources: []SourceConfig{{Type: SourceTypeJournal, Args: []string{"--file", "/etc/shadow"}}}},
+		},
+		{
+			name:   "matched line comment",
+			config: Config{Events: []EventConfig{{Name: "Line", Src: "Messages", Regex: ".*", CommentMatchedLine: true}}},
+		},
+		{
+			name:   "field comment",
+			config: Config{Events: []EventConfig{{Name: "Field", Src: "kubelet", Fields: []FieldConfig{{Field: "msg"}}, CommentField: "msg"}}},
+		},
+		{
+			name:   "expression comment",
+			config: Config{Events: []EventConfig{{Name: "Expression", Src: "Messages", Expression: "true", CommentExpression: "line"}}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.validateUntrusted(DefaultUntrustedPaths)
+			if tc.valid && err != nil {
+				t.Errorf("expected the config to be valid, got %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("expected the config to be rejected")
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NodeLatencyMeasurement custom resource consts, the CRD is installed by the helm chart
+const (
+	MeasurementResourceKind      = "NodeLatencyMeasurement"
+	MeasurementResourceNodeLabel = NodeAnnotationPrefix + "node-name"
+)
+
+// MeasurementResource is the cluster scoped NodeLatencyMeasurement resource a Measurement is persisted as
+var MeasurementResource = schema.GroupVersionResource{Group: "node-latency.aws", Version: "v1alpha1", Resource: "nodelatencymeasurements"}
+
+// EmitMeasurementResource persists the Measurement as a NodeLatencyMeasurement with the versioned JSON document as its status
+// The resource is owned by the Node so it is garbage collected with the Node, and is labeled with the node name and instance type to be listed by.
+// It returns the name of the created resource.
+func (m *Measurement) EmitMeasurementResource(ctx context.Context, clientset kubernetes.Interface, dynamicClient dynamic.Interface, nodeName string) (string, error) {
+	if nodeName == "" {
+		return "", errors.New("node name is required to create a measurement resource")
+	}
+	node, err := clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("unable to get node %s: %w", nodeName, err)
+	}
+	doc, err := json.Marshal(m)
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal measurement: %w", err)
+	}
+	var status map[string]any
+	if err := json.Unmarshal(doc, &status); err != nil {
+		return "", fmt.Errorf("unable to unmarshal measurement: %w", err)
+	}
+	labels := map[string]string{MeasurementResourceNodeLabel: node.Name}
+	if m.Metadata != nil && m.Metadata.InstanceType != "" {
+		labels["node.kubernetes.io/instance-type"] = m.Metadata.InstanceType
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(MeasurementResource.GroupVersion().String())
+	obj.SetKind(MeasurementResourceKind)
+	obj.SetGenerateName(node.Name + "-")
+	obj.SetLabels(labels)
+	obj.SetOwnerReferences([]metav1.OwnerReference{
+		{APIVersion: "v1", Kind: "Node", Name: node.Name, UID: node.UID},
+	})
+	if err := unstructured.SetNestedField(obj.Object, node.Name, "spec", "nodeName"); err != nil {
+		return "", err
+	}
+	client := dynamicClient.Resource(MeasurementResource)
+	created, err := client.Create(ctx, obj, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("unable to create %s for node %s: %w", MeasurementResourceKind, nodeName, err)
+	}
+	// the status subresource is ignored on create, so it is written separately
+	created.Object["status"] = status
+	if _, err := client.UpdateStatus(ctx, created, metav1.UpdateOptions{}); err != nil {
+		return created.GetName(), fmt.Errorf("unable to update status of %s %s: %w", MeasurementResourceKind, created.GetName(), err)
+	}
+	return created.GetName(), nil
+}
@@ -0,0 +1,133 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// CriticalPhase is the phase of an event which the terminal event depends on through the events' predecessors
+// A phase starts when the last of the event's predecessors was timed, that predecessor gated the event.
+type CriticalPhase struct {
+	Metric string `json:"metric"`
+	// Gate is the predecessor which was timed last before the event, it is empty if the event has no timed predecessors
+	Gate string `json:"gate,omitempty"`
+	// Seconds is the duration of the phase, from when its gate was timed to when the event was timed
+	Seconds float64 `json:"seconds"`
+	// SlackSeconds is how much later the event could have been timed without delaying the terminal event
+	SlackSeconds float64 `json:"slackSeconds"`
+	// Critical is true when the event gated the terminal event, directly or through the events it gated
+	Critical bool `json:"critical,omitempty"`
+}
+
+// String is a human readable description of the phase's slack
+func (p CriticalPhase) String() string {
+	if p.Critical {
+		return "critical"
+	}
+	return fmt.Sprintf("+%.0fs", p.SlackSeconds)
+}
+
+// criticalPath computes the phases of the events the last terminal event depends on, in the order they were timed
+// Each event is gated by its last timed predecessor, so the critical path is the chain of gates back from the terminal
+// event, and the slack of the other events is how much later they could have been timed before gating an event on the path.
+// Only the first successful timing of each event is used, predecessors timed after the event, i.e. anomalies, are ignored.
+func criticalPath(timings []*sources.Timing) []CriticalPhase {
+	measured := map[string]*sources.Timing{}
+	for _, t := range timings {
+		if _, ok := measured[t.Event.Metric]; !ok && t.Error == nil {
+			measured[t.Event.Metric] = t
+		}
+	}
+	terminal, _, ok := lo.FindLastIndexOf(timings, func(t *sources.Timing) bool { return t.Error == nil && t.Event.Terminal })
+	if !ok {
+		return nil
+	}
+	terminal = measured[terminal.Event.Metric]
+	predecessors := func(t *sources.Timing) []*sources.Timing {
+		return lo.FilterMap(t.Event.After, func(metric string, _ int) (*sources.Timing, bool) {
+			p, ok := measured[metric]
+			return p, ok && p != t && !p.Timestamp.After(t.Timestamp)
+		})
+	}
+	// the events the terminal event depends on, and the events each of them gates
+	path := map[string]*sources.Timing{terminal.Event.Metric: terminal}
+	successors := map[string][]*sources.Timing{}
+	for queue := []*sources.Timing{terminal}; len(queue) > 0; queue = queue[1:] {
+		for _, p := range predecessors(queue[0]) {
+			successors[p.Event.Metric] = append(successors[p.Event.Metric], queue[0])
+			if _, ok := path[p.Event.Metric]; !ok {
+				path[p.Event.Metric] = p
+				queue = append(queue, p)
+			}
+		}
+	}
+	if len(path) == 1 {
+		return nil
+	}
+	gates := lo.MapValues(path, func(t *sources.Timing, _ string) *sources.Timing {
+		return lo.MaxBy(predecessors(t), func(a, b *sources.Timing) bool { return a.Timestamp.After(b.Timestamp) })
+	})
+	// latest is the latest time an event could have been timed at without delaying the terminal event, a successor's
+	// phase would have started that much later
+	latest := map[string]time.Time{}
+	var latestOf func(t *sources.Timing) time.Time
+	latestOf = func(t *sources.Timing) time.Time {
+		if l, ok := latest[t.Event.Metric]; ok {
+			return l
+		}
+		// the timestamp bounds events with equal timestamps which are each other's predecessors
+		latest[t.Event.Metric] = t.Timestamp
+		if t == terminal {
+			return t.Timestamp
+		}
+		l := lo.MinBy(lo.Map(successors[t.Event.Metric], func(s *sources.Timing, _ int) time.Time {
+			return latestOf(s).Add(-s.Timestamp.Sub(gates[s.Event.Metric].Timestamp))
+		}), func(a, b time.Time) bool { return a.Before(b) })
+		latest[t.Event.Metric] = l
+		return l
+	}
+	critical := map[string]bool{}
+	for t := terminal; t != nil; t = gates[t.Event.Metric] {
+		critical[t.Event.Metric] = true
+	}
+	phases := lo.MapToSlice(path, func(metric string, t *sources.Timing) CriticalPhase {
+		phase := CriticalPhase{Metric: metric, Critical: critical[metric]}
+		if gate := gates[metric]; gate != nil {
+			phase.Gate = gate.Event.Metric
+			phase.Seconds = t.Timestamp.Sub(gate.Timestamp).Seconds()
+		}
+		if !phase.Critical {
+			phase.SlackSeconds = latestOf(t).Sub(t.Timestamp).Seconds()
+		}
+		return phase
+	})
+	sort.SliceStable(phases, func(i, j int) bool {
+		ti, tj := path[phases[i].Metric].Timestamp, path[phases[j].Metric].Timestamp
+		return ti.Before(tj) || (ti.Equal(tj) && phases[i].Metric < phases[j].Metric)
+	})
+	return phases
+}
+
+// criticalMetrics are the metrics of the events on the critical path, in the order they were timed
+func (m *Measurement) criticalMetrics() []string {
+	return lo.FilterMap(m.CriticalPath, func(p CriticalPhase, _ int) (string, bool) { return p.Metric, p.Critical })
+}
@@ -0,0 +1,72 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// CSVHeader is the header row of the CSV output, metadata columns are repeated on every row so files from many nodes can be concatenated
+var CSVHeader = []string{
+	"instance_id", "instance_type", "architecture", "region", "availability_zone", "ami_id", "private_ip", "account_id", "node_group",
+	"event", "metric", "src", "terminal", "timestamp", "seconds", "comment", "error",
+	"max_latency_seconds", "slo",
+}
+
+// CSVOptions allows configuration of the CSV output
+type CSVOptions struct {
+	// NoHeader omits the header row, i.e. when appending to an existing file
+	NoHeader bool
+}
+
+// CSV writes a row per timing of the Measurement with the node metadata as columns
+func (m *Measurement) CSV(w io.Writer, opts CSVOptions) error {
+	writer := csv.NewWriter(w)
+	if !opts.NoHeader {
+		if err := writer.Write(CSVHeader); err != nil {
+			return fmt.Errorf("unable to write csv header: %w", err)
+		}
+	}
+	metadata := m.Metadata
+	if metadata == nil {
+		metadata = &Metadata{}
+	}
+	for _, t := range m.Timings {
+		var timestamp, seconds, timingErr, maxLatency string
+		if t.Error != nil {
+			timingErr = t.Error.Error()
+		} else {
+			timestamp = t.Timestamp.UTC().Format(time.RFC3339)
+			seconds = strconv.FormatFloat(t.T.Seconds(), 'f', -1, 64)
+		}
+		if t.Event.MaxLatency > 0 {
+			maxLatency = strconv.FormatFloat(t.Event.MaxLatency.Seconds(), 'f', -1, 64)
+		}
+		if err := writer.Write([]string{
+			metadata.InstanceID, metadata.InstanceType, metadata.Architecture, metadata.Region, metadata.AvailabilityZone,
+			metadata.AMIID, metadata.PrivateIP, metadata.AccountID, metadata.NodeGroup,
+			t.Event.Name, t.Event.Metric, t.Event.SrcName, strconv.FormatBool(t.Event.Terminal), timestamp, seconds, t.Comment, timingErr,
+			maxLatency, t.SLOStatus(),
+		}); err != nil {
+			return fmt.Errorf("unable to write csv row for event %s: %w", t.Event.Name, err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
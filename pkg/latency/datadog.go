@@ -0,0 +1,132 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+)
+
+// DatadogMetricPrefix is prepended to metric names emitted to Datadog
+const DatadogMetricPrefix = "node_latency."
+
+// DatadogOptions configures how metrics are emitted to Datadog
+// Metrics are submitted to the Datadog API when an APIKey is set, otherwise they are sent to DogStatsD.
+type DatadogOptions struct {
+	// Site is the Datadog site of the API, i.e. datadoghq.com or datadoghq.eu
+	Site   string
+	APIKey string
+	// StatsdAddr is the host:port of DogStatsD, i.e. the Datadog agent
+	StatsdAddr          string
+	ExperimentDimension string
+}
+
+// datadogSeries is a metric series of the Datadog v2 series API
+type datadogSeries struct {
+	Metric string         `json:"metric"`
+	Type   int            `json:"type"`
+	Unit   string         `json:"unit"`
+	Points []datadogPoint `json:"points"`
+	Tags   []string       `json:"tags"`
+}
+
+// datadogPoint is a point of a Datadog series
+type datadogPoint struct {
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+// datadogGaugeType is the gauge metric type of the Datadog v2 series API
+const datadogGaugeType = 3
+
+// EmitDatadogMetrics emits gauges to Datadog with the same dimensions as CloudWatch, as tags
+func (m *Measurement) EmitDatadogMetrics(ctx context.Context, opts DatadogOptions) error {
+	dimensions := m.metricDimensions(opts.ExperimentDimension)
+	if opts.APIKey != "" {
+		return m.emitDatadogAPI(ctx, opts, dimensions)
+	}
+	return m.emitDogStatsD(opts.StatsdAddr, dimensions)
+}
+
+// emitDatadogAPI submits the metrics to the Datadog v2 series API
+func (m *Measurement) emitDatadogAPI(ctx context.Context, opts DatadogOptions, dimensions map[string]string) error {
+	now := time.Now().Unix()
+	var series []datadogSeries
+	for _, timing := range m.Timings {
+		series = append(series, datadogSeries{
+			Metric: DatadogMetricPrefix + timing.Event.Metric,
+			Type:   datadogGaugeType,
+			Unit:   "second",
+			Points: []datadogPoint{{Timestamp: now, Value: timing.T.Seconds()}},
+			Tags:   datadogTags(timingDimensions(dimensions, timing)),
+		})
+	}
+	body, err := json.Marshal(map[string][]datadogSeries{"series": series})
+	if err != nil {
+		return fmt.Errorf("unable to marshal datadog series: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("https://api.%s/api/v2/series", opts.Site), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("DD-API-KEY", opts.APIKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to submit datadog series: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unable to submit datadog series, status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// emitDogStatsD sends the metrics as gauges to DogStatsD over UDP
+func (m *Measurement) emitDogStatsD(addr string, dimensions map[string]string) error {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return fmt.Errorf("unable to connect to dogstatsd at %s: %w", addr, err)
+	}
+	defer conn.Close()
+	var errs error
+	for _, timing := range m.Timings {
+		if _, err := fmt.Fprintf(conn, "%s%s:%f|g|#%s", DatadogMetricPrefix, timing.Event.Metric, timing.T.Seconds(), strings.Join(datadogTags(timingDimensions(dimensions, timing)), ",")); err != nil {
+			errs = multierr.Append(errs, err)
+		}
+	}
+	return errs
+}
+
+// datadogTags converts the metric dimensions to sorted Datadog tags
+func datadogTags(dimensions map[string]string) []string {
+	tags := lo.MapToSlice(dimensions, func(k, v string) string {
+		return fmt.Sprintf("%s:%s", k, v)
+	})
+	sort.Strings(tags)
+	return tags
+}
@@ -0,0 +1,109 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// Diff status consts of an EventDiff
+const (
+	DiffStatusChanged = "changed"
+	DiffStatusAdded   = "added"
+	DiffStatusMissing = "missing"
+)
+
+// MeasurementDiff is the per event difference between a base Measurement and another Measurement
+type MeasurementDiff struct {
+	Events []EventDiff `json:"events"`
+	// TotalDeltaSeconds is the difference of the latency of the last successful timings, a positive delta is a regression
+	TotalDeltaSeconds float64 `json:"totalDeltaSeconds"`
+}
+
+// EventDiff is the difference of an event's latency, added events are only in the other Measurement and missing events are only in the base
+type EventDiff struct {
+	Event        string  `json:"event"`
+	Metric       string  `json:"metric"`
+	Status       string  `json:"status"`
+	BaseSeconds  float64 `json:"baseSeconds"`
+	Seconds      float64 `json:"seconds"`
+	DeltaSeconds float64 `json:"deltaSeconds"`
+}
+
+// Diff compares the first successful timing of each metric of the Measurement (the base) to the other Measurement
+// Events are ordered by the base Measurement with added events last.
+func (m *Measurement) Diff(other *Measurement) *MeasurementDiff {
+	base, otherTimings := m.firstTimings(), other.firstTimings()
+	otherByMetric := lo.KeyBy(otherTimings, func(t *sources.Timing) string { return t.Event.Metric })
+	baseByMetric := lo.KeyBy(base, func(t *sources.Timing) string { return t.Event.Metric })
+	diff := &MeasurementDiff{Events: []EventDiff{}}
+	for _, t := range base {
+		eventDiff := EventDiff{Event: t.Event.Name, Metric: t.Event.Metric, Status: DiffStatusMissing, BaseSeconds: t.T.Seconds()}
+		if o, ok := otherByMetric[t.Event.Metric]; ok {
+			eventDiff.Status = DiffStatusChanged
+			eventDiff.Seconds = o.T.Seconds()
+			eventDiff.DeltaSeconds = eventDiff.Seconds - eventDiff.BaseSeconds
+		}
+		diff.Events = append(diff.Events, eventDiff)
+	}
+	for _, o := range otherTimings {
+		if _, ok := baseByMetric[o.Event.Metric]; !ok {
+			diff.Events = append(diff.Events, EventDiff{Event: o.Event.Name, Metric: o.Event.Metric, Status: DiffStatusAdded, Seconds: o.T.Seconds()})
+		}
+	}
+	if len(base) > 0 && len(otherTimings) > 0 {
+		diff.TotalDeltaSeconds = otherTimings[len(otherTimings)-1].T.Seconds() - base[len(base)-1].T.Seconds()
+	}
+	return diff
+}
+
+// firstTimings returns the first successful timing of each metric in chronological order
+func (m *Measurement) firstTimings() []*sources.Timing {
+	return lo.UniqBy(lo.Filter(m.Timings, func(t *sources.Timing, _ int) bool { return t.Error == nil }), func(t *sources.Timing) string { return t.Event.Metric })
+}
+
+// Markdown formats the MeasurementDiff as a markdown table with the total regression, i.e. for a PR comment
+func (d *MeasurementDiff) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "### Node latency diff: %s total\n\n", formatDelta(d.TotalDeltaSeconds))
+	table := tablewriter.NewWriter(&sb)
+	table.SetHeader([]string{"Event", "Base", "New", "Delta"})
+	for _, e := range d.Events {
+		switch e.Status {
+		case DiffStatusAdded:
+			table.Append([]string{e.Event, "-", fmt.Sprintf("%.0fs", e.Seconds), DiffStatusAdded})
+		case DiffStatusMissing:
+			table.Append([]string{e.Event, fmt.Sprintf("%.0fs", e.BaseSeconds), "-", DiffStatusMissing})
+		default:
+			table.Append([]string{e.Event, fmt.Sprintf("%.0fs", e.BaseSeconds), fmt.Sprintf("%.0fs", e.Seconds), formatDelta(e.DeltaSeconds)})
+		}
+	}
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	table.SetAutoWrapText(false)
+	table.Render()
+	return sb.String()
+}
+
+// formatDelta formats seconds with an explicit sign, i.e. +5s
+func formatDelta(seconds float64) string {
+	return fmt.Sprintf("%+.0fs", seconds)
+}
@@ -0,0 +1,134 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/samber/lo"
+)
+
+// emfMetadata is the _aws metadata of a CloudWatch Embedded Metric Format log line
+type emfMetadata struct {
+	Timestamp         int64          `json:"Timestamp"`
+	CloudWatchMetrics []emfDirective `json:"CloudWatchMetrics"`
+}
+
+// emfDirective tells CloudWatch which members of the log line are metrics and dimensions
+type emfDirective struct {
+	Namespace  string      `json:"Namespace"`
+	Dimensions [][]string  `json:"Dimensions"`
+	Metrics    []emfMetric `json:"Metrics"`
+}
+
+// emfMetric is a metric definition of an EMF directive
+type emfMetric struct {
+	Name              string `json:"Name"`
+	Unit              string `json:"Unit"`
+	StorageResolution int    `json:"StorageResolution,omitempty"`
+}
+
+// EMF returns a single CloudWatch Embedded Metric Format log line with every metric of the Measurement
+// The metrics have the same namespace and dimensions as EmitCloudWatchMetrics, metrics measured more than once are a list of values.
+func (m *Measurement) EMF(experimentDimension string, opts CloudWatchOptions, now time.Time) ([]byte, error) {
+	dimensions := opts.dimensions(m.metricDimensions(experimentDimension))
+	dimensionKeys := lo.Keys(dimensions)
+	sort.Strings(dimensionKeys)
+
+	values := map[string][]float64{}
+	for _, timing := range m.Timings {
+		values[timing.Event.Metric] = append(values[timing.Event.Metric], timing.T.Seconds())
+	}
+	metrics := lo.Keys(values)
+	sort.Strings(metrics)
+
+	doc := map[string]any{}
+	for k, v := range dimensions {
+		doc[k] = v
+	}
+	for metric, v := range values {
+		if len(v) == 1 {
+			doc[metric] = v[0]
+		} else {
+			doc[metric] = v
+		}
+	}
+	doc["_aws"] = emfMetadata{
+		Timestamp: now.UnixMilli(),
+		CloudWatchMetrics: []emfDirective{
+			{
+				Namespace:  opts.namespace(),
+				Dimensions: [][]string{dimensionKeys},
+				Metrics: lo.Map(metrics, func(metric string, _ int) emfMetric {
+					return emfMetric{Name: metric, Unit: "Seconds", StorageResolution: int(lo.FromPtr(opts.storageResolution()))}
+				}),
+			},
+		},
+	}
+	return json.Marshal(doc)
+}
+
+// EmitEMF puts the EMF log line to a CloudWatch Logs stream which CloudWatch extracts the metrics from
+// The log group must already exist, the stream is created if it does not exist.
+func (m *Measurement) EmitEMF(ctx context.Context, client *cloudwatchlogs.Client, logGroup string, logStream string, experimentDimension string, opts CloudWatchOptions) error {
+	now := time.Now()
+	line, err := m.EMF(experimentDimension, opts, now)
+	if err != nil {
+		return fmt.Errorf("unable to marshal embedded metric format: %w", err)
+	}
+	if logStream == "" {
+		logStream = m.emfLogStream()
+	}
+	if _, err := client.CreateLogStream(ctx, &cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String(logGroup),
+		LogStreamName: aws.String(logStream),
+	}); err != nil {
+		var alreadyExists *types.ResourceAlreadyExistsException
+		if !errors.As(err, &alreadyExists) {
+			return fmt.Errorf("unable to create log stream %s in log group %s: %w", logStream, logGroup, err)
+		}
+	}
+	if _, err := client.PutLogEvents(ctx, &cloudwatchlogs.PutLogEventsInput{
+		LogGroupName:  aws.String(logGroup),
+		LogStreamName: aws.String(logStream),
+		LogEvents: []types.InputLogEvent{
+			{
+				Message:   aws.String(string(line)),
+				Timestamp: aws.Int64(now.UnixMilli()),
+			},
+		},
+	}); err != nil {
+		return fmt.Errorf("unable to put log events to log stream %s in log group %s: %w", logStream, logGroup, err)
+	}
+	return nil
+}
+
+// emfLogStream is the default log stream of the node, the hostname is used when the instance-id is unknown
+func (m *Measurement) emfLogStream() string {
+	if m.Metadata != nil && m.Metadata.InstanceID != "" {
+		return m.Metadata.InstanceID
+	}
+	hostname, _ := os.Hostname()
+	return hostname
+}
@@ -0,0 +1,108 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// CloudEvent source and types of Measurements
+const (
+	CloudEventSource        = "node-latency-for-k8s"
+	CloudEventTypeCompleted = "com.github.awslabs.node-latency-for-k8s.measurement.completed"
+	CloudEventTypeTimedOut  = "com.github.awslabs.node-latency-for-k8s.measurement.timedout"
+)
+
+// CloudEvent is a CloudEvents v1.0 structured mode envelope of a Measurement
+type CloudEvent struct {
+	SpecVersion     string       `json:"specversion"`
+	ID              string       `json:"id"`
+	Source          string       `json:"source"`
+	Type            string       `json:"type"`
+	Subject         string       `json:"subject,omitempty"`
+	Time            time.Time    `json:"time"`
+	DataContentType string       `json:"datacontenttype"`
+	Data            *Measurement `json:"data"`
+}
+
+// CloudEvent wraps the Measurement in a CloudEvent
+// The type is completed when a terminal event was measured, otherwise timedout. The subject is the instance-id when known.
+func (m *Measurement) CloudEvent() (*CloudEvent, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("unable to generate event id: %w", err)
+	}
+	eventType := CloudEventTypeTimedOut
+	if lo.ContainsBy(m.Timings, func(t *sources.Timing) bool { return t.Event.Terminal && t.Error == nil }) {
+		eventType = CloudEventTypeCompleted
+	}
+	subject := ""
+	if m.Metadata != nil {
+		subject = m.Metadata.InstanceID
+	}
+	return &CloudEvent{
+		SpecVersion:     "1.0",
+		ID:              hex.EncodeToString(id),
+		Source:          CloudEventSource,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		Data:            m,
+	}, nil
+}
+
+// EmitEventBridge publishes the Measurement as a CloudEvent to an EventBridge event bus
+// The EventBridge detail-type is the CloudEvent type so rules can match completed or timed out measurements.
+func (m *Measurement) EmitEventBridge(ctx context.Context, client *eventbridge.Client, eventBusName string) error {
+	event, err := m.CloudEvent()
+	if err != nil {
+		return err
+	}
+	detail, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("unable to marshal cloud event: %w", err)
+	}
+	out, err := client.PutEvents(ctx, &eventbridge.PutEventsInput{
+		Entries: []types.PutEventsRequestEntry{
+			{
+				EventBusName: aws.String(eventBusName),
+				Source:       aws.String(event.Source),
+				DetailType:   aws.String(event.Type),
+				Detail:       aws.String(string(detail)),
+				Time:         aws.Time(event.Time),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to put event to event bus %s: %w", eventBusName, err)
+	}
+	if out.FailedEntryCount > 0 && len(out.Entries) > 0 {
+		return fmt.Errorf("unable to put event to event bus %s: %s %s", eventBusName, aws.ToString(out.Entries[0].ErrorCode), aws.ToString(out.Entries[0].ErrorMessage))
+	}
+	return nil
+}
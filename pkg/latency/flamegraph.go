@@ -0,0 +1,189 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// ComponentLabel is the static label of an event which sets the component it is nested under in profiles, i.e. component: cni
+const ComponentLabel = "component"
+
+// defaultComponents are the components of the default events by metric prefix, other events are nested under their source
+var defaultComponents = []struct{ prefix, component string }{
+	{"instance_", "ec2"},
+	{"fleet_", "ec2"},
+	{"asg_", "autoscaling"},
+	{"vm_", "kernel"},
+	{"network_", "network"},
+	{"cloudinit_", "cloud-init"},
+	{"conatinerd_", "containerd"},
+	{"containerd_", "containerd"},
+	{"kubelet_", "kubelet"},
+	{"kube_proxy_", "kube-proxy"},
+	{"vpc_cni_", "vpc-cni"},
+	{"aws_node_", "vpc-cni"},
+	{"node_", "kubernetes"},
+	{"pod_", "kubernetes"},
+}
+
+// foldedEscaper replaces the frame separator and newlines in frame names of folded stacks
+var foldedEscaper = strings.NewReplacer(";", " ", "\n", " ")
+
+// phase is the duration of a successful timing, from the previous timing to the timing's timestamp like the trace's spans
+type phase struct {
+	timing    *sources.Timing
+	component string
+	// start and end are milliseconds since the first timing
+	start, end float64
+}
+
+// phases returns the phases of the Measurement's successful timings
+func (m *Measurement) phases() []phase {
+	timings := lo.Filter(m.Timings, func(t *sources.Timing, _ int) bool { return t.Error == nil })
+	if len(timings) == 0 {
+		return nil
+	}
+	first := timings[0].Timestamp
+	var phases []phase
+	for i, timing := range timings {
+		start := timing.Timestamp
+		if i > 0 {
+			start = timings[i-1].Timestamp
+		}
+		phases = append(phases, phase{
+			timing:    timing,
+			component: component(timing),
+			start:     float64(start.Sub(first).Microseconds()) / 1000,
+			end:       float64(timing.Timestamp.Sub(first).Microseconds()) / 1000,
+		})
+	}
+	return phases
+}
+
+// component returns the component of the timing's event, from its component label, its metric, or its source
+func component(t *sources.Timing) string {
+	if c, ok := t.Labels[ComponentLabel]; ok && c != "" {
+		return c
+	}
+	for _, c := range defaultComponents {
+		if strings.HasPrefix(t.Event.Metric, c.prefix) {
+			return c.component
+		}
+	}
+	return t.Event.SrcName
+}
+
+// Folded renders the Measurement as folded stacks of the boot phases nested by component, i.e. for flamegraph.pl or speedscope
+// Each line is a phase's stack, "Node Boot;<component>;<event>", and its duration in milliseconds. Phases of no duration are left out.
+func (m *Measurement) Folded() string {
+	var b strings.Builder
+	for _, p := range m.phases() {
+		if ms := int64(p.end - p.start); ms > 0 {
+			fmt.Fprintf(&b, "%s;%s;%s %d\n", rootSpanName, foldedEscaper.Replace(p.component), foldedEscaper.Replace(p.timing.Event.Name), ms)
+		}
+	}
+	return b.String()
+}
+
+// speedscopeSchema is the JSON schema of the speedscope file format
+const speedscopeSchema = "https://www.speedscope.app/file-format-schema.json"
+
+type speedscopeFile struct {
+	Schema   string              `json:"$schema"`
+	Name     string              `json:"name"`
+	Exporter string              `json:"exporter"`
+	Shared   speedscopeShared    `json:"shared"`
+	Profiles []speedscopeProfile `json:"profiles"`
+}
+
+type speedscopeShared struct {
+	Frames []speedscopeFrame `json:"frames"`
+}
+
+type speedscopeFrame struct {
+	Name string `json:"name"`
+}
+
+type speedscopeProfile struct {
+	Type       string            `json:"type"`
+	Name       string            `json:"name"`
+	Unit       string            `json:"unit"`
+	StartValue float64           `json:"startValue"`
+	EndValue   float64           `json:"endValue"`
+	Events     []speedscopeEvent `json:"events"`
+}
+
+type speedscopeEvent struct {
+	// Type is O to open a frame and C to close it
+	Type  string  `json:"type"`
+	Frame int     `json:"frame"`
+	At    float64 `json:"at"`
+}
+
+// Speedscope renders the Measurement as a speedscope evented profile of the boot phases nested by component
+// The root frame spans the boot, consecutive phases of the same component are nested under one frame of the component,
+// so the time order view of speedscope shows the boot as a flame chart and the left heavy view sums the phases by component.
+func (m *Measurement) Speedscope() ([]byte, error) {
+	phases := m.phases()
+	name := rootSpanName
+	if m.Metadata != nil && m.Metadata.InstanceID != "" {
+		name = fmt.Sprintf("%s %s", rootSpanName, m.Metadata.InstanceID)
+	}
+	var frames []speedscopeFrame
+	frameIndexes := map[string]int{}
+	frame := func(key string, name string) int {
+		if i, ok := frameIndexes[key]; ok {
+			return i
+		}
+		frames = append(frames, speedscopeFrame{Name: name})
+		frameIndexes[key] = len(frames) - 1
+		return len(frames) - 1
+	}
+	profile := speedscopeProfile{Type: "evented", Name: name, Unit: "milliseconds", Events: []speedscopeEvent{}}
+	if len(phases) > 0 {
+		root := frame("", rootSpanName)
+		profile.EndValue = phases[len(phases)-1].end
+		profile.Events = append(profile.Events, speedscopeEvent{Type: "O", Frame: root, At: 0})
+		for i, p := range phases {
+			c := frame("component/"+p.component, p.component)
+			if i == 0 || phases[i-1].component != p.component {
+				profile.Events = append(profile.Events, speedscopeEvent{Type: "O", Frame: c, At: p.start})
+			}
+			e := frame("event/"+p.component+"/"+p.timing.Event.Name, p.timing.Event.Name)
+			profile.Events = append(profile.Events,
+				speedscopeEvent{Type: "O", Frame: e, At: p.start},
+				speedscopeEvent{Type: "C", Frame: e, At: p.end},
+			)
+			if i == len(phases)-1 || phases[i+1].component != p.component {
+				profile.Events = append(profile.Events, speedscopeEvent{Type: "C", Frame: c, At: p.end})
+			}
+		}
+		profile.Events = append(profile.Events, speedscopeEvent{Type: "C", Frame: root, At: profile.EndValue})
+	}
+	return json.Marshal(speedscopeFile{
+		Schema:   speedscopeSchema,
+		Name:     name,
+		Exporter: serviceName,
+		Shared:   speedscopeShared{Frames: lo.Ternary(frames == nil, []speedscopeFrame{}, frames)},
+		Profiles: []speedscopeProfile{profile},
+	})
+}
@@ -0,0 +1,157 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// DefaultHistogramBuckets are the upper bounds in seconds of the latency histogram buckets, node boots take seconds to minutes
+var DefaultHistogramBuckets = []float64{5, 10, 15, 20, 30, 45, 60, 90, 120, 180, 300, 600}
+
+// TraceIDExemplarLabel is the exemplar label of the Measurement's trace ID, Grafana links exemplars to traces by it
+const TraceIDExemplarLabel = "trace_id"
+
+// defaultDimensions are the metric dimensions of every Measurement, the histograms label all of them so the label set of
+// a histogram does not change between measurements, dimensions which are not set are empty
+var defaultDimensions = []string{"experiment", "instanceType", "amiID", "region", "availabilityZone", "warmStart"}
+
+// ParseHistogramBuckets parses comma separated durations to the upper bounds in seconds of histogram buckets, i.e. 10s,30s,1m,5m
+func ParseHistogramBuckets(bucketsStr string) ([]float64, error) {
+	var buckets []float64
+	for _, bucket := range strings.Split(bucketsStr, ",") {
+		if bucket == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(bucket)
+		if err != nil {
+			return nil, fmt.Errorf("invalid histogram bucket \"%s\": %w", bucket, err)
+		}
+		if len(buckets) > 0 && duration.Seconds() <= buckets[len(buckets)-1] {
+			return nil, fmt.Errorf("histogram buckets must be in increasing order, \"%s\" is not", bucket)
+		}
+		buckets = append(buckets, duration.Seconds())
+	}
+	return buckets, nil
+}
+
+// Histograms records the timings of Measurements into prometheus histograms, rather than setting gauges to the latest
+// Measurement, so percentiles can be computed across nodes and over time. A timing is only observed once, so
+// re-measuring the same boot, i.e. on an interval, does not skew the histograms.
+type Histograms struct {
+	mu                  sync.Mutex
+	register            prometheus.Registerer
+	experimentDimension string
+	buckets             []float64
+	collectors          map[string]*prometheus.HistogramVec
+	// labels are the label names of each metric's histogram, which are fixed when it is registered
+	labels   map[string][]string
+	observed map[string]struct{}
+}
+
+// NewHistograms creates Histograms which registers a histogram per metric with the buckets, the default buckets are
+// used if none are passed
+func NewHistograms(register prometheus.Registerer, experimentDimension string, buckets []float64) *Histograms {
+	return &Histograms{
+		register:            register,
+		experimentDimension: experimentDimension,
+		buckets:             lo.Ternary(len(buckets) == 0, DefaultHistogramBuckets, buckets),
+		collectors:          map[string]*prometheus.HistogramVec{},
+		labels:              map[string][]string{},
+		observed:            map[string]struct{}{},
+	}
+}
+
+// Observe records the successful timings of the Measurement which were not observed before
+func (h *Histograms) Observe(m *Measurement) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	dimensions := m.metricDimensions(h.experimentDimension)
+	for _, dimension := range defaultDimensions {
+		if _, ok := dimensions[dimension]; !ok {
+			dimensions[dimension] = ""
+		}
+	}
+	for _, timing := range m.Timings {
+		if timing.Error != nil {
+			continue
+		}
+		key := timingKey(timing)
+		if _, ok := h.observed[key]; ok {
+			continue
+		}
+		collector, err := h.collector(timing, dimensions)
+		if err != nil {
+			log.Printf("error registering metric %s: %v", timing.Event.Metric, err)
+			continue
+		}
+		values := timingDimensions(dimensions, timing)
+		labels := lo.SliceToMap(h.labels[timing.Event.Metric], func(label string) (string, string) { return label, values[label] })
+		m.ObserveWithExemplar(collector.With(labels), timing.T.Seconds())
+		h.observed[key] = struct{}{}
+	}
+	if m.checksOrdering() {
+		collector, err := registerGauge(h.register, OrderingAnomaliesMetric, lo.Keys(dimensions))
+		if err != nil {
+			log.Printf("error registering metric %s: %v", OrderingAnomaliesMetric, err)
+			return
+		}
+		collector.With(dimensions).Set(float64(len(m.OrderingAnomalies)))
+	}
+}
+
+// collector returns the histogram of the timing's metric, the histogram is registered on the first timing of the metric
+// with the dimensions, i.e. the node label dimensions, and the event's labels as its labels
+func (h *Histograms) collector(timing *sources.Timing, dimensions map[string]string) (*prometheus.HistogramVec, error) {
+	if collector, ok := h.collectors[timing.Event.Metric]; ok {
+		return collector, nil
+	}
+	dimensionNames := lo.Keys(dimensions)
+	sort.Strings(dimensionNames)
+	labels := lo.Uniq(append(dimensionNames, timing.Event.Labels...))
+	collector := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    timing.Event.Metric,
+		Help:    fmt.Sprintf("Latency in seconds of the %s event", timing.Event.Name),
+		Buckets: h.buckets,
+	}, labels)
+	if err := h.register.Register(collector); err != nil {
+		return nil, err
+	}
+	h.collectors[timing.Event.Metric] = collector
+	h.labels[timing.Event.Metric] = labels
+	return collector, nil
+}
+
+// ObserveWithExemplar observes the value with an exemplar of the Measurement's trace ID, so a sample links to the trace
+// of the boot it was measured on. The value is observed without an exemplar if the Measurement was not exported as a
+// trace or the observer does not support exemplars, i.e. summaries.
+func (m *Measurement) ObserveWithExemplar(observer prometheus.Observer, value float64) {
+	exemplarObserver, ok := observer.(prometheus.ExemplarObserver)
+	if m.TraceID == "" || !ok {
+		observer.Observe(value)
+		return
+	}
+	exemplarObserver.ObserveWithExemplar(value, prometheus.Labels{TraceIDExemplarLabel: m.TraceID})
+}
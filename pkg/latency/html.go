@@ -0,0 +1,164 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// htmlReport is the data of the HTML report template
+type htmlReport struct {
+	Metadata    *Metadata
+	GeneratedAt string
+	Rows        []htmlRow
+}
+
+// htmlRow is a timing of the HTML report, the bar offset and width are percentages of the timeline
+type htmlRow struct {
+	Event     string
+	Metric    string
+	Src       string
+	Terminal  bool
+	Timestamp string
+	T         string
+	Comment   string
+	Error     string
+	Line      string
+	Offset    float64
+	Width     float64
+}
+
+// htmlReportTemplate is a single-file report without external assets so it can be attached to incident reviews
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Node Latency{{ with .Metadata }} | {{ .InstanceID }}{{ end }}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292f; }
+h1 { font-size: 1.4em; }
+dl { display: grid; grid-template-columns: max-content auto; gap: 0.2em 1em; }
+dt { font-weight: bold; }
+dd { margin: 0; }
+input { margin: 1em 0; padding: 0.3em; width: 20em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #d0d7de; padding: 0.3em 0.5em; text-align: left; vertical-align: top; }
+td.timeline { width: 40%; }
+.track { position: relative; height: 1em; background: #f6f8fa; }
+.bar { position: absolute; height: 100%; min-width: 2px; background: #0969da; }
+.terminal .bar { background: #1a7f37; }
+.error { color: #cf222e; }
+tr:hover { background: #f6f8fa; }
+pre { white-space: pre-wrap; word-break: break-all; margin: 0.3em 0; }
+</style>
+</head>
+<body>
+<h1>Node Latency Report</h1>
+{{- with .Metadata }}
+<dl>
+<dt>Instance ID</dt><dd>{{ .InstanceID }}</dd>
+<dt>Private IP</dt><dd>{{ .PrivateIP }}</dd>
+<dt>Instance Type</dt><dd>{{ .InstanceType }}</dd>
+<dt>Architecture</dt><dd>{{ .Architecture }}</dd>
+<dt>Region</dt><dd>{{ .Region }}</dd>
+<dt>Availability Zone</dt><dd>{{ .AvailabilityZone }}</dd>
+<dt>AMI ID</dt><dd>{{ .AMIID }}</dd>
+{{- if .NodeGroup }}
+<dt>Node Group</dt><dd>{{ .NodeGroup }}</dd>
+{{- end }}
+{{- if .WarmStart }}
+<dt>Warm Start</dt><dd>{{ .WarmStart }}</dd>
+{{- end }}
+</dl>
+{{- end }}
+<p>Generated at {{ .GeneratedAt }}</p>
+<input id="filter" type="search" placeholder="Filter events" oninput="filterRows(this.value)">
+<table>
+<thead><tr><th>Event</th><th>Timestamp</th><th>T</th><th>Timeline</th><th>Comment</th></tr></thead>
+<tbody>
+{{- range .Rows }}
+<tr class="row{{ if .Terminal }} terminal{{ end }}" data-event="{{ .Event }} {{ .Metric }} {{ .Src }}">
+<td title="{{ .Metric }} ({{ .Src }})">{{ .Event }}</td>
+{{- if .Error }}
+<td></td><td></td><td></td><td class="error">{{ .Error }}</td>
+{{- else }}
+<td>{{ .Timestamp }}</td>
+<td>{{ .T }}</td>
+<td class="timeline"><div class="track"><div class="bar" style="left: {{ printf "%.2f" .Offset }}%; width: {{ printf "%.2f" .Width }}%" title="{{ .Event }}: {{ .T }}"></div></div></td>
+<td>{{ .Comment }}{{ if .Line }}<details><summary>matched line</summary><pre>{{ .Line }}</pre></details>{{ end }}</td>
+{{- end }}
+</tr>
+{{- end }}
+</tbody>
+</table>
+<script>
+function filterRows(value) {
+  var query = value.toLowerCase();
+  document.querySelectorAll("tr.row").forEach(function (row) {
+    row.style.display = row.dataset.event.toLowerCase().includes(query) ? "" : "none";
+  });
+}
+</script>
+</body>
+</html>
+`))
+
+// HTML writes a self-contained HTML report of the Measurement with a metadata header, an interactive timeline, and the raw matched lines
+// Like the trace, each successful timing is a bar starting at the previous timing and ending at the timing's timestamp.
+func (m *Measurement) HTML(w io.Writer) error {
+	report := htmlReport{
+		Metadata:    m.Metadata,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	timings := lo.Filter(m.Timings, func(t *sources.Timing, _ int) bool { return t.Error == nil })
+	var start, end time.Time
+	if len(timings) > 0 {
+		start = timings[0].Timestamp
+		end = timings[len(timings)-1].Timestamp
+	}
+	total := end.Sub(start).Seconds()
+	prev := start
+	for _, t := range m.Timings {
+		row := htmlRow{
+			Event:    t.Event.Name,
+			Metric:   t.Event.Metric,
+			Src:      t.Event.SrcName,
+			Terminal: t.Event.Terminal,
+			Comment:  t.Comment,
+			Line:     t.Line,
+		}
+		if t.Error != nil {
+			row.Error = t.Error.Error()
+			report.Rows = append(report.Rows, row)
+			continue
+		}
+		row.Timestamp = t.Timestamp.Format("2006-01-02T15:04:05Z")
+		row.T = fmt.Sprintf("%.0fs", t.T.Seconds())
+		if total > 0 {
+			row.Offset = prev.Sub(start).Seconds() / total * 100
+			row.Width = t.Timestamp.Sub(prev).Seconds() / total * 100
+		}
+		prev = t.Timestamp
+		report.Rows = append(report.Rows, row)
+	}
+	return htmlReportTemplate.Execute(w, report)
+}
@@ -0,0 +1,145 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// JSONSchemaVersion is the version of the JSON document a Measurement is marshaled to
+// The version is bumped on any breaking change to the document
+const JSONSchemaVersion = "v1"
+
+// MeasurementDocument is the versioned JSON representation of a Measurement
+type MeasurementDocument struct {
+	SchemaVersion string           `json:"schemaVersion"`
+	Metadata      *Metadata        `json:"metadata"`
+	Timings       []TimingDocument `json:"timings"`
+	// MetadataUnavailable is only set when a configured metadata service could not be used
+	MetadataUnavailable string `json:"metadataUnavailable,omitempty"`
+	// BootID is only set when the Measurement was taken on the live node
+	BootID string `json:"bootID,omitempty"`
+	// TraceID is only set when the Measurement was exported as an OpenTelemetry trace
+	TraceID string `json:"traceID,omitempty"`
+	// OrderingAnomalies are only set when events were timed before, or without, the events they are expected after
+	OrderingAnomalies []OrderingAnomaly `json:"orderingAnomalies,omitempty"`
+	// CriticalPath is only set when the terminal event depends on other events
+	CriticalPath []CriticalPhase `json:"criticalPath,omitempty"`
+}
+
+// TimingDocument is the versioned JSON representation of a Timing
+type TimingDocument struct {
+	Event     string    `json:"event"`
+	Metric    string    `json:"metric"`
+	Src       string    `json:"src"`
+	Terminal  bool      `json:"terminal"`
+	Timestamp time.Time `json:"timestamp"`
+	Seconds   float64   `json:"seconds"`
+	Comment   string    `json:"comment,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	// Labels are the values of the event's metric labels, i.e. regex capture groups
+	Labels map[string]string `json:"labels,omitempty"`
+	// MaxLatencySeconds and SLO are only set when the event declares a max latency
+	MaxLatencySeconds float64 `json:"maxLatencySeconds,omitempty"`
+	SLO               string  `json:"slo,omitempty"`
+}
+
+// JSON returns the indented versioned JSON document of the Measurement
+func (m *Measurement) JSON() ([]byte, error) {
+	return json.MarshalIndent(m, "", "    ")
+}
+
+// MarshalJSON marshals the Measurement to the versioned JSON document
+func (m *Measurement) MarshalJSON() ([]byte, error) {
+	doc := MeasurementDocument{
+		SchemaVersion:       JSONSchemaVersion,
+		Metadata:            m.Metadata,
+		MetadataUnavailable: m.MetadataUnavailable,
+		BootID:              m.BootID,
+		TraceID:             m.TraceID,
+		Timings:             []TimingDocument{},
+		OrderingAnomalies:   m.OrderingAnomalies,
+		CriticalPath:        m.CriticalPath,
+	}
+	for _, t := range m.Timings {
+		timingDoc := TimingDocument{
+			Event:             t.Event.Name,
+			Metric:            t.Event.Metric,
+			Src:               t.Event.SrcName,
+			Terminal:          t.Event.Terminal,
+			Timestamp:         t.Timestamp,
+			Seconds:           t.T.Seconds(),
+			Comment:           t.Comment,
+			Labels:            t.Labels,
+			MaxLatencySeconds: t.Event.MaxLatency.Seconds(),
+			SLO:               t.SLOStatus(),
+		}
+		if t.Error != nil {
+			timingDoc.Error = t.Error.Error()
+		}
+		doc.Timings = append(doc.Timings, timingDoc)
+	}
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON unmarshals a versioned JSON document into the Measurement
+// The Events of the unmarshaled Timings only have descriptive fields set and are not registered to a source
+func (m *Measurement) UnmarshalJSON(data []byte) error {
+	var doc MeasurementDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if doc.SchemaVersion != JSONSchemaVersion {
+		return fmt.Errorf("unsupported measurement schema version \"%s\", expected \"%s\"", doc.SchemaVersion, JSONSchemaVersion)
+	}
+	m.Metadata = doc.Metadata
+	m.MetadataUnavailable = doc.MetadataUnavailable
+	m.BootID = doc.BootID
+	m.TraceID = doc.TraceID
+	m.OrderingAnomalies = doc.OrderingAnomalies
+	m.CriticalPath = doc.CriticalPath
+	m.Timings = nil
+	for _, t := range doc.Timings {
+		timing := &sources.Timing{
+			Event: &sources.Event{
+				Name:       t.Event,
+				Metric:     t.Metric,
+				SrcName:    t.Src,
+				Terminal:   t.Terminal,
+				MaxLatency: time.Duration(t.MaxLatencySeconds * float64(time.Second)),
+			},
+			Timestamp: t.Timestamp,
+			T:         time.Duration(t.Seconds * float64(time.Second)),
+			Comment:   t.Comment,
+			Labels:    t.Labels,
+		}
+		// only the labels that were found are in the document, so they are also the event's labels
+		timing.Event.Labels = lo.Keys(t.Labels)
+		sort.Strings(timing.Event.Labels)
+		if t.Error != "" {
+			timing.Error = errors.New(t.Error)
+		}
+		m.Timings = append(m.Timings, timing)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NodeAnnotationPrefix is the prefix of the Node annotations the timings are written to, i.e. node-latency.aws/pod_ready=42s
+const NodeAnnotationPrefix = "node-latency.aws/"
+
+// Reasons of the Kubernetes Events created on the Node
+const (
+	NodeEventReasonMeasured = "NodeLatencyMeasured"
+	NodeEventReasonBreached = "NodeLatencyThresholdBreached"
+)
+
+// NodeAnnotations returns an annotation per metric of the successful timings, the last timing of a metric wins
+func (m *Measurement) NodeAnnotations() map[string]string {
+	annotations := map[string]string{}
+	for _, timing := range m.Timings {
+		if timing.Error != nil {
+			continue
+		}
+		annotations[NodeAnnotationPrefix+timing.Event.Metric] = fmt.Sprintf("%.0fs", timing.T.Seconds())
+	}
+	return annotations
+}
+
+// EmitNodeAnnotations patches the Node with the timings as annotations so they are queryable via kubectl and by other controllers
+func (m *Measurement) EmitNodeAnnotations(ctx context.Context, clientset kubernetes.Interface, nodeName string) error {
+	if nodeName == "" {
+		return errors.New("node name is required to annotate the node")
+	}
+	annotations := m.NodeAnnotations()
+	if len(annotations) == 0 {
+		return errors.New("no successful timings to annotate the node with")
+	}
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{"annotations": annotations},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to marshal node annotations patch: %w", err)
+	}
+	if _, err := clientset.CoreV1().Nodes().Patch(ctx, nodeName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("unable to annotate node %s: %w", nodeName, err)
+	}
+	return nil
+}
+
+// EmitNodeEvents creates a Kubernetes Event on the Node for each terminal event and threshold breach so slow boots are visible in kubectl describe node
+// Terminal events are Normal events and threshold breaches are Warning events.
+func (m *Measurement) EmitNodeEvents(ctx context.Context, clientset kubernetes.Interface, nodeName string, thresholds Thresholds) error {
+	if nodeName == "" {
+		return errors.New("node name is required to create node events")
+	}
+	node, err := clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to get node %s: %w", nodeName, err)
+	}
+	ref := corev1.ObjectReference{Kind: "Node", APIVersion: "v1", Name: node.Name, UID: node.UID}
+	summary := m.Summary(thresholds)
+	var events []*corev1.Event
+	for _, t := range summary.TerminalEvents {
+		events = append(events, nodeEvent(ref, corev1.EventTypeNormal, NodeEventReasonMeasured,
+			fmt.Sprintf("%s (%s) measured at %.0fs", t.Event, t.Metric, t.Seconds)))
+	}
+	for _, b := range summary.ThresholdBreaches {
+		events = append(events, nodeEvent(ref, corev1.EventTypeWarning, NodeEventReasonBreached,
+			fmt.Sprintf("%s measured at %.0fs exceeded the threshold of %.0fs", b.Metric, b.Seconds, b.ThresholdSeconds)))
+	}
+	var errs error
+	for _, event := range events {
+		if _, err := clientset.CoreV1().Events(metav1.NamespaceDefault).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("unable to create %s event on node %s: %w", event.Reason, nodeName, err))
+		}
+	}
+	return errs
+}
+
+// nodeEvent constructs an Event of the Node, named like client-go's event recorder so names are unique
+func nodeEvent(ref corev1.ObjectReference, eventType string, reason string, message string) *corev1.Event {
+	now := metav1.NewTime(time.Now())
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", ref.Name, now.UnixNano()),
+			Namespace: metav1.NamespaceDefault,
+		},
+		InvolvedObject: ref,
+		Type:           eventType,
+		Reason:         reason,
+		Message:        message,
+		Source:         corev1.EventSource{Component: serviceName},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+}
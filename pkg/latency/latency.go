@@ -23,46 +23,118 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"reflect"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/olekukonko/tablewriter"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	asgsrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/asg"
 	"github.com/awslabs/node-latency-for-k8s/pkg/sources/awsnode"
+	azuresrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/azure"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/cloudinit"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/dnsprobe"
 	ec2src "github.com/awslabs/node-latency-for-k8s/pkg/sources/ec2"
+	gcesrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/gce"
 	imdssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/imds"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/journal"
 	k8ssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/k8s"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/logfile"
 	"github.com/awslabs/node-latency-for-k8s/pkg/sources/messages"
+	talossrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/talos"
 )
 
+// DefaultConcurrency is the default number of events searched concurrently during a timing run
+const DefaultConcurrency = 8
+
 // Measurer holds registered sources and events to use for timing runs
 type Measurer struct {
-	sources      map[string]sources.Source
-	events       []*sources.Event
-	metadata     *Metadata
-	imdsClient   *imds.Client
-	ec2Client    *ec2.Client
-	k8sClientset *kubernetes.Clientset
-	podNamespace string
-	nodeName     string
+	sources          map[string]sources.Source
+	events           []*sources.Event
+	metadata         *Metadata
+	metadataProvider MetadataProvider
+	imdsClient       *imds.Client
+	imdsCache        *imdssrc.Cache
+	fallbackMetadata *Metadata
+	gceSource        *gcesrc.Source
+	azureSource      *azuresrc.Source
+	dnsProbe         *dnsprobe.Source
+	talosSource      *talossrc.Source
+	ec2Client        *ec2.Client
+	asgClient        *autoscaling.Client
+	k8sClientset     *kubernetes.Clientset
+	podNamespace     string
+	eventParams      DefaultEventParams
+	nodeName         string
+	profiles         []*Profile
+	slos             map[string]time.Duration
+	deadlines        map[string]time.Duration
+	terminalEvents   []string
+	zeroEvents       []string
+	// spotEvents registers the default spot interruption and rebalance recommendation events
+	spotEvents bool
+	// metadataUnavailable is why the node's metadata service could not be used, it is reported on each Measurement
+	metadataUnavailable string
+	// nodeLabelDimensions are the Node's labels added to the metric dimensions by dimension name, nodeLabels caches their values
+	nodeLabelDimensions map[string]string
+	nodeLabels          map[string]string
+	// versionDimensions are the versions of the node's OS and components added to the metric dimensions, versions caches them
+	versionDimensions []string
+	versions          componentVersions
+	// bootSegmentation drops the events logged before the current boot, bootTime caches when it booted and bootID its ID
+	bootSegmentation bool
+	bootTime         time.Time
+	bootID           string
+	// precision is the precision the timings' T are rounded to
+	precision time.Duration
+	// clockStepCorrection corrects the timestamps logged before the node's clock was stepped
+	clockStepCorrection bool
+	checkpointPath      string
+	emitted             map[string]struct{}
+	concurrency         int
+	// sourceLocks serialize the searches of each source since sources cache their data
+	// They are channels so that waiting for a source can be abandoned when its timeout elapses.
+	sourceLocks    map[string]chan struct{}
+	sourceTimeout  time.Duration
+	sourceTimeouts map[string]time.Duration
+	// root is the directory the node's log files are read under, i.e. an extracted recording
+	root string
+	// clock returns the current time, it is time.Now unless a fake clock is set
+	clock func() time.Time
 }
 
 // Measurement is a specific timing produced from a Measurer run
 type Measurement struct {
 	Metadata *Metadata         `json:"metadata"`
 	Timings  []*sources.Timing `json:"timings"`
+	// MetadataUnavailable is why the Metadata could not be retrieved from a configured metadata service
+	MetadataUnavailable string `json:"metadataUnavailable,omitempty"`
+	// BootID is the ID of the node's boot the Measurement was taken during, it is empty when replaying
+	BootID string `json:"bootID,omitempty"`
+	// TraceID is the ID of the trace the Measurement was exported as, it is attached as an exemplar to the histograms
+	TraceID string `json:"traceID,omitempty"`
+	// OrderingAnomalies are the events timed before, or without, the events they are expected after
+	OrderingAnomalies []OrderingAnomaly `json:"orderingAnomalies,omitempty"`
+	// CriticalPath are the phases of the events the terminal event depends on, with the slack of those not on the critical path
+	CriticalPath []CriticalPhase `json:"criticalPath,omitempty"`
 }
 
 // Metadata provides data about the node where measurements are executed
@@ -75,11 +147,29 @@ type Metadata struct {
 	AvailabilityZone string `json:"availabilityZone"`
 	PrivateIP        string `json:"privateIP"`
 	AMIID            string `json:"amiID"`
+	NodeGroup        string `json:"nodeGroup,omitempty"`
+	// WarmStart is set when the node was started from a warm pool or resumed from hibernation rather than cold booted
+	WarmStart string `json:"warmStart,omitempty"`
+	// NodeLabels are the values of the Node's labels selected as metric dimensions, by dimension name
+	NodeLabels map[string]string `json:"nodeLabels,omitempty"`
+	// OSImage, KernelVersion, KubeletVersion, and ContainerRuntimeVersion are the versions of the node's OS and components
+	OSImage                 string `json:"osImage,omitempty"`
+	KernelVersion           string `json:"kernelVersion,omitempty"`
+	KubeletVersion          string `json:"kubeletVersion,omitempty"`
+	ContainerRuntimeVersion string `json:"containerRuntimeVersion,omitempty"`
+	// VersionDimensions are the versions added to the metric dimensions, i.e. kernelVersion
+	VersionDimensions []string `json:"versionDimensions,omitempty"`
 }
 
 // ChartOptions allows configuration of the markdown chart
 type ChartOptions struct {
 	HiddenColumns []string
+	// Columns are the columns to show in order, all columns are shown in the default order if none are set
+	Columns []string
+	// TimestampFormat is the go layout of the timestamp column, DefaultChartTimestampFormat if not set
+	TimestampFormat string
+	// Precision is the precision of the T and Δ columns, DefaultPrecision if not set
+	Precision time.Duration
 }
 
 // Chart column label consts
@@ -87,51 +177,183 @@ const (
 	ChartColumnEvent     = "Event"
 	ChartColumnTimestamp = "Timestamp"
 	ChartColumnT         = "T"
+	ChartColumnDelta     = "Δ"
 	ChartColumnComment   = "Comment"
+	ChartColumnSLO       = "SLO"
+	ChartColumnSlack     = "Slack"
 )
 
+// DefaultChartTimestampFormat is the default go layout of the chart's timestamp column
+const DefaultChartTimestampFormat = "2006-01-02T15:04:05Z"
+
+// DefaultPrecision is the default precision of the timings' T, so sub-second phases, i.e. the CNI copy, are distinguishable
+const DefaultPrecision = time.Millisecond
+
+// ChartColumns are the columns of the markdown chart in the default order
+var ChartColumns = []string{ChartColumnEvent, ChartColumnTimestamp, ChartColumnT, ChartColumnDelta, ChartColumnComment, ChartColumnSLO, ChartColumnSlack}
+
+// ParseChartColumns parses comma separated chart column names, which are case insensitive, to the columns in order
+// "delta" is accepted for the Δ column, i.e. event,t,delta,comment
+func ParseChartColumns(columnsStr string) ([]string, error) {
+	var columns []string
+	for _, name := range strings.Split(columnsStr, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if strings.EqualFold(name, "delta") {
+			name = ChartColumnDelta
+		}
+		column, ok := lo.Find(ChartColumns, func(c string) bool { return strings.EqualFold(c, name) })
+		if !ok {
+			return nil, fmt.Errorf("invalid chart column \"%s\", expected one of %s", name, strings.Join(ChartColumns, ", "))
+		}
+		columns = append(columns, column)
+	}
+	return lo.Uniq(columns), nil
+}
+
 // Default Event regular expressions
 var (
 	vmInit                = regexp.MustCompile(`.*kernel: Linux version.*`)
 	networkStart          = regexp.MustCompile(`.*Reached target Network \(Pre\).*`)
 	networkReady          = regexp.MustCompile(`.*Reached target Network\..*`)
-	cloudInitInitialStart = regexp.MustCompile(`.*cloud-init: Cloud-init v.* running 'init'.*`)
-	cloudInitConfigStart  = regexp.MustCompile(`.*cloud-init: Cloud-init v.* running 'modules:config'.*`)
-	cloudInitFinalStart   = regexp.MustCompile(`.*cloud-init: Cloud-init v.* running 'modules:final'.*`)
-	cloudInitFinalFinish  = regexp.MustCompile(`.*cloud-init: Cloud-init v.* finished`)
+	cloudInitInitialStart = regexp.MustCompile(`.*cloud-init(\[[0-9]+\])?: Cloud-init v.* running 'init'.*`)
+	cloudInitConfigStart  = regexp.MustCompile(`.*cloud-init(\[[0-9]+\])?: Cloud-init v.* running 'modules:config'.*`)
+	cloudInitFinalStart   = regexp.MustCompile(`.*cloud-init(\[[0-9]+\])?: Cloud-init v.* running 'modules:final'.*`)
+	cloudInitFinalFinish  = regexp.MustCompile(`.*cloud-init(\[[0-9]+\])?: Cloud-init v.* finished`)
 	containerdStart       = regexp.MustCompile(`.*Starting containerd container runtime.*`)
 	containerdInitialized = regexp.MustCompile(`.*Started containerd container runtime.*`)
 	kubeletStart          = regexp.MustCompile(`.*Starting Kubernetes Kubelet.*`)
 	kubeletInitialized    = regexp.MustCompile(`.*Started kubelet.*`)
 	kubeletRegistered     = regexp.MustCompile(`.*Successfully registered node.*`)
-	kubeProxyStart        = regexp.MustCompile(`.*CreateContainer within sandbox .*Name:kube-proxy.* returns container id.*`)
-	vpcCNIInitStart       = regexp.MustCompile(`.*CreateContainer within sandbox .*Name:aws-vpc-cni-init.* returns container id.*`)
-	awsNodeStart          = regexp.MustCompile(`.*CreateContainer within sandbox .*Name:aws-node.* returns container id.*`)
-	vpcCNIInitialized     = regexp.MustCompile(`.*Successfully copied CNI plugin binary and config file.*`)
-	nodeReady             = regexp.MustCompile(`.*event="NodeReady".*`)
-	throttled             = regexp.MustCompile(`.*Waited for .* due to client-side throttling, not priority and fairness, request: .*`)
-	podReadyStr           = `.*%s/.* Type:ContainerStarted.*`
+	// the kubelet's first informer list marks its first authenticated API request, it is logged at --v=2
+	kubeletAuthenticated = regexp.MustCompile(`.*(?:Caches populated for \*v1\.(?P<resource>[A-Za-z]+) from|"Caches populated" type="\*v1\.(?P<resource>[A-Za-z]+)").*`)
+	vpcCNIInitialized    = regexp.MustCompile(`.*Successfully copied CNI plugin binary and config file.*`)
+	nodeReady            = regexp.MustCompile(`.*event="NodeReady".*`)
+	hibernationResumed   = regexp.MustCompile(`.*(?:kernel: PM: (?:hibernation: )?[Hh]ibernation exit|systemd-sleep\[[0-9]+\]: System returned from sleep).*`)
+	clockStepped         = regexp.MustCompile(`.*chronyd\[[0-9]+\]: System clock was stepped by (?P<offset>-?[0-9.]+) seconds.*`)
+	throttled            = regexp.MustCompile(`.*Waited for (?P<wait>\S+) due to client-side throttling, not priority and fairness, request: (?P<request>.*)`)
 )
 
 // New creates a new instance of a Measurer
 func New() *Measurer {
 	return &Measurer{
-		sources: make(map[string]sources.Source),
+		sources:     make(map[string]sources.Source),
+		sourceLocks: make(map[string]chan struct{}),
+		concurrency: DefaultConcurrency,
+		precision:   DefaultPrecision,
 	}
 }
 
+// WithPrecision is a builder func that rounds the timings' T to the precision, i.e. time.Second for whole seconds
+// The JSON output and the emitted metrics use the rounded T, T is not rounded if the precision is not positive.
+func (m *Measurer) WithPrecision(precision time.Duration) *Measurer {
+	m.precision = precision
+	return m
+}
+
+// WithConcurrency is a builder func that sets the number of events searched concurrently during a timing run
+// Events of the same source are still searched one at a time, so a source does not need to be safe for concurrent use.
+func (m *Measurer) WithConcurrency(concurrency int) *Measurer {
+	m.concurrency = concurrency
+	return m
+}
+
 // WithIMDS is a builder func that adds an EC2 Instance Metadata Service (IMDS) client to a Measurer
 func (m *Measurer) WithIMDS(imdsClient *imds.Client) *Measurer {
 	m.imdsClient = imdsClient
 	return m
 }
 
+// WithIMDSCache is a builder func that persists the instance-identity document and the IMDS event times to a cache,
+// so they are reused across restarts and measurements instead of queried from IMDS again
+func (m *Measurer) WithIMDSCache(cache *imdssrc.Cache) *Measurer {
+	m.imdsCache = cache
+	return m
+}
+
+// WithSpotEvents is a builder func that registers the spot interruption and rebalance recommendation IMDS events with the
+// default events, so the interruption signals of short-lived spot nodes can be correlated with their boot timeline
+func (m *Measurer) WithSpotEvents(enabled bool) *Measurer {
+	m.spotEvents = enabled
+	return m
+}
+
+// WithGCEMetadata is a builder func that adds a Google Compute Engine (GCE) metadata source to a Measurer
+// The GCE metadata server is used as the MetadataProvider unless one is set with WithMetadataProvider
+func (m *Measurer) WithGCEMetadata(src *gcesrc.Source) *Measurer {
+	m.gceSource = src
+	return m
+}
+
+// WithDNSProbe is a builder func that adds an active cluster DNS probe source to a Measurer, which times when DNS first resolves
+func (m *Measurer) WithDNSProbe(src *dnsprobe.Source) *Measurer {
+	m.dnsProbe = src
+	return m
+}
+
+// WithTalos is a builder func that adds a Talos machine API source to a Measurer, which reads the node's kernel log with talosctl
+func (m *Measurer) WithTalos(src *talossrc.Source) *Measurer {
+	m.talosSource = src
+	return m
+}
+
+// WithAzureIMDS is a builder func that adds an Azure Instance Metadata Service (IMDS) source to a Measurer
+// Azure IMDS is used as the MetadataProvider unless one is set with WithMetadataProvider
+func (m *Measurer) WithAzureIMDS(src *azuresrc.Source) *Measurer {
+	m.azureSource = src
+	return m
+}
+
+// WithMetadataProvider is a builder func that sets the provider of the Metadata attached to a Measurement
+// If a provider is not set, the metadata is retrieved from EC2 IMDS, GCE, or Azure IMDS depending on which client is configured
+func (m *Measurer) WithMetadataProvider(provider MetadataProvider) *Measurer {
+	m.metadataProvider = provider
+	return m
+}
+
+// WithFallbackMetadata is a builder func that sets the metadata used when the metadata service is unavailable, i.e.
+// supplied by env vars, the empty fields are filled from the node's well-known labels
+func (m *Measurer) WithFallbackMetadata(metadata *Metadata) *Measurer {
+	m.fallbackMetadata = metadata
+	return m
+}
+
+// WithMetadataUnavailable is a builder func that marks the node's metadata service as unavailable for the reason, i.e.
+// IMDS failed a probe, so Measurements report why their Metadata is missing
+func (m *Measurer) WithMetadataUnavailable(reason string) *Measurer {
+	m.metadataUnavailable = reason
+	return m
+}
+
+// WithNodeLabelDimensions is a builder func that adds the Node's labels to the metric dimensions, the dimensions are keyed
+// by dimension name, i.e. nodepool: karpenter.sh/nodepool, and require a K8s clientset
+func (m *Measurer) WithNodeLabelDimensions(dimensions map[string]string) *Measurer {
+	m.nodeLabelDimensions = dimensions
+	m.nodeLabels = nil
+	return m
+}
+
+// WithVersionDimensions is a builder func that adds the versions of the node's OS and components to the metric
+// dimensions, i.e. kernelVersion, the versions are part of the Metadata either way
+func (m *Measurer) WithVersionDimensions(dimensions []string) *Measurer {
+	m.versionDimensions = dimensions
+	return m
+}
+
 // WithEC2Client is a builder func that adds an ec2 client to a Measurer
 func (m *Measurer) WithEC2Client(ec2Client *ec2.Client) *Measurer {
 	m.ec2Client = ec2Client
 	return m
 }
 
+// WithASGClient is a builder func that adds an EC2 Auto Scaling client to a Measurer
+func (m *Measurer) WithASGClient(asgClient *autoscaling.Client) *Measurer {
+	m.asgClient = asgClient
+	return m
+}
+
 // WithK8sClientset is a builder func that adds a k8s clientset to a Measurer
 func (m *Measurer) WithK8sClientset(clientset *kubernetes.Clientset) *Measurer {
 	m.k8sClientset = clientset
@@ -150,6 +372,11 @@ func (m *Measurer) WithNodeName(nodeName string) *Measurer {
 	return m
 }
 
+// NodeName returns the node name of the Measurer, which is discovered via EC2 IMDS when registering the default sources if not set
+func (m *Measurer) NodeName() string {
+	return m.nodeName
+}
+
 // MustWithDefaultConfig registers the default sources and events to the Measurer and panics if any errors occur
 func (m *Measurer) MustWithDefaultConfig() *Measurer {
 	return lo.Must(m.RegisterDefaultSources().RegisterDefaultEvents())
@@ -159,6 +386,9 @@ func (m *Measurer) MustWithDefaultConfig() *Measurer {
 func (m *Measurer) RegisterSources(srcs ...sources.Source) *Measurer {
 	for _, src := range srcs {
 		m.sources[src.Name()] = src
+		if _, ok := m.sourceLocks[src.Name()]; !ok {
+			m.sourceLocks[src.Name()] = make(chan struct{}, 1)
+		}
 	}
 	return m
 }
@@ -173,6 +403,19 @@ func (m *Measurer) RegisterEvents(events ...*sources.Event) (*Measurer, error) {
 			continue
 		}
 		e.Src = src
+		// an event's own max latency takes precedence over the SLOs by metric name
+		if maxLatency, ok := m.slos[e.Metric]; ok && e.MaxLatency == 0 {
+			e.MaxLatency = maxLatency
+		}
+		if deadline, ok := m.deadlines[e.Metric]; ok && e.Deadline == 0 {
+			e.Deadline = deadline
+		}
+		m.applyTerminalEvents(e)
+		if len(e.StaticLabels) > 0 {
+			staticLabels := lo.Keys(e.StaticLabels)
+			sort.Strings(staticLabels)
+			e.Labels = lo.Uniq(append(e.Labels, staticLabels...))
+		}
 		m.events = append(m.events, e)
 	}
 	return m, errs
@@ -186,25 +429,14 @@ func (m *Measurer) GetSource(name string) (sources.Source, bool) {
 
 // Measure executes a single timing run with the registered sources and events
 func (m *Measurer) Measure(ctx context.Context) *Measurement {
-	var timings []*sources.Timing
-	for _, event := range m.events {
-		results, err := event.Src.Find(event)
-		if len(results) == 0 {
-			results = []sources.FindResult{}
-		}
-		for _, result := range results {
-			timings = append(timings, &sources.Timing{
-				Event:     event,
-				Timestamp: result.Timestamp,
-				Comment:   result.Comment,
-				Error:     multierr.Append(err, result.Err),
-			})
-		}
-	}
+	timings, timedOut := m.findTimings(ctx)
+	m.correctClockSteps(timings)
 	// Sort timings so they are in chronological order
 	sort.Slice(timings, func(i, j int) bool {
 		return timings[i].Timestamp.UnixMicro() < timings[j].Timestamp.UnixMicro()
 	})
+	absentTimings := m.absentTimings(timings, m.now())
+	anomalies := m.orderingAnomalies(timings)
 
 	// Find the last terminal event index to filter out everything past
 	if _, lastTerminalIndex, ok := lo.FindLastIndexOf(timings, func(t *sources.Timing) bool {
@@ -212,125 +444,413 @@ func (m *Measurer) Measure(ctx context.Context) *Measurement {
 	}); ok {
 		timings = timings[:lastTerminalIndex+1]
 	}
-	firstSuccessfulTiming := timings[0]
-	// Find first successful timing
-	for _, t := range timings {
-		if t.Error == nil {
-			firstSuccessfulTiming = t
-			break
+	kind, start, warm := warmStart(timings)
+	if len(timings) > 0 {
+		firstSuccessfulTiming := timings[0]
+		// Find first successful timing
+		for _, t := range timings {
+			if t.Error == nil {
+				firstSuccessfulTiming = t
+				break
+			}
+		}
+		// a configured zero event wins, otherwise a warm start is the zero point so warm and cold starts are comparable, the
+		// events of the boot that warmed the node are before it
+		if zero, ok := m.zeroTiming(timings); ok {
+			firstSuccessfulTiming = zero
+		} else if warm {
+			firstSuccessfulTiming = start
 		}
+		// Add normalized time delta
+		for _, t := range timings {
+			t.T = t.Timestamp.Sub(firstSuccessfulTiming.Timestamp).Round(m.precision)
+		}
+	}
+	phases := criticalPath(timings)
+	// the events of sources that timed out, and events that were not observed by their deadline, are reported as errored after the measured timings
+	timings = append(append(timings, timedOut...), absentTimings...)
+	m.saveCheckpoints()
+	// metadata errors do not fail the measurement, they are reported when a metadata service is configured
+	metadataUnavailable := m.metadataUnavailable
+	metadata, err := m.getMetadata(ctx)
+	if err != nil && !errors.Is(err, errNoMetadataProvider) {
+		metadataUnavailable = err.Error()
 	}
-	// Add normalized time delta
-	for _, t := range timings {
-		t.T = t.Timestamp.Sub(firstSuccessfulTiming.Timestamp)
+	// the metadata is unavailable even if it is only populated with the warm start, node labels, or versions below
+	metadataUnavailable = lo.Ternary(metadata == nil, metadataUnavailable, "")
+	if warm {
+		metadata = lo.Ternary(metadata == nil, &Metadata{}, metadata)
+		metadata.WarmStart = kind
+	}
+	// the metadata is copied since it is cached
+	enriched := lo.FromPtr(metadata)
+	if nodeLabels, err := m.getNodeLabels(ctx); err != nil {
+		log.Printf("Unable to get the node label dimensions: %s", err)
+	} else if len(nodeLabels) > 0 {
+		enriched.NodeLabels = nodeLabels
+	}
+	versions := m.getVersions(ctx)
+	enriched.OSImage = versions.OSImage
+	enriched.KernelVersion = versions.KernelVersion
+	enriched.KubeletVersion = versions.KubeletVersion
+	enriched.ContainerRuntimeVersion = versions.ContainerRuntimeVersion
+	enriched.VersionDimensions = m.versionDimensions
+	if metadata != nil || !reflect.DeepEqual(enriched, Metadata{}) {
+		metadata = &enriched
 	}
-	// ignore metadata errors
-	metadata, _ := m.getMetadata(ctx)
 	return &Measurement{
-		Metadata: metadata,
-		Timings:  timings,
+		Metadata:            metadata,
+		MetadataUnavailable: metadataUnavailable,
+		BootID:              m.getBootID(),
+		Timings:             timings,
+		OrderingAnomalies:   anomalies,
+		CriticalPath:        phases,
+	}
+}
+
+// findTimings searches the sources for all events with a pool of workers, so network calls and file scans overlap
+// The timings are in the order the events were registered, events of sources that timed out are returned as errored timings.
+func (m *Measurer) findTimings(ctx context.Context) ([]*sources.Timing, []*sources.Timing) {
+	srcCtxs, cancel := m.sourceContexts(ctx)
+	defer cancel()
+	m.registerProvidedEvents(ctx, srcCtxs)
+	if len(m.events) == 0 {
+		return nil, nil
+	}
+	bootStart := m.bootStart()
+	eventTimings := make([][]*sources.Timing, len(m.events))
+	timedOut := make([]*sources.Timing, len(m.events))
+	jobs := make(chan int)
+	wg := sync.WaitGroup{}
+	for i := 0; i < lo.Clamp(m.concurrency, 1, len(m.events)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				event := m.events[j]
+				timings, err := m.find(lo.ValueOr(srcCtxs, event.SrcName, ctx), event, bootStart)
+				if err != nil {
+					timedOut[j] = &sources.Timing{Event: event, Error: fmt.Errorf("source %s timed out: %w", event.SrcName, err)}
+				}
+				eventTimings[j] = timings
+			}
+		}()
+	}
+	for i := range m.events {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return lo.Flatten(eventTimings), lo.Compact(timedOut)
+}
+
+// find searches the event's source while holding the source's lock and returns the timings of the results
+// The context's error is returned if it is done before the source is searched, a search still running is abandoned
+// and the source stays locked until the search returns. Results logged before the boot start are dropped unless it is zero.
+func (m *Measurer) find(ctx context.Context, event *sources.Event, bootStart time.Time) ([]*sources.Timing, error) {
+	unlock, err := m.lockSource(ctx, event.SrcName)
+	if err != nil {
+		return nil, err
+	}
+	type found struct {
+		results []sources.FindResult
+		err     error
+	}
+	done := make(chan found, 1)
+	go func() {
+		defer unlock()
+		results, err := findInBoot(ctx, event, bootStart)
+		done <- found{results: results, err: err}
+	}()
+	var f found
+	select {
+	case f = <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	var timings []*sources.Timing
+	for _, result := range f.results {
+		timing := &sources.Timing{
+			Event:     event,
+			Timestamp: result.Timestamp,
+			Comment:   result.Comment,
+			Error:     multierr.Append(f.err, result.Err),
+			Line:      result.Line,
+		}
+		if event.LabelFn != nil {
+			timing.Labels = event.LabelFn(result.Line)
+		}
+		if len(event.StaticLabels) > 0 {
+			timing.Labels = lo.Assign(event.StaticLabels, timing.Labels)
+		}
+		timings = append(timings, timing)
+	}
+	return timings, nil
+}
+
+// registerProvidedEvents registers the events of EventProvider sources, i.e. plugins, which are not registered yet
+func (m *Measurer) registerProvidedEvents(ctx context.Context, srcCtxs map[string]context.Context) {
+	names := lo.Keys(m.sources)
+	sort.Strings(names)
+	for _, name := range names {
+		provider, ok := m.sources[name].(sources.EventProvider)
+		if !ok {
+			continue
+		}
+		srcCtx := lo.ValueOr(srcCtxs, name, ctx)
+		unlock, err := m.lockSource(srcCtx, name)
+		if err != nil {
+			continue
+		}
+		events, err := provider.Events(srcCtx)
+		unlock()
+		if err != nil {
+			log.Printf("Unable to retrieve the events of source \"%s\": %v\n", name, err)
+			continue
+		}
+		registered := lo.FilterMap(m.events, func(e *sources.Event, _ int) (string, bool) { return e.Name, e.SrcName == name })
+		if _, err := m.RegisterEvents(lo.Filter(events, func(e *sources.Event, _ int) bool { return !lo.Contains(registered, e.Name) })...); err != nil {
+			log.Printf("Unable to register the events of source \"%s\": %v\n", name, err)
+		}
 	}
 }
 
 // MeasureUntil executes timing runs with the registered sources and events until all terminal events have timings or the timeout is reached
 func (m *Measurer) MeasureUntil(ctx context.Context, timeout time.Duration, retryDelay time.Duration) (*Measurement, error) {
 	startTime := time.Now().UTC()
+	// sources are cancelled at the timeout rather than hanging past it, i.e. on an unreachable IMDS
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 	var measurement *Measurement
-	terminalEvents := lo.CountBy(m.events, func(e *sources.Event) bool { return e.Terminal })
-	done := false
-	for !done && time.Since(startTime) < timeout {
-		done = false
+	for time.Since(startTime) < timeout && (measurement == nil || ctx.Err() == nil) {
 		measurement = m.Measure(ctx)
 		for _, m := range measurement.Timings {
 			if m.Error != nil {
 				log.Printf("Unable to retrieve timing for Event \"%s\": %v\n", m.Event.Name, m.Error)
 			}
 		}
-		measuredEvents := lo.CountBy(measurement.Timings, func(t *sources.Timing) bool { return t.Error == nil })
-		measuredTerminalEvents := lo.CountBy(measurement.Timings, func(t *sources.Timing) bool { return t.Event.Terminal && t.Error == nil })
-		// check if there are any terminal events, if so, check if they have completed successfully
-		if terminalEvents > 0 && terminalEvents == measuredTerminalEvents {
-			done = true
-			// if all events are not terminal, then try to time all events without errors until the timeout is reached.
-		} else if terminalEvents == 0 && measuredEvents >= len(m.events) {
-			done = true
-		}
-
-		if done {
+		if m.measured(measurement) {
 			return measurement, nil
 		}
-		for _, s := range m.sources {
-			s.ClearCache()
+		m.ClearCache()
+		select {
+		case <-ctx.Done():
+		case <-time.After(retryDelay):
 		}
-		time.Sleep(retryDelay)
 	}
+	return measurement, m.unmeasuredError(measurement)
+}
+
+// measured checks if all terminal events have timings, or all events when there are no terminal events
+// Events that were not observed by their deadline are settled as absent, so a stuck node does not wait for the timeout.
+func (m *Measurer) measured(measurement *Measurement) bool {
+	terminalEvents := lo.CountBy(m.events, func(e *sources.Event) bool { return e.Terminal })
+	measuredEvents := lo.CountBy(measurement.Timings, func(t *sources.Timing) bool { return t.Error == nil }) +
+		lo.CountBy(m.events, func(e *sources.Event) bool { return absent(measurement, e) })
+	measuredTerminalEvents := lo.CountBy(measurement.Timings, func(t *sources.Timing) bool { return t.Event.Terminal && t.Error == nil }) +
+		lo.CountBy(m.events, func(e *sources.Event) bool { return e.Terminal && absent(measurement, e) })
+	// check if there are any terminal events, if so, check if they have completed successfully
 	if terminalEvents > 0 {
+		return terminalEvents == measuredTerminalEvents
+	}
+	// if all events are not terminal, then try to time all events without errors until the timeout is reached.
+	return measuredEvents >= len(m.events)
+}
+
+// unmeasuredError is the error of a measurement which timed out before all terminal events, or all events when there are no terminal events, had timings
+func (m *Measurer) unmeasuredError(measurement *Measurement) error {
+	var timings []*sources.Timing
+	if measurement != nil {
+		timings = measurement.Timings
+	}
+	if lo.SomeBy(m.events, func(e *sources.Event) bool { return e.Terminal }) {
 		unmeasuredTerminalEvents := lo.Filter(m.events, func(e *sources.Event, _ int) bool {
-			return e.Terminal && lo.CountBy(measurement.Timings, func(t *sources.Timing) bool { return t.Event.Name == e.Name }) == 0
+			return e.Terminal && lo.CountBy(timings, func(t *sources.Timing) bool { return t.Event.Name == e.Name }) == 0
 		})
 		unmeasuredTerminalEventNames := lo.Map(unmeasuredTerminalEvents, func(e *sources.Event, _ int) string { return e.Name })
-		return measurement, fmt.Errorf("unable to measure terminal events: %v", unmeasuredTerminalEventNames)
+		return fmt.Errorf("unable to measure terminal events: %v", unmeasuredTerminalEventNames)
 	}
 	unmeasuredEvents := lo.Filter(m.events, func(e *sources.Event, _ int) bool {
-		return lo.CountBy(measurement.Timings, func(t *sources.Timing) bool { return t.Event.Name == e.Name }) == 0
+		return lo.CountBy(timings, func(t *sources.Timing) bool { return t.Event.Name == e.Name }) == 0
 	})
 	unmeasuredEventNames := lo.Map(unmeasuredEvents, func(e *sources.Event, _ int) string { return e.Name })
-	return measurement, fmt.Errorf("unable to measure events %v within timeout window", unmeasuredEventNames)
+	return fmt.Errorf("unable to measure events %v within timeout window", unmeasuredEventNames)
 }
 
+// ClearCache clears the cached data of all registered sources so that the next Measure reads fresh data
+func (m *Measurer) ClearCache() {
+	for name, s := range m.sources {
+		// a source still being searched after it timed out keeps its cache
+		if unlock, ok := m.tryLockSource(name); ok {
+			s.ClearCache()
+			unlock()
+		}
+	}
+}
+
+// errNoMetadataProvider is returned by getMetadata when no metadata service is configured, i.e. off of a cloud provider
+var errNoMetadataProvider = errors.New("no metadata provider is configured")
+
 // getMetadata populates the metadata for a Measurement
 func (m *Measurer) getMetadata(ctx context.Context) (*Metadata, error) {
 	if m.metadata != nil {
 		return m.metadata, nil
 	}
-	if m.imdsClient == nil {
-		return nil, errors.New("imds client is nil")
+	metadata, err := m.getProviderMetadata(ctx)
+	if err != nil {
+		// the fallback metadata is not cached, so the metadata service is used once it is reachable
+		if fallback, fallbackErr := m.getFallbackMetadata(ctx); fallbackErr == nil {
+			return fallback, nil
+		}
+		return nil, err
+	}
+	m.metadata = metadata
+	return metadata, nil
+}
+
+// getProviderMetadata retrieves the metadata from the MetadataProvider, or the metadata service which is configured
+func (m *Measurer) getProviderMetadata(ctx context.Context) (*Metadata, error) {
+	provider := m.metadataProvider
+	if provider == nil {
+		switch {
+		case m.imdsClient != nil:
+			provider = NewIMDSMetadataProvider(m.imdsClient).WithCache(m.imdsCache)
+		case m.gceSource != nil:
+			provider = NewGCEMetadataProvider(m.gceSource)
+		case m.azureSource != nil:
+			provider = NewAzureMetadataProvider(m.azureSource)
+		default:
+			return nil, errNoMetadataProvider
+		}
 	}
-	idDoc, err := m.imdsClient.GetInstanceIdentityDocument(ctx, &imds.GetInstanceIdentityDocumentInput{})
+	return provider.Metadata(ctx)
+}
+
+// getFallbackMetadata merges the fallback metadata with the metadata of the node's well-known labels, the fallback
+// metadata's fields take precedence
+func (m *Measurer) getFallbackMetadata(ctx context.Context) (*Metadata, error) {
+	var metadata *Metadata
+	if m.fallbackMetadata != nil {
+		fallback := *m.fallbackMetadata
+		metadata = &fallback
+	}
+	if m.k8sClientset != nil && m.nodeName != "" {
+		node, err := NewNodeMetadataProvider(m.k8sClientset, m.nodeName).Metadata(ctx)
+		if err != nil && metadata == nil {
+			return nil, err
+		}
+		if err == nil && metadata == nil {
+			metadata = node
+		} else if err == nil {
+			metadata = mergeMetadata(metadata, node)
+		}
+	}
+	if metadata == nil {
+		return nil, errors.New("no fallback metadata is configured")
+	}
+	return metadata, nil
+}
+
+// getNodeLabels retrieves the values of the Node's labels which are metric dimensions, by dimension name
+// The values are cached once retrieved, labels which are not set on the Node are empty.
+func (m *Measurer) getNodeLabels(ctx context.Context) (map[string]string, error) {
+	if len(m.nodeLabelDimensions) == 0 || m.nodeLabels != nil {
+		return m.nodeLabels, nil
+	}
+	if m.k8sClientset == nil || m.nodeName == "" {
+		return nil, errors.New("a K8s clientset and the node name are required")
+	}
+	node, err := m.k8sClientset.CoreV1().Nodes().Get(ctx, m.nodeName, metav1.GetOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("unable to retrieve instance-identity document: %w", err)
-	}
-	return &Metadata{
-		Region:           idDoc.Region,
-		InstanceType:     idDoc.InstanceType,
-		InstanceID:       idDoc.InstanceID,
-		AccountID:        idDoc.AccountID,
-		Architecture:     idDoc.Architecture,
-		AvailabilityZone: idDoc.AvailabilityZone,
-		AMIID:            idDoc.ImageID,
-		PrivateIP:        idDoc.PrivateIP,
-	}, nil
-}
-
-// Chart generates a markdown chart view of a Measurement
-func (m *Measurement) Chart(opts ChartOptions) {
-	if m.Metadata != nil {
-		fmt.Printf("### %s (%s) | %s | %s | %s | %s\n",
+		return nil, fmt.Errorf("unable to get node %s: %w", m.nodeName, err)
+	}
+	m.nodeLabels = lo.MapValues(m.nodeLabelDimensions, func(label string, _ string) string { return node.Labels[label] })
+	return m.nodeLabels, nil
+}
+
+// Chart writes a markdown chart view of a Measurement to w
+func (m *Measurement) Chart(w io.Writer, opts ChartOptions) {
+	if m.MetadataUnavailable != "" {
+		fmt.Fprintf(w, "### metadata unavailable: %s\n", m.MetadataUnavailable)
+	} else if m.Metadata != nil && lo.SomeBy([]string{m.Metadata.InstanceID, m.Metadata.InstanceType, m.Metadata.AvailabilityZone, m.Metadata.AMIID}, func(v string) bool { return v != "" }) {
+		// the metadata may only be populated with the warm start, node labels, or versions
+		fmt.Fprintf(w, "### %s (%s) | %s | %s | %s | %s\n",
 			m.Metadata.InstanceID, m.Metadata.PrivateIP, m.Metadata.InstanceType, m.Metadata.Architecture,
 			m.Metadata.AvailabilityZone, m.Metadata.AMIID)
 	}
-	table := tablewriter.NewWriter(os.Stdout)
-	headers := []string{ChartColumnEvent, ChartColumnTimestamp, ChartColumnT, ChartColumnComment}
-	table.SetHeader(filterColumns(opts.HiddenColumns, headers, headers))
+	if summary := m.Metadata.versionsSummary(); len(summary) > 0 {
+		fmt.Fprintf(w, "### %s\n", strings.Join(summary, " | "))
+	}
+	table := tablewriter.NewWriter(w)
+	headers := lo.Ternary(len(opts.Columns) == 0, ChartColumns, opts.Columns)
+	timestampFormat := lo.Ternary(opts.TimestampFormat == "", DefaultChartTimestampFormat, opts.TimestampFormat)
+	precision := lo.Ternary(opts.Precision <= 0, DefaultPrecision, opts.Precision)
+	hiddenColumns := append([]string{}, opts.HiddenColumns...)
+	// the SLO column is only shown when an event declares a max latency
+	if lo.NoneBy(m.Timings, func(t *sources.Timing) bool { return t.Event.MaxLatency > 0 }) {
+		hiddenColumns = append(hiddenColumns, ChartColumnSLO)
+	}
+	// the slack column is only shown when the terminal event depends on other events
+	if len(m.CriticalPath) == 0 {
+		hiddenColumns = append(hiddenColumns, ChartColumnSlack)
+	}
+	phases := lo.SliceToMap(m.CriticalPath, func(p CriticalPhase) (string, CriticalPhase) { return p.Metric, p })
+	table.SetHeader(filterColumns(hiddenColumns, headers, headers))
 
 	var data [][]string
+	var prev *sources.Timing
 	for _, t := range m.Timings {
 		if t.Error != nil {
 			log.Printf("Error with event \"%s\" timing: %v\n", t.Event.Name, t.Error)
 			continue
 		}
-		data = append(data, filterColumns(opts.HiddenColumns, headers, []string{
-			t.Event.Name,
-			t.Timestamp.Format("2006-01-02T15:04:05Z"),
-			fmt.Sprintf("%.0fs", t.T.Seconds()),
-			t.Comment,
-		}))
+		var slo string
+		if status := t.SLOStatus(); status != "" {
+			slo = fmt.Sprintf("%s (%s)", status, t.Event.MaxLatency)
+		}
+		var slack string
+		// only the first timing of an event is a phase
+		if phase, ok := phases[t.Event.Metric]; ok {
+			slack = phase.String()
+			delete(phases, t.Event.Metric)
+		}
+		// the delta is the time since the previous event in the chart, so the slow phases stand out
+		var delta string
+		if prev != nil {
+			delta = FormatSeconds(t.T-prev.T, precision)
+		}
+		prev = t
+		values := map[string]string{
+			ChartColumnEvent:     t.Event.Name,
+			ChartColumnTimestamp: t.Timestamp.Format(timestampFormat),
+			ChartColumnT:         FormatSeconds(t.T, precision),
+			ChartColumnDelta:     delta,
+			ChartColumnComment:   t.Comment,
+			ChartColumnSLO:       slo,
+			ChartColumnSlack:     slack,
+		}
+		data = append(data, filterColumns(hiddenColumns, headers, lo.Map(headers, func(h string, _ int) string { return values[h] })))
 	}
 
 	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
 	table.SetCenterSeparator("|")
 	table.AppendBulk(data)
 	table.Render()
+	if critical := m.criticalMetrics(); len(critical) > 0 {
+		fmt.Fprintf(w, "Critical path: %s\n", strings.Join(critical, " -> "))
+	}
+	for _, anomaly := range m.OrderingAnomalies {
+		log.Printf("Ordering anomaly: %s\n", anomaly)
+	}
+}
+
+// FormatSeconds formats the duration in seconds with the decimals of the precision, i.e. 1.234s at millisecond precision
+func FormatSeconds(d time.Duration, precision time.Duration) string {
+	decimals := 0
+	for p := precision; p < time.Second && decimals < 9; p *= 10 {
+		decimals++
+	}
+	return strconv.FormatFloat(d.Round(precision).Seconds(), 'f', decimals, 64) + "s"
 }
 
 // filterColumns will filter out specified columns via case insensitive string matching
@@ -362,11 +882,10 @@ func (m *Measurement) RegisterMetrics(register prometheus.Registerer, experiment
 
 	metricCollectors := map[string]*prometheus.GaugeVec{}
 	for _, timing := range lo.UniqBy(m.Timings, func(t *sources.Timing) string { return t.Event.Metric }) {
-		collector := prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Name: timing.Event.Metric,
-		}, labels)
-		if err := register.Register(collector); err != nil {
+		collector, err := registerGauge(register, timing.Event.Metric, lo.Uniq(append(append([]string{}, labels...), timing.Event.Labels...)))
+		if err != nil {
 			log.Printf("error registering metric %s: %v", timing.Event.Metric, err)
+			continue
 		}
 		metricCollectors[timing.Event.Metric] = collector
 	}
@@ -376,28 +895,167 @@ func (m *Measurement) RegisterMetrics(register prometheus.Registerer, experiment
 			log.Printf("error emitting metric for %s", timing.Event.Metric)
 			continue
 		}
-		collector.With(dimensions).Set(timing.T.Seconds())
+		collector.With(timingDimensions(dimensions, timing)).Set(timing.T.Seconds())
+	}
+	if m.checksOrdering() {
+		collector, err := registerGauge(register, OrderingAnomaliesMetric, labels)
+		if err != nil {
+			log.Printf("error registering metric %s: %v", OrderingAnomaliesMetric, err)
+			return
+		}
+		collector.With(dimensions).Set(float64(len(m.OrderingAnomalies)))
+	}
+}
+
+// registerGauge registers a gauge with the labels, or reuses the existing gauge when metrics are re-registered on the
+// same registry, i.e. periodic measurements
+func registerGauge(register prometheus.Registerer, name string, labels []string) (*prometheus.GaugeVec, error) {
+	collector := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name}, labels)
+	if err := register.Register(collector); err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if !errors.As(err, &alreadyRegistered) {
+			return nil, err
+		}
+		existing, ok := alreadyRegistered.ExistingCollector.(*prometheus.GaugeVec)
+		if !ok {
+			return nil, fmt.Errorf("an incompatible collector is already registered")
+		}
+		// drop stale label values from the previous measurement
+		existing.Reset()
+		collector = existing
+	}
+	return collector, nil
+}
+
+// WrapRegisterer prefixes the names of the metrics registered through it with the namespace, i.e. nlk_node_ready, and adds
+// the const labels to them, i.e. the cluster name, so the metrics of fleets scraped into one Prometheus do not collide
+func WrapRegisterer(register prometheus.Registerer, namespace string, constLabels map[string]string) prometheus.Registerer {
+	if len(constLabels) > 0 {
+		register = prometheus.WrapRegistererWith(constLabels, register)
+	}
+	if namespace != "" {
+		register = prometheus.WrapRegistererWithPrefix(metricName(namespace, ""), register)
+	}
+	return register
+}
+
+// metricName prefixes the metric with the namespace, the namespace may end with the separating underscore or not
+func metricName(namespace string, metric string) string {
+	if namespace == "" {
+		return metric
+	}
+	return strings.TrimSuffix(namespace, "_") + "_" + metric
+}
+
+// ParseConstLabels parses comma separated const labels of the prometheus metrics, i.e. cluster=prod,env=staging
+func ParseConstLabels(labelsStr string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, label := range strings.Split(labelsStr, ",") {
+		if label == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(label, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid const label \"%s\", expected <name>=<value>", label)
+		}
+		key = strings.TrimSpace(key)
+		if !model.LabelName(key).IsValid() {
+			return nil, fmt.Errorf("invalid const label name \"%s\"", key)
+		}
+		labels[key] = value
 	}
+	return labels, nil
+}
+
+// CloudWatchNamespace is the default namespace of metrics emitted to CloudWatch
+const CloudWatchNamespace = "KubernetesNodeLatency"
+
+// CloudWatchOptions configure the namespace, dimensions, and resolution of the metrics emitted to CloudWatch
+type CloudWatchOptions struct {
+	// Namespace of the metrics, CloudWatchNamespace is used if it is empty
+	Namespace string
+	// Dimensions are added to every metric, i.e. cluster=prod-1
+	Dimensions map[string]string
+	// ExcludeDimensions are dropped from every metric, i.e. the high cardinality amiID
+	ExcludeDimensions []string
+	// HighResolution stores the metrics at 1 second resolution instead of 1 minute
+	HighResolution bool
+}
+
+// namespace returns the namespace of the metrics
+func (o CloudWatchOptions) namespace() string {
+	return lo.Ternary(o.Namespace == "", CloudWatchNamespace, o.Namespace)
+}
+
+// dimensions adds the extra dimensions to the metric dimensions and drops the excluded ones
+func (o CloudWatchOptions) dimensions(dimensions map[string]string) map[string]string {
+	return lo.OmitByKeys(lo.Assign(o.Dimensions, dimensions), o.ExcludeDimensions)
+}
+
+// storageResolution is the storage resolution in seconds of the metrics, nil is CloudWatch's default of 60 seconds
+func (o CloudWatchOptions) storageResolution() *int32 {
+	if o.HighResolution {
+		return aws.Int32(1)
+	}
+	return nil
+}
+
+// ParseDimensions parses comma separated CloudWatch dimensions, i.e. cluster=prod-1,env=prod
+func ParseDimensions(dimensionsStr string) (map[string]string, error) {
+	dimensions := map[string]string{}
+	for _, dimension := range strings.Split(dimensionsStr, ",") {
+		if dimension == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(dimension, "=")
+		if !ok || strings.TrimSpace(key) == "" || value == "" {
+			return nil, fmt.Errorf("invalid dimension \"%s\", expected <name>=<value>", dimension)
+		}
+		dimensions[strings.TrimSpace(key)] = value
+	}
+	return dimensions, nil
+}
+
+// cloudWatchDimensions converts the dimensions to CloudWatch dimensions
+func cloudWatchDimensions(dimensions map[string]string) []types.Dimension {
+	return lo.MapToSlice(dimensions, func(k, v string) types.Dimension {
+		return types.Dimension{
+			Name:  aws.String(k),
+			Value: aws.String(v),
+		}
+	})
 }
 
 // EmitCloudWatchMetrics posts metric data to CloudWatch based on a Measurement
-func (m *Measurement) EmitCloudWatchMetrics(ctx context.Context, cw *cloudwatch.Client, experimentDimension string) error {
+func (m *Measurement) EmitCloudWatchMetrics(ctx context.Context, cw *cloudwatch.Client, experimentDimension string, opts CloudWatchOptions) error {
 	var errs error
 	dimensions := m.metricDimensions(experimentDimension)
 	for _, timing := range m.Timings {
 		if _, err := cw.PutMetricData(ctx, &cloudwatch.PutMetricDataInput{
-			Namespace: aws.String("KubernetesNodeLatency"),
+			Namespace: aws.String(opts.namespace()),
 			MetricData: []types.MetricDatum{
 				{
-					MetricName: aws.String(timing.Event.Metric),
-					Value:      aws.Float64(timing.T.Seconds()),
-					Unit:       types.StandardUnitSeconds,
-					Dimensions: lo.MapToSlice(dimensions, func(k, v string) types.Dimension {
-						return types.Dimension{
-							Name:  aws.String(k),
-							Value: aws.String(v),
-						}
-					}),
+					MetricName:        aws.String(timing.Event.Metric),
+					Value:             aws.Float64(timing.T.Seconds()),
+					Unit:              types.StandardUnitSeconds,
+					StorageResolution: opts.storageResolution(),
+					Dimensions:        cloudWatchDimensions(opts.dimensions(timingDimensions(dimensions, timing))),
+				},
+			},
+		}); err != nil {
+			errs = multierr.Append(errs, err)
+		}
+	}
+	if m.checksOrdering() {
+		if _, err := cw.PutMetricData(ctx, &cloudwatch.PutMetricDataInput{
+			Namespace: aws.String(opts.namespace()),
+			MetricData: []types.MetricDatum{
+				{
+					MetricName:        aws.String(OrderingAnomaliesMetric),
+					Value:             aws.Float64(float64(len(m.OrderingAnomalies))),
+					Unit:              types.StandardUnitCount,
+					StorageResolution: opts.storageResolution(),
+					Dimensions:        cloudWatchDimensions(opts.dimensions(dimensions)),
 				},
 			},
 		}); err != nil {
@@ -419,18 +1077,64 @@ func (m *Measurement) metricDimensions(experimentDimension string) map[string]st
 			"region":           m.Metadata.Region,
 			"availabilityZone": m.Metadata.AvailabilityZone,
 		})
+		// warm starts are published as separate metrics so they do not skew the statistics of cold boots
+		if m.Metadata.WarmStart != "" {
+			dimensions["warmStart"] = m.Metadata.WarmStart
+		}
+		dimensions = lo.Assign(m.Metadata.NodeLabels, lo.PickByKeys(m.Metadata.versions(), m.Metadata.VersionDimensions), dimensions)
 	}
 	return dimensions
 }
 
+// timingDimensions adds the labels of the timing's event to the metric dimensions, labels that were not found are empty
+// unless they are static labels of the event
+func timingDimensions(dimensions map[string]string, timing *sources.Timing) map[string]string {
+	labels := map[string]string{}
+	for _, label := range timing.Event.Labels {
+		value, ok := timing.Labels[label]
+		if !ok {
+			value = timing.Event.StaticLabels[label]
+		}
+		labels[label] = value
+	}
+	return lo.Assign(dimensions, labels)
+}
+
 // RegisterDefaultSources registers the default sources to the Measurer
 func (m *Measurer) RegisterDefaultSources() *Measurer {
 	m.RegisterSources([]sources.Source{
-		messages.New(messages.DefaultPath),
-		awsnode.New(awsnode.DefaultPath),
+		messages.New(m.hostPath(messages.DefaultPath), m.hostPath(messages.SyslogPath)),
+		awsnode.New(m.hostPath(awsnode.DefaultPath)),
 	}...)
+	// cloud-init's own log is preferred over its syslog lines for stage and per-module timings
+	if _, err := os.Stat(m.hostPath(cloudinit.DefaultPath)); err == nil {
+		m.RegisterSources(cloudinit.New(m.hostPath(cloudinit.DefaultPath), m.hostPath(cloudinit.DefaultStatusPath)))
+	}
+	// journald-only hosts do not write /var/log/messages or /var/log/syslog, so fallback to reading the journal directly,
+	// or the journal's recorded output when replaying
+	logs, _ := filepath.Glob(m.hostPath(messages.DefaultPath))
+	syslogs, _ := filepath.Glob(m.hostPath(messages.SyslogPath))
+	if len(logs)+len(syslogs) == 0 {
+		if m.root != "" {
+			m.RegisterSources(logfile.New(journal.Name, m.hostPath(recordJournalName), journal.TimestampFormat, journal.TimestampLayout))
+		} else if journal.Available() {
+			m.RegisterSources(journal.New())
+		}
+	}
 	if m.imdsClient != nil {
-		m.RegisterSources(imdssrc.New(m.imdsClient))
+		m.RegisterSources(imdssrc.New(m.imdsClient).WithCache(m.imdsCache))
+	}
+	if m.gceSource != nil {
+		m.RegisterSources(m.gceSource)
+	}
+	if m.azureSource != nil {
+		m.RegisterSources(m.azureSource)
+	}
+	if m.dnsProbe != nil {
+		m.RegisterSources(m.dnsProbe)
+	}
+	if m.talosSource != nil {
+		m.RegisterSources(m.talosSource)
 	}
 	if m.ec2Client != nil {
 		instanceID := ""
@@ -444,6 +1148,15 @@ func (m *Measurer) RegisterDefaultSources() *Measurer {
 		}
 		m.RegisterSources(ec2src.New(m.ec2Client, instanceID, m.nodeName))
 	}
+	// the instance-id is required to find the scaling activity that launched the instance
+	if m.asgClient != nil && m.imdsClient != nil {
+		md, err := m.getMetadata(context.TODO())
+		if err != nil {
+			log.Printf("unable to retrieve instance-id to register the asg event source: %s", err)
+		} else {
+			m.RegisterSources(asgsrc.New(m.asgClient, md.InstanceID))
+		}
+	}
 	if m.k8sClientset != nil && m.podNamespace != "" {
 		if m.nodeName == "" && m.imdsClient != nil {
 			out, err := m.imdsClient.GetMetadata(context.TODO(), &imds.GetMetadataInput{Path: "/hostname"})
@@ -460,137 +1173,359 @@ func (m *Measurer) RegisterDefaultSources() *Measurer {
 			m.RegisterSources(k8ssrc.New(m.k8sClientset, m.nodeName, m.podNamespace))
 		}
 	}
+	for _, profile := range m.profiles {
+		if profile.Sources != nil {
+			m.RegisterSources(profile.Sources(m)...)
+		}
+	}
 	return m
 }
 
-// RegisterDefaultEvents registers all default events shipped
-func (m *Measurer) RegisterDefaultEvents() (*Measurer, error) {
-	return m.RegisterEvents([]*sources.Event{
+// syslogSource returns the source used for the default system log events
+// The journal source is only registered by default when /var/log/messages is unavailable, so it is preferred if present.
+func (m *Measurer) syslogSource() sources.RegexFinder {
+	if src, ok := m.GetSource(journal.Name); ok {
+		if finder, ok := src.(sources.RegexFinder); ok {
+			return finder
+		}
+	}
+	return lo.Must(m.GetSource(messages.Name)).(sources.RegexFinder)
+}
+
+// defaultAPIEvents returns the default events of the API sources that are registered
+// API sources depend on clients that may not be configured (i.e. no IMDS off of EC2), so their events are only returned when the source is registered.
+func (m *Measurer) defaultAPIEvents() []*sources.Event {
+	var events []*sources.Event
+	if src, ok := m.GetSource(k8ssrc.Name); ok {
+		if k8sSrc, ok := src.(*k8ssrc.Source); ok {
+			events = append(events, &sources.Event{
+				Name:          "Pod Created",
+				Metric:        "pod_created",
+				SrcName:       k8ssrc.Name,
+				MatchSelector: sources.EventMatchSelectorFirst,
+				FindFn:        k8sSrc.FindPodCreationTime(),
+			})
+		}
+	}
+	if src, ok := m.GetSource(ec2src.Name); ok {
+		if ec2Src, ok := src.(*ec2src.Source); ok {
+			events = append(events, &sources.Event{
+				Name:          "Fleet Requested",
+				Metric:        "fleet_requested",
+				SrcName:       ec2src.Name,
+				MatchSelector: sources.EventMatchSelectorFirst,
+				FindFn:        ec2Src.FindFleetStart(),
+			})
+		}
+	}
+	if src, ok := m.GetSource(asgsrc.Name); ok {
+		if asgSrc, ok := src.(*asgsrc.Source); ok {
+			events = append(events, []*sources.Event{
+				{
+					Name:          "ASG Scale-Out Decision",
+					Metric:        "asg_scale_out_decision",
+					SrcName:       asgsrc.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        asgSrc.FindScaleOutDecision(),
+					CommentFn:     asgSrc.CommentDetail(),
+				},
+				{
+					Name:          "ASG Launch Started",
+					Metric:        "asg_launch_started",
+					SrcName:       asgsrc.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        asgSrc.FindLaunchStarted(),
+				},
+				{
+					Name:          "ASG Launch Completed",
+					Metric:        "asg_launch_completed",
+					SrcName:       asgsrc.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        asgSrc.FindLaunchCompleted(),
+				},
+				{
+					Name:          "Warm Pool Exit",
+					Metric:        WarmPoolExitMetric,
+					SrcName:       asgsrc.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        asgSrc.FindWarmPoolExit(),
+					CommentFn:     asgSrc.CommentDetail(),
+				},
+			}...)
+		}
+	}
+	if src, ok := m.GetSource(imdssrc.Name); ok {
+		if imdsSrc, ok := src.(*imdssrc.Source); ok {
+			events = append(events, &sources.Event{
+				Name:          "Instance Pending",
+				Metric:        "instance_pending",
+				SrcName:       imdssrc.Name,
+				MatchSelector: sources.EventMatchSelectorFirst,
+				FindFn:        imdsSrc.FindByPath(imdssrc.PendingTime),
+			}, &sources.Event{
+				Name:          "Credentials Available",
+				Metric:        "credentials_available",
+				After:         []string{"instance_pending"},
+				SrcName:       imdssrc.Name,
+				MatchSelector: sources.EventMatchSelectorFirst,
+				FindFn:        imdsSrc.FindByPath(imdssrc.CredentialsLastUpdated),
+			})
+			if m.spotEvents {
+				events = append(events, &sources.Event{
+					Name:          "Rebalance Recommendation",
+					Metric:        "rebalance_recommendation",
+					SrcName:       imdssrc.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        imdsSrc.FindByPath(imdssrc.RebalanceRecommendation),
+				}, &sources.Event{
+					Name:          "Spot Interruption",
+					Metric:        "spot_interruption",
+					SrcName:       imdssrc.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					CommentFn:     imdsSrc.CommentAction(),
+					FindFn:        imdsSrc.FindByPath(imdssrc.SpotInstanceAction),
+				})
+			}
+		}
+	}
+	if src, ok := m.GetSource(dnsprobe.Name); ok {
+		if dnsProbe, ok := src.(*dnsprobe.Source); ok {
+			events = append(events, &sources.Event{
+				Name:          "DNS Reachable",
+				Metric:        "dns_reachable",
+				SrcName:       dnsprobe.Name,
+				MatchSelector: sources.EventMatchSelectorFirst,
+				CommentFn:     dnsProbe.CommentResolution(),
+				FindFn:        dnsProbe.FindFirstResolution(),
+			})
+		}
+	}
+	if src, ok := m.GetSource(gcesrc.Name); ok {
+		if gceSrc, ok := src.(*gcesrc.Source); ok {
+			events = append(events, &sources.Event{
+				Name:          "Instance Requested",
+				Metric:        "instance_requested",
+				SrcName:       gcesrc.Name,
+				MatchSelector: sources.EventMatchSelectorFirst,
+				FindFn:        gceSrc.FindByPath(gcesrc.CreationTimestamp),
+			})
+		}
+	}
+	if src, ok := m.GetSource(azuresrc.Name); ok {
+		if azureSrc, ok := src.(*azuresrc.Source); ok {
+			events = append(events, &sources.Event{
+				Name:          "Instance Requested",
+				Metric:        "instance_requested",
+				SrcName:       azuresrc.Name,
+				MatchSelector: sources.EventMatchSelectorFirst,
+				FindFn:        azureSrc.FindByPath(azuresrc.TimeCreated),
+			}, &sources.Event{
+				Name:          "Instance Provisioned",
+				Metric:        "instance_provisioned",
+				SrcName:       azuresrc.Name,
+				MatchSelector: sources.EventMatchSelectorFirst,
+				FindFn:        azureSrc.FindByPath(azuresrc.ProvisioningSucceeded),
+			})
+		}
+	}
+	return events
+}
+
+// cloudInitEvents returns the cloud-init stage events from status.json along with user-data and per-module timings when the cloud-init source is registered
+// The stage events replace the default cloud-init events matched in the system log.
+func (m *Measurer) cloudInitEvents() []*sources.Event {
+	src, ok := m.GetSource(cloudinit.Name)
+	if !ok {
+		return nil
+	}
+	cloudInit := src.(*cloudinit.Source)
+	return []*sources.Event{
+		{
+			Name:          "Cloud-Init Initial Start",
+			Metric:        "cloudinit_initial_start",
+			SrcName:       cloudinit.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        cloudInit.FindByStage(cloudinit.StageInit, cloudinit.BoundaryStart),
+		},
 		{
-			Name:          "Pod Created",
-			Metric:        "pod_created",
-			SrcName:       k8ssrc.Name,
+			Name:          "Cloud-Init Config Start",
+			Metric:        "cloudinit_config_start",
+			After:         []string{"cloudinit_initial_start"},
+			SrcName:       cloudinit.Name,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(k8ssrc.Name)).(*k8ssrc.Source).FindPodCreationTime(),
+			FindFn:        cloudInit.FindByStage(cloudinit.StageModulesConfig, cloudinit.BoundaryStart),
 		},
 		{
-			Name:          "Fleet Requested",
-			Metric:        "fleet_requested",
-			SrcName:       ec2src.Name,
+			Name:          "Cloud-Init Final Start",
+			Metric:        "cloudinit_final_start",
+			After:         []string{"cloudinit_config_start"},
+			SrcName:       cloudinit.Name,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(ec2src.Name)).(*ec2src.Source).FindFleetStart(),
+			FindFn:        cloudInit.FindByStage(cloudinit.StageModulesFinal, cloudinit.BoundaryStart),
 		},
 		{
-			Name:          "Instance Pending",
-			Metric:        "instance_pending",
-			SrcName:       imdssrc.Name,
+			Name:          "User-Data Start",
+			Metric:        "cloudinit_user_data_start",
+			SrcName:       cloudinit.Name,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(imdssrc.Name)).(*imdssrc.Source).FindByPath(imdssrc.PendingTime),
+			FindFn:        cloudInit.FindByModule("config-scripts-user", cloudinit.BoundaryStart),
 		},
+		{
+			Name:          "User-Data Finish",
+			Metric:        "cloudinit_user_data_finish",
+			After:         []string{"cloudinit_user_data_start"},
+			SrcName:       cloudinit.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        cloudInit.FindByModule("config-scripts-user", cloudinit.BoundaryFinished),
+			CommentFn:     cloudInit.CommentModuleDuration(),
+		},
+		{
+			Name:          "Cloud-Init Module Finish",
+			Metric:        "cloudinit_module_finish",
+			SrcName:       cloudinit.Name,
+			MatchSelector: sources.EventMatchSelectorAll,
+			FindFn:        cloudInit.FindModuleFinishes(),
+			CommentFn:     cloudInit.CommentModuleDuration(),
+		},
+		{
+			Name:          "Cloud-Init Final Finish",
+			Metric:        "cloudinit_final_finish",
+			After:         []string{"cloudinit_final_start"},
+			SrcName:       cloudinit.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        cloudInit.FindByStage(cloudinit.StageModulesFinal, cloudinit.BoundaryFinished),
+		},
+	}
+}
+
+// RegisterDefaultEvents registers all default events shipped
+func (m *Measurer) RegisterDefaultEvents() (*Measurer, error) {
+	syslog := m.syslogSource()
+	params := m.defaultEventParams()
+	podReady := params.podReadyRegex()
+	events := append(m.defaultAPIEvents(), []*sources.Event{
+		m.clockStepEvent(syslog),
+		m.hibernationResumedEvent(syslog),
 		{
 			Name:          "VM Initialized",
 			Metric:        "vm_initialized",
-			SrcName:       messages.Name,
+			SrcName:       syslog.Name(),
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(vmInit),
+			FindFn:        syslog.FindByRegex(vmInit),
 		},
 		{
 			Name:          "Network Start",
 			Metric:        "network_start",
-			SrcName:       messages.Name,
+			SrcName:       syslog.Name(),
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(networkStart),
+			FindFn:        syslog.FindByRegex(networkStart),
 		},
 		{
 			Name:          "Network Ready",
 			Metric:        "network_ready",
-			SrcName:       messages.Name,
+			After:         []string{"network_start"},
+			SrcName:       syslog.Name(),
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(networkReady),
+			FindFn:        syslog.FindByRegex(networkReady),
 		},
 		{
 			Name:          "Cloud-Init Initial Start",
 			Metric:        "cloudinit_initial_start",
-			SrcName:       messages.Name,
+			SrcName:       syslog.Name(),
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(cloudInitInitialStart),
+			FindFn:        syslog.FindByRegex(cloudInitInitialStart),
 		},
 		{
 			Name:          "Cloud-Init Config Start",
 			Metric:        "cloudinit_config_start",
-			SrcName:       messages.Name,
+			After:         []string{"cloudinit_initial_start"},
+			SrcName:       syslog.Name(),
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(cloudInitConfigStart),
+			FindFn:        syslog.FindByRegex(cloudInitConfigStart),
 		},
 		{
 			Name:          "Cloud-Init Final Start",
 			Metric:        "cloudinit_final_start",
-			SrcName:       messages.Name,
+			After:         []string{"cloudinit_config_start"},
+			SrcName:       syslog.Name(),
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(cloudInitFinalStart),
+			FindFn:        syslog.FindByRegex(cloudInitFinalStart),
 		},
 		{
 			Name:          "Cloud-Init Final Finish",
 			Metric:        "cloudinit_final_finish",
-			SrcName:       messages.Name,
+			After:         []string{"cloudinit_final_start"},
+			SrcName:       syslog.Name(),
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(cloudInitFinalFinish),
+			FindFn:        syslog.FindByRegex(cloudInitFinalFinish),
 		},
 		{
 			Name:          "Containerd Start",
 			Metric:        "conatinerd_start",
-			SrcName:       messages.Name,
+			SrcName:       syslog.Name(),
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(containerdStart),
+			FindFn:        syslog.FindByRegex(containerdStart),
 		},
 		{
 			Name:          "Containerd Initialized",
 			Metric:        "conatinerd_initialized",
-			SrcName:       messages.Name,
+			After:         []string{"conatinerd_start"},
+			SrcName:       syslog.Name(),
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(containerdInitialized),
+			FindFn:        syslog.FindByRegex(containerdInitialized),
 		},
 		{
 			Name:          "Kubelet Start",
 			Metric:        "kubelet_start",
-			SrcName:       messages.Name,
+			SrcName:       syslog.Name(),
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(kubeletStart),
+			FindFn:        syslog.FindByRegex(kubeletStart),
 		},
 		{
 			Name:          "Kubelet Initialized",
 			Metric:        "kubelet_initialized",
-			SrcName:       messages.Name,
+			After:         []string{"kubelet_start"},
+			SrcName:       syslog.Name(),
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        syslog.FindByRegex(kubeletInitialized),
+		},
+		{
+			Name:          "Kubelet Authenticated",
+			Metric:        "kubelet_authenticated",
+			After:         []string{"kubelet_start"},
+			SrcName:       syslog.Name(),
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(kubeletInitialized),
+			CommentFn:     sources.CommentCaptureGroups(kubeletAuthenticated),
+			FindFn:        syslog.FindByRegex(kubeletAuthenticated),
 		},
 		{
 			Name:          "Kubelet Registered",
 			Metric:        "kubelet_registered",
-			SrcName:       messages.Name,
+			After:         []string{"kubelet_start"},
+			SrcName:       syslog.Name(),
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(kubeletRegistered),
+			FindFn:        syslog.FindByRegex(kubeletRegistered),
 		},
 		{
 			Name:          "Kube-Proxy Start",
 			Metric:        "kube_proxy_start",
-			SrcName:       messages.Name,
+			SrcName:       syslog.Name(),
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(kubeProxyStart),
+			FindFn:        syslog.FindByRegex(containerStartRegex(params.ProxyContainer)),
 		},
 		{
 			Name:          "VPC CNI Init Start",
 			Metric:        "vpc_cni_init_start",
-			SrcName:       messages.Name,
+			SrcName:       syslog.Name(),
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(vpcCNIInitStart),
+			FindFn:        syslog.FindByRegex(containerStartRegex(params.CNIInitContainer)),
 		},
 		{
 			Name:          "AWS Node Start",
 			Metric:        "aws_node_start",
-			SrcName:       messages.Name,
+			SrcName:       syslog.Name(),
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(awsNodeStart),
+			FindFn:        syslog.FindByRegex(containerStartRegex(params.CNIContainer)),
 		},
 		{
 			Name:          "VPC CNI Plugin Initialized",
@@ -602,26 +1537,30 @@ func (m *Measurer) RegisterDefaultEvents() (*Measurer, error) {
 		{
 			Name:          "Kube-APIServer Throttled",
 			Metric:        "kube_apiserver_throttled",
-			SrcName:       messages.Name,
+			SrcName:       syslog.Name(),
 			MatchSelector: sources.EventMatchSelectorAll,
-			CommentFn:     sources.CommentMatchedLine(),
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(throttled),
+			CommentFn:     sources.CommentCaptureGroups(throttled),
+			FindFn:        syslog.FindByRegex(throttled),
 		},
 		{
 			Name:          "Node Ready",
 			Metric:        "node_ready",
-			SrcName:       messages.Name,
+			After:         []string{"kubelet_registered"},
+			SrcName:       syslog.Name(),
 			Terminal:      true,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(nodeReady),
+			FindFn:        syslog.FindByRegex(nodeReady),
 		},
 		{
 			Name:          "Pod Ready",
 			Metric:        "pod_ready",
-			SrcName:       messages.Name,
+			After:         []string{"node_ready"},
+			SrcName:       syslog.Name(),
 			Terminal:      true,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(regexp.MustCompile(fmt.Sprintf(podReadyStr, m.podNamespace))),
+			CommentFn:     sources.CommentCaptureGroups(podReady),
+			FindFn:        syslog.FindByRegex(podReady),
 		},
 	}...)
+	return m.RegisterEvents(m.applyProfileEvents(replaceEventsByMetric(events, m.cloudInitEvents()))...)
 }
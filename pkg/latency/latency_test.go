@@ -0,0 +1,52 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+)
+
+func TestSpotEvents(t *testing.T) {
+	spotMetrics := []string{"rebalance_recommendation", "spot_interruption"}
+	for _, tc := range []struct {
+		name       string
+		spotEvents bool
+	}{
+		{name: "default"},
+		{name: "enabled", spotEvents: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := New().WithIMDS(imds.New(imds.Options{})).WithSpotEvents(tc.spotEvents).RegisterDefaultSources()
+			m, err := m.RegisterDefaultEvents()
+			if err != nil {
+				t.Fatalf("unable to register default events: %v", err)
+			}
+			registered := map[string]bool{}
+			for _, event := range m.events {
+				registered[event.Metric] = true
+			}
+			if !registered["instance_pending"] {
+				t.Errorf("expected the IMDS events to be registered")
+			}
+			for _, metric := range spotMetrics {
+				if registered[metric] != tc.spotEvents {
+					t.Errorf("expected %s registered to be %t", metric, tc.spotEvents)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package latencytest provides fixtures for unit testing event definitions, a fake clock, fake sources, and golden
+// measurement comparisons, so custom events can be tested against captured log samples without a node.
+package latencytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/logfile"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/messages"
+)
+
+// UpdateGoldenEnv is the environment variable which rewrites the golden files with the measured JSON when it is "true"
+const UpdateGoldenEnv = "NODE_LATENCY_UPDATE_GOLDEN"
+
+// Clock is a fake clock which only moves when it is set or stepped
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock creates a fake clock at the time
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now returns the fake clock's time
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Step moves the fake clock forward by the duration
+func (c *Clock) Step(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set moves the fake clock to the time
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// NewLogSource writes the log lines to a file in the test's temp directory and returns a log file source reading it
+// The file is modified at the clock's time, so timestamps without a year are parsed in the clock's year.
+func NewLogSource(t testing.TB, clock *Clock, name string, timestampRegex *regexp.Regexp, timestampLayout string, lines ...string) *logfile.Source {
+	t.Helper()
+	return logfile.New(name, writeLog(t, clock, name, lines), timestampRegex, timestampLayout)
+}
+
+// NewMessagesSource writes the syslog lines to a file in the test's temp directory and returns a messages source reading it,
+// which the default events search
+func NewMessagesSource(t testing.TB, clock *Clock, lines ...string) *messages.Source {
+	t.Helper()
+	return messages.New(writeLog(t, clock, messages.Name, lines))
+}
+
+// writeLog writes the lines to a log file modified at the clock's time
+func writeLog(t testing.TB, clock *Clock, name string, lines []string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), strings.ReplaceAll(strings.ToLower(name), " ", "-")+".log")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatalf("unable to write log %s: %v", path, err)
+	}
+	if err := os.Chtimes(path, clock.Now(), clock.Now()); err != nil {
+		t.Fatalf("unable to set the modification time of log %s: %v", path, err)
+	}
+	return path
+}
+
+// Source is a fake source which finds scripted results by event metric, i.e. in place of an API source
+type Source struct {
+	name    string
+	results map[string][]sources.FindResult
+	errs    map[string]error
+}
+
+// NewSource creates a fake source with the name events refer to
+func NewSource(name string) *Source {
+	return &Source{
+		name:    name,
+		results: map[string][]sources.FindResult{},
+		errs:    map[string]error{},
+	}
+}
+
+// WithResult is a builder func that adds a result of the event metric, which is commented by the event's CommentFn when found
+func (s *Source) WithResult(metric string, ts time.Time, line string) *Source {
+	s.results[metric] = append(s.results[metric], sources.FindResult{Line: line, Timestamp: ts})
+	return s
+}
+
+// WithError is a builder func that fails finding the event metric with the error
+func (s *Source) WithError(metric string, err error) *Source {
+	s.errs[metric] = err
+	return s
+}
+
+// Find returns the results of the event's metric in chronological order, filtered by the event's match selector
+func (s *Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err, ok := s.errs[event.Metric]; ok {
+		return nil, err
+	}
+	if len(s.results[event.Metric]) == 0 {
+		return nil, fmt.Errorf("no results in %s for metric \"%s\"", s.name, event.Metric)
+	}
+	results := append([]sources.FindResult{}, s.results[event.Metric]...)
+	for i := range results {
+		if event.CommentFn != nil {
+			results[i].Comment = event.CommentFn(results[i].Line)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Timestamp.UnixMicro() < results[j].Timestamp.UnixMicro()
+	})
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
+
+// Name is the name of the fake source
+func (s *Source) Name() string {
+	return s.name
+}
+
+// ClearCache is a noop since the results are scripted
+func (s *Source) ClearCache() {}
+
+// String is a human readable string of the fake source
+func (s *Source) String() string {
+	return fmt.Sprintf("fake source %s", s.name)
+}
+
+// NewMeasurer creates a Measurer with the fake clock and the sources registered
+func NewMeasurer(clock *Clock, srcs ...sources.Source) *latency.Measurer {
+	return latency.New().WithClock(clock.Now).RegisterSources(srcs...)
+}
+
+// Measure registers the events and executes a single timing run, the test fails if an event's source is not registered
+func Measure(t testing.TB, m *latency.Measurer, events ...*sources.Event) *latency.Measurement {
+	t.Helper()
+	if _, err := m.RegisterEvents(events...); err != nil {
+		t.Fatalf("unable to register events: %v", err)
+	}
+	return m.Measure(context.Background())
+}
+
+// AssertGolden compares the measurement's JSON document with the golden file, the test fails on the first differing line
+// The golden file is written instead when UpdateGoldenEnv is "true", i.e. after an intended change to the events.
+func AssertGolden(t testing.TB, golden string, measurement *latency.Measurement) {
+	t.Helper()
+	actual, err := measurement.JSON()
+	if err != nil {
+		t.Fatalf("unable to marshal measurement: %v", err)
+	}
+	actual = append(actual, '\n')
+	if os.Getenv(UpdateGoldenEnv) == "true" {
+		if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+			t.Fatalf("unable to create directory for golden file %s: %v", golden, err)
+		}
+		if err := os.WriteFile(golden, actual, 0o600); err != nil {
+			t.Fatalf("unable to write golden file %s: %v", golden, err)
+		}
+		return
+	}
+	expected, err := os.ReadFile(golden)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("golden file %s does not exist, set %s=true to write it", golden, UpdateGoldenEnv)
+	}
+	if err != nil {
+		t.Fatalf("unable to read golden file %s: %v", golden, err)
+	}
+	if diff := firstDiff(string(expected), string(actual)); diff != "" {
+		t.Errorf("measurement does not match golden file %s, set %s=true to update it\n%s", golden, UpdateGoldenEnv, diff)
+	}
+}
+
+// firstDiff describes the first line which differs between the expected and actual documents, or is empty if they are equal
+func firstDiff(expected string, actual string) string {
+	expectedLines := strings.Split(expected, "\n")
+	actualLines := strings.Split(actual, "\n")
+	for i := 0; i < len(expectedLines) || i < len(actualLines); i++ {
+		var want, got string
+		if i < len(expectedLines) {
+			want = expectedLines[i]
+		}
+		if i < len(actualLines) {
+			got = actualLines[i]
+		}
+		if want != got {
+			return fmt.Sprintf("line %d:\n  want: %s\n  got:  %s", i+1, want, got)
+		}
+	}
+	return ""
+}
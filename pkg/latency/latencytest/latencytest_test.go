@@ -0,0 +1,90 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latencytest_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency/latencytest"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/awsnode"
+)
+
+// TestDefaultEvents measures the default events against the logs of an EKS node boot, set NODE_LATENCY_UPDATE_GOLDEN=true
+// to update the golden file after an intended change to the default events
+func TestDefaultEvents(t *testing.T) {
+	clock := latencytest.NewClock(time.Date(2024, 1, 15, 10, 1, 0, 0, time.UTC))
+	messages, err := os.ReadFile("testdata/messages.log")
+	if err != nil {
+		t.Fatalf("unable to read messages fixture: %v", err)
+	}
+	m := latencytest.NewMeasurer(clock,
+		latencytest.NewMessagesSource(t, clock, strings.Split(strings.TrimSpace(string(messages)), "\n")...),
+		awsnode.New("testdata/aws-node.log"),
+	)
+	if _, err := m.RegisterDefaultEvents(); err != nil {
+		t.Fatalf("unable to register default events: %v", err)
+	}
+	latencytest.AssertGolden(t, "testdata/default-events.golden.json", latencytest.Measure(t, m))
+}
+
+// TestZeroPoint measures the T of the kubelet's start relative to the zero events, the warm start, or the first timing
+func TestZeroPoint(t *testing.T) {
+	pending := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name       string
+		zeroEvents []string
+		warm       bool
+		expected   time.Duration
+	}{
+		{name: "first timing", expected: 11 * time.Minute},
+		{name: "warm start", warm: true, expected: time.Minute},
+		{name: "zero event", zeroEvents: []string{"instance_pending"}, expected: 11 * time.Minute},
+		{name: "zero event wins over the warm start", zeroEvents: []string{"instance_pending"}, warm: true, expected: 11 * time.Minute},
+		{name: "zero event by name", zeroEvents: []string{"Warm Pool Exit"}, warm: true, expected: time.Minute},
+		{name: "untimed zero event falls back to the warm start", zeroEvents: []string{"instance_requested"}, warm: true, expected: time.Minute},
+		{name: "untimed zero event falls back to the first timing", zeroEvents: []string{"instance_requested"}, expected: 11 * time.Minute},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			clock := latencytest.NewClock(pending.Add(time.Hour))
+			src := latencytest.NewSource("fake").
+				WithResult("instance_pending", pending, "pending").
+				WithResult("kubelet_start", pending.Add(11*time.Minute), "kubelet")
+			events := []*sources.Event{
+				{Name: "Instance Pending", Metric: "instance_pending", SrcName: "fake", MatchSelector: sources.EventMatchSelectorFirst},
+				{Name: "Kubelet Start", Metric: "kubelet_start", SrcName: "fake", MatchSelector: sources.EventMatchSelectorFirst},
+			}
+			if tc.warm {
+				src = src.WithResult(latency.WarmPoolExitMetric, pending.Add(10*time.Minute), "warm pool exit")
+				events = append(events, &sources.Event{Name: "Warm Pool Exit", Metric: latency.WarmPoolExitMetric, SrcName: "fake", MatchSelector: sources.EventMatchSelectorFirst})
+			}
+			m := latencytest.NewMeasurer(clock, src).WithZeroEvents(tc.zeroEvents...)
+			measurement := latencytest.Measure(t, m, events...)
+			kubeletStart, ok := lo.Find(measurement.Timings, func(timing *sources.Timing) bool { return timing.Event.Metric == "kubelet_start" })
+			if !ok {
+				t.Fatalf("expected the kubelet start to be timed")
+			}
+			if kubeletStart.T != tc.expected {
+				t.Errorf("expected the kubelet to start at T %s, got %s", tc.expected, kubeletStart.T)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// mermaidTimeFormat is the go layout of the mermaid gantt dateFormat YYYY-MM-DDTHH:mm:ss.SSS
+const mermaidTimeFormat = "2006-01-02T15:04:05.000"
+
+// mermaidEscaper replaces characters that end a mermaid gantt task name or start a comment
+var mermaidEscaper = strings.NewReplacer(":", " ", ";", " ", "#", " ")
+
+// Mermaid renders the Measurement as a mermaid gantt diagram, i.e. to embed in a ```mermaid markdown code block
+// Like the trace, each successful timing is a bar starting at the previous timing and ending at the timing's timestamp,
+// timings at the same time as the previous timing are milestones.
+func (m *Measurement) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("gantt\n")
+	if m.Metadata != nil {
+		fmt.Fprintf(&b, "    title %s\n", mermaidEscaper.Replace(fmt.Sprintf("%s (%s) | %s | %s | %s | %s",
+			m.Metadata.InstanceID, m.Metadata.PrivateIP, m.Metadata.InstanceType, m.Metadata.Architecture,
+			m.Metadata.AvailabilityZone, m.Metadata.AMIID)))
+	}
+	b.WriteString("    dateFormat YYYY-MM-DDTHH:mm:ss.SSS\n")
+	b.WriteString("    axisFormat %M:%S\n")
+	fmt.Fprintf(&b, "    section %s\n", rootSpanName)
+	timings := lo.Filter(m.Timings, func(t *sources.Timing, _ int) bool { return t.Error == nil })
+	for i, timing := range timings {
+		start := timing.Timestamp
+		if i > 0 {
+			start = timings[i-1].Timestamp
+		}
+		name := mermaidEscaper.Replace(timing.Event.Name)
+		if !timing.Timestamp.After(start) {
+			fmt.Fprintf(&b, "    %s :milestone, %s, 0s\n", name, timing.Timestamp.UTC().Format(mermaidTimeFormat))
+			continue
+		}
+		fmt.Fprintf(&b, "    %s :%s, %s\n", name, start.UTC().Format(mermaidTimeFormat), timing.Timestamp.UTC().Format(mermaidTimeFormat))
+	}
+	return b.String()
+}
@@ -0,0 +1,288 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/prometheus/common/model"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	azuresrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/azure"
+	gcesrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/gce"
+	imdssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/imds"
+)
+
+// MetadataProvider retrieves Metadata about the node where measurements are executed
+type MetadataProvider interface {
+	Metadata(ctx context.Context) (*Metadata, error)
+}
+
+// IMDSMetadataProvider provides Metadata from the EC2 Instance Metadata Service (IMDS) instance-identity document
+type IMDSMetadataProvider struct {
+	client *imds.Client
+	cache  *imdssrc.Cache
+}
+
+// NewIMDSMetadataProvider creates a new MetadataProvider backed by EC2 IMDS
+func NewIMDSMetadataProvider(client *imds.Client) *IMDSMetadataProvider {
+	return &IMDSMetadataProvider{client: client}
+}
+
+// WithCache is a builder func that reads the instance-identity document from the IMDS cache, and caches it once retrieved
+func (p *IMDSMetadataProvider) WithCache(cache *imdssrc.Cache) *IMDSMetadataProvider {
+	p.cache = cache
+	return p
+}
+
+// Metadata retrieves the instance-identity document from IMDS
+func (p *IMDSMetadataProvider) Metadata(ctx context.Context) (*Metadata, error) {
+	idDoc, err := p.identityDocument(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Metadata{
+		Region:           idDoc.Region,
+		InstanceType:     idDoc.InstanceType,
+		InstanceID:       idDoc.InstanceID,
+		AccountID:        idDoc.AccountID,
+		Architecture:     idDoc.Architecture,
+		AvailabilityZone: idDoc.AvailabilityZone,
+		AMIID:            idDoc.ImageID,
+		PrivateIP:        idDoc.PrivateIP,
+	}, nil
+}
+
+// identityDocument retrieves the instance-identity document from the cache, or from IMDS if it is not cached
+func (p *IMDSMetadataProvider) identityDocument(ctx context.Context) (*imds.InstanceIdentityDocument, error) {
+	if p.cache != nil {
+		if cached, ok := p.cache.Get(imdssrc.DynamicDocPrefix); ok {
+			var idDoc imds.InstanceIdentityDocument
+			if err := json.Unmarshal([]byte(cached), &idDoc); err == nil {
+				return &idDoc, nil
+			}
+		}
+	}
+	out, err := p.client.GetInstanceIdentityDocument(ctx, &imds.GetInstanceIdentityDocumentInput{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve instance-identity document: %w", err)
+	}
+	if p.cache != nil {
+		if data, err := json.Marshal(out.InstanceIdentityDocument); err != nil {
+			log.Printf("Unable to cache the instance-identity document: %s\n", err)
+		} else if err := p.cache.Set(imdssrc.DynamicDocPrefix, string(data)); err != nil {
+			log.Printf("Unable to cache the instance-identity document: %s\n", err)
+		}
+	}
+	return &out.InstanceIdentityDocument, nil
+}
+
+// GCEMetadataProvider provides Metadata from the Google Compute Engine (GCE) metadata server
+// The GCE project is used as the AccountID and the boot image as the AMIID.
+type GCEMetadataProvider struct {
+	src *gcesrc.Source
+}
+
+// NewGCEMetadataProvider creates a new MetadataProvider backed by the GCE metadata server
+func NewGCEMetadataProvider(src *gcesrc.Source) *GCEMetadataProvider {
+	return &GCEMetadataProvider{src: src}
+}
+
+// Metadata queries the GCE metadata server for the instance's metadata
+func (p *GCEMetadataProvider) Metadata(ctx context.Context) (*Metadata, error) {
+	md := &Metadata{Architecture: hostArchitecture()}
+	for _, field := range []struct {
+		path  string
+		value *string
+	}{
+		{path: "instance/id", value: &md.InstanceID},
+		{path: "instance/machine-type", value: &md.InstanceType},
+		{path: "instance/zone", value: &md.AvailabilityZone},
+		{path: "instance/image", value: &md.AMIID},
+		{path: "instance/network-interfaces/0/ip", value: &md.PrivateIP},
+		{path: "project/project-id", value: &md.AccountID},
+	} {
+		value, err := p.src.GetMetadataBase(ctx, field.path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to retrieve GCE metadata: %w", err)
+		}
+		*field.value = value
+	}
+	// zones are formatted as <region>-<zone letter>, i.e. us-central1-a
+	md.Region = md.AvailabilityZone
+	if i := strings.LastIndex(md.AvailabilityZone, "-"); i > 0 {
+		md.Region = md.AvailabilityZone[:i]
+	}
+	return md, nil
+}
+
+// AzureMetadataProvider provides Metadata from the Azure Instance Metadata Service (IMDS)
+// The subscription is used as the AccountID, the image reference as the AMIID, and the VM Scale Set as the NodeGroup.
+type AzureMetadataProvider struct {
+	src *azuresrc.Source
+}
+
+// NewAzureMetadataProvider creates a new MetadataProvider backed by Azure IMDS
+func NewAzureMetadataProvider(src *azuresrc.Source) *AzureMetadataProvider {
+	return &AzureMetadataProvider{src: src}
+}
+
+// Metadata retrieves the instance document from Azure IMDS
+func (p *AzureMetadataProvider) Metadata(ctx context.Context) (*Metadata, error) {
+	instance, err := p.src.GetInstance(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve Azure IMDS metadata: %w", err)
+	}
+	// zones are formatted the same as the topology.kubernetes.io/zone label on AKS, i.e. eastus-1
+	zone := instance.Compute.Location
+	if instance.Compute.Zone != "" {
+		zone = fmt.Sprintf("%s-%s", instance.Compute.Location, instance.Compute.Zone)
+	}
+	return &Metadata{
+		Region:           instance.Compute.Location,
+		InstanceType:     instance.Compute.VMSize,
+		InstanceID:       instance.Compute.VMID,
+		AccountID:        instance.Compute.SubscriptionID,
+		Architecture:     hostArchitecture(),
+		AvailabilityZone: zone,
+		PrivateIP:        instance.PrivateIP(),
+		AMIID:            instance.Image(),
+		NodeGroup:        instance.Compute.VMScaleSetName,
+	}, nil
+}
+
+// Well-known node labels of the metadata, the first label set on the node is used
+var (
+	NodeRegionLabels       = []string{corev1.LabelTopologyRegion, corev1.LabelFailureDomainBetaRegion}
+	NodeZoneLabels         = []string{corev1.LabelTopologyZone, corev1.LabelFailureDomainBetaZone}
+	NodeInstanceTypeLabels = []string{corev1.LabelInstanceTypeStable, corev1.LabelInstanceType}
+	NodeGroupLabels        = []string{
+		"eks.amazonaws.com/nodegroup",
+		"alpha.eksctl.io/nodegroup-name",
+		"karpenter.sh/nodepool",
+		"karpenter.sh/provisioner-name",
+		"cloud.google.com/gke-nodepool",
+		"kubernetes.azure.com/agentpool",
+	}
+)
+
+// ParseNodeLabelDimensions parses comma separated Node labels to add to the metric dimensions, optionally named, i.e.
+// nodepool=karpenter.sh/nodepool,eks.amazonaws.com/nodegroup. Unnamed labels are named after the label's name without
+// its prefix, i.e. nodegroup.
+func ParseNodeLabelDimensions(dimensionsStr string) (map[string]string, error) {
+	dimensions := map[string]string{}
+	for _, dimension := range strings.Split(dimensionsStr, ",") {
+		if dimension == "" {
+			continue
+		}
+		name, label, ok := strings.Cut(dimension, "=")
+		if !ok {
+			label = dimension
+			name = invalidLabelChars.ReplaceAllString(label[strings.LastIndex(label, "/")+1:], "_")
+		}
+		if !model.LabelName(name).IsValid() || lo.Contains(defaultDimensions, name) {
+			return nil, fmt.Errorf("invalid node label dimension name \"%s\" of label %s, it must be a valid label name other than %v", name, label, defaultDimensions)
+		}
+		if _, ok := dimensions[name]; ok {
+			return nil, fmt.Errorf("duplicate node label dimension name \"%s\"", name)
+		}
+		dimensions[name] = label
+	}
+	return dimensions, nil
+}
+
+// invalidLabelChars are the characters of node label names which are not valid in metric label names
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// NodeMetadataProvider provides Metadata from the well-known labels, provider ID, and internal IP of the K8s node, so
+// the metric dimensions are populated when the node's metadata service is unreachable from the pod
+type NodeMetadataProvider struct {
+	clientset kubernetes.Interface
+	nodeName  string
+}
+
+// NewNodeMetadataProvider creates a new MetadataProvider backed by the K8s node
+func NewNodeMetadataProvider(clientset kubernetes.Interface, nodeName string) *NodeMetadataProvider {
+	return &NodeMetadataProvider{clientset: clientset, nodeName: nodeName}
+}
+
+// Metadata retrieves the node and reads its labels, the instance ID is only set for the aws provider ID
+func (p *NodeMetadataProvider) Metadata(ctx context.Context) (*Metadata, error) {
+	node, err := p.clientset.CoreV1().Nodes().Get(ctx, p.nodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get node %s: %w", p.nodeName, err)
+	}
+	label := func(keys []string) string {
+		key, _ := lo.Find(keys, func(key string) bool { return node.Labels[key] != "" })
+		return node.Labels[key]
+	}
+	md := &Metadata{
+		Region:           label(NodeRegionLabels),
+		AvailabilityZone: label(NodeZoneLabels),
+		InstanceType:     label(NodeInstanceTypeLabels),
+		NodeGroup:        label(NodeGroupLabels),
+		Architecture:     lo.Ternary(node.Labels[corev1.LabelArchStable] == "amd64", "x86_64", node.Labels[corev1.LabelArchStable]),
+	}
+	// aws provider IDs are formatted as aws:///<zone>/<instance-id>
+	if strings.HasPrefix(node.Spec.ProviderID, "aws://") {
+		md.InstanceID = node.Spec.ProviderID[strings.LastIndex(node.Spec.ProviderID, "/")+1:]
+	}
+	if address, ok := lo.Find(node.Status.Addresses, func(a corev1.NodeAddress) bool { return a.Type == corev1.NodeInternalIP }); ok {
+		md.PrivateIP = address.Address
+	}
+	return md, nil
+}
+
+// mergeMetadata fills the empty fields of the Metadata with the fields of the other Metadata
+func mergeMetadata(md *Metadata, other *Metadata) *Metadata {
+	merged := *md
+	for _, field := range []struct {
+		value *string
+		other string
+	}{
+		{value: &merged.Region, other: other.Region},
+		{value: &merged.InstanceType, other: other.InstanceType},
+		{value: &merged.InstanceID, other: other.InstanceID},
+		{value: &merged.AccountID, other: other.AccountID},
+		{value: &merged.Architecture, other: other.Architecture},
+		{value: &merged.AvailabilityZone, other: other.AvailabilityZone},
+		{value: &merged.PrivateIP, other: other.PrivateIP},
+		{value: &merged.AMIID, other: other.AMIID},
+		{value: &merged.NodeGroup, other: other.NodeGroup},
+	} {
+		if *field.value == "" {
+			*field.value = field.other
+		}
+	}
+	return &merged
+}
+
+// hostArchitecture returns the architecture of the host using the EC2 naming convention
+func hostArchitecture() string {
+	if runtime.GOARCH == "amd64" {
+		return "x86_64"
+	}
+	return runtime.GOARCH
+}
@@ -0,0 +1,171 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// Thresholds are the maximum latencies of metrics, keyed by metric name
+type Thresholds map[string]time.Duration
+
+// Summary is a compact notification of a Measurement with the node metadata, terminal event latencies, and threshold breaches
+type Summary struct {
+	Metadata          *Metadata         `json:"metadata"`
+	TerminalEvents    []SummaryTiming   `json:"terminalEvents"`
+	ThresholdBreaches []ThresholdBreach `json:"thresholdBreaches"`
+}
+
+// SummaryTiming is the latency of an event in a Summary
+type SummaryTiming struct {
+	Event   string  `json:"event"`
+	Metric  string  `json:"metric"`
+	Seconds float64 `json:"seconds"`
+}
+
+// ThresholdBreach is a metric whose latency exceeded its threshold
+type ThresholdBreach struct {
+	Metric           string  `json:"metric"`
+	Seconds          float64 `json:"seconds"`
+	ThresholdSeconds float64 `json:"thresholdSeconds"`
+}
+
+// ParseThresholds parses comma separated metric thresholds, i.e. "node_ready=60s,pod_ready=1m30s"
+func ParseThresholds(thresholdsStr string) (Thresholds, error) {
+	thresholds := Thresholds{}
+	for _, threshold := range strings.Split(thresholdsStr, ",") {
+		if threshold == "" {
+			continue
+		}
+		metric, durationStr, ok := strings.Cut(threshold, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid threshold \"%s\", expected <metric>=<duration>", threshold)
+		}
+		duration, err := time.ParseDuration(durationStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration for threshold \"%s\": %w", threshold, err)
+		}
+		thresholds[metric] = duration
+	}
+	return thresholds, nil
+}
+
+// Summary summarizes the Measurement and checks the successful timings against the thresholds
+func (m *Measurement) Summary(thresholds Thresholds) *Summary {
+	summary := &Summary{
+		Metadata:          m.Metadata,
+		TerminalEvents:    []SummaryTiming{},
+		ThresholdBreaches: []ThresholdBreach{},
+	}
+	for _, timing := range lo.Filter(m.Timings, func(t *sources.Timing, _ int) bool { return t.Error == nil }) {
+		if timing.Event.Terminal {
+			summary.TerminalEvents = append(summary.TerminalEvents, SummaryTiming{
+				Event:   timing.Event.Name,
+				Metric:  timing.Event.Metric,
+				Seconds: timing.T.Seconds(),
+			})
+		}
+		if threshold, ok := thresholds[timing.Event.Metric]; ok && timing.T > threshold {
+			summary.ThresholdBreaches = append(summary.ThresholdBreaches, ThresholdBreach{
+				Metric:           timing.Event.Metric,
+				Seconds:          timing.T.Seconds(),
+				ThresholdSeconds: threshold.Seconds(),
+			})
+		}
+	}
+	sort.Slice(summary.ThresholdBreaches, func(i, j int) bool {
+		return summary.ThresholdBreaches[i].Metric < summary.ThresholdBreaches[j].Metric
+	})
+	return summary
+}
+
+// Subject is a one line description of the Summary
+func (s *Summary) Subject() string {
+	node := "node"
+	if s.Metadata != nil && s.Metadata.InstanceID != "" {
+		node = s.Metadata.InstanceID
+	}
+	var latencies []string
+	for _, t := range s.TerminalEvents {
+		latencies = append(latencies, fmt.Sprintf("%s %.0fs", t.Metric, t.Seconds))
+	}
+	if len(latencies) == 0 {
+		latencies = append(latencies, "no terminal events")
+	}
+	subject := fmt.Sprintf("Node latency %s: %s", node, strings.Join(latencies, ", "))
+	if len(s.ThresholdBreaches) > 0 {
+		subject = fmt.Sprintf("%s (%d threshold breaches)", subject, len(s.ThresholdBreaches))
+	}
+	return subject
+}
+
+// EmitSNS publishes the Summary of the Measurement to an SNS topic
+// The "breached" message attribute can be used in subscription filter policies to only alert on threshold breaches.
+func (m *Measurement) EmitSNS(ctx context.Context, client *sns.Client, topicARN string, thresholds Thresholds) error {
+	summary := m.Summary(thresholds)
+	message, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("unable to marshal summary: %w", err)
+	}
+	// SNS subjects are limited to 100 characters
+	subject := summary.Subject()
+	if len(subject) > 100 {
+		subject = subject[:97] + "..."
+	}
+	if _, err := client.Publish(ctx, &sns.PublishInput{
+		TopicArn: aws.String(topicARN),
+		Subject:  aws.String(subject),
+		Message:  aws.String(string(message)),
+		MessageAttributes: map[string]snstypes.MessageAttributeValue{
+			"breached": {DataType: aws.String("String"), StringValue: aws.String(fmt.Sprint(len(summary.ThresholdBreaches) > 0))},
+		},
+	}); err != nil {
+		return fmt.Errorf("unable to publish summary to %s: %w", topicARN, err)
+	}
+	return nil
+}
+
+// EmitSQS sends the Summary of the Measurement to an SQS queue
+func (m *Measurement) EmitSQS(ctx context.Context, client *sqs.Client, queueURL string, thresholds Thresholds) error {
+	summary := m.Summary(thresholds)
+	message, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("unable to marshal summary: %w", err)
+	}
+	if _, err := client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(queueURL),
+		MessageBody: aws.String(string(message)),
+		MessageAttributes: map[string]sqstypes.MessageAttributeValue{
+			"breached": {DataType: aws.String("String"), StringValue: aws.String(fmt.Sprint(len(summary.ThresholdBreaches) > 0))},
+		},
+	}); err != nil {
+		return fmt.Errorf("unable to send summary to %s: %w", queueURL, err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// OrderingAnomaliesMetric is the metric of the number of ordering anomalies of a measurement
+const OrderingAnomaliesMetric = "ordering_anomalies"
+
+// OrderingAnomaly is an event that was timed before an event it is expected after, i.e. from a clock step or a restart,
+// or whose predecessor is registered but was not timed, i.e. a missing phase
+type OrderingAnomaly struct {
+	Metric      string `json:"metric"`
+	Predecessor string `json:"predecessor"`
+	// Missing is true when the predecessor was not timed, otherwise the event was timed before the predecessor
+	Missing bool `json:"missing,omitempty"`
+}
+
+// String is a human readable description of the anomaly
+func (a OrderingAnomaly) String() string {
+	if a.Missing {
+		return fmt.Sprintf("%s was timed but its predecessor %s was not", a.Metric, a.Predecessor)
+	}
+	return fmt.Sprintf("%s was timed before its predecessor %s", a.Metric, a.Predecessor)
+}
+
+// orderingAnomalies checks the successful timings of events against the timings of the events they are expected after
+// Predecessors which are not registered, i.e. excluded by a profile, are not checked.
+func (m *Measurer) orderingAnomalies(timings []*sources.Timing) []OrderingAnomaly {
+	measured := lo.Filter(timings, func(t *sources.Timing, _ int) bool { return t.Error == nil })
+	var anomalies []OrderingAnomaly
+	for _, t := range measured {
+		for _, predecessor := range t.Event.After {
+			if !lo.ContainsBy(m.events, func(e *sources.Event) bool { return e.Metric == predecessor }) {
+				continue
+			}
+			predecessorTimings := lo.Filter(measured, func(p *sources.Timing, _ int) bool { return p.Event.Metric == predecessor })
+			anomaly := OrderingAnomaly{Metric: t.Event.Metric, Predecessor: predecessor, Missing: len(predecessorTimings) == 0}
+			if anomaly.Missing || lo.NoneBy(predecessorTimings, func(p *sources.Timing) bool { return !p.Timestamp.After(t.Timestamp) }) {
+				anomalies = append(anomalies, anomaly)
+			}
+		}
+	}
+	return lo.Uniq(anomalies)
+}
+
+// checksOrdering checks if any event declares predecessors, the ordering anomalies metric is only emitted if so
+func (m *Measurement) checksOrdering() bool {
+	return lo.SomeBy(m.Timings, func(t *sources.Timing) bool { return len(t.Event.After) > 0 })
+}
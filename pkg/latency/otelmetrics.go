@@ -0,0 +1,64 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/instrument"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.uber.org/multierr"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// meterName is the instrumentation scope of the OpenTelemetry metrics
+const meterName = "github.com/awslabs/node-latency-for-k8s/pkg/latency"
+
+// EmitOTLPMetrics exports a gauge per metric to an OpenTelemetry metrics exporter, i.e. OTLP to a collector
+// The gauges have the same dimensions as CloudWatch and Prometheus as attributes, and the node metadata as resource attributes.
+func (m *Measurement) EmitOTLPMetrics(ctx context.Context, exporter sdkmetric.Exporter, experimentDimension string) error {
+	// the measurement is exported once on Shutdown rather than periodically
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)),
+		sdkmetric.WithResource(m.otelResource()),
+	)
+	meter := mp.Meter(meterName)
+	dimensions := m.metricDimensions(experimentDimension)
+	var errs error
+	for _, timing := range lo.UniqBy(m.Timings, func(t *sources.Timing) string { return t.Event.Metric }) {
+		metric := timing.Event.Metric
+		metricTimings := lo.Filter(m.Timings, func(t *sources.Timing, _ int) bool { return t.Event.Metric == metric })
+		if _, err := meter.Float64ObservableGauge(metric,
+			instrument.WithUnit("s"),
+			instrument.WithDescription(timing.Event.Name),
+			instrument.WithFloat64Callback(func(_ context.Context, observer instrument.Float64Observer) error {
+				for _, t := range metricTimings {
+					observer.Observe(t.T.Seconds(), lo.MapToSlice(timingDimensions(dimensions, t), func(k, v string) attribute.KeyValue {
+						return attribute.String(k, v)
+					})...)
+				}
+				return nil
+			}),
+		); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("unable to create gauge %s: %w", metric, err))
+		}
+	}
+	// Shutdown collects the gauges, exports them, and shuts down the exporter
+	return multierr.Append(errs, mp.Shutdown(ctx))
+}
@@ -0,0 +1,85 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/samber/lo"
+)
+
+// Default event templates which are parameterized by DefaultEventParams, the params are quoted so they match literally
+var (
+	podReadyStr       = `.*%s/(?P<pod>%s[^" ]*).* Type:ContainerStarted.*`
+	containerStartStr = `.*CreateContainer within sandbox .*Name:%s.* returns container id.*`
+	// CRI-O describes started containers as <namespace>/<pod>/<container>
+	crioContainerStartStr = `(?m).*msg="Started container".* description="?%s/[^/" \n]+/%s"?(?: .*)?$`
+)
+
+// DefaultEventParams parameterize the default events which depend on the workloads running on the node
+// Empty params are the defaults of an EKS node, the pod namespace defaults to the Measurer's pod namespace.
+type DefaultEventParams struct {
+	// PodNamespace and PodNamePrefix select the pods whose first started container marks "Pod Ready"
+	PodNamespace  string `json:"podNamespace"`
+	PodNamePrefix string `json:"podNamePrefix"`
+	// SystemNamespace is the namespace of the proxy and CNI DaemonSets, defaults to kube-system
+	SystemNamespace string `json:"systemNamespace"`
+	// ProxyDaemonSet and ProxyContainer mark "Kube-Proxy Start", default to kube-proxy
+	ProxyDaemonSet string `json:"proxyDaemonSet"`
+	ProxyContainer string `json:"proxyContainer"`
+	// CNIDaemonSet and CNIContainer mark "AWS Node Start" and CNIInitContainer marks "VPC CNI Init Start",
+	// default to aws-node and aws-vpc-cni-init
+	CNIDaemonSet     string `json:"cniDaemonSet"`
+	CNIInitContainer string `json:"cniInitContainer"`
+	CNIContainer     string `json:"cniContainer"`
+}
+
+// WithDefaultEventParams is a builder func that parameterizes the default events, i.e. the namespace of the pods that mark "Pod Ready"
+// The params must be set before the default events are registered.
+func (m *Measurer) WithDefaultEventParams(params DefaultEventParams) *Measurer {
+	m.eventParams = params
+	return m
+}
+
+// defaultEventParams are the Measurer's default event params with the defaults of empty params
+func (m *Measurer) defaultEventParams() DefaultEventParams {
+	p := m.eventParams
+	return DefaultEventParams{
+		PodNamespace:     lo.Ternary(p.PodNamespace == "", lo.Ternary(m.podNamespace == "", "default", m.podNamespace), p.PodNamespace),
+		PodNamePrefix:    p.PodNamePrefix,
+		SystemNamespace:  lo.Ternary(p.SystemNamespace == "", "kube-system", p.SystemNamespace),
+		ProxyDaemonSet:   lo.Ternary(p.ProxyDaemonSet == "", "kube-proxy", p.ProxyDaemonSet),
+		ProxyContainer:   lo.Ternary(p.ProxyContainer == "", "kube-proxy", p.ProxyContainer),
+		CNIDaemonSet:     lo.Ternary(p.CNIDaemonSet == "", "aws-node", p.CNIDaemonSet),
+		CNIInitContainer: lo.Ternary(p.CNIInitContainer == "", "aws-vpc-cni-init", p.CNIInitContainer),
+		CNIContainer:     lo.Ternary(p.CNIContainer == "", "aws-node", p.CNIContainer),
+	}
+}
+
+// podReadyRegex matches the kubelet's container started events of the pods selected by the params
+func (p DefaultEventParams) podReadyRegex() *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(podReadyStr, regexp.QuoteMeta(p.PodNamespace), regexp.QuoteMeta(p.PodNamePrefix)))
+}
+
+// containerStartRegex matches containerd's create container log line of the container
+func containerStartRegex(containerName string) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(containerStartStr, regexp.QuoteMeta(containerName)))
+}
+
+// crioContainerStartRegex matches CRI-O's started container log line of the container in the namespace
+func crioContainerStartRegex(namespace string, containerName string) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(crioContainerStartStr, regexp.QuoteMeta(namespace), regexp.QuoteMeta(containerName)))
+}
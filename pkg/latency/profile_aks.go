@@ -0,0 +1,106 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"regexp"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/logfile"
+)
+
+// ProfileAKS is the profile name for AKS nodes running Ubuntu
+const ProfileAKS = "aks"
+
+// AKS source names and log paths, the Azure Linux agent runs the custom script extension which bootstraps the node,
+// and the Azure CNI plugin logs each CNI command
+var (
+	WALinuxAgentLogName     = "Azure Linux Agent"
+	WALinuxAgentLogPath     = "/var/log/waagent.log"
+	AzureCNILogName         = "Azure CNI"
+	AzureCNILogPath         = "/var/log/azure-vnet.log"
+	AzureCNITimestampFormat = regexp.MustCompile(`[0-9]{4}/[0-9]{2}/[0-9]{2} [0-9]{2}:[0-9]{2}:[0-9]{2}`)
+	AzureCNITimestampLayout = "2006/01/02 15:04:05"
+)
+
+// AKS Event regular expressions
+var (
+	aksCSEStart = regexp.MustCompile(`.*\[Microsoft\.Azure\.Extensions\.CustomScript-[^\]]+\].*Enable extension.*`)
+	// the AKS kubelet unit is described as "Kubelet"
+	aksKubeletStart       = regexp.MustCompile(`.*Starting (?:kubelet\.service - )?Kubelet\b.*`)
+	aksKubeletInitialized = regexp.MustCompile(`.*Started (?:kubelet\.service - )?Kubelet\b.*`)
+	azureCNIAdd           = regexp.MustCompile(`.*Processing ADD command.*`)
+)
+
+// The aks profile adds the custom script extension start, which runs the AKS node bootstrapping after cloud-init, from
+// the Azure Linux agent's log and the first Azure CNI ADD command, when the first pod's network was set up, from the
+// Azure CNI log. The kubelet unit events are replaced with the AKS kubelet unit, and the VPC CNI events are excluded.
+// The cloud-init and containerd events of Ubuntu are the defaults. Azure IMDS metadata and the VM created and
+// provisioned events are measured with --cloud-provider azure.
+func init() {
+	RegisterProfile(&Profile{
+		Name: ProfileAKS,
+		Sources: func(m *Measurer) []sources.Source {
+			return []sources.Source{
+				logfile.New(WALinuxAgentLogName, m.hostPath(WALinuxAgentLogPath), sources.SyslogTimestampFormat, sources.TimestampLayoutAuto),
+				logfile.New(AzureCNILogName, m.hostPath(AzureCNILogPath), AzureCNITimestampFormat, AzureCNITimestampLayout),
+			}
+		},
+		ExcludeMetrics: []string{
+			"vpc_cni_init_start",
+			"aws_node_start",
+			"vpc_cni_plugin_initialized",
+		},
+		Events: func(m *Measurer) []*sources.Event {
+			waagent := lo.Must(m.GetSource(WALinuxAgentLogName)).(sources.RegexFinder)
+			azureCNI := lo.Must(m.GetSource(AzureCNILogName)).(sources.RegexFinder)
+			syslog := m.syslogSource()
+			return []*sources.Event{
+				{
+					Name:          "AKS CSE Start",
+					Metric:        "aks_cse_start",
+					SrcName:       WALinuxAgentLogName,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        waagent.FindByRegex(aksCSEStart),
+				},
+				{
+					Name:          "Kubelet Start",
+					Metric:        "kubelet_start",
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(aksKubeletStart),
+				},
+				{
+					Name:          "Kubelet Initialized",
+					Metric:        "kubelet_initialized",
+					After:         []string{"kubelet_start"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(aksKubeletInitialized),
+				},
+				{
+					Name:          "Azure CNI First Add",
+					Metric:        "azure_cni_first_add",
+					After:         []string{"kubelet_start"},
+					SrcName:       AzureCNILogName,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        azureCNI.FindByRegex(azureCNIAdd),
+				},
+			}
+		},
+	})
+}
@@ -0,0 +1,92 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/awsnode"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/journal"
+)
+
+// ProfileBottlerocket is the profile name for Bottlerocket nodes
+const ProfileBottlerocket = "bottlerocket"
+
+// bottlerocketRootFS is where the host filesystem is mounted when running in a Bottlerocket host container
+const bottlerocketRootFS = "/.bottlerocket/rootfs"
+
+// Bottlerocket Event regular expressions
+var (
+	bottlerocketKubeletStart          = regexp.MustCompile(`.*Starting [Kk]ubelet.*`)
+	bottlerocketEarlyBootConfigStart  = regexp.MustCompile(`.*Starting Bottlerocket userdata configuration system.*`)
+	bottlerocketEarlyBootConfigFinish = regexp.MustCompile(`.*(Started|Finished) Bottlerocket userdata configuration system.*`)
+	bottlerocketSettingsApplied       = regexp.MustCompile(`.*(Started|Finished) Applies settings to create config files.*`)
+)
+
+// Bottlerocket does not write /var/log/messages or run cloud-init, so the journal is read directly
+// and cloud-init events are replaced with Bottlerocket's userdata configuration events.
+func init() {
+	RegisterProfile(&Profile{
+		Name: ProfileBottlerocket,
+		Sources: func(m *Measurer) []sources.Source {
+			journalDir := firstExistingPath(bottlerocketRootFS+"/var/log/journal", "/var/log/journal")
+			return []sources.Source{
+				journal.New(fmt.Sprintf("--directory=%s", journalDir)),
+				awsnode.New(firstExistingPath(bottlerocketRootFS+"/var/log/pods", "/var/log/pods") + "/kube-system_aws-node-*/aws-node/*.log"),
+			}
+		},
+		ExcludeMetrics: []string{
+			"cloudinit_initial_start",
+			"cloudinit_config_start",
+			"cloudinit_final_start",
+			"cloudinit_final_finish",
+		},
+		Events: func(m *Measurer) []*sources.Event {
+			syslog := m.syslogSource()
+			return []*sources.Event{
+				{
+					Name:          "Early Boot Config Start",
+					Metric:        "early_boot_config_start",
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(bottlerocketEarlyBootConfigStart),
+				},
+				{
+					Name:          "Early Boot Config Finish",
+					Metric:        "early_boot_config_finish",
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(bottlerocketEarlyBootConfigFinish),
+				},
+				{
+					Name:          "Settings Applied",
+					Metric:        "settings_applied",
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(bottlerocketSettingsApplied),
+				},
+				{
+					Name:          "Kubelet Start",
+					Metric:        "kubelet_start",
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(bottlerocketKubeletStart),
+				},
+			}
+		},
+	})
+}
@@ -0,0 +1,103 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/awsnode"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/logfile"
+)
+
+// ProfileCalico is the profile name for nodes running the Calico CNI instead of the VPC CNI
+const ProfileCalico = "calico"
+
+// CalicoNodeLogName is the source name of the calico-node container logs
+var CalicoNodeLogName = "calico-node"
+
+// Calico Event regular expressions
+var (
+	calicoFelixStart = regexp.MustCompile(`.*Felix starting up(?:.* version="(?P<version>[^"]+)")?.*`)
+	// BIRD logs BGP sessions coming up, felix logs configuring the VXLAN device when VXLAN is used instead of BGP
+	calicoPeerEstablished = regexp.MustCompile(`.*(?:bird6?: (?P<peer>\S+): State changed to up|felix/vxlan_mgr\.go [0-9]+: .*VXLAN device).*`)
+	// the kubelet logs readiness probe transitions, the calico-node pod may be in kube-system or calico-system
+	calicoNodeReadyStr = `.*"SyncLoop \(probe\)" probe="readiness" status="ready" pod="(?P<pod>%s/%s-[^"]*)".*`
+)
+
+// calicoDaemonSet is the calico-node daemonset name, or the CNI daemonset param if set
+func (m *Measurer) calicoDaemonSet() string {
+	return lo.Ternary(m.eventParams.CNIDaemonSet == "", "calico-node", m.eventParams.CNIDaemonSet)
+}
+
+// calicoNamespace is the namespace glob of the calico-node pods, or the system namespace param if set
+func (m *Measurer) calicoNamespace() string {
+	return lo.Ternary(m.eventParams.SystemNamespace == "", "*", m.eventParams.SystemNamespace)
+}
+
+// The calico profile replaces the VPC CNI events with felix start, the first BGP session or VXLAN device, and
+// calico-node readiness events from the calico-node container logs and the kubelet's readiness probe logs.
+func init() {
+	RegisterProfile(&Profile{
+		Name: ProfileCalico,
+		Sources: func(m *Measurer) []sources.Source {
+			container := lo.Ternary(m.eventParams.CNIContainer == "", "calico-node", m.eventParams.CNIContainer)
+			path := fmt.Sprintf("/var/log/pods/%s_%s-*/%s/*.log", m.calicoNamespace(), m.calicoDaemonSet(), container)
+			return []sources.Source{logfile.New(CalicoNodeLogName, m.hostPath(path), awsnode.TimestampFormat, awsnode.TimestampLayout)}
+		},
+		ExcludeMetrics: []string{
+			"vpc_cni_init_start",
+			"aws_node_start",
+			"vpc_cni_plugin_initialized",
+		},
+		Events: func(m *Measurer) []*sources.Event {
+			nodeLog := lo.Must(m.GetSource(CalicoNodeLogName)).(sources.RegexFinder)
+			syslog := m.syslogSource()
+			namespace := lo.Ternary(m.eventParams.SystemNamespace == "", `[^/"]+`, regexp.QuoteMeta(m.eventParams.SystemNamespace))
+			calicoNodeReady := regexp.MustCompile(fmt.Sprintf(calicoNodeReadyStr, namespace, regexp.QuoteMeta(m.calicoDaemonSet())))
+			return []*sources.Event{
+				{
+					Name:          "Calico Felix Start",
+					Metric:        "calico_felix_start",
+					SrcName:       CalicoNodeLogName,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					CommentFn:     sources.CommentCaptureGroups(calicoFelixStart),
+					FindFn:        nodeLog.FindByRegex(calicoFelixStart),
+				},
+				{
+					Name:          "Calico Peer Established",
+					Metric:        "calico_peer_established",
+					After:         []string{"calico_felix_start"},
+					SrcName:       CalicoNodeLogName,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					CommentFn:     sources.CommentCaptureGroups(calicoPeerEstablished),
+					FindFn:        nodeLog.FindByRegex(calicoPeerEstablished),
+				},
+				{
+					Name:          "Calico Node Ready",
+					Metric:        "calico_node_ready",
+					After:         []string{"calico_felix_start"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					CommentFn:     sources.CommentCaptureGroups(calicoNodeReady),
+					FindFn:        syslog.FindByRegex(calicoNodeReady),
+				},
+			}
+		},
+	})
+}
@@ -0,0 +1,101 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/awsnode"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/logfile"
+)
+
+// ProfileCilium is the profile name for nodes running the Cilium CNI instead of the VPC CNI
+const ProfileCilium = "cilium"
+
+// CiliumAgentLogName is the source name of the cilium-agent container logs
+var CiliumAgentLogName = "cilium-agent"
+
+// Cilium Event regular expressions
+var (
+	ciliumAgentStart          = regexp.MustCompile(`.*msg="Cilium (?P<version>[0-9][^ "]*) .*`)
+	ciliumBPFLoaded           = regexp.MustCompile(`.*msg="Compiled new BPF template".*`)
+	ciliumEndpointRegenerated = regexp.MustCompile(`.*msg="Rewrote endpoint BPF program".*`)
+	ciliumHealthReady         = regexp.MustCompile(`.*msg="Serving cilium health API at .*`)
+)
+
+// ciliumAgentLogPath is the glob of the cilium-agent container logs, the daemonset and container names are the CNI params if set
+func (m *Measurer) ciliumAgentLogPath() string {
+	params := m.defaultEventParams()
+	daemonSet := lo.Ternary(m.eventParams.CNIDaemonSet == "", "cilium", m.eventParams.CNIDaemonSet)
+	container := lo.Ternary(m.eventParams.CNIContainer == "", "cilium-agent", m.eventParams.CNIContainer)
+	return m.hostPath(fmt.Sprintf("/var/log/pods/%s_%s-*/%s/*.log", params.SystemNamespace, daemonSet, container))
+}
+
+// The cilium profile replaces the VPC CNI events with the cilium-agent's start, BPF datapath load, first endpoint
+// regeneration, and health API events from its container logs.
+func init() {
+	RegisterProfile(&Profile{
+		Name: ProfileCilium,
+		Sources: func(m *Measurer) []sources.Source {
+			return []sources.Source{logfile.New(CiliumAgentLogName, m.ciliumAgentLogPath(), awsnode.TimestampFormat, awsnode.TimestampLayout)}
+		},
+		ExcludeMetrics: []string{
+			"vpc_cni_init_start",
+			"aws_node_start",
+			"vpc_cni_plugin_initialized",
+		},
+		Events: func(m *Measurer) []*sources.Event {
+			agentLog := lo.Must(m.GetSource(CiliumAgentLogName)).(sources.RegexFinder)
+			return []*sources.Event{
+				{
+					Name:          "Cilium Agent Start",
+					Metric:        "cilium_agent_start",
+					SrcName:       CiliumAgentLogName,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					CommentFn:     sources.CommentCaptureGroups(ciliumAgentStart),
+					FindFn:        agentLog.FindByRegex(ciliumAgentStart),
+				},
+				{
+					Name:          "Cilium BPF Loaded",
+					Metric:        "cilium_bpf_loaded",
+					After:         []string{"cilium_agent_start"},
+					SrcName:       CiliumAgentLogName,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        agentLog.FindByRegex(ciliumBPFLoaded),
+				},
+				{
+					Name:          "Cilium Endpoint Regenerated",
+					Metric:        "cilium_endpoint_regenerated",
+					After:         []string{"cilium_bpf_loaded"},
+					SrcName:       CiliumAgentLogName,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        agentLog.FindByRegex(ciliumEndpointRegenerated),
+				},
+				{
+					Name:          "Cilium Health Ready",
+					Metric:        "cilium_health_ready",
+					After:         []string{"cilium_agent_start"},
+					SrcName:       CiliumAgentLogName,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        agentLog.FindByRegex(ciliumHealthReady),
+				},
+			}
+		},
+	})
+}
@@ -0,0 +1,129 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"regexp"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// ProfileCOS is the profile name for GKE nodes running Container-Optimized OS
+const ProfileCOS = "cos"
+
+// COS Event regular expressions, GKE installs and configures the node with the kube-node-installation and
+// kube-node-configuration oneshot units, which systemd logs as "Finished" on newer versions and "Started" on older ones
+var (
+	cosNodeInstallationStart  = regexp.MustCompile(`.*Starting (?:kube-node-installation\.service - )?Download and install k8s binaries and configurations.*`)
+	cosNodeInstallationFinish = regexp.MustCompile(`.*(?:Finished|Started) (?:kube-node-installation\.service - )?Download and install k8s binaries and configurations.*`)
+	cosNodeConfigurationStart = regexp.MustCompile(`.*Starting (?:kube-node-configuration\.service - )?Configure kubernetes node.*`)
+	cosNodeConfigurationEnd   = regexp.MustCompile(`.*(?:Finished|Started) (?:kube-node-configuration\.service - )?Configure kubernetes node.*`)
+	// systemd 249 and later prefix the unit descriptions with the unit name, i.e. "Starting containerd.service - containerd container runtime"
+	containerdUnitStart       = regexp.MustCompile(`.*Starting (?:containerd\.service - )?containerd container runtime.*`)
+	containerdUnitInitialized = regexp.MustCompile(`.*Started (?:containerd\.service - )?containerd container runtime.*`)
+	// the GKE kubelet unit is described as "Kubernetes kubelet" rather than "Kubernetes Kubelet"
+	cosKubeletStart       = regexp.MustCompile(`.*Starting (?:kubelet\.service - )?Kubernetes kubelet.*`)
+	cosKubeletInitialized = regexp.MustCompile(`.*Started (?:kubelet\.service - )?Kubernetes kubelet.*`)
+)
+
+// The cos profile adds the GKE node installation and configuration units, which download the kubelet and write its
+// config before it starts, replaces the containerd and kubelet unit events with COS' unit lines, and replaces the VPC
+// CNI events with the CNI being applied (the last time the kubelet logged that the network was not ready). COS only
+// writes the journal, so the default journal source is read.
+func init() {
+	RegisterProfile(&Profile{
+		Name: ProfileCOS,
+		ExcludeMetrics: []string{
+			"vpc_cni_init_start",
+			"aws_node_start",
+			"vpc_cni_plugin_initialized",
+		},
+		Events: func(m *Measurer) []*sources.Event {
+			syslog := m.syslogSource()
+			return []*sources.Event{
+				{
+					Name:          "GKE Node Installation Start",
+					Metric:        "gke_node_installation_start",
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(cosNodeInstallationStart),
+				},
+				{
+					Name:          "GKE Node Installation Finish",
+					Metric:        "gke_node_installation_finish",
+					After:         []string{"gke_node_installation_start"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(cosNodeInstallationFinish),
+				},
+				{
+					Name:          "GKE Node Configuration Start",
+					Metric:        "gke_node_configuration_start",
+					After:         []string{"gke_node_installation_finish"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(cosNodeConfigurationStart),
+				},
+				{
+					Name:          "GKE Node Configuration Finish",
+					Metric:        "gke_node_configuration_finish",
+					After:         []string{"gke_node_configuration_start"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(cosNodeConfigurationEnd),
+				},
+				{
+					Name:          "Containerd Start",
+					Metric:        "conatinerd_start",
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(containerdUnitStart),
+				},
+				{
+					Name:          "Containerd Initialized",
+					Metric:        "conatinerd_initialized",
+					After:         []string{"conatinerd_start"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(containerdUnitInitialized),
+				},
+				{
+					Name:          "Kubelet Start",
+					Metric:        "kubelet_start",
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(cosKubeletStart),
+				},
+				{
+					Name:          "Kubelet Initialized",
+					Metric:        "kubelet_initialized",
+					After:         []string{"kubelet_start"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(cosKubeletInitialized),
+				},
+				{
+					// the last time the network was not ready is within a sync of when the CNI was applied
+					Name:          "CNI Applied",
+					Metric:        "cni_applied",
+					After:         []string{"kubelet_start"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorLast,
+					FindFn:        syslog.FindByRegex(kubeletNetworkNotReady),
+				},
+			}
+		},
+	})
+}
@@ -0,0 +1,75 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"strings"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/cri"
+)
+
+// ProfileCRI is the profile name for reading daemonset container timestamps from the container runtime's CRI API
+const ProfileCRI = "cri"
+
+// criEndpoint returns the endpoint of the first CRI runtime socket that exists, containerd or CRI-O
+func criEndpoint() string {
+	return "unix://" + firstExistingPath(
+		strings.TrimPrefix(cri.ContainerdEndpoint, "unix://"),
+		strings.TrimPrefix(cri.CRIOEndpoint, "unix://"),
+		strings.TrimPrefix(cri.DefaultEndpoint, "unix://"),
+	)
+}
+
+// The cri profile replaces the kube-proxy and VPC CNI container log regexes, which depend on containerd's log format, with container start times from the CRI API
+func init() {
+	RegisterProfile(&Profile{
+		Name: ProfileCRI,
+		Sources: func(m *Measurer) []sources.Source {
+			return []sources.Source{cri.New(criEndpoint())}
+		},
+		Events: func(m *Measurer) []*sources.Event {
+			return criContainerEvents(m, cri.New(criEndpoint()))
+		},
+	})
+}
+
+// criContainerEvents are the kube-proxy and VPC CNI container start events read from the CRI API
+func criContainerEvents(m *Measurer, src *cri.Source) []*sources.Event {
+	params := m.defaultEventParams()
+	return []*sources.Event{
+		{
+			Name:          "Kube-Proxy Start",
+			Metric:        "kube_proxy_start",
+			SrcName:       cri.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        src.FindByContainer(params.SystemNamespace, params.ProxyDaemonSet, params.ProxyContainer, cri.ContainerStartedAt),
+		},
+		{
+			Name:          "VPC CNI Init Start",
+			Metric:        "vpc_cni_init_start",
+			SrcName:       cri.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        src.FindByContainer(params.SystemNamespace, params.CNIDaemonSet, params.CNIInitContainer, cri.ContainerStartedAt),
+		},
+		{
+			Name:          "AWS Node Start",
+			Metric:        "aws_node_start",
+			SrcName:       cri.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        src.FindByContainer(params.SystemNamespace, params.CNIDaemonSet, params.CNIContainer, cri.ContainerStartedAt),
+		},
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/cri"
+)
+
+// ProfileDocker is the profile name for nodes running the Docker runtime with cri-dockerd or the kubelet's dockershim
+const ProfileDocker = "docker"
+
+// Docker Event regular expressions
+var (
+	dockerStart           = regexp.MustCompile(`.*Starting Docker Application Container Engine.*`)
+	dockerInitialized     = regexp.MustCompile(`.*Started Docker Application Container Engine.*`)
+	criDockerdStart       = regexp.MustCompile(`.*Starting CRI Interface for Docker Application Container Engine.*`)
+	criDockerdInitialized = regexp.MustCompile(`.*Started CRI Interface for Docker Application Container Engine.*`)
+)
+
+// dockerEndpoint returns the endpoint of the first Docker CRI socket that exists, cri-dockerd or the kubelet's dockershim
+func dockerEndpoint() string {
+	return "unix://" + firstExistingPath(
+		strings.TrimPrefix(cri.DockershimEndpoint, "unix://"),
+		strings.TrimPrefix(cri.CRIDockerdEndpoint, "unix://"),
+	)
+}
+
+// The docker profile replaces the containerd events with the Docker daemon and cri-dockerd service events, and reads
+// the kube-proxy and VPC CNI container start times from the CRI API of cri-dockerd, or the dockershim on older kubelets,
+// since the Docker daemon does not log container starts.
+func init() {
+	RegisterProfile(&Profile{
+		Name: ProfileDocker,
+		Sources: func(m *Measurer) []sources.Source {
+			return []sources.Source{cri.New(dockerEndpoint())}
+		},
+		ExcludeMetrics: []string{
+			"conatinerd_start",
+			"conatinerd_initialized",
+		},
+		Events: func(m *Measurer) []*sources.Event {
+			syslog := m.syslogSource()
+			return append([]*sources.Event{
+				{
+					Name:          "Docker Start",
+					Metric:        "docker_start",
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(dockerStart),
+				},
+				{
+					Name:          "Docker Initialized",
+					Metric:        "docker_initialized",
+					After:         []string{"docker_start"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(dockerInitialized),
+				},
+				{
+					Name:          "CRI-Dockerd Start",
+					Metric:        "cri_dockerd_start",
+					After:         []string{"docker_initialized"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(criDockerdStart),
+				},
+				{
+					Name:          "CRI-Dockerd Initialized",
+					Metric:        "cri_dockerd_initialized",
+					After:         []string{"cri_dockerd_start"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(criDockerdInitialized),
+				},
+			}, criContainerEvents(m, cri.New(dockerEndpoint()))...)
+		},
+	})
+}
@@ -0,0 +1,81 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"regexp"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// ProfileEBSCSI is the profile name for nodes running the EBS CSI driver for stateful workloads
+const ProfileEBSCSI = "ebs-csi"
+
+// EBSCSIPluginContainer is the container name of the EBS CSI driver's ebs-csi-node daemonset
+var EBSCSIPluginContainer = "ebs-plugin"
+
+// EBS CSI Event regular expressions
+var (
+	// the root volume is nvme0, so attached EBS volumes are the later NVMe controllers hot plugged by the kernel
+	ebsVolumeAttached   = regexp.MustCompile(`.*kernel: (?:\[[ 0-9.]+\] )?nvme (?P<device>nvme[1-9][0-9]*): pci function.*`)
+	ebsCSIRegistered    = regexp.MustCompile(`.*Register new plugin with name: (?P<driver>ebs\.csi\.aws\.com) at endpoint.*`)
+	ebsCSIVolumeMounted = regexp.MustCompile(`.*MountVolume\.MountDevice succeeded for volume \\?"(?P<volume>[^"\\]+)\\?".*ebs\.csi\.aws\.com\^(?P<volume_id>vol-[0-9a-f]+).*`)
+)
+
+// The ebs-csi profile adds the EBS CSI node driver start and registration with the kubelet, and each EBS volume
+// attached to the node by the kernel and mounted by the kubelet, which stateful workloads wait on after the node is ready.
+func init() {
+	RegisterProfile(&Profile{
+		Name: ProfileEBSCSI,
+		Events: func(m *Measurer) []*sources.Event {
+			syslog := m.syslogSource()
+			return []*sources.Event{
+				{
+					Name:          "EBS CSI Node Start",
+					Metric:        "ebs_csi_node_start",
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(containerStartRegex(EBSCSIPluginContainer)),
+				},
+				{
+					Name:          "EBS CSI Driver Registered",
+					Metric:        "ebs_csi_driver_registered",
+					After:         []string{"ebs_csi_node_start"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					CommentFn:     sources.CommentCaptureGroups(ebsCSIRegistered),
+					FindFn:        syslog.FindByRegex(ebsCSIRegistered),
+				},
+				{
+					Name:          "EBS Volume Attached",
+					Metric:        "ebs_volume_attached",
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorAll,
+					CommentFn:     sources.CommentCaptureGroups(ebsVolumeAttached),
+					FindFn:        syslog.FindByRegex(ebsVolumeAttached),
+				},
+				{
+					Name:          "EBS Volume Mounted",
+					Metric:        "ebs_volume_mounted",
+					After:         []string{"ebs_csi_driver_registered", "ebs_volume_attached"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorAll,
+					CommentFn:     sources.CommentCaptureGroups(ebsCSIVolumeMounted),
+					FindFn:        syslog.FindByRegex(ebsCSIVolumeMounted),
+				},
+			}
+		},
+	})
+}
@@ -0,0 +1,125 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"os"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/ignition"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/journal"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/logfile"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/messages"
+)
+
+// ProfileFlatcar is the profile name for Flatcar Container Linux nodes, which are provisioned by Ignition
+const ProfileFlatcar = "flatcar"
+
+// ignitionSource returns an Ignition source with its own reader of the journal, the recorded journal when replaying,
+// or the system log of hosts without journalctl
+func (m *Measurer) ignitionSource() *ignition.Source {
+	if m.root != "" {
+		if _, err := os.Stat(m.hostPath(recordJournalName)); err == nil {
+			return ignition.NewFromLog(logfile.New(ignition.Name, m.hostPath(recordJournalName), journal.TimestampFormat, journal.TimestampLayout))
+		}
+	} else if journal.Available() {
+		return ignition.New()
+	}
+	return ignition.NewFromLog(messages.New(m.hostPath(messages.DefaultPath), m.hostPath(messages.SyslogPath)))
+}
+
+// ignitionEvents are the Ignition start (with its version), fetch, and files stage events of the Ignition source, and
+// the ignition-complete target of the system journal
+func (m *Measurer) ignitionEvents() []*sources.Event {
+	src := lo.Must(m.GetSource(ignition.Name)).(*ignition.Source)
+	syslog := m.syslogSource()
+	return []*sources.Event{
+		{
+			Name:          "Ignition Start",
+			Metric:        "ignition_start",
+			SrcName:       ignition.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			CommentFn:     sources.CommentCaptureGroups(ignition.Version),
+			FindFn:        src.FindByRegex(ignition.Version),
+		},
+		{
+			Name:          "Ignition Fetched",
+			Metric:        "ignition_fetched",
+			After:         []string{"ignition_start"},
+			SrcName:       ignition.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        src.FindByStage(ignition.StageFetch, ignition.BoundaryFinished),
+		},
+		{
+			Name:          "Ignition Files Written",
+			Metric:        "ignition_files_written",
+			After:         []string{"ignition_fetched"},
+			SrcName:       ignition.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        src.FindByStage(ignition.StageFiles, ignition.BoundaryFinished),
+		},
+		{
+			Name:          "Ignition Complete",
+			Metric:        "ignition_complete",
+			After:         []string{"ignition_files_written"},
+			SrcName:       syslog.Name(),
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        syslog.FindByRegex(ignition.Complete),
+		},
+	}
+}
+
+// The flatcar profile replaces the cloud-init events with the Ignition stages, which provision the node from the
+// initramfs on first boot, and matches the containerd unit lines of Flatcar's systemd. Flatcar only writes the journal,
+// so the journal is read. The kubelet is started by a unit of the Ignition config, so its default events are kept.
+func init() {
+	RegisterProfile(&Profile{
+		Name: ProfileFlatcar,
+		Sources: func(m *Measurer) []sources.Source {
+			return []sources.Source{m.ignitionSource()}
+		},
+		ExcludeMetrics: []string{
+			"cloudinit_initial_start",
+			"cloudinit_config_start",
+			"cloudinit_final_start",
+			"cloudinit_user_data_start",
+			"cloudinit_user_data_finish",
+			"cloudinit_module_finish",
+			"cloudinit_final_finish",
+		},
+		Events: func(m *Measurer) []*sources.Event {
+			syslog := m.syslogSource()
+			return append(m.ignitionEvents(), []*sources.Event{
+				{
+					Name:          "Containerd Start",
+					Metric:        "conatinerd_start",
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(containerdUnitStart),
+				},
+				{
+					Name:          "Containerd Initialized",
+					Metric:        "conatinerd_initialized",
+					After:         []string{"conatinerd_start"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(containerdUnitInitialized),
+				},
+			}...)
+		},
+	})
+}
@@ -0,0 +1,150 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"regexp"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/journal"
+)
+
+// ProfileK3s is the profile name for k3s and RKE2 nodes, which run containerd, the kubelet, and kube-proxy in the k3s or rke2 process
+const ProfileK3s = "k3s"
+
+// k3s journal source name and the units of the k3s and RKE2 servers and agents
+var (
+	K3sJournalName = "k3s Journal"
+	K3sUnits       = []string{"k3s.service", "k3s-agent.service", "rke2-server.service", "rke2-agent.service"}
+)
+
+// k3s Event regular expressions, k3s and RKE2 log in logrus' text format, i.e. time="..." level=info msg="..."
+var (
+	k3sStart             = regexp.MustCompile(`.*msg="Starting (?:k3s|rke2) (?:agent )?(?P<version>v[^ "]+).*`)
+	k3sInitialized       = regexp.MustCompile(`.*Started (?:(?:k3s|k3s-agent|rke2-server|rke2-agent)\.service - )?(?:Lightweight Kubernetes|Rancher Kubernetes Engine v2).*`)
+	k3sContainerdStart   = regexp.MustCompile(`.*msg="Running containerd .*`)
+	k3sContainerdRunning = regexp.MustCompile(`.*msg="containerd is now running".*`)
+	k3sTunnelConnected   = regexp.MustCompile(`.*msg="Remotedialer connected to proxy".*`)
+	k3sKubeletStart      = regexp.MustCompile(`.*msg="Running kubelet .*`)
+	k3sKubeProxyStart    = regexp.MustCompile(`.*msg="Running kube-proxy .*`)
+	k3sFlannelStart      = regexp.MustCompile(`.*msg="Starting flannel with backend (?P<backend>[a-z0-9-]+)".*`)
+)
+
+// k3sSource returns the journal of the k3s and RKE2 units, or the system log when the journal is not read directly,
+// i.e. on hosts with a syslog daemon or when replaying a recording
+func (m *Measurer) k3sSource() sources.RegexFinder {
+	if src, ok := m.GetSource(K3sJournalName); ok {
+		if finder, ok := src.(sources.RegexFinder); ok {
+			return finder
+		}
+	}
+	return m.syslogSource()
+}
+
+// The k3s profile replaces the containerd, kubelet, and kube-proxy events with the lines k3s and RKE2 log when starting
+// their embedded components, since there are no containerd or kubelet units, and adds the k3s start, tunnel, and flannel
+// events. The VPC CNI events are excluded since k3s runs flannel. RKE2 runs kube-proxy as a static pod and logs the
+// kubelet to its own file, so the kube-proxy start and the kubelet's own events are only found on k3s.
+func init() {
+	RegisterProfile(&Profile{
+		Name: ProfileK3s,
+		Sources: func(m *Measurer) []sources.Source {
+			if m.root != "" || !journal.Available() {
+				return nil
+			}
+			return []sources.Source{journal.New(lo.Map(K3sUnits, func(u string, _ int) string { return "--unit=" + u })...).WithName(K3sJournalName)}
+		},
+		ExcludeMetrics: []string{
+			"kubelet_initialized",
+			"vpc_cni_init_start",
+			"aws_node_start",
+			"vpc_cni_plugin_initialized",
+		},
+		Events: func(m *Measurer) []*sources.Event {
+			k3s := m.k3sSource()
+			return []*sources.Event{
+				{
+					Name:          "k3s Start",
+					Metric:        "k3s_start",
+					SrcName:       k3s.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					CommentFn:     sources.CommentCaptureGroups(k3sStart),
+					FindFn:        k3s.FindByRegex(k3sStart),
+				},
+				{
+					Name:          "Containerd Start",
+					Metric:        "conatinerd_start",
+					After:         []string{"k3s_start"},
+					SrcName:       k3s.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        k3s.FindByRegex(k3sContainerdStart),
+				},
+				{
+					Name:          "Containerd Initialized",
+					Metric:        "conatinerd_initialized",
+					After:         []string{"conatinerd_start"},
+					SrcName:       k3s.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        k3s.FindByRegex(k3sContainerdRunning),
+				},
+				{
+					// agents connect a websocket tunnel to the server before starting the kubelet
+					Name:          "k3s Tunnel Connected",
+					Metric:        "k3s_tunnel_connected",
+					After:         []string{"k3s_start"},
+					SrcName:       k3s.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        k3s.FindByRegex(k3sTunnelConnected),
+				},
+				{
+					Name:          "Kubelet Start",
+					Metric:        "kubelet_start",
+					After:         []string{"conatinerd_initialized"},
+					SrcName:       k3s.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        k3s.FindByRegex(k3sKubeletStart),
+				},
+				{
+					Name:          "Kube-Proxy Start",
+					Metric:        "kube_proxy_start",
+					After:         []string{"k3s_start"},
+					SrcName:       k3s.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        k3s.FindByRegex(k3sKubeProxyStart),
+				},
+				{
+					Name:          "Flannel Start",
+					Metric:        "flannel_start",
+					After:         []string{"k3s_start"},
+					SrcName:       k3s.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					CommentFn:     sources.CommentCaptureGroups(k3sFlannelStart),
+					FindFn:        k3s.FindByRegex(k3sFlannelStart),
+				},
+				{
+					// the units are Type=notify, so they are started once k3s or rke2 is ready
+					Name:          "k3s Initialized",
+					Metric:        "k3s_initialized",
+					After:         []string{"k3s_start"},
+					SrcName:       k3s.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        k3s.FindByRegex(k3sInitialized),
+				},
+			}
+		},
+	})
+}
@@ -0,0 +1,74 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/karpenter"
+)
+
+// ProfileKarpenter is the profile name for nodes launched by Karpenter
+const ProfileKarpenter = "karpenter"
+
+// The karpenter profile adds the NodeClaim lifecycle to the timeline, it requires the K8s clientset and node name
+func init() {
+	RegisterProfile(&Profile{
+		Name: ProfileKarpenter,
+		Sources: func(m *Measurer) []sources.Source {
+			if m.k8sClientset == nil || m.nodeName == "" {
+				return nil
+			}
+			return []sources.Source{karpenter.New(m.k8sClientset, m.nodeName)}
+		},
+		Events: func(m *Measurer) []*sources.Event {
+			src, ok := m.GetSource(karpenter.Name)
+			if !ok {
+				return nil
+			}
+			nodeClaims := src.(*karpenter.Source)
+			return []*sources.Event{
+				{
+					Name:          "NodeClaim Created",
+					Metric:        "nodeclaim_created",
+					SrcName:       karpenter.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        nodeClaims.FindNodeClaimCreationTime(),
+					CommentFn:     nodeClaims.CommentNodeClaimName(),
+				},
+				{
+					Name:          "NodeClaim Launched",
+					Metric:        "nodeclaim_launched",
+					SrcName:       karpenter.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        nodeClaims.FindNodeClaimCondition(karpenter.ConditionLaunched),
+				},
+				{
+					Name:          "NodeClaim Registered",
+					Metric:        "nodeclaim_registered",
+					SrcName:       karpenter.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        nodeClaims.FindNodeClaimCondition(karpenter.ConditionRegistered),
+				},
+				{
+					Name:          "NodeClaim Initialized",
+					Metric:        "nodeclaim_initialized",
+					SrcName:       karpenter.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        nodeClaims.FindNodeClaimCondition(karpenter.ConditionInitialized),
+				},
+			}
+		},
+	})
+}
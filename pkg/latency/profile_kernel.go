@@ -0,0 +1,85 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"regexp"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/kmsg"
+)
+
+// ProfileKernel is the profile name for timing kernel events from the kernel ring buffer
+const ProfileKernel = "kernel"
+
+// Kernel Event regular expressions
+var (
+	kernelInitDone       = regexp.MustCompile(`.*Freeing unused kernel.*memory.*`)
+	kernelNVMeAttached   = regexp.MustCompile(`.*nvme nvme[0-9]+: .*`)
+	kernelNetworkDriver  = regexp.MustCompile(`.*(ena [0-9a-f:.]+: Elastic Network Adapter|ena [0-9a-f:.]+: .*found at mem|eth0: .*[Ll]ink (is )?[Uu]p).*`)
+	kernelRootFSMounted  = regexp.MustCompile(`.*(EXT4-fs \(.*\): mounted filesystem|XFS \(.*\): Ending clean mount).*`)
+	kernelSystemdStarted = regexp.MustCompile(`.*systemd\[1\]: .*running in system mode.*`)
+)
+
+// The kernel profile reads /dev/kmsg which is available before syslog starts, so /dev/kmsg must be accessible (a privileged pod).
+func init() {
+	RegisterProfile(&Profile{
+		Name: ProfileKernel,
+		Sources: func(m *Measurer) []sources.Source {
+			return []sources.Source{kmsg.New(kmsg.DefaultPath)}
+		},
+		Events: func(m *Measurer) []*sources.Event {
+			src := kmsg.New(kmsg.DefaultPath)
+			return []*sources.Event{
+				{
+					Name:          "Kernel Init Done",
+					Metric:        "kernel_init_done",
+					SrcName:       kmsg.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        src.FindByRegex(kernelInitDone),
+				},
+				{
+					Name:          "NVMe Attached",
+					Metric:        "nvme_attached",
+					SrcName:       kmsg.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        src.FindByRegex(kernelNVMeAttached),
+					CommentFn:     sources.CommentMatchedLine(),
+				},
+				{
+					Name:          "Root FS Mounted",
+					Metric:        "root_fs_mounted",
+					SrcName:       kmsg.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        src.FindByRegex(kernelRootFSMounted),
+				},
+				{
+					Name:          "Network Driver Up",
+					Metric:        "network_driver_up",
+					SrcName:       kmsg.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        src.FindByRegex(kernelNetworkDriver),
+				},
+				{
+					Name:          "Systemd Start",
+					Metric:        "systemd_start",
+					SrcName:       kmsg.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        src.FindByRegex(kernelSystemdStarted),
+				},
+			}
+		},
+	})
+}
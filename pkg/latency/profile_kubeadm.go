@@ -0,0 +1,130 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"regexp"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/logfile"
+)
+
+// ProfileKubeadm is the profile name for self-managed nodes joined to the cluster with kubeadm join
+const ProfileKubeadm = "kubeadm"
+
+// kubeadm source name and log paths, kubeadm join is usually run from user data, so its output is in the cloud-init output log
+var (
+	KubeadmLogName  = "kubeadm"
+	KubeadmLogPaths = []string{"/var/log/cloud-init-output.log", "/var/log/kubeadm*.log"}
+)
+
+// kubeadm Event regular expressions, the join phases are only logged with timestamps at --v=1 or higher
+var (
+	// the kubelet unit of the kubeadm packages is described as "kubelet: The Kubernetes Node Agent"
+	kubeadmKubeletUnitStart       = regexp.MustCompile(`.*Starting (?:kubelet\.service - )?kubelet: The Kubernetes Node Agent.*`)
+	kubeadmKubeletUnitInitialized = regexp.MustCompile(`.*Started (?:kubelet\.service - )?kubelet: The Kubernetes Node Agent.*`)
+	kubeadmPreflight              = regexp.MustCompile(`.*\] \[preflight\] .*`)
+	kubeadmKubeletStart           = regexp.MustCompile(`.*\] \[kubelet-start\] .*`)
+	kubeadmJoinFinish             = regexp.MustCompile(`.*\] \[patchnode\] Uploading the CRI Socket information.*`)
+	kubeletTLSBootstrap           = regexp.MustCompile(`.*Using bootstrap kubeconfig to generate TLS client cert, key and kubeconfig file.*`)
+	kubeletTLSBootstrapped        = regexp.MustCompile(`.*kubernetes\.io/kube-apiserver-client-kubelet.*Certificate expiration is.*`)
+	// the kubelet logs that the network is not ready every sync until the CNI config is written
+	kubeletNetworkNotReady = regexp.MustCompile(`.*"?Container runtime network not ready"?.*`)
+)
+
+// The kubeadm profile adds the kubeadm join preflight, kubelet start, and finish phases from the kubeadm output, and the
+// kubelet's TLS bootstrap and the CNI being applied from the system log, so self-managed clusters get a meaningful timeline.
+// The kubelet start events match the kubelet unit of the kubeadm packages, which restarts until kubeadm join configures
+// it, so the last start is used.
+func init() {
+	RegisterProfile(&Profile{
+		Name: ProfileKubeadm,
+		Sources: func(m *Measurer) []sources.Source {
+			paths := lo.Map(KubeadmLogPaths, func(p string, _ int) string { return m.hostPath(p) })
+			return []sources.Source{logfile.New(KubeadmLogName, paths[0], klogTimestampFormat, klogTimestampLayout, paths[1:]...)}
+		},
+		Events: func(m *Measurer) []*sources.Event {
+			kubeadmLog := lo.Must(m.GetSource(KubeadmLogName)).(sources.RegexFinder)
+			syslog := m.syslogSource()
+			return []*sources.Event{
+				{
+					Name:          "Kubelet Start",
+					Metric:        "kubelet_start",
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorLast,
+					FindFn:        syslog.FindByRegex(kubeadmKubeletUnitStart),
+				},
+				{
+					Name:          "Kubelet Initialized",
+					Metric:        "kubelet_initialized",
+					After:         []string{"kubelet_start"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorLast,
+					FindFn:        syslog.FindByRegex(kubeadmKubeletUnitInitialized),
+				},
+				{
+					Name:          "kubeadm Join Start",
+					Metric:        "kubeadm_join_start",
+					SrcName:       KubeadmLogName,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        kubeadmLog.FindByRegex(kubeadmPreflight),
+				},
+				{
+					Name:          "kubeadm Kubelet Start",
+					Metric:        "kubeadm_kubelet_start",
+					After:         []string{"kubeadm_join_start"},
+					SrcName:       KubeadmLogName,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        kubeadmLog.FindByRegex(kubeadmKubeletStart),
+				},
+				{
+					Name:          "Kubelet TLS Bootstrap Start",
+					Metric:        "kubelet_tls_bootstrap_start",
+					After:         []string{"kubelet_start"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(kubeletTLSBootstrap),
+				},
+				{
+					Name:          "Kubelet TLS Bootstrapped",
+					Metric:        "kubelet_tls_bootstrapped",
+					After:         []string{"kubelet_tls_bootstrap_start"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(kubeletTLSBootstrapped),
+				},
+				{
+					Name:          "kubeadm Join Finish",
+					Metric:        "kubeadm_join_finish",
+					After:         []string{"kubeadm_kubelet_start"},
+					SrcName:       KubeadmLogName,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        kubeadmLog.FindByRegex(kubeadmJoinFinish),
+				},
+				{
+					// the last time the network was not ready is within a sync of when the CNI was applied
+					Name:          "CNI Applied",
+					Metric:        "cni_applied",
+					After:         []string{"kubelet_start"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorLast,
+					FindFn:        syslog.FindByRegex(kubeletNetworkNotReady),
+				},
+			}
+		},
+	})
+}
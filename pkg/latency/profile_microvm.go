@@ -0,0 +1,123 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"os"
+	"strings"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/procfs"
+)
+
+// ProfileMicroVM is the profile name for Fargate and other Firecracker microVMs, it is selected automatically when one is detected
+const ProfileMicroVM = "microvm"
+
+// MicroVM platforms returned by DetectMicroVM
+const (
+	PlatformFargate     = "fargate"
+	PlatformFirecracker = "firecracker"
+)
+
+// MicroVM detection signals
+var (
+	// FargateExecutionEnv is the value of $AWS_EXECUTION_ENV in ECS tasks on Fargate
+	FargateExecutionEnv = "AWS_ECS_FARGATE"
+	// FargateNodePrefix prefixes the names of the virtual nodes of EKS Fargate pods, i.e. "fargate-ip-192-168-1-10.ec2.internal"
+	FargateNodePrefix = "fargate-"
+	// KernelCmdlinePath is the kernel command line, Firecracker adds its virtio-mmio devices to it since it has no ACPI or PCI
+	KernelCmdlinePath = "/proc/cmdline"
+	firecrackerDevice = "virtio_mmio.device="
+)
+
+// DetectMicroVM returns the microVM platform the measurer is running on, or "" if it is not running in a microVM
+// The node name is checked for the EKS Fargate prefix if it is known.
+func DetectMicroVM(nodeName string) string {
+	if os.Getenv("AWS_EXECUTION_ENV") == FargateExecutionEnv || strings.HasPrefix(nodeName, FargateNodePrefix) {
+		return PlatformFargate
+	}
+	if cmdline, err := os.ReadFile(KernelCmdlinePath); err == nil && strings.Contains(string(cmdline), firecrackerDevice) {
+		return PlatformFirecracker
+	}
+	return ""
+}
+
+// A microVM runs a single pod or task with no host system log, cloud-init, or EC2 instance identity, so the microvm
+// profile excludes the default system log events and times the microVM's boot and the container's start from /proc.
+// The pod creation time of the K8s API is kept.
+func init() {
+	RegisterProfile(&Profile{
+		Name: ProfileMicroVM,
+		Sources: func(m *Measurer) []sources.Source {
+			// /proc is the live microVM's, so it is not read when replaying
+			if m.root != "" {
+				return nil
+			}
+			return []sources.Source{procfs.New(procfs.DefaultPath)}
+		},
+		ExcludeMetrics: []string{
+			ClockSteppedMetric,
+			HibernationResumedMetric,
+			"vm_initialized",
+			"network_start",
+			"network_ready",
+			"cloudinit_initial_start",
+			"cloudinit_config_start",
+			"cloudinit_final_start",
+			"cloudinit_user_data_start",
+			"cloudinit_user_data_finish",
+			"cloudinit_module_finish",
+			"cloudinit_final_finish",
+			"conatinerd_start",
+			"conatinerd_initialized",
+			"kubelet_start",
+			"kubelet_initialized",
+			"kubelet_authenticated",
+			"kubelet_registered",
+			"kube_proxy_start",
+			"vpc_cni_init_start",
+			"aws_node_start",
+			"vpc_cni_plugin_initialized",
+			"kube_apiserver_throttled",
+			"node_ready",
+			"pod_ready",
+		},
+		Events: func(m *Measurer) []*sources.Event {
+			src, ok := m.GetSource(procfs.Name)
+			if !ok {
+				return nil
+			}
+			proc := src.(*procfs.Source)
+			return []*sources.Event{
+				{
+					Name:          "MicroVM Boot",
+					Metric:        "microvm_boot",
+					SrcName:       procfs.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        proc.FindBootTime(),
+				},
+				{
+					Name:          "Container Start",
+					Metric:        "container_start",
+					After:         []string{"microvm_boot"},
+					SrcName:       procfs.Name,
+					Terminal:      true,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        proc.FindProcessStart(1),
+				},
+			}
+		},
+	})
+}
@@ -0,0 +1,62 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/npd"
+)
+
+// ProfileNPD is the profile name for nodes running Node Problem Detector
+const ProfileNPD = "npd"
+
+// The npd profile adds the problems Node Problem Detector reported on the node to the timeline, so kernel or runtime
+// problems give context to outlier boots, it requires the K8s clientset and node name
+func init() {
+	RegisterProfile(&Profile{
+		Name: ProfileNPD,
+		Sources: func(m *Measurer) []sources.Source {
+			if m.k8sClientset == nil || m.nodeName == "" {
+				return nil
+			}
+			return []sources.Source{npd.New(m.k8sClientset, m.nodeName)}
+		},
+		Events: func(m *Measurer) []*sources.Event {
+			src, ok := m.GetSource(npd.Name)
+			if !ok {
+				return nil
+			}
+			problems := src.(*npd.Source)
+			return []*sources.Event{
+				{
+					Name:          "Node Problem",
+					Metric:        "node_problem",
+					SrcName:       npd.Name,
+					MatchSelector: sources.EventMatchSelectorAll,
+					FindFn:        problems.FindProblemEvents(),
+					CommentFn:     problems.CommentProblem(),
+				},
+				{
+					Name:          "Node Problem Condition",
+					Metric:        "node_problem_condition",
+					SrcName:       npd.Name,
+					MatchSelector: sources.EventMatchSelectorAll,
+					FindFn:        problems.FindProblemConditions(),
+					CommentFn:     problems.CommentProblem(),
+				},
+			}
+		},
+	})
+}
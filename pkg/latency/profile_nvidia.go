@@ -0,0 +1,79 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"regexp"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// ProfileNVIDIA is the profile name for GPU nodes running the NVIDIA driver, container toolkit, and device plugin
+const ProfileNVIDIA = "nvidia"
+
+// NVIDIADevicePluginContainer is the container name of the NVIDIA k8s-device-plugin daemonset
+var NVIDIADevicePluginContainer = "nvidia-device-plugin-ctr"
+
+// NVIDIA Event regular expressions
+var (
+	nvidiaDriverLoaded           = regexp.MustCompile(`.*NVRM: loading NVIDIA UNIX .*Kernel Module +(?P<version>[0-9.]+).*`)
+	nvidiaToolkitConfigured      = regexp.MustCompile(`.*(?:msg="Wrote updated config to (?P<config>[^"]+)"|msg="Generated CDI spec).*`)
+	nvidiaDevicePluginRegistered = regexp.MustCompile(`.*"Got registration request from device plugin with resource" resourceName="(?P<resource>nvidia\.com/[^"]+)".*`)
+)
+
+// The nvidia profile adds the GPU driver, container toolkit, and device plugin events which GPU nodes wait on before
+// GPUs are allocatable, from the kernel, nvidia-ctk, containerd, and kubelet lines of the system log.
+func init() {
+	RegisterProfile(&Profile{
+		Name: ProfileNVIDIA,
+		Events: func(m *Measurer) []*sources.Event {
+			syslog := m.syslogSource()
+			return []*sources.Event{
+				{
+					Name:          "NVIDIA Driver Loaded",
+					Metric:        "nvidia_driver_loaded",
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					CommentFn:     sources.CommentCaptureGroups(nvidiaDriverLoaded),
+					FindFn:        syslog.FindByRegex(nvidiaDriverLoaded),
+				},
+				{
+					Name:          "NVIDIA Container Toolkit Configured",
+					Metric:        "nvidia_container_toolkit_configured",
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					CommentFn:     sources.CommentCaptureGroups(nvidiaToolkitConfigured),
+					FindFn:        syslog.FindByRegex(nvidiaToolkitConfigured),
+				},
+				{
+					Name:          "NVIDIA Device Plugin Start",
+					Metric:        "nvidia_device_plugin_start",
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(containerStartRegex(NVIDIADevicePluginContainer)),
+				},
+				{
+					Name:          "NVIDIA Device Plugin Registered",
+					Metric:        "nvidia_device_plugin_registered",
+					After:         []string{"nvidia_driver_loaded", "nvidia_device_plugin_start"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					CommentFn:     sources.CommentCaptureGroups(nvidiaDevicePluginRegistered),
+					FindFn:        syslog.FindByRegex(nvidiaDevicePluginRegistered),
+				},
+			}
+		},
+	})
+}
@@ -0,0 +1,132 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"regexp"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// ProfileOpenShift is the profile name for OpenShift nodes running Red Hat CoreOS with the CRI-O runtime
+const ProfileOpenShift = "openshift"
+
+// OpenShift namespaces and containers of the machine config daemon and the OVN-Kubernetes CNI
+var (
+	OpenShiftMCDNamespace = "openshift-machine-config-operator"
+	OpenShiftMCDContainer = "machine-config-daemon"
+	OpenShiftCNINamespace = "openshift-ovn-kubernetes"
+	OpenShiftCNIContainer = "ovnkube-controller"
+)
+
+// OpenShift Event regular expressions, systemd logs oneshot units as "Finished" on newer versions and "Started" on older ones
+var (
+	mcdFirstbootStart           = regexp.MustCompile(`.*Starting (?:machine-config-daemon-firstboot\.service - )?Machine Config Daemon Firstboot.*`)
+	mcdFirstbootFinish          = regexp.MustCompile(`.*(?:Finished|Started) (?:machine-config-daemon-firstboot\.service - )?Machine Config Daemon Firstboot.*`)
+	crioStart                   = regexp.MustCompile(`.*Starting (?:crio\.service - )?Container Runtime Interface for OCI \(CRI-O\).*`)
+	crioInitialized             = regexp.MustCompile(`.*Started (?:crio\.service - )?Container Runtime Interface for OCI \(CRI-O\).*`)
+	openShiftKubeletStart       = regexp.MustCompile(`.*Starting (?:kubelet\.service - )?Kubernetes Kubelet.*`)
+	openShiftKubeletInitialized = regexp.MustCompile(`.*Started (?:kubelet\.service - )?Kubernetes Kubelet.*`)
+)
+
+// The openshift profile adds the Ignition stages in the initramfs and the machine config daemon's firstboot, which
+// applies the MachineConfig and reboots before the kubelet starts, replaces the containerd events with the CRI-O unit,
+// and replaces the kube-proxy and VPC CNI container starts with the machine config daemon and OVN-Kubernetes container
+// starts from CRI-O's log lines. Red Hat CoreOS only writes the journal, which is persistent, so the events before the
+// firstboot reboot are found.
+func init() {
+	RegisterProfile(&Profile{
+		Name: ProfileOpenShift,
+		Sources: func(m *Measurer) []sources.Source {
+			return []sources.Source{m.ignitionSource()}
+		},
+		ExcludeMetrics: []string{
+			"conatinerd_start",
+			"conatinerd_initialized",
+			"kube_proxy_start",
+			"vpc_cni_init_start",
+			"aws_node_start",
+			"vpc_cni_plugin_initialized",
+		},
+		Events: func(m *Measurer) []*sources.Event {
+			syslog := m.syslogSource()
+			return append(m.ignitionEvents(), []*sources.Event{
+				{
+					Name:          "MachineConfig Firstboot Start",
+					Metric:        "machineconfig_firstboot_start",
+					After:         []string{"ignition_complete"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(mcdFirstbootStart),
+				},
+				{
+					Name:          "MachineConfig Firstboot Finish",
+					Metric:        "machineconfig_firstboot_finish",
+					After:         []string{"machineconfig_firstboot_start"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(mcdFirstbootFinish),
+				},
+				{
+					// CRI-O also starts before the firstboot reboot, the last start is the one the kubelet connects to
+					Name:          "CRI-O Start",
+					Metric:        "crio_start",
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorLast,
+					FindFn:        syslog.FindByRegex(crioStart),
+				},
+				{
+					Name:          "CRI-O Initialized",
+					Metric:        "crio_initialized",
+					After:         []string{"crio_start"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorLast,
+					FindFn:        syslog.FindByRegex(crioInitialized),
+				},
+				{
+					Name:          "Kubelet Start",
+					Metric:        "kubelet_start",
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(openShiftKubeletStart),
+				},
+				{
+					Name:          "Kubelet Initialized",
+					Metric:        "kubelet_initialized",
+					After:         []string{"kubelet_start"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(openShiftKubeletInitialized),
+				},
+				{
+					Name:          "Machine Config Daemon Start",
+					Metric:        "machine_config_daemon_start",
+					After:         []string{"kubelet_start"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(crioContainerStartRegex(OpenShiftMCDNamespace, OpenShiftMCDContainer)),
+				},
+				{
+					Name:          "OVN-Kubernetes Start",
+					Metric:        "ovn_kubernetes_start",
+					After:         []string{"kubelet_start"},
+					SrcName:       syslog.Name(),
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        syslog.FindByRegex(crioContainerStartRegex(OpenShiftCNINamespace, OpenShiftCNIContainer)),
+				},
+			}...)
+		},
+	})
+}
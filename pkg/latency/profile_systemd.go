@@ -0,0 +1,59 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/systemd"
+)
+
+// ProfileSystemd is the profile name for reading containerd and kubelet unit timestamps from systemd over D-Bus
+const ProfileSystemd = "systemd"
+
+// The systemd profile replaces the containerd and kubelet start log regexes with microsecond precision unit timestamps
+func init() {
+	RegisterProfile(&Profile{
+		Name: ProfileSystemd,
+		Sources: func(m *Measurer) []sources.Source {
+			return []sources.Source{systemd.New()}
+		},
+		Events: func(m *Measurer) []*sources.Event {
+			src := systemd.New()
+			return []*sources.Event{
+				{
+					Name:          "Containerd Start",
+					Metric:        "conatinerd_start",
+					SrcName:       systemd.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        src.FindByUnitProperty("containerd.service", systemd.ExecMainStartTimestamp),
+				},
+				{
+					Name:          "Containerd Initialized",
+					Metric:        "conatinerd_initialized",
+					SrcName:       systemd.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        src.FindByUnitProperty("containerd.service", systemd.ActiveEnterTimestamp),
+				},
+				{
+					Name:          "Kubelet Start",
+					Metric:        "kubelet_start",
+					SrcName:       systemd.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        src.FindByUnitProperty("kubelet.service", systemd.ExecMainStartTimestamp),
+				},
+			}
+		},
+	})
+}
@@ -0,0 +1,130 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	talossrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/talos"
+)
+
+// ProfileTalos is the profile name for Talos Linux nodes, which are measured from the machine API
+const ProfileTalos = "talos"
+
+// Talos Event regular expressions, machined logs service state changes as "service[<id>](<state>): <message>"
+var (
+	talosStart          = regexp.MustCompile(`.*\[talos\] (?:\[initramfs\] )?booting Talos (?P<version>v[0-9][0-9A-Za-z.+-]*).*`)
+	talosBootSequence   = regexp.MustCompile(`.*\[talos\] boot sequence: done.*`)
+	talosServiceStr     = `.*\[talos\] service\[%s\]\(%s\)%s.*`
+	talosHealthCheckStr = ": Health check successful"
+)
+
+// talosServiceRegex matches machined's log line of the service entering the state
+func talosServiceRegex(service string, state string, message string) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(talosServiceStr, regexp.QuoteMeta(service), regexp.QuoteMeta(state), regexp.QuoteMeta(message)))
+}
+
+// The talos profile replaces the default events, which search the system log that Talos does not have, with machined's
+// boot sequence and its CRI (containerd) and kubelet service states from the kernel log of the Talos machine API. The
+// node's machine API is set with --talos-node, or talosctl's default nodes of the talosconfig are read.
+func init() {
+	RegisterProfile(&Profile{
+		Name: ProfileTalos,
+		Sources: func(m *Measurer) []sources.Source {
+			if m.talosSource != nil {
+				return nil
+			}
+			return []sources.Source{talossrc.New("")}
+		},
+		ExcludeMetrics: []string{
+			"vm_initialized",
+			"network_start",
+			"network_ready",
+			"cloudinit_initial_start",
+			"cloudinit_config_start",
+			"cloudinit_final_start",
+			"cloudinit_user_data_start",
+			"cloudinit_user_data_finish",
+			"cloudinit_module_finish",
+			"cloudinit_final_finish",
+			"kubelet_authenticated",
+			"kubelet_registered",
+			"kube_proxy_start",
+			"vpc_cni_init_start",
+			"aws_node_start",
+			"vpc_cni_plugin_initialized",
+			"kube_apiserver_throttled",
+			"node_ready",
+			"pod_ready",
+		},
+		Events: func(m *Measurer) []*sources.Event {
+			talos := lo.Must(m.GetSource(talossrc.Name)).(*talossrc.Source)
+			return []*sources.Event{
+				{
+					Name:          "Talos Start",
+					Metric:        "talos_start",
+					SrcName:       talossrc.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					CommentFn:     sources.CommentCaptureGroups(talosStart),
+					FindFn:        talos.FindByRegex(talosStart),
+				},
+				{
+					Name:          "Containerd Start",
+					Metric:        "conatinerd_start",
+					SrcName:       talossrc.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        talos.FindByRegex(talosServiceRegex("cri", "Starting", "")),
+				},
+				{
+					Name:          "Containerd Initialized",
+					Metric:        "conatinerd_initialized",
+					After:         []string{"conatinerd_start"},
+					SrcName:       talossrc.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        talos.FindByRegex(talosServiceRegex("cri", "Running", talosHealthCheckStr)),
+				},
+				{
+					Name:          "Kubelet Start",
+					Metric:        "kubelet_start",
+					After:         []string{"conatinerd_initialized"},
+					SrcName:       talossrc.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        talos.FindByRegex(talosServiceRegex("kubelet", "Starting", "")),
+				},
+				{
+					Name:          "Kubelet Initialized",
+					Metric:        "kubelet_initialized",
+					After:         []string{"kubelet_start"},
+					SrcName:       talossrc.Name,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        talos.FindByRegex(talosServiceRegex("kubelet", "Running", talosHealthCheckStr)),
+				},
+				{
+					Name:          "Talos Boot Finished",
+					Metric:        "talos_boot_finished",
+					After:         []string{"kubelet_start"},
+					SrcName:       talossrc.Name,
+					Terminal:      true,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        talos.FindByRegex(talosBootSequence),
+				},
+			}
+		},
+	})
+}
@@ -0,0 +1,166 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/eventlog"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/logfile"
+)
+
+// ProfileWindows is the profile name for Windows nodes, it is selected automatically when running on Windows
+const ProfileWindows = "windows"
+
+// Windows source names and log paths
+var (
+	WindowsKubeletLogName   = "Kubelet Log"
+	WindowsKubeletLogPath   = `C:\ProgramData\kubernetes\logs\kubelet\kubelet.log*`
+	WindowsEC2LaunchLogName = "EC2Launch"
+	WindowsEC2LaunchLogPath = `C:\ProgramData\Amazon\EC2Launch\log\agent.log*`
+
+	// klog timestamps do not include the year, i.e. "I0130 19:03:22.416123"
+	klogTimestampFormat = regexp.MustCompile(`[0-9]{4} [0-9]{2}:[0-9]{2}:[0-9]{2}\.[0-9]{6}`)
+	klogTimestampLayout = "0102 15:04:05.000000 2006"
+	// EC2Launch v2 timestamps, i.e. "2023-01-30 19:03:15"
+	ec2LaunchTimestampFormat = regexp.MustCompile(`[0-9]{4}-[0-9]{2}-[0-9]{2} [0-9]{2}:[0-9]{2}:[0-9]{2}`)
+	ec2LaunchTimestampLayout = "2006-01-02 15:04:05"
+)
+
+// Windows Event regular expressions
+var (
+	windowsVMInit            = regexp.MustCompile(`.*Microsoft-Windows-Kernel-General 12: The operating system started.*`)
+	windowsEC2LaunchStart    = regexp.MustCompile(`.*Info: .*`)
+	windowsServiceRunningStr = `.*Service Control Manager 7036: The %s service entered the running state.*`
+	windowsContainerdStart   = regexp.MustCompile(fmt.Sprintf(windowsServiceRunningStr, "containerd"))
+	windowsKubeletStart      = regexp.MustCompile(fmt.Sprintf(windowsServiceRunningStr, "kubelet"))
+	windowsKubeProxyStart    = regexp.MustCompile(fmt.Sprintf(windowsServiceRunningStr, "kube-proxy"))
+)
+
+// Windows nodes do not write /var/log/messages, so events are read from the System event log, the kubelet log, and the EC2Launch v2 log.
+// The event log is retained across reboots, so the last match is used for boot and service events.
+func init() {
+	RegisterProfile(&Profile{
+		Name: ProfileWindows,
+		Sources: func(m *Measurer) []sources.Source {
+			return []sources.Source{
+				eventlog.New(eventlog.DefaultLog, ""),
+				logfile.New(WindowsKubeletLogName, WindowsKubeletLogPath, klogTimestampFormat, klogTimestampLayout),
+				logfile.New(WindowsEC2LaunchLogName, WindowsEC2LaunchLogPath, ec2LaunchTimestampFormat, ec2LaunchTimestampLayout),
+			}
+		},
+		ExcludeMetrics: []string{
+			"network_start",
+			"network_ready",
+			"cloudinit_initial_start",
+			"cloudinit_config_start",
+			"cloudinit_final_start",
+			"cloudinit_final_finish",
+			"hibernation_resumed",
+			"conatinerd_initialized",
+			"kubelet_initialized",
+			"vpc_cni_init_start",
+			"aws_node_start",
+			"vpc_cni_plugin_initialized",
+		},
+		Events: func(m *Measurer) []*sources.Event {
+			eventLog := lo.Must(m.GetSource(eventlog.Name)).(sources.RegexFinder)
+			kubeletLog := lo.Must(m.GetSource(WindowsKubeletLogName)).(sources.RegexFinder)
+			ec2LaunchLog := lo.Must(m.GetSource(WindowsEC2LaunchLogName)).(sources.RegexFinder)
+			podReady := m.defaultEventParams().podReadyRegex()
+			return []*sources.Event{
+				{
+					Name:          "VM Initialized",
+					Metric:        "vm_initialized",
+					SrcName:       eventlog.Name,
+					MatchSelector: sources.EventMatchSelectorLast,
+					FindFn:        eventLog.FindByRegex(windowsVMInit),
+				},
+				{
+					Name:          "EC2Launch Start",
+					Metric:        "ec2launch_start",
+					SrcName:       WindowsEC2LaunchLogName,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        ec2LaunchLog.FindByRegex(windowsEC2LaunchStart),
+				},
+				{
+					Name:          "Containerd Start",
+					Metric:        "conatinerd_start",
+					SrcName:       eventlog.Name,
+					MatchSelector: sources.EventMatchSelectorLast,
+					FindFn:        eventLog.FindByRegex(windowsContainerdStart),
+				},
+				{
+					Name:          "Kubelet Start",
+					Metric:        "kubelet_start",
+					SrcName:       eventlog.Name,
+					MatchSelector: sources.EventMatchSelectorLast,
+					FindFn:        eventLog.FindByRegex(windowsKubeletStart),
+				},
+				{
+					Name:          "Kubelet Authenticated",
+					Metric:        "kubelet_authenticated",
+					SrcName:       WindowsKubeletLogName,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					CommentFn:     sources.CommentCaptureGroups(kubeletAuthenticated),
+					FindFn:        kubeletLog.FindByRegex(kubeletAuthenticated),
+				},
+				{
+					Name:          "Kubelet Registered",
+					Metric:        "kubelet_registered",
+					SrcName:       WindowsKubeletLogName,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        kubeletLog.FindByRegex(kubeletRegistered),
+				},
+				{
+					Name:          "Kube-Proxy Start",
+					Metric:        "kube_proxy_start",
+					SrcName:       eventlog.Name,
+					MatchSelector: sources.EventMatchSelectorLast,
+					FindFn:        eventLog.FindByRegex(windowsKubeProxyStart),
+				},
+				{
+					Name:          "Kube-APIServer Throttled",
+					Metric:        "kube_apiserver_throttled",
+					SrcName:       WindowsKubeletLogName,
+					MatchSelector: sources.EventMatchSelectorAll,
+					CommentFn:     sources.CommentCaptureGroups(throttled),
+					FindFn:        kubeletLog.FindByRegex(throttled),
+				},
+				{
+					Name:          "Node Ready",
+					Metric:        "node_ready",
+					SrcName:       WindowsKubeletLogName,
+					Terminal:      true,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					FindFn:        kubeletLog.FindByRegex(nodeReady),
+				},
+				{
+					Name:          "Pod Ready",
+					Metric:        "pod_ready",
+					SrcName:       WindowsKubeletLogName,
+					Terminal:      true,
+					MatchSelector: sources.EventMatchSelectorFirst,
+					CommentFn:     sources.CommentCaptureGroups(podReady),
+					FindFn:        kubeletLog.FindByRegex(podReady),
+				},
+			}
+		},
+	})
+}
@@ -0,0 +1,99 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// Profile customizes the default sources and events for a node OS, container runtime, or add-on
+type Profile struct {
+	Name string
+	// Sources returns sources that are registered after the default sources, a source with the same name as a default source replaces it
+	Sources func(m *Measurer) []sources.Source
+	// Events returns events that are registered with the default events, an event with the same metric as a default event replaces it
+	Events func(m *Measurer) []*sources.Event
+	// ExcludeMetrics are the metrics of default events that do not apply to the profile
+	ExcludeMetrics []string
+}
+
+// profiles are the built-in profiles that can be selected by name
+var profiles = map[string]*Profile{}
+
+// RegisterProfile adds a profile that can be selected by name with GetProfile
+func RegisterProfile(profile *Profile) {
+	profiles[profile.Name] = profile
+}
+
+// GetProfile looks up a built-in or registered profile by name
+func GetProfile(name string) (*Profile, bool) {
+	profile, ok := profiles[name]
+	return profile, ok
+}
+
+// ProfileNames returns the sorted names of all built-in and registered profiles
+func ProfileNames() []string {
+	names := lo.Keys(profiles)
+	sort.Strings(names)
+	return names
+}
+
+// WithProfiles is a builder func that adds profiles by name which customize the default sources and events
+func (m *Measurer) WithProfiles(names ...string) (*Measurer, error) {
+	for _, name := range names {
+		profile, ok := GetProfile(name)
+		if !ok {
+			return m, fmt.Errorf("unknown profile \"%s\", available profiles are %v", name, ProfileNames())
+		}
+		m.profiles = append(m.profiles, profile)
+	}
+	return m, nil
+}
+
+// applyProfileEvents excludes and replaces default events based on the Measurer's profiles
+func (m *Measurer) applyProfileEvents(events []*sources.Event) []*sources.Event {
+	for _, profile := range m.profiles {
+		events = lo.Reject(events, func(e *sources.Event, _ int) bool { return lo.Contains(profile.ExcludeMetrics, e.Metric) })
+		if profile.Events == nil {
+			continue
+		}
+		events = replaceEventsByMetric(events, profile.Events(m))
+	}
+	return events
+}
+
+// replaceEventsByMetric removes the events with the same metric as a replacement and then appends the replacements
+func replaceEventsByMetric(events []*sources.Event, replacements []*sources.Event) []*sources.Event {
+	replacementMetrics := lo.Map(replacements, func(e *sources.Event, _ int) string { return e.Metric })
+	events = lo.Reject(events, func(e *sources.Event, _ int) bool { return lo.Contains(replacementMetrics, e.Metric) })
+	return append(events, replacements...)
+}
+
+// firstExistingPath returns the first path that exists or the last path if none exist
+// This is helpful for profiles where the host filesystem may be mounted at different locations
+func firstExistingPath(paths ...string) string {
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return paths[len(paths)-1]
+}
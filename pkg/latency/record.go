@@ -0,0 +1,228 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/awsnode"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/cloudinit"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/journal"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/messages"
+)
+
+// Recording archive layout, log files are stored under their absolute path in the files directory and IMDS responses
+// under their IMDS path in the imds directory, i.e. "files/var/log/messages" and "imds/dynamic/instance-identity/document"
+const (
+	RecordManifestName = "manifest.json"
+	recordFilesDir     = "files"
+	recordIMDSDir      = "imds"
+	recordJournalName  = "journal.log"
+)
+
+var (
+	// RecordPaths are the log files recorded by default, the logs of the default sources and the VPC CNI's ipamd log
+	RecordPaths = []string{
+		messages.DefaultPath,
+		messages.SyslogPath,
+		awsnode.DefaultPath,
+		"/var/log/aws-routed-eni/*.log",
+		cloudinit.DefaultPath,
+		cloudinit.DefaultStatusPath,
+	}
+	// RecordIMDSPaths are the IMDS responses recorded by default, paths which are not available, i.e. the spot
+	// instance-action before an interruption notice, are skipped
+	RecordIMDSPaths = []string{
+		"/dynamic/instance-identity/document",
+		"/meta-data/hostname",
+		"/meta-data/iam/info",
+		"/meta-data/autoscaling/target-lifecycle-state",
+		"/meta-data/spot/instance-action",
+		"/meta-data/events/recommendations/rebalance",
+	}
+)
+
+// RecordOptions configures the inputs which are recorded
+type RecordOptions struct {
+	// Paths are more log files or globs to record in addition to RecordPaths
+	Paths []string
+	// IMDS is the client to record the IMDS responses with, they are not recorded if it is nil
+	IMDS *imds.Client
+	// Version is the version of node-latency-for-k8s which recorded the inputs
+	Version string
+}
+
+// RecordManifest describes the inputs of a recording
+type RecordManifest struct {
+	Hostname   string         `json:"hostname"`
+	RecordedAt time.Time      `json:"recordedAt"`
+	Version    string         `json:"version,omitempty"`
+	Files      []RecordedFile `json:"files"`
+	// Skipped are the inputs which were not recorded and why, i.e. an IMDS path which was not found
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// RecordedFile is an input stored in the recording
+type RecordedFile struct {
+	// Source is the log file path or IMDS path the input was recorded from
+	Source  string    `json:"source"`
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// Record collects the raw inputs of a measurement, the logs and IMDS responses, into a tar.gz written to w with a manifest
+// so the boot can be analyzed later or off of the node. The journal is recorded on journald-only hosts, like the journal source.
+func Record(ctx context.Context, w io.Writer, opts RecordOptions) (*RecordManifest, error) {
+	hostname, _ := os.Hostname()
+	manifest := &RecordManifest{
+		Hostname:   hostname,
+		RecordedAt: time.Now().UTC(),
+		Version:    opts.Version,
+	}
+	gzWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzWriter)
+	var errs error
+	for _, pattern := range lo.Uniq(append(append([]string{}, RecordPaths...), opts.Paths...)) {
+		files, err := (&sources.LogReader{Path: pattern, Glob: true}).Files()
+		if err != nil {
+			manifest.Skipped = append(manifest.Skipped, err.Error())
+			continue
+		}
+		for _, file := range files {
+			if err := manifest.addFile(tarWriter, file); err != nil {
+				errs = multierr.Append(errs, err)
+			}
+		}
+	}
+	logs, _ := filepath.Glob(messages.DefaultPath)
+	syslogs, _ := filepath.Glob(messages.SyslogPath)
+	if len(logs)+len(syslogs) == 0 && journal.Available() {
+		entries, err := journal.New().Read(ctx)
+		if err != nil {
+			manifest.Skipped = append(manifest.Skipped, err.Error())
+		} else {
+			errs = multierr.Append(errs, manifest.add(tarWriter, journal.Command, archivePath(recordFilesDir, recordJournalName), entries, manifest.RecordedAt))
+		}
+	}
+	if opts.IMDS != nil {
+		for _, imdsPath := range RecordIMDSPaths {
+			response, err := getIMDS(ctx, opts.IMDS, imdsPath)
+			if err != nil {
+				manifest.Skipped = append(manifest.Skipped, err.Error())
+				continue
+			}
+			errs = multierr.Append(errs, manifest.add(tarWriter, imdsPath, archivePath(recordIMDSDir, imdsPath), response, manifest.RecordedAt))
+		}
+	}
+	manifestJSON, err := json.MarshalIndent(manifest, "", "    ")
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal record manifest: %w", err)
+	}
+	errs = multierr.Append(errs, writeTarEntry(tarWriter, RecordManifestName, manifestJSON, manifest.RecordedAt))
+	errs = multierr.Append(errs, tarWriter.Close())
+	errs = multierr.Append(errs, gzWriter.Close())
+	return manifest, errs
+}
+
+// addFile stores the log file in the recording under its absolute path
+func (r *RecordManifest) addFile(tarWriter *tar.Writer, file string) error {
+	stat, err := os.Stat(file)
+	if err != nil {
+		return fmt.Errorf("unable to stat %s: %w", file, err)
+	}
+	contents, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %w", file, err)
+	}
+	absPath, err := filepath.Abs(file)
+	if err != nil {
+		return fmt.Errorf("unable to resolve the absolute path of %s: %w", file, err)
+	}
+	return r.add(tarWriter, file, archivePath(recordFilesDir, filepath.ToSlash(absPath)), contents, stat.ModTime())
+}
+
+// add stores the input in the recording and the manifest
+func (r *RecordManifest) add(tarWriter *tar.Writer, source string, name string, contents []byte, modTime time.Time) error {
+	if err := writeTarEntry(tarWriter, name, contents, modTime); err != nil {
+		return err
+	}
+	r.Files = append(r.Files, RecordedFile{
+		Source:  source,
+		Name:    name,
+		Size:    int64(len(contents)),
+		ModTime: modTime.UTC(),
+	})
+	return nil
+}
+
+// writeTarEntry writes a regular file to the tar archive
+func writeTarEntry(tarWriter *tar.Writer, name string, contents []byte, modTime time.Time) error {
+	if err := tarWriter.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(contents)),
+		ModTime: modTime,
+	}); err != nil {
+		return fmt.Errorf("unable to write tar header for %s: %w", name, err)
+	}
+	if _, err := tarWriter.Write(contents); err != nil {
+		return fmt.Errorf("unable to write %s to tar: %w", name, err)
+	}
+	return nil
+}
+
+// getIMDS retrieves the raw IMDS response of the dynamic data or metadata path
+func getIMDS(ctx context.Context, client *imds.Client, imdsPath string) ([]byte, error) {
+	var content io.ReadCloser
+	if strings.HasPrefix(imdsPath, "/dynamic") {
+		out, err := client.GetDynamicData(ctx, &imds.GetDynamicDataInput{Path: strings.TrimPrefix(imdsPath, "/dynamic")})
+		if err != nil {
+			return nil, fmt.Errorf("unable to retrieve IMDS path %s: %w", imdsPath, err)
+		}
+		content = out.Content
+	} else {
+		out, err := client.GetMetadata(ctx, &imds.GetMetadataInput{Path: strings.TrimPrefix(imdsPath, "/meta-data")})
+		if err != nil {
+			return nil, fmt.Errorf("unable to retrieve IMDS path %s: %w", imdsPath, err)
+		}
+		content = out.Content
+	}
+	defer content.Close()
+	response, err := io.ReadAll(content)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read IMDS path %s: %w", imdsPath, err)
+	}
+	return response, nil
+}
+
+// archivePath joins the archive directory and name with forward slashes
+func archivePath(dir string, name string) string {
+	return dir + "/" + strings.TrimPrefix(name, "/")
+}
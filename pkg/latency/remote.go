@@ -0,0 +1,186 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/journal"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/messages"
+)
+
+// Remote collection consts, the SSM agent uploads the output of the run command to S3 under the command and instance IDs
+const (
+	SSMDocumentName   = "AWS-RunShellScript"
+	ssmOutputPath     = "awsrunShellScript/0.awsrunShellScript/stdout"
+	imdsScriptAddress = "http://169.254.169.254/latest"
+	// DefaultSSHCommand is the ssh client executed to collect recordings over SSH
+	DefaultSSHCommand = "ssh"
+)
+
+// SSMCollectOptions configures collecting recordings from instances with SSM Run Command
+type SSMCollectOptions struct {
+	InstanceIDs []string
+	// Bucket receives the run command's output, which is truncated in the SSM API past 24,000 characters
+	Bucket string
+	Prefix string
+	// Timeout is how long to wait for the run command on every instance, default: 5 minutes
+	Timeout time.Duration
+	// PollInterval is the delay in-between checks of the run command's status, default: 5 seconds
+	PollInterval time.Duration
+}
+
+// RemoteRecording is a recording collected from a remote node, or the error collecting it
+type RemoteRecording struct {
+	// Target is the instance ID or SSH host the recording was collected from
+	Target    string
+	Recording []byte
+	Err       error
+}
+
+// RecordScript returns a shell script that records the logs and IMDS responses of the node like Record, and writes the
+// tar.gz base64 encoded to stdout, so a node can be recorded remotely without node-latency-for-k8s installed on it.
+// The script must run as root to read the logs.
+func RecordScript() string {
+	var script strings.Builder
+	script.WriteString("set -e\n")
+	script.WriteString("d=$(mktemp -d)\n")
+	script.WriteString("trap 'rm -rf \"$d\"' EXIT\n")
+	fmt.Fprintf(&script, "mkdir -p \"$d/%s\" \"$d/%s\"\n", recordFilesDir, recordIMDSDir)
+	// the globs are left unquoted so the shell expands them, the default paths have no spaces
+	fmt.Fprintf(&script, "for f in %s; do if [ -f \"$f\" ]; then mkdir -p \"$d/%s$(dirname \"$f\")\"; cp -p \"$f\" \"$d/%s$f\"; fi; done\n",
+		strings.Join(RecordPaths, " "), recordFilesDir, recordFilesDir)
+	// the journal is only recorded on journald-only hosts, like the journal source
+	fmt.Fprintf(&script, "if [ -z \"$(ls -d %s %s 2>/dev/null)\" ] && command -v %s >/dev/null 2>&1; then %s %s > \"$d/%s/%s\"; fi\n",
+		messages.DefaultPath, messages.SyslogPath, journal.Command, journal.Command, strings.Join(journal.DefaultArgs, " "), recordFilesDir, recordJournalName)
+	fmt.Fprintf(&script, "t=$(curl -sf -X PUT -H 'X-aws-ec2-metadata-token-ttl-seconds: 300' %s/api/token || true)\n", imdsScriptAddress)
+	fmt.Fprintf(&script, "for p in %s; do mkdir -p \"$d/%s$(dirname \"$p\")\"; curl -sf -H \"X-aws-ec2-metadata-token: $t\" -o \"$d/%s$p\" \"%s$p\" || rm -f \"$d/%s$p\"; done\n",
+		strings.Join(RecordIMDSPaths, " "), recordIMDSDir, recordIMDSDir, imdsScriptAddress, recordIMDSDir)
+	fmt.Fprintf(&script, "printf '{\"hostname\":\"%%s\",\"recordedAt\":\"%%s\",\"files\":[]}' \"$(hostname)\" \"$(date -u +%%Y-%%m-%%dT%%H:%%M:%%SZ)\" > \"$d/%s\"\n", RecordManifestName)
+	script.WriteString("tar czf - -C \"$d\" . | base64 -w 0\n")
+	return script.String()
+}
+
+// CollectSSM records the instances with SSM Run Command, the recordings are read from the run command's output in S3
+// Recordings are returned in the order of the instance IDs, with the error of each instance that could not be recorded.
+func CollectSSM(ctx context.Context, client *ssm.Client, s3Client *s3.Client, opts SSMCollectOptions) ([]RemoteRecording, error) {
+	timeout := lo.Ternary(opts.Timeout > 0, opts.Timeout, 5*time.Minute)
+	pollInterval := lo.Ternary(opts.PollInterval > 0, opts.PollInterval, 5*time.Second)
+	out, err := client.SendCommand(ctx, &ssm.SendCommandInput{
+		DocumentName:       aws.String(SSMDocumentName),
+		InstanceIds:        opts.InstanceIDs,
+		Comment:            aws.String("node-latency-for-k8s remote recording"),
+		Parameters:         map[string][]string{"commands": {RecordScript()}},
+		OutputS3BucketName: aws.String(opts.Bucket),
+		OutputS3KeyPrefix:  aws.String(opts.Prefix),
+		TimeoutSeconds:     aws.Int32(int32(timeout.Seconds())),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to send SSM command to %v: %w", opts.InstanceIDs, err)
+	}
+	commandID := aws.ToString(out.Command.CommandId)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var recordings []RemoteRecording
+	for _, instanceID := range opts.InstanceIDs {
+		recording := RemoteRecording{Target: instanceID}
+		if err := waitForCommand(ctx, client, commandID, instanceID, pollInterval); err != nil {
+			recording.Err = err
+		} else {
+			recording.Recording, recording.Err = getSSMOutput(ctx, s3Client, opts, commandID, instanceID)
+		}
+		recordings = append(recordings, recording)
+	}
+	return recordings, nil
+}
+
+// waitForCommand polls the run command's invocation on the instance until it completes
+func waitForCommand(ctx context.Context, client *ssm.Client, commandID string, instanceID string, pollInterval time.Duration) error {
+	for {
+		invocation, err := client.GetCommandInvocation(ctx, &ssm.GetCommandInvocationInput{
+			CommandId:  aws.String(commandID),
+			InstanceId: aws.String(instanceID),
+		})
+		// the invocation is not found until the command has been delivered to the instance
+		if err == nil {
+			switch invocation.Status {
+			case ssmtypes.CommandInvocationStatusSuccess:
+				return nil
+			case ssmtypes.CommandInvocationStatusFailed, ssmtypes.CommandInvocationStatusCancelled, ssmtypes.CommandInvocationStatusTimedOut:
+				return fmt.Errorf("SSM command %s on %s %s: %s", commandID, instanceID, strings.ToLower(string(invocation.Status)), aws.ToString(invocation.StandardErrorContent))
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("unable to wait for SSM command %s on %s: %w", commandID, instanceID, ctx.Err())
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// getSSMOutput downloads and decodes the recording the run command wrote to stdout
+func getSSMOutput(ctx context.Context, s3Client *s3.Client, opts SSMCollectOptions, commandID string, instanceID string) ([]byte, error) {
+	key := path.Join(opts.Prefix, commandID, instanceID, ssmOutputPath)
+	out, err := s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(opts.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to download the SSM command output s3://%s/%s: %w", opts.Bucket, key, err)
+	}
+	defer out.Body.Close()
+	encoded, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the SSM command output s3://%s/%s: %w", opts.Bucket, key, err)
+	}
+	return decodeRecording(instanceID, encoded)
+}
+
+// CollectSSH records the host by running the record script with sudo over SSH, the args are passed to the ssh client
+// before the host, i.e. "-i" and a key, and the host may include the user, i.e. "ec2-user@10.0.0.1"
+func CollectSSH(ctx context.Context, host string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, DefaultSSHCommand, append(append([]string{}, args...), host, "sudo", "sh", "-s")...) //nolint:gosec
+	cmd.Stdin = strings.NewReader(RecordScript())
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("unable to record %s over ssh: %w: %s", host, err, strings.TrimSpace(stderr.String()))
+	}
+	return decodeRecording(host, stdout.Bytes())
+}
+
+// decodeRecording decodes the base64 encoded tar.gz the record script wrote to stdout
+func decodeRecording(target string, encoded []byte) ([]byte, error) {
+	recording, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode the recording of %s: %w", target, err)
+	}
+	return recording, nil
+}
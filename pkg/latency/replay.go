@@ -0,0 +1,193 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"go.uber.org/multierr"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/synthetic"
+)
+
+// WithRoot is a builder func that reads the node's log files under the root directory, i.e. an extracted recording
+// The root must be set before the default sources are registered.
+func (m *Measurer) WithRoot(root string) *Measurer {
+	m.root = root
+	return m
+}
+
+// hostPath is the path of the node's file under the Measurer's root
+func (m *Measurer) hostPath(file string) string {
+	if m.root == "" {
+		return file
+	}
+	return filepath.Join(m.root, file)
+}
+
+// Replay is a recording, or a directory of log files, which is measured offline instead of the node
+type Replay struct {
+	// Root is the directory the node's log files are read under
+	Root string
+	// IMDS serves the recorded IMDS responses from a local server, it is nil if no responses were recorded
+	IMDS     *imds.Client
+	tempDir  string
+	listener net.Listener
+}
+
+// OpenReplay opens a recording written by Record, either the tar.gz or its extracted directory, or a directory of
+// log files laid out like the node's filesystem, i.e. "<dir>/var/log/messages". IMDS responses are served from the
+// "imds" directory, i.e. "<dir>/imds/dynamic/instance-identity/document", if it exists.
+func OpenReplay(location string) (*Replay, error) {
+	stat, err := os.Stat(location)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open replay %s: %w", location, err)
+	}
+	replay := &Replay{}
+	dir := location
+	if !stat.IsDir() {
+		if replay.tempDir, err = os.MkdirTemp("", "node-latency-replay-"); err != nil {
+			return nil, fmt.Errorf("unable to create replay directory: %w", err)
+		}
+		if err := extract(location, replay.tempDir); err != nil {
+			return nil, multierr.Append(err, replay.Close())
+		}
+		dir = replay.tempDir
+	}
+	replay.Root = dir
+	if _, err := os.Stat(filepath.Join(dir, RecordManifestName)); err == nil {
+		replay.Root = filepath.Join(dir, recordFilesDir)
+	}
+	if stat, err := os.Stat(filepath.Join(dir, recordIMDSDir)); err == nil && stat.IsDir() {
+		if err := replay.serveIMDS(filepath.Join(dir, recordIMDSDir)); err != nil {
+			return nil, multierr.Append(err, replay.Close())
+		}
+	}
+	return replay, nil
+}
+
+// OpenSynthetic fabricates a node boot with the synthetic source, with the delays of its events overridden by metric,
+// and opens it like a recording, so the outputs and sinks can be tried without an EC2 instance
+func OpenSynthetic(delays map[string]time.Duration) (*Replay, error) {
+	dir, err := os.MkdirTemp("", "node-latency-synthetic-")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create synthetic directory: %w", err)
+	}
+	if err := synthetic.Write(dir, synthetic.Options{Delays: delays}); err != nil {
+		return nil, multierr.Append(err, os.RemoveAll(dir))
+	}
+	replay, err := OpenReplay(dir)
+	if err != nil {
+		return nil, multierr.Append(err, os.RemoveAll(dir))
+	}
+	replay.tempDir = dir
+	return replay, nil
+}
+
+// Close stops the IMDS server and removes the extracted recording
+func (r *Replay) Close() error {
+	var errs error
+	if r.listener != nil {
+		if err := r.listener.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = multierr.Append(errs, err)
+		}
+	}
+	if r.tempDir != "" {
+		errs = multierr.Append(errs, os.RemoveAll(r.tempDir))
+	}
+	return errs
+}
+
+// serveIMDS serves the recorded IMDS responses on a local port, IMDSv2 session tokens are accepted but not checked
+func (r *Replay) serveIMDS(dir string) error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("unable to listen for the replay IMDS server: %w", err)
+	}
+	r.listener = listener
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest/api/token", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Aws-Ec2-Metadata-Token-Ttl-Seconds", req.Header.Get("X-Aws-Ec2-Metadata-Token-Ttl-Seconds"))
+		_, _ = w.Write([]byte("replay"))
+	})
+	mux.HandleFunc("/latest/", func(w http.ResponseWriter, req *http.Request) {
+		response, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(path.Clean("/"+strings.TrimPrefix(req.URL.Path, "/latest/")))))
+		if err != nil {
+			http.NotFound(w, req)
+			return
+		}
+		_, _ = w.Write(response)
+	})
+	go func() { _ = http.Serve(listener, mux) }() //nolint:gosec
+	r.IMDS = imds.New(imds.Options{Endpoint: fmt.Sprintf("http://%s", listener.Addr())})
+	return nil
+}
+
+// extract extracts the regular files of the tar.gz to the directory with their modification times, since timestamps
+// without a year are assumed to be logged before the log was last modified
+func extract(recording string, dir string) error {
+	file, err := os.Open(recording)
+	if err != nil {
+		return fmt.Errorf("unable to open recording %s: %w", recording, err)
+	}
+	defer file.Close()
+	gzReader, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("unable to create gzip reader for recording %s: %w", recording, err)
+	}
+	defer gzReader.Close()
+	tarReader := tar.NewReader(gzReader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read recording %s: %w", recording, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		// entries are kept within the directory, even if their names are absolute or have ".." elements
+		target := filepath.Join(dir, filepath.FromSlash(path.Clean("/"+header.Name)))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return fmt.Errorf("unable to create directory for %s: %w", header.Name, err)
+		}
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+		if err != nil {
+			return fmt.Errorf("unable to create %s: %w", header.Name, err)
+		}
+		_, err = io.Copy(out, tarReader) //nolint:gosec
+		err = multierr.Append(err, out.Close())
+		if err != nil {
+			return fmt.Errorf("unable to extract %s: %w", header.Name, err)
+		}
+		if err := os.Chtimes(target, header.ModTime, header.ModTime); err != nil {
+			return fmt.Errorf("unable to set the modification time of %s: %w", header.Name, err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3Options configures where a Measurement is uploaded to S3
+type S3Options struct {
+	Bucket string
+	// Prefix is prepended to the object keys, objects are keyed by <prefix>/<cluster>/<instance-id>/<timestamp>
+	Prefix      string
+	ClusterName string
+	// IncludeLogLines uploads the raw matched log lines of each timing alongside the JSON measurement
+	IncludeLogLines bool
+}
+
+// EmitS3 uploads the JSON Measurement, and optionally the raw matched log lines, to S3
+// It returns the key of the JSON measurement object.
+func (m *Measurement) EmitS3(ctx context.Context, client *s3.Client, opts S3Options) (string, error) {
+	keyPrefix := m.s3KeyPrefix(opts, time.Now().UTC())
+	measurementJSON, err := m.JSON()
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal measurement: %w", err)
+	}
+	jsonKey := keyPrefix + ".json"
+	if _, err := client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(opts.Bucket),
+		Key:         aws.String(jsonKey),
+		Body:        bytes.NewReader(measurementJSON),
+		ContentType: aws.String("application/json"),
+	}); err != nil {
+		return "", fmt.Errorf("unable to upload measurement to s3://%s/%s: %w", opts.Bucket, jsonKey, err)
+	}
+	if !opts.IncludeLogLines {
+		return jsonKey, nil
+	}
+	var logLines bytes.Buffer
+	for _, timing := range m.Timings {
+		if timing.Line != "" {
+			fmt.Fprintf(&logLines, "%s: %s\n", timing.Event.Name, timing.Line)
+		}
+	}
+	logKey := keyPrefix + ".log"
+	if _, err := client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(opts.Bucket),
+		Key:         aws.String(logKey),
+		Body:        bytes.NewReader(logLines.Bytes()),
+		ContentType: aws.String("text/plain"),
+	}); err != nil {
+		return jsonKey, fmt.Errorf("unable to upload log lines to s3://%s/%s: %w", opts.Bucket, logKey, err)
+	}
+	return jsonKey, nil
+}
+
+// s3KeyPrefix constructs the object key without an extension, the hostname is used when the instance-id is unknown
+func (m *Measurement) s3KeyPrefix(opts S3Options, now time.Time) string {
+	cluster := opts.ClusterName
+	if cluster == "" {
+		cluster = "unknown"
+	}
+	instance := ""
+	if m.Metadata != nil {
+		instance = m.Metadata.InstanceID
+	}
+	if instance == "" {
+		instance, _ = os.Hostname()
+	}
+	return path.Join(opts.Prefix, cluster, instance, now.Format("2006-01-02T15-04-05Z"))
+}
@@ -0,0 +1,45 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// WithSLOs sets the expected max latency of events by metric name, i.e. to declare SLOs for the default events
+// The SLOs apply to both registered events and events registered afterwards.
+func (m *Measurer) WithSLOs(slos map[string]time.Duration) *Measurer {
+	if m.slos == nil {
+		m.slos = map[string]time.Duration{}
+	}
+	for metric, maxLatency := range slos {
+		m.slos[metric] = maxLatency
+	}
+	for _, e := range m.events {
+		if maxLatency, ok := m.slos[e.Metric]; ok {
+			e.MaxLatency = maxLatency
+		}
+	}
+	return m
+}
+
+// SLOViolations returns the successful timings which exceeded their event's max latency
+func (m *Measurement) SLOViolations() []*sources.Timing {
+	return lo.Filter(m.Timings, func(t *sources.Timing, _ int) bool { return t.SLOStatus() == sources.SLOStatusFail })
+}
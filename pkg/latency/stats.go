@@ -0,0 +1,103 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+)
+
+// EventStatistics is the aggregate latency of an event across many measurements
+type EventStatistics struct {
+	Event  string  `json:"event"`
+	Metric string  `json:"metric"`
+	Count  int     `json:"count"`
+	Min    float64 `json:"min"`
+	P50    float64 `json:"p50"`
+	P90    float64 `json:"p90"`
+	P99    float64 `json:"p99"`
+	Max    float64 `json:"max"`
+}
+
+// MeasureRuns executes runs measurement cycles, each until all terminal events have timings or the timeout is reached, with the interval in-between
+// The cache is cleared before each cycle so logs are re-read. The measurements of all cycles are returned along with any timeout errors.
+func (m *Measurer) MeasureRuns(ctx context.Context, runs int, interval time.Duration, timeout time.Duration, retryDelay time.Duration) ([]*Measurement, error) {
+	var measurements []*Measurement
+	var errs error
+	for i := 0; i < runs; i++ {
+		if i > 0 {
+			time.Sleep(interval)
+		}
+		m.ClearCache()
+		measurement, err := m.MeasureUntil(ctx, timeout, retryDelay)
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("run %d: %w", i+1, err))
+		}
+		measurements = append(measurements, measurement)
+	}
+	return measurements, errs
+}
+
+// Statistics computes the min, p50, p90, p99, and max latency of each event across the successful timings of the measurements
+// Events are ordered by their first occurrence so they follow the boot order.
+func Statistics(measurements []*Measurement) []EventStatistics {
+	values := map[string][]float64{}
+	var stats []EventStatistics
+	for _, m := range measurements {
+		for _, t := range m.Timings {
+			if t.Error != nil {
+				continue
+			}
+			if _, ok := values[t.Event.Metric]; !ok {
+				stats = append(stats, EventStatistics{Event: t.Event.Name, Metric: t.Event.Metric})
+			}
+			values[t.Event.Metric] = append(values[t.Event.Metric], t.T.Seconds())
+		}
+	}
+	return lo.Map(stats, func(s EventStatistics, _ int) EventStatistics {
+		v := values[s.Metric]
+		sort.Float64s(v)
+		s.Count = len(v)
+		s.Min = v[0]
+		s.P50 = percentile(v, 50)
+		s.P90 = percentile(v, 90)
+		s.P99 = percentile(v, 99)
+		s.Max = v[len(v)-1]
+		return s
+	})
+}
+
+// ChartStatistics writes the event statistics as a markdown table to w
+func ChartStatistics(w io.Writer, stats []EventStatistics) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Event", "N", "Min", "P50", "P90", "P99", "Max"})
+	for _, s := range stats {
+		table.Append([]string{
+			s.Event, fmt.Sprint(s.Count),
+			fmt.Sprintf("%.0fs", s.Min), fmt.Sprintf("%.0fs", s.P50), fmt.Sprintf("%.0fs", s.P90), fmt.Sprintf("%.0fs", s.P99), fmt.Sprintf("%.0fs", s.Max),
+		})
+	}
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	table.SetAutoWrapText(false)
+	table.Render()
+}
@@ -0,0 +1,80 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// MeasureStream tails the log sources and re-measures as they are written to until all terminal events have timings or the timeout is reached
+// Unlike MeasureUntil, logs are not re-read and re-parsed every retry delay, only appended lines are read and matched.
+// Other sources, i.e. APIs, are re-queried every poll interval, which is also the fallback when the logs cannot be watched.
+// onTiming is called once with each successful timing as soon as it is measured, T is relative to the first timing measured so far.
+func (m *Measurer) MeasureStream(ctx context.Context, timeout time.Duration, pollInterval time.Duration, onTiming func(*sources.Timing)) (*Measurement, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var dirs []string
+	for _, src := range m.sources {
+		if tailer, ok := src.(sources.Tailer); ok {
+			tailer.Tail()
+			dirs = append(dirs, tailer.WatchPaths()...)
+		}
+	}
+	changes, err := watchDirs(ctx, lo.Uniq(dirs))
+	if err != nil {
+		log.Printf("Unable to watch log sources, polling every %s: %s\n", pollInterval, err)
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	// emitted timings are kept on the Measurer so they are checkpointed
+	if m.emitted == nil {
+		m.emitted = map[string]struct{}{}
+	}
+	for {
+		measurement := m.Measure(ctx)
+		for _, t := range measurement.Timings {
+			key := timingKey(t)
+			if _, ok := m.emitted[key]; ok || t.Error != nil {
+				continue
+			}
+			m.emitted[key] = struct{}{}
+			if onTiming != nil {
+				onTiming(t)
+			}
+		}
+		m.saveCheckpoints()
+		if m.measured(measurement) {
+			return measurement, nil
+		}
+		select {
+		case <-ctx.Done():
+			return measurement, m.unmeasuredError(measurement)
+		case _, ok := <-changes:
+			// stop receiving from a closed watch, the poll interval still applies
+			if !ok {
+				changes = nil
+			}
+		case <-ticker.C:
+		}
+		m.ClearCache()
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// SVG timeline layout in pixels
+const (
+	svgLabelWidth    = 280
+	svgTimelineWidth = 640
+	svgValueWidth    = 48
+	svgRowHeight     = 24
+	svgHeaderHeight  = 56
+	svgPadding       = 16
+)
+
+// SVG renders a horizontal waterfall of the successful timings as a standalone SVG image, i.e. for slides or dashboards
+// Like the trace, each timing is a bar starting at the previous timing and ending at the timing's timestamp.
+func (m *Measurement) SVG() string {
+	timings := lo.Filter(m.Timings, func(t *sources.Timing, _ int) bool { return t.Error == nil })
+	width := svgPadding*2 + svgLabelWidth + svgTimelineWidth + svgValueWidth
+	height := svgPadding*2 + svgHeaderHeight + len(timings)*svgRowHeight
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Helvetica, Arial, sans-serif" font-size="12">`+"\n",
+		width, height, width, height)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#ffffff"/>`+"\n", width, height)
+	title := rootSpanName
+	if m.Metadata != nil {
+		title = fmt.Sprintf("%s (%s) | %s | %s | %s | %s",
+			m.Metadata.InstanceID, m.Metadata.PrivateIP, m.Metadata.InstanceType, m.Metadata.Architecture,
+			m.Metadata.AvailabilityZone, m.Metadata.AMIID)
+	}
+	fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="14" font-weight="bold">%s</text>`+"\n", svgPadding, svgPadding+14, html.EscapeString(title))
+	if len(timings) == 0 {
+		b.WriteString("</svg>\n")
+		return b.String()
+	}
+
+	start := timings[0].Timestamp
+	total := timings[len(timings)-1].Timestamp.Sub(start).Seconds()
+	x := func(seconds float64) float64 {
+		if total <= 0 {
+			return svgPadding + svgLabelWidth
+		}
+		return svgPadding + svgLabelWidth + seconds/total*svgTimelineWidth
+	}
+
+	// axis with a tick every step seconds, so there are at most ~10 ticks
+	axisY := svgPadding + svgHeaderHeight - 8
+	step := math.Max(1, math.Ceil(total/10))
+	for s := 0.0; s <= total; s += step {
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%d" stroke="#d0d7de"/>`+"\n", x(s), axisY, x(s), height-svgPadding)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle" fill="#57606a">%.0fs</text>`+"\n", x(s), axisY-4, s)
+	}
+
+	prev := start
+	for i, t := range timings {
+		y := svgPadding + svgHeaderHeight + i*svgRowHeight
+		fill := "#0969da"
+		if t.Event.Terminal {
+			fill = "#1a7f37"
+		}
+		barX := x(prev.Sub(start).Seconds())
+		barWidth := math.Max(2, x(t.Timestamp.Sub(start).Seconds())-barX)
+		fmt.Fprintf(&b, `<text x="%d" y="%d">%s</text>`+"\n", svgPadding, y+16, html.EscapeString(t.Event.Name))
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%d" width="%.1f" height="%d" rx="2" fill="%s"><title>%s: %.0fs</title></rect>`+"\n",
+			barX, y+4, barWidth, svgRowHeight-8, fill, html.EscapeString(t.Event.Name), t.T.Seconds())
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" fill="#57606a">%.0fs</text>`+"\n", barX+barWidth+4, y+16, t.T.Seconds())
+		prev = t.Timestamp
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
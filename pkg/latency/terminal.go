@@ -0,0 +1,39 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// WithTerminalEvents is a builder func that sets the events which complete a measurement by metric or event name, i.e. node_ready instead of pod_ready
+// Only the named events are terminal, which applies to both registered events and events registered afterwards.
+func (m *Measurer) WithTerminalEvents(names ...string) *Measurer {
+	m.terminalEvents = names
+	for _, e := range m.events {
+		m.applyTerminalEvents(e)
+	}
+	return m
+}
+
+// applyTerminalEvents marks the event as terminal if it is named by the terminal events, if any are set
+func (m *Measurer) applyTerminalEvents(e *sources.Event) {
+	if len(m.terminalEvents) == 0 {
+		return
+	}
+	e.Terminal = lo.Contains(m.terminalEvents, e.Metric) || lo.Contains(m.terminalEvents, e.Name)
+}
@@ -0,0 +1,90 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"time"
+)
+
+// WithSourceTimeout sets how long each source may be searched during a timing run, zero means no timeout
+// The events of a source that times out are reported as errored while the other sources are still measured,
+// so a hung IMDS endpoint or NFS mounted log can't stall the whole measurement.
+func (m *Measurer) WithSourceTimeout(timeout time.Duration) *Measurer {
+	m.sourceTimeout = timeout
+	return m
+}
+
+// WithSourceTimeouts sets the timeouts of sources by source name, taking precedence over WithSourceTimeout
+func (m *Measurer) WithSourceTimeouts(timeouts map[string]time.Duration) *Measurer {
+	if m.sourceTimeouts == nil {
+		m.sourceTimeouts = map[string]time.Duration{}
+	}
+	for name, timeout := range timeouts {
+		m.sourceTimeouts[name] = timeout
+	}
+	return m
+}
+
+// sourceContexts returns a context per source with a timeout, all events of a source share the source's deadline in a timing run
+func (m *Measurer) sourceContexts(ctx context.Context) (map[string]context.Context, context.CancelFunc) {
+	srcCtxs := map[string]context.Context{}
+	var cancels []context.CancelFunc
+	for name := range m.sources {
+		timeout, ok := m.sourceTimeouts[name]
+		if !ok {
+			timeout = m.sourceTimeout
+		}
+		if timeout <= 0 {
+			continue
+		}
+		srcCtx, cancel := context.WithTimeout(ctx, timeout)
+		srcCtxs[name] = srcCtx
+		cancels = append(cancels, cancel)
+	}
+	return srcCtxs, func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}
+}
+
+// lockSource locks the source, waiting until the source is not being searched or the context is done
+func (m *Measurer) lockSource(ctx context.Context, name string) (func(), error) {
+	lock, ok := m.sourceLocks[name]
+	if !ok {
+		return func() {}, nil
+	}
+	select {
+	case lock <- struct{}{}:
+		return func() { <-lock }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// tryLockSource locks the source unless it is still being searched, i.e. by a search abandoned after the source timed out
+func (m *Measurer) tryLockSource(name string) (func(), bool) {
+	lock, ok := m.sourceLocks[name]
+	if !ok {
+		return func() {}, true
+	}
+	select {
+	case lock <- struct{}{}:
+		return func() { <-lock }, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"errors"
+
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+const (
+	tracerName   = "github.com/awslabs/node-latency-for-k8s/pkg/latency"
+	serviceName  = "node-latency-for-k8s"
+	rootSpanName = "Node Boot"
+)
+
+// EmitTraces exports the Measurement as a single OpenTelemetry trace representing the node boot
+// Each successful timing is a child span of the root span, starting at the previous timing and ending at the timing's timestamp,
+// so that the boot phases render as a waterfall. The trace's ID is set on the Measurement to link its metrics to the trace.
+func (m *Measurement) EmitTraces(ctx context.Context, exporter sdktrace.SpanExporter) error {
+	timings := lo.Filter(m.Timings, func(t *sources.Timing, _ int) bool { return t.Error == nil })
+	if len(timings) == 0 {
+		return errors.New("no successful timings to export as a trace")
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithResource(m.otelResource()),
+	)
+	tracer := tp.Tracer(tracerName)
+
+	rootCtx, rootSpan := tracer.Start(ctx, rootSpanName, trace.WithTimestamp(timings[0].Timestamp))
+	m.TraceID = rootSpan.SpanContext().TraceID().String()
+	prev := timings[0]
+	for _, timing := range timings {
+		_, span := tracer.Start(rootCtx, timing.Event.Name,
+			trace.WithTimestamp(prev.Timestamp),
+			trace.WithAttributes(
+				attribute.String("event.metric", timing.Event.Metric),
+				attribute.String("event.src", timing.Event.SrcName),
+				attribute.Bool("event.terminal", timing.Event.Terminal),
+				attribute.Float64("event.t_seconds", timing.T.Seconds()),
+				attribute.String("event.comment", timing.Comment),
+			),
+		)
+		span.SetStatus(codes.Ok, "")
+		span.End(trace.WithTimestamp(timing.Timestamp))
+		prev = timing
+	}
+	rootSpan.End(trace.WithTimestamp(timings[len(timings)-1].Timestamp))
+	// Shutdown flushes the spans to the exporter and shuts down the exporter
+	return tp.Shutdown(ctx)
+}
+
+// otelResource describes the node that the trace or metrics were measured on using OpenTelemetry semantic conventions
+func (m *Measurement) otelResource() *resource.Resource {
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(serviceName),
+	}
+	if m.Metadata != nil {
+		attrs = append(attrs,
+			semconv.CloudProviderAWS,
+			semconv.CloudPlatformAWSEC2,
+			semconv.CloudRegion(m.Metadata.Region),
+			semconv.CloudAvailabilityZone(m.Metadata.AvailabilityZone),
+			semconv.CloudAccountID(m.Metadata.AccountID),
+			semconv.HostID(m.Metadata.InstanceID),
+			semconv.HostType(m.Metadata.InstanceType),
+			semconv.HostImageID(m.Metadata.AMIID),
+			semconv.HostArchKey.String(m.Metadata.Architecture),
+		)
+	}
+	return resource.NewWithAttributes(semconv.SchemaURL, attrs...)
+}
@@ -0,0 +1,183 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Version dimensions which can be added to the metric dimensions with WithVersionDimensions
+const (
+	VersionDimensionOSImage                 = "osImage"
+	VersionDimensionKernelVersion           = "kernelVersion"
+	VersionDimensionKubeletVersion          = "kubeletVersion"
+	VersionDimensionContainerRuntimeVersion = "containerRuntimeVersion"
+)
+
+// VersionDimensions are the version dimensions in the order they are shown
+var VersionDimensions = []string{VersionDimensionOSImage, VersionDimensionKernelVersion, VersionDimensionKubeletVersion, VersionDimensionContainerRuntimeVersion}
+
+// Paths and commands the versions are read from when the node is measured locally rather than from a pod
+var (
+	OSReleasePath     = "/etc/os-release"
+	KernelReleasePath = "/proc/sys/kernel/osrelease"
+	versionTimeout    = 5 * time.Second
+)
+
+// componentVersions are the versions of the node's OS and components
+type componentVersions struct {
+	OSImage                 string
+	KernelVersion           string
+	KubeletVersion          string
+	ContainerRuntimeVersion string
+}
+
+// complete is true when every version is known
+func (v componentVersions) complete() bool {
+	return v.OSImage != "" && v.KernelVersion != "" && v.KubeletVersion != "" && v.ContainerRuntimeVersion != ""
+}
+
+// ParseVersionDimensions parses comma separated version dimensions, i.e. kernelVersion,kubeletVersion
+func ParseVersionDimensions(dimensionsStr string) ([]string, error) {
+	dimensions := lo.Filter(strings.Split(dimensionsStr, ","), func(d string, _ int) bool { return d != "" })
+	if invalid, _ := lo.Difference(dimensions, VersionDimensions); len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid version dimensions %v, expected any of %v", invalid, VersionDimensions)
+	}
+	return lo.Uniq(dimensions), nil
+}
+
+// versions returns the Metadata's versions by version dimension
+func (md *Metadata) versions() map[string]string {
+	return map[string]string{
+		VersionDimensionOSImage:                 md.OSImage,
+		VersionDimensionKernelVersion:           md.KernelVersion,
+		VersionDimensionKubeletVersion:          md.KubeletVersion,
+		VersionDimensionContainerRuntimeVersion: md.ContainerRuntimeVersion,
+	}
+}
+
+// versionsSummary are the known versions in the order they are shown, i.e. [Amazon Linux 2, kernel 5.10.192, kubelet v1.28.3, containerd://1.7.2]
+func (md *Metadata) versionsSummary() []string {
+	if md == nil {
+		return nil
+	}
+	var summary []string
+	for _, version := range [][2]string{
+		{"", md.OSImage},
+		{"kernel ", md.KernelVersion},
+		{"kubelet ", md.KubeletVersion},
+		{"", md.ContainerRuntimeVersion},
+	} {
+		if version[1] != "" {
+			summary = append(summary, version[0]+version[1])
+		}
+	}
+	return summary
+}
+
+// getVersions retrieves the versions of the node's OS and components, the versions are cached once they are all known
+// The Node's status is used when there is a K8s clientset, since the OS of a pod is not the node's, and the node's
+// os-release, kernel release, and kubelet and containerd binaries are used for versions which are still unknown, unless
+// replaying or with a fake clock.
+func (m *Measurer) getVersions(ctx context.Context) componentVersions {
+	if m.versions.complete() {
+		return m.versions
+	}
+	var versions componentVersions
+	if m.k8sClientset != nil && m.nodeName != "" {
+		if node, err := m.k8sClientset.CoreV1().Nodes().Get(ctx, m.nodeName, metav1.GetOptions{}); err == nil {
+			versions = componentVersions{
+				OSImage:                 node.Status.NodeInfo.OSImage,
+				KernelVersion:           node.Status.NodeInfo.KernelVersion,
+				KubeletVersion:          node.Status.NodeInfo.KubeletVersion,
+				ContainerRuntimeVersion: node.Status.NodeInfo.ContainerRuntimeVersion,
+			}
+		}
+	}
+	// a recording, or a fake clock's fixture, is not the node the measurer runs on
+	if m.root == "" && m.clock == nil {
+		if versions.KernelVersion == "" {
+			if release, err := os.ReadFile(KernelReleasePath); err == nil {
+				versions.KernelVersion = strings.TrimSpace(string(release))
+			}
+		}
+		if m.k8sClientset == nil {
+			versions.OSImage = lo.Ternary(versions.OSImage == "", osImage(), versions.OSImage)
+			versions.KubeletVersion = lo.Ternary(versions.KubeletVersion == "", kubeletVersion(ctx), versions.KubeletVersion)
+			versions.ContainerRuntimeVersion = lo.Ternary(versions.ContainerRuntimeVersion == "", containerdVersion(ctx), versions.ContainerRuntimeVersion)
+		}
+	}
+	m.versions = versions
+	return versions
+}
+
+// osImage is the PRETTY_NAME of the os-release, i.e. Amazon Linux 2, which is the Node's OS image
+func osImage() string {
+	osRelease, err := os.ReadFile(OSReleasePath)
+	if err != nil {
+		return ""
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(osRelease))
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "PRETTY_NAME=") {
+			return strings.Trim(strings.TrimPrefix(line, "PRETTY_NAME="), `"'`)
+		}
+	}
+	return ""
+}
+
+// kubeletVersion is the version of `kubelet --version`, i.e. v1.28.3 of "Kubernetes v1.28.3"
+func kubeletVersion(ctx context.Context) string {
+	fields := strings.Fields(versionOutput(ctx, "kubelet"))
+	if len(fields) < 2 {
+		return ""
+	}
+	return fields[1]
+}
+
+// containerdVersion is the version of `containerd --version` formatted as the Node's container runtime version, i.e.
+// containerd://1.7.2 of "containerd github.com/containerd/containerd v1.7.2 <commit>"
+func containerdVersion(ctx context.Context) string {
+	fields := strings.Fields(versionOutput(ctx, "containerd"))
+	if len(fields) < 3 {
+		return ""
+	}
+	return "containerd://" + strings.TrimPrefix(fields[2], "v")
+}
+
+// versionOutput runs the binary with --version if it is installed
+func versionOutput(ctx context.Context, binary string) string {
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(ctx, versionTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
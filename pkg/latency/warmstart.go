@@ -0,0 +1,54 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// Warm start metrics and the Metadata WarmStart values of nodes which did not cold boot
+const (
+	WarmPoolExitMetric       = "warm_pool_exit"
+	HibernationResumedMetric = "hibernation_resumed"
+	WarmStartWarmPool        = "warm-pool"
+	WarmStartHibernation     = "hibernation"
+)
+
+// hibernationResumedEvent is the default event of the node resuming from hibernation, the last resume is the current start
+func (m *Measurer) hibernationResumedEvent(syslog sources.RegexFinder) *sources.Event {
+	return &sources.Event{
+		Name:          "Hibernation Resumed",
+		Metric:        HibernationResumedMetric,
+		SrcName:       syslog.Name(),
+		MatchSelector: sources.EventMatchSelectorLast,
+		FindFn:        syslog.FindByRegex(hibernationResumed),
+	}
+}
+
+// warmStart detects a node which was started from an ASG warm pool or resumed from hibernation rather than cold booted,
+// and returns its kind and the timing of the start. An instance in a hibernated warm pool is a warm pool start.
+func warmStart(timings []*sources.Timing) (string, *sources.Timing, bool) {
+	for _, warm := range []struct{ kind, metric string }{
+		{kind: WarmStartWarmPool, metric: WarmPoolExitMetric},
+		{kind: WarmStartHibernation, metric: HibernationResumedMetric},
+	} {
+		if start, ok := lo.Find(timings, func(t *sources.Timing) bool { return t.Event.Metric == warm.metric && t.Error == nil }); ok {
+			return warm.kind, start, true
+		}
+	}
+	return "", nil, false
+}
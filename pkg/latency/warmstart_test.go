@@ -0,0 +1,78 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+func TestWarmStart(t *testing.T) {
+	boot := time.Date(2024, 1, 14, 10, 0, 0, 0, time.UTC)
+	timing := func(metric string, at time.Duration) *sources.Timing {
+		return &sources.Timing{Event: &sources.Event{Metric: metric}, Timestamp: boot.Add(at)}
+	}
+	for _, tc := range []struct {
+		name    string
+		timings []*sources.Timing
+		kind    string
+		start   time.Duration
+	}{
+		{
+			name:    "cold boot",
+			timings: []*sources.Timing{timing("vm_initialized", 0), timing("node_ready", time.Minute)},
+		},
+		{
+			name:    "warm pool",
+			timings: []*sources.Timing{timing("vm_initialized", 0), timing(WarmPoolExitMetric, time.Hour), timing("node_ready", time.Hour+time.Minute)},
+			kind:    WarmStartWarmPool,
+			start:   time.Hour,
+		},
+		{
+			name:    "hibernation",
+			timings: []*sources.Timing{timing("vm_initialized", 0), timing(HibernationResumedMetric, time.Hour)},
+			kind:    WarmStartHibernation,
+			start:   time.Hour,
+		},
+		{
+			name:    "hibernated warm pool",
+			timings: []*sources.Timing{timing(HibernationResumedMetric, time.Hour), timing(WarmPoolExitMetric, time.Hour+time.Second)},
+			kind:    WarmStartWarmPool,
+			start:   time.Hour + time.Second,
+		},
+		{
+			name: "errored warm pool exit",
+			timings: []*sources.Timing{
+				{Event: &sources.Event{Metric: WarmPoolExitMetric}, Error: errors.New("no scaling activity")},
+				timing(HibernationResumedMetric, time.Hour),
+			},
+			kind:  WarmStartHibernation,
+			start: time.Hour,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			kind, start, warm := warmStart(tc.timings)
+			if warm != (tc.kind != "") || kind != tc.kind {
+				t.Fatalf("expected warm start %q, got %q", tc.kind, kind)
+			}
+			if warm && !start.Timestamp.Equal(boot.Add(tc.start)) {
+				t.Errorf("expected the warm start at %s, got %s", boot.Add(tc.start), start.Timestamp)
+			}
+		})
+	}
+}
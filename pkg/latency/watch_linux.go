@@ -0,0 +1,61 @@
+//go:build linux
+
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// watchDirs signals on the returned channel when a file in one of the directories is written, created, or moved to with inotify
+// Signals are coalesced so a burst of writes is read once. The watch is closed when the context is done.
+func watchDirs(ctx context.Context, dirs []string) (<-chan struct{}, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize inotify: %w", err)
+	}
+	for _, dir := range dirs {
+		if _, err := unix.InotifyAddWatch(fd, dir, unix.IN_MODIFY|unix.IN_CREATE|unix.IN_MOVED_TO); err != nil {
+			unix.Close(fd)
+			return nil, fmt.Errorf("unable to watch %s: %w", dir, err)
+		}
+	}
+	// the non-blocking fd is registered with the runtime poller so closing the file unblocks the read
+	watcher := os.NewFile(uintptr(fd), "inotify")
+	go func() {
+		<-ctx.Done()
+		watcher.Close()
+	}()
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+		buf := make([]byte, 64*1024)
+		for {
+			if _, err := watcher.Read(buf); err != nil {
+				return
+			}
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return changes, nil
+}
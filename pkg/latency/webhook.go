@@ -0,0 +1,112 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+// WebhookOptions configures the HTTP webhook the Measurement is POSTed to
+type WebhookOptions struct {
+	URL string
+	// Headers are set on the request, i.e. Authorization, the Content-Type defaults to application/json
+	Headers map[string]string
+	// Template is an optional Go text/template of the payload rendered with WebhookData, the JSON Measurement is sent when empty
+	Template   string
+	Thresholds Thresholds
+}
+
+// WebhookData is the data the webhook payload template is rendered with
+type WebhookData struct {
+	Measurement *Measurement
+	Summary     *Summary
+}
+
+// webhookTemplateFuncs are available to webhook payload templates, json marshals a value, i.e. to quote strings in JSON payloads
+var webhookTemplateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// ParseHeaders parses comma separated HTTP headers, i.e. "Authorization=Bearer token,X-Source=nlk"
+func ParseHeaders(headersStr string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, header := range strings.Split(headersStr, ",") {
+		if header == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(header, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid header \"%s\", expected <key>=<value>", header)
+		}
+		headers[strings.TrimSpace(key)] = value
+	}
+	return headers, nil
+}
+
+// EmitWebhook POSTs the JSON Measurement, or the rendered payload template, to an HTTP webhook, i.e. Slack, PagerDuty, or internal systems
+func (m *Measurement) EmitWebhook(ctx context.Context, opts WebhookOptions) error {
+	payload, err := m.webhookPayload(opts)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, opts.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range opts.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to post to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unable to post to webhook, status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// webhookPayload renders the payload template, or marshals the Measurement when there is no template
+func (m *Measurement) webhookPayload(opts WebhookOptions) ([]byte, error) {
+	if opts.Template == "" {
+		payload, err := m.JSON()
+		if err != nil {
+			return nil, fmt.Errorf("unable to marshal measurement: %w", err)
+		}
+		return payload, nil
+	}
+	tmpl, err := template.New("webhook").Funcs(webhookTemplateFuncs).Parse(opts.Template)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse webhook template: %w", err)
+	}
+	var payload bytes.Buffer
+	if err := tmpl.Execute(&payload, WebhookData{Measurement: m, Summary: m.Summary(opts.Thresholds)}); err != nil {
+		return nil, fmt.Errorf("unable to render webhook template: %w", err)
+	}
+	return payload.Bytes(), nil
+}
@@ -0,0 +1,41 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// WithZeroEvents is a builder func that sets the events timings are relative to by metric or event name, in order of preference
+// The first of the events which was timed is the zero point, i.e. instance_requested and then instance_pending, so a
+// missing early event does not shift the zero point on some nodes. Timings are relative to the first timing if none were.
+func (m *Measurer) WithZeroEvents(names ...string) *Measurer {
+	m.zeroEvents = names
+	return m
+}
+
+// zeroTiming returns the first successful timing of the first zero event which was timed, the timings are in chronological order
+func (m *Measurer) zeroTiming(timings []*sources.Timing) (*sources.Timing, bool) {
+	for _, name := range m.zeroEvents {
+		if zero, ok := lo.Find(timings, func(t *sources.Timing) bool {
+			return t.Error == nil && (t.Event.Metric == name || t.Event.Name == name)
+		}); ok {
+			return zero, true
+		}
+	}
+	return nil, false
+}
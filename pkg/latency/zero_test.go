@@ -0,0 +1,51 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+func TestZeroTiming(t *testing.T) {
+	boot := time.Date(2024, 1, 14, 10, 0, 0, 0, time.UTC)
+	requestedErr := &sources.Timing{Event: &sources.Event{Name: "Instance Requested", Metric: "instance_requested"}, Error: errors.New("no EC2 API")}
+	pending := &sources.Timing{Event: &sources.Event{Name: "Instance Pending", Metric: "instance_pending"}, Timestamp: boot}
+	vmInit := &sources.Timing{Event: &sources.Event{Name: "VM Initialized", Metric: "vm_initialized"}, Timestamp: boot.Add(time.Second)}
+	restarted := &sources.Timing{Event: &sources.Event{Name: "VM Initialized", Metric: "vm_initialized"}, Timestamp: boot.Add(time.Hour)}
+	timings := []*sources.Timing{requestedErr, pending, vmInit, restarted}
+	for _, tc := range []struct {
+		name       string
+		zeroEvents []string
+		expected   *sources.Timing
+	}{
+		{name: "no zero events"},
+		{name: "by metric", zeroEvents: []string{"vm_initialized"}, expected: vmInit},
+		{name: "by name", zeroEvents: []string{"Instance Pending"}, expected: pending},
+		{name: "in order of preference", zeroEvents: []string{"vm_initialized", "instance_pending"}, expected: vmInit},
+		{name: "errored timings are skipped", zeroEvents: []string{"instance_requested", "instance_pending"}, expected: pending},
+		{name: "not timed", zeroEvents: []string{"instance_requested", "node_ready"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			zero, ok := New().WithZeroEvents(tc.zeroEvents...).zeroTiming(timings)
+			if ok != (tc.expected != nil) || zero != tc.expected {
+				t.Errorf("expected zero timing %v, got %v", tc.expected, zero)
+			}
+		})
+	}
+}
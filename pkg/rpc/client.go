@@ -0,0 +1,65 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Client calls the Measurer gRPC service
+type Client struct {
+	conn grpc.ClientConnInterface
+}
+
+// NewClient creates a new Client on a connection, the connection must use the json codec, i.e. Dial
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{conn: conn}
+}
+
+// Dial connects to the Measurer gRPC service at the addr without TLS, i.e. a node-local DaemonSet pod
+// Pass grpc.WithTransportCredentials to connect to a server serving TLS.
+func Dial(ctx context.Context, addr string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	return grpc.DialContext(ctx, addr, append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype(codecName)),
+	}, opts...)...)
+}
+
+// Measure triggers a single timing run
+func (c *Client) Measure(ctx context.Context, req *MeasureRequest, opts ...grpc.CallOption) (*MeasureResponse, error) {
+	resp := new(MeasureResponse)
+	return resp, c.conn.Invoke(ctx, "/"+ServiceName+"/Measure", req, resp, opts...)
+}
+
+// MeasureUntil triggers timing runs until a terminal event is measured or the timeout elapses
+func (c *Client) MeasureUntil(ctx context.Context, req *MeasureUntilRequest, opts ...grpc.CallOption) (*MeasureResponse, error) {
+	resp := new(MeasureResponse)
+	return resp, c.conn.Invoke(ctx, "/"+ServiceName+"/MeasureUntil", req, resp, opts...)
+}
+
+// RegisterConfig registers custom sources and events
+func (c *Client) RegisterConfig(ctx context.Context, req *RegisterConfigRequest, opts ...grpc.CallOption) (*RegisterConfigResponse, error) {
+	resp := new(RegisterConfigResponse)
+	return resp, c.conn.Invoke(ctx, "/"+ServiceName+"/RegisterConfig", req, resp, opts...)
+}
+
+// Latest retrieves the most recent Measurement
+func (c *Client) Latest(ctx context.Context, req *LatestRequest, opts ...grpc.CallOption) (*MeasureResponse, error) {
+	resp := new(MeasureResponse)
+	return resp, c.conn.Invoke(ctx, "/"+ServiceName+"/Latest", req, resp, opts...)
+}
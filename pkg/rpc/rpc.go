@@ -0,0 +1,240 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rpc exposes a Measurer over gRPC so other controllers can trigger and consume measurements across process boundaries
+// Messages are JSON encoded with a registered "json" codec rather than protobuf, so the Measurement keeps its versioned JSON document.
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+)
+
+// ServiceName is the fully qualified gRPC service name of the Measurer
+const ServiceName = "nodelatency.v1.Measurer"
+
+// codecName is the content-subtype of the JSON codec, i.e. application/grpc+json
+const codecName = "json"
+
+// MeasureRequest triggers a single timing run
+type MeasureRequest struct{}
+
+// MeasureUntilRequest triggers timing runs until a terminal event is measured or the timeout elapses
+type MeasureUntilRequest struct {
+	TimeoutSeconds    int `json:"timeoutSeconds"`
+	RetryDelaySeconds int `json:"retryDelaySeconds"`
+}
+
+// LatestRequest retrieves the most recent Measurement
+type LatestRequest struct{}
+
+// MeasureResponse is a Measurement, the Error is set when MeasureUntil timed out before a terminal event was measured
+type MeasureResponse struct {
+	Measurement *latency.Measurement `json:"measurement"`
+	Error       string               `json:"error,omitempty"`
+}
+
+// RegisterConfigRequest registers the custom sources and events of a Config, i.e. a config file's contents
+// The Config is untrusted, so its sources may only read the logs under the server's allowed paths, see
+// latency.RegisterUntrustedConfig.
+type RegisterConfigRequest struct {
+	Config *latency.Config `json:"config"`
+}
+
+// RegisterConfigResponse is returned once the Config is registered
+type RegisterConfigResponse struct{}
+
+// MeasurerServer is the gRPC service of the Measurer
+type MeasurerServer interface {
+	Measure(context.Context, *MeasureRequest) (*MeasureResponse, error)
+	MeasureUntil(context.Context, *MeasureUntilRequest) (*MeasureResponse, error)
+	RegisterConfig(context.Context, *RegisterConfigRequest) (*RegisterConfigResponse, error)
+	Latest(context.Context, *LatestRequest) (*MeasureResponse, error)
+}
+
+// jsonCodec encodes gRPC messages as JSON
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Name() string                       { return codecName }
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// serviceDesc is written by hand in place of protoc generated code
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*MeasurerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("Measure", MeasurerServer.Measure),
+		unaryMethod("MeasureUntil", MeasurerServer.MeasureUntil),
+		unaryMethod("RegisterConfig", MeasurerServer.RegisterConfig),
+		unaryMethod("Latest", MeasurerServer.Latest),
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+// unaryMethod adapts a MeasurerServer method to a grpc.MethodDesc
+func unaryMethod[Req any, Resp any](name string, call func(MeasurerServer, context.Context, *Req) (*Resp, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			req := new(Req)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return call(srv.(MeasurerServer), ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + ServiceName + "/" + name}
+			return interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+				return call(srv.(MeasurerServer), ctx, req.(*Req))
+			})
+		},
+	}
+}
+
+// RegisterMeasurerServer registers the MeasurerServer to a gRPC server
+func RegisterMeasurerServer(s grpc.ServiceRegistrar, srv MeasurerServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+// Server implements the MeasurerServer with a Measurer
+// The Measurer is not safe for concurrent use, so requests are serialized.
+type Server struct {
+	mu           sync.Mutex
+	measurer     *latency.Measurer
+	latest       *latency.Measurement
+	allowedPaths []string
+}
+
+// NewServer creates a new Server, the initial measurement is optional and is returned by Latest until the next measurement
+func NewServer(measurer *latency.Measurer, initial *latency.Measurement) *Server {
+	return &Server{measurer: measurer, latest: initial, allowedPaths: latency.DefaultUntrustedPaths}
+}
+
+// WithAllowedPaths is a builder func that sets the paths the sources of registered configs may read logs from, no paths
+// only allows the default paths of the source types
+func (s *Server) WithAllowedPaths(paths ...string) *Server {
+	s.allowedPaths = paths
+	return s
+}
+
+// Measure executes a single timing run, the cache is cleared so logs are re-read
+func (s *Server) Measure(ctx context.Context, _ *MeasureRequest) (*MeasureResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.measurer.ClearCache()
+	s.latest = s.measurer.Measure(ctx)
+	return &MeasureResponse{Measurement: s.latest}, nil
+}
+
+// MeasureUntil executes timing runs until a terminal event is measured or the timeout elapses
+func (s *Server) MeasureUntil(ctx context.Context, req *MeasureUntilRequest) (*MeasureResponse, error) {
+	if req.TimeoutSeconds <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "timeoutSeconds must be greater than 0")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.measurer.ClearCache()
+	measurement, err := s.measurer.MeasureUntil(ctx, time.Duration(req.TimeoutSeconds)*time.Second, time.Duration(req.RetryDelaySeconds)*time.Second)
+	s.latest = measurement
+	resp := &MeasureResponse{Measurement: measurement}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	return resp, nil
+}
+
+// RegisterConfig registers the custom sources and events of the Config to the Measurer, unless it reads outside of the allowed paths
+func (s *Server) RegisterConfig(_ context.Context, req *RegisterConfigRequest) (*RegisterConfigResponse, error) {
+	if req.Config == nil {
+		return nil, status.Error(codes.InvalidArgument, "config is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.measurer.RegisterUntrustedConfig(req.Config, s.allowedPaths...); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "unable to register config: %v", err)
+	}
+	return &RegisterConfigResponse{}, nil
+}
+
+// Latest returns the most recent Measurement
+func (s *Server) Latest(_ context.Context, _ *LatestRequest) (*MeasureResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.latest == nil {
+		return nil, status.Error(codes.NotFound, "no measurement has been taken yet")
+	}
+	return &MeasureResponse{Measurement: s.latest}, nil
+}
+
+// ListenAndServe serves the gRPC API on the addr until the context is cancelled, i.e. with the TLS ServerOption
+func (s *Server) ListenAndServe(ctx context.Context, addr string, opts ...grpc.ServerOption) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	srv := grpc.NewServer(opts...)
+	RegisterMeasurerServer(srv, s)
+	go func() {
+		<-ctx.Done()
+		srv.GracefulStop()
+	}()
+	if err := srv.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	return nil
+}
+
+// TLS returns the ServerOption to serve the gRPC API with the TLS certificate and key, clients must present a
+// certificate signed by the client CA if one is set (mTLS)
+func TLS(certFile string, keyFile string, clientCAFile string) (grpc.ServerOption, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load TLS certificate: %w", err)
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if clientCAFile != "" {
+		caPEM, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read client CA %s: %w", clientCAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("unable to parse client CA %s", clientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return grpc.Creds(credentials.NewTLS(config)), nil
+}
@@ -0,0 +1,145 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency/latencytest"
+	"github.com/awslabs/node-latency-for-k8s/pkg/rpc"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var start = time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+// newClient serves a Server around a Measurer of a fake source with a terminal "ready" event over an in-memory listener
+func newClient(t *testing.T, initial *latency.Measurement) *rpc.Client {
+	clock := latencytest.NewClock(start.Add(time.Minute))
+	src := latencytest.NewSource("fake").WithResult("ready", start, "agent is ready")
+	m, err := latencytest.NewMeasurer(clock, src).RegisterEvents(&sources.Event{Name: "Ready", Metric: "ready", SrcName: "fake", Terminal: true})
+	if err != nil {
+		t.Fatalf("unable to register events: %v", err)
+	}
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	rpc.RegisterMeasurerServer(srv, rpc.NewServer(m, initial))
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	conn, err := rpc.Dial(context.Background(), "bufconn", grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}))
+	if err != nil {
+		t.Fatalf("unable to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return rpc.NewClient(conn)
+}
+
+func TestMeasure(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t, nil)
+	if _, err := client.Latest(ctx, &rpc.LatestRequest{}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected Latest to be not found before a measurement, got %v", err)
+	}
+	resp, err := client.Measure(ctx, &rpc.MeasureRequest{})
+	if err != nil {
+		t.Fatalf("unable to measure: %v", err)
+	}
+	if len(resp.Measurement.Timings) != 1 || !resp.Measurement.Timings[0].Timestamp.Equal(start) {
+		t.Fatalf("expected the ready timing at %s, got %+v", start, resp.Measurement.Timings)
+	}
+	latest, err := client.Latest(ctx, &rpc.LatestRequest{})
+	if err != nil {
+		t.Fatalf("unable to retrieve the latest measurement: %v", err)
+	}
+	if len(latest.Measurement.Timings) != 1 {
+		t.Errorf("expected the latest measurement to have the ready timing, got %+v", latest.Measurement.Timings)
+	}
+}
+
+func TestMeasureUntil(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t, nil)
+	for _, tc := range []struct {
+		name string
+		req  *rpc.MeasureUntilRequest
+		code codes.Code
+	}{
+		{name: "no timeout", req: &rpc.MeasureUntilRequest{}, code: codes.InvalidArgument},
+		{name: "negative timeout", req: &rpc.MeasureUntilRequest{TimeoutSeconds: -1}, code: codes.InvalidArgument},
+		{name: "terminal event", req: &rpc.MeasureUntilRequest{TimeoutSeconds: 5, RetryDelaySeconds: 1}, code: codes.OK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := client.MeasureUntil(ctx, tc.req)
+			if code := status.Code(err); code != tc.code {
+				t.Fatalf("expected code %s, got %v", tc.code, err)
+			}
+			if err == nil && resp.Error != "" {
+				t.Errorf("expected the terminal event to be measured, got %s", resp.Error)
+			}
+		})
+	}
+}
+
+func TestRegisterConfig(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t, &latency.Measurement{})
+	for _, tc := range []struct {
+		name   string
+		config *latency.Config
+		code   codes.Code
+	}{
+		{name: "no config", code: codes.InvalidArgument},
+		{
+			name: "log under the allowed paths",
+			config: &latency.Config{
+				Sources: []latency.SourceConfig{{Name: "agent", Type: latency.SourceTypeLog, Path: "/var/log/agent.log", TimestampLayout: "auto"}},
+				Events:  []latency.EventConfig{{Name: "Agent Ready", Metric: "agent_ready", Src: "agent", Regex: "agent is ready"}},
+			},
+			code: codes.OK,
+		},
+		{
+			name:   "log outside of the allowed paths",
+			config: &latency.Config{Sources: []latency.SourceConfig{{Name: "shadow", Type: latency.SourceTypeLog, Path: "/etc/shadow", TimestampLayout: "auto"}}},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "exec source",
+			config: &latency.Config{Sources: []latency.SourceConfig{{Name: "exec", Type: latency.SourceTypeExec, Command: "/bin/sh"}}},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "matched line comment",
+			config: &latency.Config{Events: []latency.EventConfig{{Name: "Ready", Metric: "ready", Src: "fake", Regex: ".*", CommentMatchedLine: true}}},
+			code:   codes.InvalidArgument,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := client.RegisterConfig(ctx, &rpc.RegisterConfigRequest{Config: tc.config})
+			if code := status.Code(err); code != tc.code {
+				t.Errorf("expected code %s, got %v", tc.code, err)
+			}
+		})
+	}
+}
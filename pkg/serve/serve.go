@@ -0,0 +1,216 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package serve runs a Measurer as a long-running daemon that periodically re-measures and serves the latest Measurement over HTTP
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+)
+
+// DefaultHistory is the default number of measurements served on /measurements
+const DefaultHistory = 10
+
+// Server periodically re-runs a Measurer and serves the latest Measurement
+type Server struct {
+	measurer            *latency.Measurer
+	interval            time.Duration
+	experimentDimension string
+	registry            *prometheus.Registry
+	histograms          *latency.Histograms
+	mu                  sync.RWMutex
+	latest              *latency.Measurement
+	// history holds the most recent measurements, newest first
+	history    []*latency.Measurement
+	maxHistory int
+	// register wraps the registry with the metric namespace and const labels
+	register         prometheus.Registerer
+	namespace        string
+	constLabels      map[string]string
+	histogramBuckets []float64
+}
+
+// New creates a new Server that re-measures on the interval
+// The initial measurement is optional and is served until the first interval elapses
+func New(measurer *latency.Measurer, initial *latency.Measurement, interval time.Duration, experimentDimension string) *Server {
+	registry := prometheus.NewRegistry()
+	s := &Server{
+		measurer:            measurer,
+		interval:            interval,
+		experimentDimension: experimentDimension,
+		registry:            registry,
+		register:            registry,
+		maxHistory:          DefaultHistory,
+	}
+	if initial != nil {
+		s.update(initial)
+	}
+	return s
+}
+
+// WithHistory sets the number of most recent measurements that are kept and served on /measurements
+func (s *Server) WithHistory(maxHistory int) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxHistory = maxHistory
+	s.history = lo.Slice(s.history, 0, maxHistory)
+	return s
+}
+
+// WithHistograms records the timings of each measurement into histograms with the buckets instead of setting gauges to
+// the latest measurement, the timings of the boot are only observed once however often it is re-measured
+func (s *Server) WithHistograms(buckets []float64) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.histogramBuckets = buckets
+	s.resetMetrics(true)
+	return s
+}
+
+// WithMetricsNamespace prefixes the metric names with the namespace and adds the const labels to the metrics
+func (s *Server) WithMetricsNamespace(namespace string, constLabels map[string]string) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.namespace = namespace
+	s.constLabels = constLabels
+	s.resetMetrics(s.histograms != nil)
+	return s
+}
+
+// resetMetrics replaces the registry, and the histograms if they are enabled, and records the latest measurement to them
+func (s *Server) resetMetrics(histograms bool) {
+	s.registry = prometheus.NewRegistry()
+	s.register = latency.WrapRegisterer(s.registry, s.namespace, s.constLabels)
+	s.histograms = nil
+	if histograms {
+		s.histograms = latency.NewHistograms(s.register, s.experimentDimension, s.histogramBuckets)
+	}
+	if s.latest != nil {
+		s.observe(s.latest)
+	}
+}
+
+// Latest returns the most recent Measurement, or nil if no measurement has been taken yet
+func (s *Server) Latest() *latency.Measurement {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.latest
+}
+
+// History returns the most recent measurements, newest first
+func (s *Server) History() []*latency.Measurement {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]*latency.Measurement{}, s.history...)
+}
+
+// Run re-measures on the interval until the context is cancelled
+func (s *Server) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.measurer.ClearCache()
+			s.update(s.measurer.Measure(ctx))
+		}
+	}
+}
+
+// update stores the measurement as the latest and updates the prometheus metrics
+func (s *Server) update(measurement *latency.Measurement) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latest = measurement
+	s.history = lo.Slice(append([]*latency.Measurement{measurement}, s.history...), 0, s.maxHistory)
+	s.observe(measurement)
+}
+
+// observe records the measurement into the histograms, or sets the gauges to it
+func (s *Server) observe(measurement *latency.Measurement) {
+	if s.histograms != nil {
+		s.histograms.Observe(measurement)
+		return
+	}
+	measurement.RegisterMetrics(s.register, s.experimentDimension)
+}
+
+// Handler returns an http.Handler that serves /metrics, /healthz, /measurement, and /measurements
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	// exemplars of the histograms are only exposed in the OpenMetrics format
+	mux.Handle("/metrics", promhttp.HandlerFor(
+		s.registry,
+		promhttp.HandlerOpts{EnableOpenMetrics: s.histograms != nil},
+	))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/measurement", func(w http.ResponseWriter, _ *http.Request) {
+		measurement := s.Latest()
+		if measurement == nil {
+			http.Error(w, "no measurement has been taken yet", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(measurement); err != nil {
+			log.Printf("unable to encode measurement: %v", err)
+		}
+	})
+	mux.HandleFunc("/measurements", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(s.History()); err != nil {
+			log.Printf("unable to encode measurements: %v", err)
+		}
+	})
+	return mux
+}
+
+// ListenAndServe re-measures in the background and serves HTTP on the addr until the context is cancelled
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go s.Run(ctx)
+	srv := &http.Server{
+		ReadTimeout:       1 * time.Second,
+		WriteTimeout:      5 * time.Second,
+		IdleTimeout:       30 * time.Second,
+		ReadHeaderTimeout: 2 * time.Second,
+		Addr:              addr,
+		Handler:           s.Handler(),
+	}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Shutdown(context.Background())
+	}()
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package server is a central aggregation server that DaemonSet agents push Measurements to
+// It aggregates fleet-wide latency percentiles per metric, instance type, and AMI and serves them on its own /metrics endpoint.
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+)
+
+// MeasurementsPath is the path agents POST JSON Measurements to
+const MeasurementsPath = "/v1/measurements"
+
+// maxMeasurementBytes limits the size of a pushed Measurement
+const maxMeasurementBytes = 1 << 20
+
+// latenciesMetric is the name of the fleet-wide latencies and latenciesLabels are its labels
+var (
+	latenciesMetric = "node_latency_fleet_seconds"
+	latenciesLabels = []string{"metric", "instanceType", "amiID"}
+)
+
+// unknownLabel is the label value when a Measurement has no metadata
+const unknownLabel = "unknown"
+
+// Server aggregates Measurements pushed by agents
+type Server struct {
+	registry  *prometheus.Registry
+	latencies prometheus.ObserverVec
+	reports   *prometheus.CounterVec
+}
+
+// New creates a new Server that aggregates the percentiles of the Measurements received within the window
+func New(window time.Duration) *Server {
+	latencies := prometheus.NewSummaryVec(prometheus.SummaryOpts{
+		Name:       latenciesMetric,
+		Help:       "Fleet-wide latency percentiles of the timings pushed by agents",
+		Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		MaxAge:     window,
+	}, latenciesLabels)
+	s := &Server{
+		registry:  prometheus.NewRegistry(),
+		latencies: latencies,
+		reports: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "node_latency_fleet_reports_total",
+			Help: "Number of Measurements pushed by agents",
+		}, []string{"instanceType", "amiID"}),
+	}
+	s.registry.MustRegister(latencies, s.reports)
+	return s
+}
+
+// WithHistograms aggregates the timings into histograms with the buckets instead of summaries, so Prometheus can compute
+// the percentiles over any time range and aggregate them across servers, the window does not apply to histograms
+func (s *Server) WithHistograms(buckets []float64) *Server {
+	// the registry keeps the help of unregistered metrics, so the histograms are registered on a new registry
+	s.registry = prometheus.NewRegistry()
+	latencies := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    latenciesMetric,
+		Help:    "Fleet-wide latency histograms of the timings pushed by agents",
+		Buckets: lo.Ternary(len(buckets) == 0, latency.DefaultHistogramBuckets, buckets),
+	}, latenciesLabels)
+	s.registry.MustRegister(latencies, s.reports)
+	s.latencies = latencies
+	return s
+}
+
+// Record aggregates the successful timings of a Measurement
+func (s *Server) Record(measurement *latency.Measurement) {
+	instanceType, amiID := unknownLabel, unknownLabel
+	if measurement.Metadata != nil {
+		instanceType = labelOrUnknown(measurement.Metadata.InstanceType)
+		amiID = labelOrUnknown(measurement.Metadata.AMIID)
+	}
+	s.reports.WithLabelValues(instanceType, amiID).Inc()
+	for _, timing := range measurement.Timings {
+		if timing.Error != nil {
+			continue
+		}
+		measurement.ObserveWithExemplar(s.latencies.WithLabelValues(timing.Event.Metric, instanceType, amiID), timing.T.Seconds())
+	}
+}
+
+// Handler returns an http.Handler that receives Measurements on /v1/measurements and serves /metrics and /healthz
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	// exemplars of the histograms are only exposed in the OpenMetrics format
+	_, histograms := s.latencies.(*prometheus.HistogramVec)
+	mux.Handle("/metrics", promhttp.HandlerFor(
+		s.registry,
+		promhttp.HandlerOpts{EnableOpenMetrics: histograms},
+	))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+	mux.HandleFunc(MeasurementsPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var measurement latency.Measurement
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxMeasurementBytes)).Decode(&measurement); err != nil {
+			http.Error(w, fmt.Sprintf("invalid measurement: %v", err), http.StatusBadRequest)
+			return
+		}
+		s.Record(&measurement)
+		w.WriteHeader(http.StatusAccepted)
+	})
+	return mux
+}
+
+// ListenAndServe serves HTTP on the addr until the context is cancelled
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		ReadTimeout:       5 * time.Second,
+		WriteTimeout:      5 * time.Second,
+		IdleTimeout:       30 * time.Second,
+		ReadHeaderTimeout: 2 * time.Second,
+		Addr:              addr,
+		Handler:           s.Handler(),
+	}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Shutdown(context.Background())
+	}()
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Push sends a Measurement to the aggregation server at the base URL, i.e. http://node-latency-for-k8s-server:8080
+func Push(ctx context.Context, serverURL string, measurement *latency.Measurement) error {
+	body, err := json.Marshal(measurement)
+	if err != nil {
+		return fmt.Errorf("unable to marshal measurement: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(serverURL, "/")+MeasurementsPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to push measurement: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unable to push measurement, status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// labelOrUnknown defaults empty label values to unknown
+func labelOrUnknown(value string) string {
+	if value == "" {
+		return unknownLabel
+	}
+	return value
+}
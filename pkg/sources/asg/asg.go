@@ -0,0 +1,204 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package asg is a latency timing source for the EC2 Auto Scaling Group scaling activity that launched the instance
+package asg
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	Name = "ASG"
+	// causeTimestamp matches the timestamps of the steps in an activity's cause, the first is the scale-out decision
+	causeTimestamp = regexp.MustCompile(`At ([0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}Z) ([^.]*)`)
+)
+
+// Source is the EC2 Auto Scaling API source
+type Source struct {
+	client     *autoscaling.Client
+	instanceID string
+	asgName    string
+}
+
+// New instantiates a new instance of the Auto Scaling Group source for the instance
+func New(client *autoscaling.Client, instanceID string) *Source {
+	return &Source{
+		client:     client,
+		instanceID: instanceID,
+	}
+}
+
+// ClearCache is a noop for the ASG Source since it is an http source, not a log file
+func (s Source) ClearCache() {}
+
+// String is a human readable string of the source
+func (s Source) String() string {
+	return Name
+}
+
+// Name is the name of the source
+func (s Source) Name() string {
+	return Name
+}
+
+// RemoteClock marks the timestamps of the Auto Scaling API as from a remote clock, they are not skewed by steps of the node's clock
+func (s Source) RemoteClock() {}
+
+// FindScaleOutDecision is a helper func that returns a FindFunc for when the desired capacity was changed, i.e. by the cluster-autoscaler, which led to the instance launch
+// Matched lines are formatted as "<RFC3339 timestamp> <cause>"
+func (s *Source) FindScaleOutDecision() sources.FindFunc {
+	return func(ctx context.Context, _ sources.Source, _ []byte) ([]string, error) {
+		activity, err := s.getLaunchActivity(ctx)
+		if err != nil {
+			return nil, err
+		}
+		match := causeTimestamp.FindStringSubmatch(lo.FromPtr(activity.Cause))
+		if len(match) != 3 {
+			return nil, fmt.Errorf("unable to find the scale-out decision in the cause of activity %s", lo.FromPtr(activity.ActivityId))
+		}
+		return []string{fmt.Sprintf("%s %s", match[1], match[2])}, nil
+	}
+}
+
+// FindLaunchStarted is a helper func that returns a FindFunc for when the launch activity of the instance started
+func (s *Source) FindLaunchStarted() sources.FindFunc {
+	return func(ctx context.Context, _ sources.Source, _ []byte) ([]string, error) {
+		activity, err := s.getLaunchActivity(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("%s %s", lo.FromPtr(activity.StartTime).Format(time.RFC3339Nano), lo.FromPtr(activity.Description))}, nil
+	}
+}
+
+// FindLaunchCompleted is a helper func that returns a FindFunc for when the launch activity of the instance completed successfully
+// The activity completes after any launch lifecycle hooks are completed and the instance is InService.
+func (s *Source) FindLaunchCompleted() sources.FindFunc {
+	return func(ctx context.Context, _ sources.Source, _ []byte) ([]string, error) {
+		activity, err := s.getLaunchActivity(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if activity.StatusCode != types.ScalingActivityStatusCodeSuccessful || activity.EndTime == nil {
+			return nil, fmt.Errorf("launch activity %s of %s is %s", lo.FromPtr(activity.ActivityId), s.instanceID, activity.StatusCode)
+		}
+		return []string{fmt.Sprintf("%s %s", activity.EndTime.Format(time.RFC3339Nano), activity.StatusCode)}, nil
+	}
+}
+
+// FindWarmPoolExit is a helper func that returns a FindFunc for when the instance was moved from the ASG's warm pool to InService
+// Matched lines are formatted as "<RFC3339 timestamp> <description>", instances which were not launched into a warm pool are not found.
+func (s *Source) FindWarmPoolExit() sources.FindFunc {
+	return func(ctx context.Context, _ sources.Source, _ []byte) ([]string, error) {
+		activity, err := s.getActivity(ctx, "warm pool exit", func(description string) bool {
+			return strings.Contains(description, "from warm pool")
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("%s %s", lo.FromPtr(activity.StartTime).Format(time.RFC3339Nano), lo.FromPtr(activity.Description))}, nil
+	}
+}
+
+// CommentDetail is a helper func that returns a CommentFunc which comments the detail of a matched line without the timestamp
+func (s *Source) CommentDetail() func(matchedLine string) string {
+	return func(matchedLine string) string {
+		_, detail, _ := strings.Cut(matchedLine, " ")
+		return detail
+	}
+}
+
+// getLaunchActivity finds the scaling activity that launched the instance
+func (s *Source) getLaunchActivity(ctx context.Context) (*types.Activity, error) {
+	return s.getActivity(ctx, "launch", func(description string) bool {
+		return strings.HasPrefix(description, "Launching")
+	})
+}
+
+// getActivity finds the most recent scaling activity of the instance whose description matches
+func (s *Source) getActivity(ctx context.Context, kind string, matches func(description string) bool) (*types.Activity, error) {
+	asgName, err := s.getASGName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	paginator := autoscaling.NewDescribeScalingActivitiesPaginator(s.client, &autoscaling.DescribeScalingActivitiesInput{
+		AutoScalingGroupName: &asgName,
+	})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe scaling activities of %s: %w", asgName, err)
+		}
+		for _, activity := range out.Activities {
+			description := lo.FromPtr(activity.Description)
+			if matches(description) && strings.Contains(description, s.instanceID) {
+				return &activity, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no %s activity found for %s in %s", kind, s.instanceID, asgName)
+}
+
+// getASGName retrieves the name of the instance's Auto Scaling Group from the cached value or DescribeAutoScalingInstances
+func (s *Source) getASGName(ctx context.Context) (string, error) {
+	if s.asgName != "" {
+		return s.asgName, nil
+	}
+	out, err := s.client.DescribeAutoScalingInstances(ctx, &autoscaling.DescribeAutoScalingInstancesInput{
+		InstanceIds: []string{s.instanceID},
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to describe auto scaling instance %s: %w", s.instanceID, err)
+	}
+	if len(out.AutoScalingInstances) != 1 {
+		return "", fmt.Errorf("%s is not in an Auto Scaling Group", s.instanceID)
+	}
+	s.asgName = lo.FromPtr(out.AutoScalingInstances[0].AutoScalingGroupName)
+	return s.asgName, nil
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search the source and return the result
+func (s *Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	lines, err := event.FindFn(ctx, s, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, line := range lines {
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(line)
+		}
+		tsStr, _, _ := strings.Cut(line, " ")
+		ts, err := time.Parse(time.RFC3339Nano, tsStr)
+		results = append(results, sources.FindResult{
+			Line:      line,
+			Timestamp: ts,
+			Comment:   comment,
+			Err:       err,
+		})
+	}
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
@@ -16,6 +16,7 @@ limitations under the License.
 package awsnode
 
 import (
+	"context"
 	"regexp"
 	"sort"
 
@@ -35,10 +36,12 @@ type Source struct {
 }
 
 // New instantiates a new instance of the AWSNode source
-func New(path string) *Source {
+// The paths are more paths, or globs, of the aws-node logs, the lines of every matched log are merged.
+func New(path string, paths ...string) *Source {
 	return &Source{
 		logReader: &sources.LogReader{
 			Path:            path,
+			Paths:           paths,
 			Glob:            true,
 			TimestampRegex:  TimestampFormat,
 			TimestampLayout: TimestampLayout,
@@ -51,9 +54,29 @@ func (a Source) ClearCache() {
 	a.logReader.ClearCache()
 }
 
+// Tail will incrementally read the log as it is appended to
+func (a Source) Tail() {
+	a.logReader.Tail()
+}
+
+// WatchPaths are the directories of the log files to watch for writes
+func (a Source) WatchPaths() []string {
+	return a.logReader.WatchPaths()
+}
+
+// Checkpoint returns the read position and matches of the tailed log
+func (a Source) Checkpoint() *sources.Checkpoint {
+	return a.logReader.Checkpoint()
+}
+
+// Restore tails the log from the checkpoint
+func (a Source) Restore(checkpoint *sources.Checkpoint) {
+	a.logReader.Restore(checkpoint)
+}
+
 // String is a human readable string of the source, usually the log file path
 func (a Source) String() string {
-	return a.logReader.Path
+	return a.logReader.String()
 }
 
 // Name is the log source name
@@ -63,18 +86,20 @@ func (a Source) Name() string {
 
 // FindByRegex is a helper func that returns a FindFunc to search for a regex in a log source that can be used in an Event
 func (a Source) FindByRegex(re *regexp.Regexp) sources.FindFunc {
-	return func(s sources.Source, log []byte) ([]string, error) {
-		return a.logReader.Find(re)
+	return func(ctx context.Context, s sources.Source, log []byte) ([]string, error) {
+		return a.logReader.Find(ctx, re)
 	}
 }
 
+// FindByLine is a helper func that returns a FindFunc to search for the lines matched by a regex which the match func accepts,
+// i.e. an expression, that can be used in an Event
+func (a Source) FindByLine(re *regexp.Regexp, match func(line string) bool) sources.FindFunc {
+	return a.logReader.FindByLine(re, match)
+}
+
 // Find will use the Event's FindFunc and CommentFunc to search the log source and return the results based on the Event's matcher
-func (a Source) Find(event *sources.Event) ([]sources.FindResult, error) {
-	logBytes, err := a.logReader.Read()
-	if err != nil {
-		return nil, err
-	}
-	matchedLines, err := event.FindFn(a, logBytes)
+func (a Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	matchedLines, err := a.logReader.FindEvent(ctx, a, event)
 	if err != nil {
 		return nil, err
 	}
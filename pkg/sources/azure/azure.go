@@ -0,0 +1,275 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package azure is a latency timing source for the Azure Instance Metadata Service (IMDS)
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	Name               = "Azure IMDS"
+	DefaultEndpoint    = "http://169.254.169.254"
+	IMDSAPIVersion     = "2021-02-01"
+	ManagementEndpoint = "https://management.azure.com"
+	ComputeAPIVersion  = "2022-03-01"
+	// TimeCreated is a pseudo-path for the VM creation time which is retrieved from the Azure Resource Manager (ARM) API
+	// using the VM's managed identity since it is not available from IMDS directly
+	TimeCreated = "timeCreated"
+	// ProvisioningSucceeded is a pseudo-path for the time the VM finished provisioning from the ARM instance view
+	ProvisioningSucceeded = "provisioningSucceeded"
+)
+
+// Instance is the subset of the IMDS instance document used for metadata
+type Instance struct {
+	Compute struct {
+		Location       string `json:"location"`
+		Name           string `json:"name"`
+		ResourceID     string `json:"resourceId"`
+		SubscriptionID string `json:"subscriptionId"`
+		VMID           string `json:"vmId"`
+		VMScaleSetName string `json:"vmScaleSetName"`
+		VMSize         string `json:"vmSize"`
+		Zone           string `json:"zone"`
+		StorageProfile struct {
+			ImageReference struct {
+				ID        string `json:"id"`
+				Offer     string `json:"offer"`
+				Publisher string `json:"publisher"`
+				SKU       string `json:"sku"`
+				Version   string `json:"version"`
+			} `json:"imageReference"`
+		} `json:"storageProfile"`
+	} `json:"compute"`
+	Network struct {
+		Interface []struct {
+			IPv4 struct {
+				IPAddress []struct {
+					PrivateIPAddress string `json:"privateIpAddress"`
+				} `json:"ipAddress"`
+			} `json:"ipv4"`
+		} `json:"interface"`
+	} `json:"network"`
+}
+
+// Image returns the image id if the VM was created from a custom image, otherwise publisher:offer:sku:version
+func (i Instance) Image() string {
+	ref := i.Compute.StorageProfile.ImageReference
+	if ref.ID != "" {
+		return ref.ID
+	}
+	return strings.Join([]string{ref.Publisher, ref.Offer, ref.SKU, ref.Version}, ":")
+}
+
+// PrivateIP returns the first private IP of the primary interface
+func (i Instance) PrivateIP() string {
+	if len(i.Network.Interface) == 0 || len(i.Network.Interface[0].IPv4.IPAddress) == 0 {
+		return ""
+	}
+	return i.Network.Interface[0].IPv4.IPAddress[0].PrivateIPAddress
+}
+
+// Source is the Azure Instance Metadata Service (IMDS) http source
+type Source struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+// New instantiates a new instance of the Azure IMDS source
+func New(endpoint string) *Source {
+	return &Source{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// ClearCache is a noop for the Azure IMDS Source since it is an http source, not a log file
+func (s Source) ClearCache() {}
+
+// String is a human readable string of the source
+func (s Source) String() string {
+	return s.endpoint
+}
+
+// Name is the name of the source
+func (s Source) Name() string {
+	return Name
+}
+
+// RemoteClock marks the timestamps of the Azure IMDS as from a remote clock, they are not skewed by steps of the node's clock
+func (s Source) RemoteClock() {}
+
+// FindByPath is a helper func that returns a FindFunc to query a pseudo-path (TimeCreated or ProvisioningSucceeded) that can be used in an Event
+func (s Source) FindByPath(path string) sources.FindFunc {
+	return func(ctx context.Context, _ sources.Source, _ []byte) ([]string, error) {
+		switch path {
+		case TimeCreated:
+			ts, err := s.timeCreated(ctx)
+			return []string{ts}, err
+		case ProvisioningSucceeded:
+			ts, err := s.provisioningSucceeded(ctx)
+			return []string{ts}, err
+		}
+		return nil, fmt.Errorf("metadata for path \"%s\" is not available", path)
+	}
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search the source and return the result
+func (s Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	timestamps, err := event.FindFn(ctx, s, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, tsStr := range timestamps {
+		ts, err := time.Parse(time.RFC3339, tsStr)
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(tsStr)
+		}
+		results = append(results, sources.FindResult{
+			Line:      tsStr,
+			Timestamp: ts,
+			Comment:   comment,
+			Err:       err,
+		})
+	}
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
+
+// GetInstance retrieves the IMDS instance document
+func (s Source) GetInstance(ctx context.Context) (*Instance, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/metadata/instance?api-version=%s", s.endpoint, IMDSAPIVersion), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+	body, err := s.do(req, "Azure IMDS instance")
+	if err != nil {
+		return nil, err
+	}
+	var instance Instance
+	if err := json.Unmarshal(body, &instance); err != nil {
+		return nil, fmt.Errorf("unable to parse Azure IMDS instance: %w", err)
+	}
+	return &instance, nil
+}
+
+// timeCreated retrieves the VM creation time from ARM
+func (s Source) timeCreated(ctx context.Context) (string, error) {
+	var vm struct {
+		Properties struct {
+			TimeCreated string `json:"timeCreated"`
+		} `json:"properties"`
+	}
+	if err := s.getResource(ctx, "", &vm); err != nil {
+		return "", err
+	}
+	if vm.Properties.TimeCreated == "" {
+		return "", fmt.Errorf("virtual machine has no timeCreated")
+	}
+	return vm.Properties.TimeCreated, nil
+}
+
+// provisioningSucceeded retrieves the time the VM finished provisioning from the ARM instance view
+func (s Source) provisioningSucceeded(ctx context.Context) (string, error) {
+	var instanceView struct {
+		Statuses []struct {
+			Code string `json:"code"`
+			Time string `json:"time"`
+		} `json:"statuses"`
+	}
+	if err := s.getResource(ctx, "/instanceView", &instanceView); err != nil {
+		return "", err
+	}
+	for _, status := range instanceView.Statuses {
+		if status.Code == "ProvisioningState/succeeded" && status.Time != "" {
+			return status.Time, nil
+		}
+	}
+	return "", fmt.Errorf("virtual machine has not finished provisioning")
+}
+
+// getResource retrieves the VM's ARM resource (with an optional sub-path) using a managed identity token from IMDS
+func (s Source) getResource(ctx context.Context, subPath string, out interface{}) error {
+	instance, err := s.GetInstance(ctx)
+	if err != nil {
+		return err
+	}
+	token, err := s.managementToken(ctx)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s%s%s?api-version=%s", ManagementEndpoint, instance.Compute.ResourceID, subPath, ComputeAPIVersion), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	body, err := s.do(req, "Azure Resource Manager")
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("unable to parse Azure Resource Manager response: %w", err)
+	}
+	return nil
+}
+
+// managementToken retrieves an ARM access token for the VM's managed identity from IMDS
+func (s Source) managementToken(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/metadata/identity/oauth2/token?api-version=2018-02-01&resource=%s",
+		s.endpoint, url.QueryEscape(ManagementEndpoint+"/")), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata", "true")
+	body, err := s.do(req, "Azure managed identity token")
+	if err != nil {
+		return "", err
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("unable to parse managed identity token: %w", err)
+	}
+	return token.AccessToken, nil
+}
+
+// do executes the request and returns the body
+func (s Source) do(req *http.Request, desc string) ([]byte, error) {
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query %s: %w", desc, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response for %s: %w", desc, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to query %s: %s", desc, resp.Status)
+	}
+	return body, nil
+}
@@ -0,0 +1,87 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"regexp"
+)
+
+// Checkpoint is the read position and regex matches of a tailed source, persisted so a restarted process does not re-read the source
+type Checkpoint struct {
+	// Path and Offset are the log file and the byte offset read up to
+	Path   string `json:"path,omitempty"`
+	Offset int64  `json:"offset,omitempty"`
+	// Cursor is the journald cursor of the last entry read
+	Cursor string `json:"cursor,omitempty"`
+	// Matches are the lines matched before the read position, keyed by regex
+	Matches map[string][]string `json:"matches"`
+}
+
+// Checkpointer is a Source that can be checkpointed and restored, restoring a checkpoint also tails the source
+type Checkpointer interface {
+	Source
+	// Checkpoint returns the current read position and matches, or nil if the source has not been read while tailing
+	Checkpoint() *Checkpoint
+	// Restore continues reading the source from the checkpoint
+	Restore(checkpoint *Checkpoint)
+}
+
+// MatchCache keeps the regex matches of an append-only log so that only the appended data is searched
+type MatchCache struct {
+	matches map[string][]string
+	scanned map[string]int
+}
+
+// FindAll returns the cached matches of the regex and the matches in the log past the previously searched length
+// The log must only be appended to in-between calls, the cache is Reset when the log is re-read from the start.
+func (c *MatchCache) FindAll(re *regexp.Regexp, log []byte) []string {
+	if c.matches == nil {
+		c.Reset()
+	}
+	key := re.String()
+	lines := c.matches[key]
+	start := c.scanned[key]
+	if start > len(log) {
+		start = 0
+	}
+	for _, line := range re.FindAll(log[start:], -1) {
+		lines = append(lines, string(line))
+	}
+	c.matches[key], c.scanned[key] = lines, len(log)
+	return lines
+}
+
+// Reset clears the cached matches
+func (c *MatchCache) Reset() {
+	c.matches, c.scanned = map[string][]string{}, map[string]int{}
+}
+
+// Matches returns the cached matches keyed by regex, i.e. for a Checkpoint
+func (c *MatchCache) Matches() map[string][]string {
+	matches := map[string][]string{}
+	for key, lines := range c.matches {
+		matches[key] = append([]string{}, lines...)
+	}
+	return matches
+}
+
+// Restore resets the cache to the matches of a Checkpoint, the restored regexes are then only searched past the scanned length of the log
+func (c *MatchCache) Restore(matches map[string][]string, scanned int) {
+	c.Reset()
+	for key, lines := range matches {
+		c.matches[key] = append([]string{}, lines...)
+		c.scanned[key] = scanned
+	}
+}
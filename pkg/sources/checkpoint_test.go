@@ -0,0 +1,58 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestMatchCache(t *testing.T) {
+	re := regexp.MustCompile(`(?m)^ready .*$`)
+	cache := &MatchCache{}
+	for _, tc := range []struct {
+		name  string
+		log   string
+		lines []string
+	}{
+		{name: "empty log", log: ""},
+		{name: "first match", log: "ready 1\nwaiting\n", lines: []string{"ready 1"}},
+		{name: "unchanged log", log: "ready 1\nwaiting\n", lines: []string{"ready 1"}},
+		{name: "appended match", log: "ready 1\nwaiting\nready 2\n", lines: []string{"ready 1", "ready 2"}},
+		{name: "appended without a match", log: "ready 1\nwaiting\nready 2\nwaiting\n", lines: []string{"ready 1", "ready 2"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if lines := cache.FindAll(re, []byte(tc.log)); !reflect.DeepEqual(lines, tc.lines) {
+				t.Errorf("expected lines %q, got %q", tc.lines, lines)
+			}
+		})
+	}
+
+	// a restored cache only searches the log past the scanned length
+	matches := cache.Matches()
+	matches[re.String()][0] = "modified"
+	restored := &MatchCache{}
+	restored.Restore(cache.Matches(), len("ready 1\nwaiting\nready 2\n"))
+	expected := []string{"ready 1", "ready 2", "ready 3"}
+	if lines := restored.FindAll(re, []byte("ready 1\nwaiting\nready 2\nready 3\n")); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected restored lines %q, got %q", expected, lines)
+	}
+
+	cache.Reset()
+	if lines := cache.FindAll(re, []byte("ready 3\n")); !reflect.DeepEqual(lines, []string{"ready 3"}) {
+		t.Errorf("expected the reset cache to search the whole log, got %q", lines)
+	}
+}
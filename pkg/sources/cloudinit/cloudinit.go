@@ -0,0 +1,258 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cloudinit is a latency timing source for cloud-init's own log (/var/log/cloud-init.log) and recorded boot stages (status.json)
+// This is the same data "cloud-init analyze" uses, so stage and per-module timings (i.e. user-data scripts) are available with millisecond precision.
+package cloudinit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	Name              = "Cloud-Init"
+	DefaultPath       = "/var/log/cloud-init.log"
+	DefaultStatusPath = "/var/lib/cloud/data/status.json"
+	TimestampFormat   = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2} [0-9]{2}:[0-9]{2}:[0-9]{2},[0-9]{3}`)
+	TimestampLayout   = "2006-01-02 15:04:05,000"
+
+	moduleEvent = regexp.MustCompile(` - handlers\.py\[DEBUG\]: (start|finish): [a-z-]+/([a-z0-9_-]+): ?([A-Z]*)`)
+)
+
+// Stages as recorded in status.json
+const (
+	StageInitLocal     = "init-local"
+	StageInit          = "init"
+	StageModulesConfig = "modules-config"
+	StageModulesFinal  = "modules-final"
+)
+
+// Stage boundaries as recorded in status.json
+const (
+	BoundaryStart    = "start"
+	BoundaryFinished = "finished"
+)
+
+// Source is the cloud-init log and status source
+type Source struct {
+	logReader  *sources.LogReader
+	statusPath string
+	status     map[string]json.RawMessage
+}
+
+// stage is a boot stage in status.json, times are seconds since the epoch and null if the stage has not been reached
+type stage struct {
+	Start    *float64 `json:"start"`
+	Finished *float64 `json:"finished"`
+}
+
+// New instantiates a new instance of the cloud-init source
+func New(path string, statusPath string) *Source {
+	return &Source{
+		logReader: &sources.LogReader{
+			Path:            path,
+			TimestampRegex:  TimestampFormat,
+			TimestampLayout: TimestampLayout,
+		},
+		statusPath: statusPath,
+	}
+}
+
+// ClearCache will clear the log reader and status cache
+func (s *Source) ClearCache() {
+	s.logReader.ClearCache()
+	s.status = nil
+}
+
+// Tail will incrementally read cloud-init.log as it is appended to, status.json is always re-read
+func (s *Source) Tail() {
+	s.logReader.Tail()
+}
+
+// WatchPaths are the directories of cloud-init.log and status.json to watch for writes
+func (s *Source) WatchPaths() []string {
+	return lo.Uniq(append(s.logReader.WatchPaths(), filepath.Dir(s.statusPath)))
+}
+
+// Checkpoint returns the read position and matches of the tailed log, status.json is always re-read
+func (s *Source) Checkpoint() *sources.Checkpoint {
+	return s.logReader.Checkpoint()
+}
+
+// Restore tails the log from the checkpoint
+func (s *Source) Restore(checkpoint *sources.Checkpoint) {
+	s.logReader.Restore(checkpoint)
+}
+
+// String is a human readable string of the source, the cloud-init log path
+func (s *Source) String() string {
+	return s.logReader.Path
+}
+
+// Name is the name of the source
+func (s *Source) Name() string {
+	return Name
+}
+
+// FindByRegex is a helper func that returns a FindFunc to search for a regex in cloud-init.log that can be used in an Event
+func (s *Source) FindByRegex(re *regexp.Regexp) sources.FindFunc {
+	return func(ctx context.Context, _ sources.Source, _ []byte) ([]string, error) {
+		return s.logReader.Find(ctx, re)
+	}
+}
+
+// FindByLine is a helper func that returns a FindFunc to search for the lines matched by a regex which the match func accepts,
+// i.e. an expression, that can be used in an Event
+func (s *Source) FindByLine(re *regexp.Regexp, match func(line string) bool) sources.FindFunc {
+	return s.logReader.FindByLine(re, match)
+}
+
+// FindByModule is a helper func that returns a FindFunc to find when a cloud-init module (i.e. "config-scripts-user") started or finished
+// boundary is either BoundaryStart or BoundaryFinished
+func (s *Source) FindByModule(module string, boundary string) sources.FindFunc {
+	action := "start"
+	if boundary == BoundaryFinished {
+		action = "finish"
+	}
+	return s.FindByRegex(regexp.MustCompile(fmt.Sprintf(`.* - handlers\.py\[DEBUG\]: %s: [a-z-]+/%s: .*`, action, regexp.QuoteMeta(module))))
+}
+
+// FindModuleFinishes is a helper func that returns a FindFunc to find when every cloud-init module finished
+// It is intended to be used with the EventMatchSelectorAll and CommentModuleDuration for per-module timings.
+func (s *Source) FindModuleFinishes() sources.FindFunc {
+	return s.FindByRegex(regexp.MustCompile(`.* - handlers\.py\[DEBUG\]: finish: [a-z-]+/config-[a-z0-9_-]+: .*`))
+}
+
+// FindByStage is a helper func that returns a FindFunc to find when a cloud-init stage started or finished from status.json
+func (s *Source) FindByStage(stageName string, boundary string) sources.FindFunc {
+	return func(_ context.Context, _ sources.Source, _ []byte) ([]string, error) {
+		status, err := s.readStatus()
+		if err != nil {
+			return nil, err
+		}
+		rawStage, ok := status[stageName]
+		if !ok {
+			return nil, fmt.Errorf("stage %s not found in %s", stageName, s.statusPath)
+		}
+		var st stage
+		if err := json.Unmarshal(rawStage, &st); err != nil {
+			return nil, fmt.Errorf("unable to parse stage %s in %s: %w", stageName, s.statusPath, err)
+		}
+		epochSecs := st.Start
+		if boundary == BoundaryFinished {
+			epochSecs = st.Finished
+		}
+		if epochSecs == nil {
+			return nil, fmt.Errorf("stage %s has no %s time in %s", stageName, boundary, s.statusPath)
+		}
+		ts := time.UnixMicro(int64(*epochSecs * 1e6)).UTC()
+		// format the same as a cloud-init.log line so that timestamps are parsed uniformly
+		return []string{fmt.Sprintf("%s - status.json: %s: %s", ts.Format(TimestampLayout), boundary, stageName)}, nil
+	}
+}
+
+// CommentModuleDuration is a helper func that returns a CommentFunc which comments a module finish line with the module name, result, and duration
+func (s *Source) CommentModuleDuration() func(matchedLine string) string {
+	return func(matchedLine string) string {
+		match := moduleEvent.FindStringSubmatch(matchedLine)
+		if len(match) != 4 || match[1] != "finish" {
+			return matchedLine
+		}
+		module, result := match[2], match[3]
+		finish, err := s.ParseTimestamp(matchedLine)
+		if err != nil {
+			return fmt.Sprintf("%s %s", module, result)
+		}
+		starts, err := s.FindByModule(module, BoundaryStart)(context.Background(), s, nil)
+		if err != nil {
+			return fmt.Sprintf("%s %s", module, result)
+		}
+		// use the latest start before the finish in case the module ran in multiple stages
+		var start time.Time
+		for _, line := range starts {
+			if ts, err := s.ParseTimestamp(line); err == nil && !ts.After(finish) {
+				start = ts
+			}
+		}
+		if start.IsZero() {
+			return fmt.Sprintf("%s %s", module, result)
+		}
+		return fmt.Sprintf("%s %s %s", module, result, finish.Sub(start))
+	}
+}
+
+// ParseTimestamp parses the timestamp of a cloud-init.log line
+func (s *Source) ParseTimestamp(line string) (time.Time, error) {
+	rawTS := TimestampFormat.FindString(line)
+	if rawTS == "" {
+		return time.Time{}, fmt.Errorf("unable to find timestamp on log line matching regex: \"%s\" \"%s\"", TimestampFormat.String(), line)
+	}
+	return time.Parse(TimestampLayout, rawTS)
+}
+
+// readStatus reads and caches the stages of status.json
+func (s *Source) readStatus() (map[string]json.RawMessage, error) {
+	if s.status != nil {
+		return s.status, nil
+	}
+	statusBytes, err := os.ReadFile(s.statusPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read cloud-init status %s: %w", s.statusPath, err)
+	}
+	var status struct {
+		V1 map[string]json.RawMessage `json:"v1"`
+	}
+	if err := json.Unmarshal(statusBytes, &status); err != nil {
+		return nil, fmt.Errorf("unable to parse cloud-init status %s: %w", s.statusPath, err)
+	}
+	s.status = status.V1
+	return s.status, nil
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search cloud-init's log or status and return the results based on the Event's matcher
+func (s *Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	matchedLines, err := event.FindFn(ctx, s, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, line := range matchedLines {
+		ts, err := s.ParseTimestamp(line)
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(line)
+		}
+		results = append(results, sources.FindResult{
+			Line:      line,
+			Timestamp: ts,
+			Err:       err,
+			Comment:   comment,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Timestamp.UnixMicro() < results[j].Timestamp.UnixMicro()
+	})
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
@@ -0,0 +1,198 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cri is a latency timing source for pod sandbox and container timestamps from a container runtime's CRI gRPC API
+// The CRI API is implemented by containerd and CRI-O, so timings do not depend on the runtime's log format.
+package cri
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	Name               = "CRI"
+	ContainerdEndpoint = "unix:///run/containerd/containerd.sock"
+	CRIOEndpoint       = "unix:///var/run/crio/crio.sock"
+	CRIDockerdEndpoint = "unix:///run/cri-dockerd.sock"
+	DockershimEndpoint = "unix:///var/run/dockershim.sock"
+	DefaultEndpoint    = ContainerdEndpoint
+	// DefaultTimeout is the timeout of each CRI API call
+	DefaultTimeout = 5 * time.Second
+)
+
+// Labels the kubelet sets on pod sandboxes and containers
+const (
+	podNameLabel       = "io.kubernetes.pod.name"
+	podNamespaceLabel  = "io.kubernetes.pod.namespace"
+	containerNameLabel = "io.kubernetes.container.name"
+)
+
+// Container timestamps
+const (
+	// ContainerCreatedAt is when the runtime created the container
+	ContainerCreatedAt = "createdAt"
+	// ContainerStartedAt is when the container's process was started
+	ContainerStartedAt = "startedAt"
+)
+
+// Source is the CRI API source
+// The runtime's socket must be accessible, i.e. mounted into the pod
+type Source struct {
+	endpoint string
+}
+
+// New instantiates a new instance of the CRI source for a runtime endpoint, i.e. "unix:///run/containerd/containerd.sock"
+func New(endpoint string) *Source {
+	return &Source{
+		endpoint: endpoint,
+	}
+}
+
+// ClearCache is a noop for the CRI Source since the runtime is queried on every Find
+func (s *Source) ClearCache() {}
+
+// String is a human readable string of the source, the runtime endpoint
+func (s *Source) String() string {
+	return s.endpoint
+}
+
+// Name is the name of the source
+func (s *Source) Name() string {
+	return Name
+}
+
+// FindBySandbox is a helper func that returns a FindFunc to find when the sandboxes of pods with the name prefix in the namespace were created
+// Matched lines are formatted as "<unix micro> <namespace>/<pod>" so the matched line can be used as a comment.
+func (s *Source) FindBySandbox(namespace string, podNamePrefix string) sources.FindFunc {
+	return func(ctx context.Context, _ sources.Source, _ []byte) ([]string, error) {
+		ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+		client, conn, err := s.connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		resp, err := client.ListPodSandbox(ctx, &runtimeapi.ListPodSandboxRequest{
+			Filter: &runtimeapi.PodSandboxFilter{LabelSelector: map[string]string{podNamespaceLabel: namespace}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to list pod sandboxes from %s: %w", s.endpoint, err)
+		}
+		var lines []string
+		for _, sandbox := range resp.Items {
+			if !strings.HasPrefix(sandbox.Metadata.GetName(), podNamePrefix) {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%d %s/%s", time.Unix(0, sandbox.CreatedAt).UnixMicro(), namespace, sandbox.Metadata.GetName()))
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("no pod sandboxes in %s with name prefix %s", namespace, podNamePrefix)
+		}
+		return lines, nil
+	}
+}
+
+// FindByContainer is a helper func that returns a FindFunc to find when a container of pods with the name prefix in the namespace was created or started
+// timestamp is either ContainerCreatedAt or ContainerStartedAt. Matched lines are formatted as "<unix micro> <namespace>/<pod>/<container>".
+func (s *Source) FindByContainer(namespace string, podNamePrefix string, containerName string, timestamp string) sources.FindFunc {
+	return func(ctx context.Context, _ sources.Source, _ []byte) ([]string, error) {
+		ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+		client, conn, err := s.connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		resp, err := client.ListContainers(ctx, &runtimeapi.ListContainersRequest{
+			Filter: &runtimeapi.ContainerFilter{LabelSelector: map[string]string{
+				podNamespaceLabel:  namespace,
+				containerNameLabel: containerName,
+			}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to list containers from %s: %w", s.endpoint, err)
+		}
+		var lines []string
+		for _, container := range resp.Containers {
+			podName := container.Labels[podNameLabel]
+			if !strings.HasPrefix(podName, podNamePrefix) {
+				continue
+			}
+			ts := container.CreatedAt
+			if timestamp == ContainerStartedAt {
+				status, err := client.ContainerStatus(ctx, &runtimeapi.ContainerStatusRequest{ContainerId: container.Id})
+				if err != nil {
+					return nil, fmt.Errorf("unable to get status of container %s from %s: %w", container.Id, s.endpoint, err)
+				}
+				// containers that have not started yet have a zero started time
+				if status.Status.GetStartedAt() == 0 {
+					continue
+				}
+				ts = status.Status.GetStartedAt()
+			}
+			lines = append(lines, fmt.Sprintf("%d %s/%s/%s", time.Unix(0, ts).UnixMicro(), namespace, podName, containerName))
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("no %s containers in %s with pod name prefix %s", containerName, namespace, podNamePrefix)
+		}
+		return lines, nil
+	}
+}
+
+// Find will use the Event's FindFunc and CommentFunc to query the runtime and return the results based on the Event's matcher
+func (s *Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	matchedLines, err := event.FindFn(ctx, s, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, line := range matchedLines {
+		tsStr, _, _ := strings.Cut(line, " ")
+		tsMicros, err := strconv.ParseInt(tsStr, 10, 64)
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(line)
+		}
+		results = append(results, sources.FindResult{
+			Line:      line,
+			Timestamp: time.UnixMicro(tsMicros),
+			Comment:   comment,
+			Err:       err,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Timestamp.UnixMicro() < results[j].Timestamp.UnixMicro()
+	})
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
+
+// connect dials the runtime endpoint
+func (s *Source) connect(ctx context.Context) (runtimeapi.RuntimeServiceClient, *grpc.ClientConn, error) {
+	conn, err := grpc.DialContext(ctx, s.endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to connect to the CRI runtime at %s: %w", s.endpoint, err)
+	}
+	return runtimeapi.NewRuntimeServiceClient(conn), conn, nil
+}
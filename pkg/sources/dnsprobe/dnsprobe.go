@@ -0,0 +1,145 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dnsprobe is a latency timing source which actively resolves a cluster DNS name from the node
+// A node can be ready before cluster DNS is reachable from it, i.e. before kube-proxy programs the service rules, which breaks pods.
+package dnsprobe
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	Name = "DNS Probe"
+	// DefaultName is resolved by the probe if no name is set, the kubernetes service exists in every cluster
+	DefaultName = "kubernetes.default.svc.cluster.local"
+	// DefaultTimeout is the timeout of each resolution
+	DefaultTimeout = 2 * time.Second
+)
+
+// Source is an active DNS probe source, the name is resolved every time the source is searched until it first resolves
+type Source struct {
+	name     string
+	server   string
+	resolver *net.Resolver
+	mu       sync.Mutex
+	probes   int
+	resolved time.Time
+	addrs    []string
+}
+
+// New instantiates a new instance of the DNS probe source
+// The server is the cluster DNS service address to query, i.e. 10.100.0.10, or the system resolver is used if it is empty.
+func New(name string, server string) *Source {
+	if name == "" {
+		name = DefaultName
+	}
+	resolver := net.DefaultResolver
+	if server != "" {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, server)
+			},
+		}
+	}
+	return &Source{
+		name:     name,
+		server:   server,
+		resolver: resolver,
+	}
+}
+
+// ClearCache is a noop for the DNS probe Source, the first resolution is kept
+func (s *Source) ClearCache() {}
+
+// String is a human readable string of the source
+func (s *Source) String() string {
+	if s.server == "" {
+		return fmt.Sprintf("%s (%s)", Name, s.name)
+	}
+	return fmt.Sprintf("%s (%s @ %s)", Name, s.name, s.server)
+}
+
+// Name is the name of the source
+func (s *Source) Name() string {
+	return Name
+}
+
+// FindFirstResolution probes the name if it has not resolved yet and returns when it first resolved
+// The time is when the probe succeeded, so it is only as precise as the interval the source is searched at.
+func (s *Source) FindFirstResolution() sources.FindFunc {
+	return func(ctx context.Context, _ sources.Source, _ []byte) ([]string, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.resolved.IsZero() {
+			s.probes++
+			probeCtx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+			defer cancel()
+			addrs, err := s.resolver.LookupHost(probeCtx, s.name)
+			if err != nil {
+				return nil, fmt.Errorf("unable to resolve %s after %d probes: %w", s.name, s.probes, err)
+			}
+			s.resolved, s.addrs = time.Now(), addrs
+		}
+		return []string{s.resolved.Format(time.RFC3339Nano)}, nil
+	}
+}
+
+// CommentResolution is a helper func that returns a CommentFunc with the resolved addresses and the number of probes
+// A name which resolved on the first probe may have been reachable before the probe started.
+func (s *Source) CommentResolution() func(matchedLine string) string {
+	return func(_ string) string {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.probes == 1 {
+			return fmt.Sprintf("%s resolved to %s on the first probe", s.name, strings.Join(s.addrs, ","))
+		}
+		return fmt.Sprintf("%s resolved to %s after %d probes", s.name, strings.Join(s.addrs, ","), s.probes)
+	}
+}
+
+// Find will use the Event's FindFunc and CommentFunc to probe the source and return the result
+func (s *Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	timestamps, err := event.FindFn(ctx, s, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, tsStr := range timestamps {
+		ts, err := time.Parse(time.RFC3339Nano, tsStr)
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(tsStr)
+		}
+		results = append(results, sources.FindResult{
+			Line:      tsStr,
+			Timestamp: ts,
+			Comment:   comment,
+			Err:       err,
+		})
+	}
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
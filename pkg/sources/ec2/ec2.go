@@ -64,10 +64,12 @@ func (s Source) Name() string {
 	return Name
 }
 
+// RemoteClock marks the timestamps of the EC2 API as from a remote clock, they are not skewed by steps of the node's clock
+func (s Source) RemoteClock() {}
+
 // FindFleetStart retrieves the Fleet request start time
 func (s *Source) FindFleetStart() sources.FindFunc {
-	return func(_ sources.Source, _ []byte) ([]string, error) {
-		ctx := context.Background()
+	return func(ctx context.Context, _ sources.Source, _ []byte) ([]string, error) {
 		var err error
 		s.instanceID, err = s.getInstanceID(ctx)
 		if err != nil {
@@ -161,8 +163,8 @@ func (s *Source) ParseTimeFor(event []byte) (time.Time, error) {
 }
 
 // Find will use the Event's FindFunc and CommentFunc to search the source and return the result
-func (s *Source) Find(event *sources.Event) ([]sources.FindResult, error) {
-	ec2Events, err := event.FindFn(s, nil)
+func (s *Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	ec2Events, err := event.FindFn(ctx, s, nil)
 	if err != nil {
 		return nil, err
 	}
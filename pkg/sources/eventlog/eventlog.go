@@ -0,0 +1,171 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package eventlog is a latency timing source for the Windows Event Log
+// Events are queried with wevtutil and rendered as one line per event: "<timestamp> <provider> <event id>: <message>"
+package eventlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	Name            = "Windows Event Log"
+	Command         = "wevtutil"
+	DefaultLog      = "System"
+	TimestampFormat = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?Z`)
+	TimestampLayout = time.RFC3339Nano
+
+	whitespace = regexp.MustCompile(`\s+`)
+)
+
+// Source is the Windows Event Log source
+type Source struct {
+	logName string
+	query   string
+	logs    []byte
+}
+
+// event is the subset of a rendered event's XML used to build a log line
+type event struct {
+	Provider struct {
+		Name string `xml:"Name,attr"`
+	} `xml:"System>Provider"`
+	EventID     string `xml:"System>EventID"`
+	TimeCreated struct {
+		SystemTime string `xml:"SystemTime,attr"`
+	} `xml:"System>TimeCreated"`
+	Message string `xml:"RenderingInfo>Message"`
+}
+
+// New instantiates a new instance of the event log source for a log (i.e. "System") and an optional XPath query
+func New(logName string, query string) *Source {
+	return &Source{
+		logName: logName,
+		query:   query,
+	}
+}
+
+// ClearCache will clear the cached events
+func (s *Source) ClearCache() {
+	s.logs = nil
+}
+
+// String is a human readable string of the source
+func (s *Source) String() string {
+	return fmt.Sprintf("%s %s", Command, s.logName)
+}
+
+// Name is the name of the source
+func (s *Source) Name() string {
+	return Name
+}
+
+// Read queries the event log and caches the events rendered as lines, the query is killed if the context is done
+// Any further calls to Read() will use the cached events until ClearCache() is called
+func (s *Source) Read(ctx context.Context) ([]byte, error) {
+	if s.logs != nil {
+		return s.logs, nil
+	}
+	args := []string{"qe", s.logName, "/f:RenderedXml", "/rd:false"}
+	if s.query != "" {
+		args = append(args, fmt.Sprintf("/q:%s", s.query))
+	}
+	out, err := exec.CommandContext(ctx, Command, args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("unable to query event log %s: %w", s.logName, err)
+	}
+	logs, err := render(out)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse events from event log %s: %w", s.logName, err)
+	}
+	s.logs = logs
+	return logs, nil
+}
+
+// render converts the stream of rendered XML events to one line per event
+func render(out []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	decoder := xml.NewDecoder(bytes.NewReader(out))
+	for {
+		var e event
+		if err := decoder.Decode(&e); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		// messages may span multiple lines and include left-to-right marks around timestamps
+		msg := strings.ReplaceAll(e.Message, "\u200e", "")
+		msg = strings.TrimSpace(whitespace.ReplaceAllString(msg, " "))
+		fmt.Fprintf(&buf, "%s %s %s: %s\n", e.TimeCreated.SystemTime, e.Provider.Name, e.EventID, msg)
+	}
+	return buf.Bytes(), nil
+}
+
+// FindByRegex is a helper func that returns a FindFunc to search for a regex in the rendered events that can be used in an Event
+func (s *Source) FindByRegex(re *regexp.Regexp) sources.FindFunc {
+	return func(_ context.Context, _ sources.Source, log []byte) ([]string, error) {
+		lines := re.FindAll(log, -1)
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("no matches in event log %s for regex \"%s\"", s.logName, re.String())
+		}
+		var lineStrs []string
+		for _, line := range lines {
+			lineStrs = append(lineStrs, string(line))
+		}
+		return lineStrs, nil
+	}
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search the event log and return the results based on the Event's matcher
+func (s *Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	logBytes, err := s.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matchedLines, err := event.FindFn(ctx, s, logBytes)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, line := range matchedLines {
+		ts, err := sources.ParseTimestamp(TimestampFormat, TimestampLayout, line)
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(line)
+		}
+		results = append(results, sources.FindResult{
+			Line:      line,
+			Timestamp: ts,
+			Err:       err,
+			Comment:   comment,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Timestamp.UnixMicro() < results[j].Timestamp.UnixMicro()
+	})
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
@@ -0,0 +1,335 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package execplugin is a latency timing source for external binaries which emit events as JSON on stdout
+package execplugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+
+const (
+	// DefaultTimeout is how long a plugin may run before it is killed
+	DefaultTimeout = 30 * time.Second
+	// DefaultMaxOutput is the most bytes a plugin may write to stdout, the plugin is killed once it writes more
+	DefaultMaxOutput = 1 << 20
+	// DefaultCacheDuration is how long the entries of a plugin are reused before it is executed again
+	DefaultCacheDuration = time.Minute
+)
+
+// Entry is an event emitted by a plugin, plugins write one JSON object per line to stdout or a JSON list of them
+// i.e. {"name": "License Activated", "timestamp": "2023-10-01T12:00:00Z", "comment": "seat 42"}
+type Entry struct {
+	Name      string    `json:"name"`
+	Timestamp time.Time `json:"timestamp"`
+	Comment   string    `json:"comment,omitempty"`
+	// Metric defaults to the snake cased name
+	Metric   string `json:"metric,omitempty"`
+	Terminal bool   `json:"terminal,omitempty"`
+}
+
+// Source is an external binary which is executed to emit events
+type Source struct {
+	name          string
+	command       string
+	args          []string
+	timeout       time.Duration
+	maxOutput     int
+	cacheDuration time.Duration
+	entries       []Entry
+	readAt        time.Time
+}
+
+// New instantiates a new instance of a plugin source which executes the command with the args
+func New(name string, command string, args ...string) *Source {
+	return &Source{
+		name:          name,
+		command:       command,
+		args:          args,
+		timeout:       DefaultTimeout,
+		maxOutput:     DefaultMaxOutput,
+		cacheDuration: DefaultCacheDuration,
+	}
+}
+
+// WithTimeout sets how long the plugin may run before it is killed
+func (s *Source) WithTimeout(timeout time.Duration) *Source {
+	s.timeout = timeout
+	return s
+}
+
+// WithMaxOutput sets the most bytes the plugin may write to stdout before it is killed
+func (s *Source) WithMaxOutput(maxOutput int) *Source {
+	s.maxOutput = maxOutput
+	return s
+}
+
+// WithCacheDuration sets how long the entries are reused before the plugin is executed again, zero executes it on every Read
+func (s *Source) WithCacheDuration(cacheDuration time.Duration) *Source {
+	s.cacheDuration = cacheDuration
+	return s
+}
+
+// ClearCache clears the cached entries so the plugin is executed again
+func (s *Source) ClearCache() {
+	s.entries, s.readAt = nil, time.Time{}
+}
+
+// String is a human readable string of the source, the plugin command line
+func (s *Source) String() string {
+	return strings.Join(append([]string{s.command}, s.args...), " ")
+}
+
+// Name is the name of the source
+func (s *Source) Name() string {
+	return s.name
+}
+
+// Read executes the plugin and caches the entries it emitted, the plugin is killed if the context is done, it runs past
+// its timeout, or it writes more than the max output to stdout
+// Any further calls to Read() will use the cached entries until ClearCache() is called or they are older than the cache duration
+func (s *Source) Read(ctx context.Context) ([]Entry, error) {
+	if s.entries != nil && time.Since(s.readAt) < s.cacheDuration {
+		return s.entries, nil
+	}
+	s.entries = nil
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	stdout := &cappedBuffer{max: s.maxOutput, exceeded: cancel}
+	stderr := &cappedBuffer{max: s.maxOutput}
+	cmd := exec.Command(s.command, s.args...) //nolint:gosec
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("unable to execute plugin \"%s\": %w", s.String(), err)
+	}
+	// the plugin's process group is killed so the processes it forked do not hold its stdout open
+	exited := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = kill(cmd)
+		case <-exited:
+		}
+	}()
+	err := cmd.Wait()
+	close(exited)
+	switch {
+	case stdout.overflowed:
+		return nil, fmt.Errorf("unable to execute plugin \"%s\": wrote more than %d bytes to stdout", s.String(), s.maxOutput)
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return nil, fmt.Errorf("unable to execute plugin \"%s\": timed out after %s", s.String(), s.timeout)
+	case err != nil:
+		return nil, fmt.Errorf("unable to execute plugin \"%s\": %w: %s", s.String(), err, strings.TrimSpace(stderr.String()))
+	}
+	entries, err := Decode(stdout.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode the events of plugin \"%s\": %w", s.String(), err)
+	}
+	s.entries, s.readAt = entries, time.Now()
+	return entries, nil
+}
+
+// cappedBuffer buffers up to max bytes, the rest is discarded and exceeded is called the first time it overflows
+type cappedBuffer struct {
+	buf        bytes.Buffer
+	max        int
+	overflowed bool
+	exceeded   func()
+}
+
+// Write buffers the bytes that fit under the max, it never fails so the plugin is not blocked on a full pipe until it is killed
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); len(p) > room {
+		b.buf.Write(p[:room])
+		if !b.overflowed && b.exceeded != nil {
+			b.exceeded()
+		}
+		b.overflowed = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// Bytes are the buffered bytes
+func (b *cappedBuffer) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+// String is the buffered bytes as a string
+func (b *cappedBuffer) String() string {
+	return b.buf.String()
+}
+
+// Decode decodes the entries emitted by a plugin, either a stream of JSON objects, i.e. one per line, or a JSON list
+func Decode(out []byte) ([]Entry, error) {
+	entries := []Entry{}
+	decoder := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if raw[0] == '[' {
+			var list []Entry
+			if err := json.Unmarshal(raw, &list); err != nil {
+				return nil, err
+			}
+			entries = append(entries, list...)
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	for i, entry := range entries {
+		if entry.Name == "" || entry.Timestamp.IsZero() {
+			return nil, fmt.Errorf("event %d requires a name and timestamp", i)
+		}
+	}
+	return entries, nil
+}
+
+// Events returns an event for each distinct entry name emitted by the plugin, in the order they were first emitted
+// Every entry of a name is timed, the entry's comment is used as the timing comment.
+func (s *Source) Events(ctx context.Context) ([]*sources.Event, error) {
+	entries, err := s.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(lo.UniqBy(entries, func(e Entry) string { return e.Name }), func(e Entry, _ int) *sources.Event {
+		return &sources.Event{
+			Name:          e.Name,
+			Metric:        lo.Ternary(e.Metric != "", e.Metric, strings.Trim(nonAlphanumeric.ReplaceAllString(strings.ToLower(e.Name), "_"), "_")),
+			MatchSelector: sources.EventMatchSelectorAll,
+			Terminal:      e.Terminal,
+			SrcName:       s.name,
+			Src:           s,
+			FindFn:        s.FindByName(e.Name),
+			CommentFn:     CommentEntry(),
+		}
+	}), nil
+}
+
+// FindByName is a helper func that returns a FindFunc to find the entries emitted with the name that can be used in an Event
+// The plugin is executed if its entries are not cached yet.
+func (s *Source) FindByName(name string) sources.FindFunc {
+	return func(ctx context.Context, _ sources.Source, _ []byte) ([]string, error) {
+		entries, err := s.Read(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var lines []string
+		for _, entry := range entries {
+			if entry.Name == name {
+				lines = append(lines, encodeLine(entry))
+			}
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("no events named \"%s\" were emitted by plugin \"%s\"", name, s.name)
+		}
+		return lines, nil
+	}
+}
+
+// FindByRegex is a helper func that returns a FindFunc to search for a regex in the entries, one JSON object per line, that can be used in an Event
+func (s *Source) FindByRegex(re *regexp.Regexp) sources.FindFunc {
+	return func(_ context.Context, _ sources.Source, log []byte) ([]string, error) {
+		var lines []string
+		for _, l := range re.FindAll(log, -1) {
+			lines = append(lines, string(l))
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("no matches in plugin \"%s\" for regex \"%s\"", s.name, re.String())
+		}
+		return lines, nil
+	}
+}
+
+// CommentEntry is a helper func that returns a CommentFunc which uses the comment of the entry
+func CommentEntry() func(matchedLine string) string {
+	return func(matchedLine string) string {
+		var entry Entry
+		if err := json.Unmarshal([]byte(matchedLine), &entry); err != nil {
+			return matchedLine
+		}
+		return entry.Comment
+	}
+}
+
+// ParseTimestamp parses the timestamp of an entry's JSON line
+func (s *Source) ParseTimestamp(matchedLine string) (time.Time, error) {
+	var entry Entry
+	if err := json.Unmarshal([]byte(matchedLine), &entry); err != nil {
+		return time.Time{}, fmt.Errorf("unable to find timestamp on plugin event \"%s\": %w", matchedLine, err)
+	}
+	return entry.Timestamp, nil
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search the plugin's entries and return the results based on the Event's matcher
+func (s *Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	entries, err := s.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log := strings.Join(lo.Map(entries, func(e Entry, _ int) string { return encodeLine(e) }), "\n")
+	matchedLines, err := event.FindFn(ctx, s, []byte(log))
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, line := range matchedLines {
+		ts, err := s.ParseTimestamp(line)
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(line)
+		}
+		results = append(results, sources.FindResult{
+			Line:      line,
+			Timestamp: ts,
+			Err:       err,
+			Comment:   comment,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Timestamp.UnixMicro() < results[j].Timestamp.UnixMicro()
+	})
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
+
+// encodeLine is an entry encoded as a single line of JSON
+func encodeLine(entry Entry) string {
+	encoded, _ := json.Marshal(entry)
+	return string(encoded)
+}
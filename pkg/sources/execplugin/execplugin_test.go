@@ -0,0 +1,178 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package execplugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	activated = Entry{Name: "License Activated", Timestamp: time.Date(2023, 10, 1, 12, 0, 5, 0, time.UTC), Comment: "seat 42"}
+	ready     = Entry{Name: "License Agent Ready", Timestamp: time.Date(2023, 10, 1, 12, 0, 9, 0, time.UTC)}
+)
+
+// script is a plugin source which runs the shell script
+func script(sh string) *Source {
+	return New("plugin", "/bin/sh", "-c", sh)
+}
+
+func TestDecode(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		out     string
+		entries []Entry
+		invalid bool
+	}{
+		{name: "empty", out: "", entries: []Entry{}},
+		{
+			name:    "one object per line",
+			out:     `{"name": "License Activated", "timestamp": "2023-10-01T12:00:05Z", "comment": "seat 42"}` + "\n" + `{"name": "License Agent Ready", "timestamp": "2023-10-01T12:00:09Z"}`,
+			entries: []Entry{activated, ready},
+		},
+		{
+			name:    "list",
+			out:     `[{"name": "License Activated", "timestamp": "2023-10-01T12:00:05Z", "comment": "seat 42"}, {"name": "License Agent Ready", "timestamp": "2023-10-01T12:00:09Z"}]`,
+			entries: []Entry{activated, ready},
+		},
+		{name: "no timestamp", out: `{"name": "License Activated"}`, invalid: true},
+		{name: "no name", out: `{"timestamp": "2023-10-01T12:00:05Z"}`, invalid: true},
+		{name: "not JSON", out: "License Activated", invalid: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			entries, err := Decode([]byte(tc.out))
+			if tc.invalid {
+				if err == nil {
+					t.Errorf("expected an error, got %v", entries)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to decode: %v", err)
+			}
+			if !reflect.DeepEqual(entries, tc.entries) {
+				t.Errorf("expected entries %+v, got %+v", tc.entries, entries)
+			}
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		src     *Source
+		entries []Entry
+		err     string
+	}{
+		{
+			name:    "entries",
+			src:     script(`echo '{"name": "License Agent Ready", "timestamp": "2023-10-01T12:00:09Z"}'`),
+			entries: []Entry{ready},
+		},
+		{name: "failed", src: script(`echo "no license" >&2; exit 3`), err: "no license"},
+		{name: "timed out", src: script(`sleep 5`).WithTimeout(100 * time.Millisecond), err: "timed out after 100ms"},
+		{name: "output exceeded", src: script(`while :; do echo '{"name": "License Agent Ready", "timestamp": "2023-10-01T12:00:09Z"}'; done`).WithMaxOutput(1024), err: "more than 1024 bytes"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			entries, err := tc.src.Read(context.Background())
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("expected an error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to read: %v", err)
+			}
+			if !reflect.DeepEqual(entries, tc.entries) {
+				t.Errorf("expected entries %+v, got %+v", tc.entries, entries)
+			}
+		})
+	}
+}
+
+func TestReadCache(t *testing.T) {
+	// the plugin emits an entry per execution
+	counter := filepath.Join(t.TempDir(), "executions")
+	sh := `echo x >> ` + counter + `; echo "{\"name\": \"Run $(wc -l < ` + counter + ` | tr -d ' ')\", \"timestamp\": \"2023-10-01T12:00:09Z\"}"`
+	for _, tc := range []struct {
+		name       string
+		src        *Source
+		clearCache bool
+		executions int
+	}{
+		{name: "cached", src: script(sh).WithCacheDuration(time.Hour), executions: 1},
+		{name: "cleared", src: script(sh).WithCacheDuration(time.Hour), clearCache: true, executions: 2},
+		{name: "expired", src: script(sh).WithCacheDuration(0), executions: 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := os.RemoveAll(counter); err != nil {
+				t.Fatalf("unable to reset the executions: %v", err)
+			}
+			var entries []Entry
+			for i := 0; i < 2; i++ {
+				if tc.clearCache {
+					tc.src.ClearCache()
+				}
+				var err error
+				if entries, err = tc.src.Read(context.Background()); err != nil {
+					t.Fatalf("unable to read: %v", err)
+				}
+			}
+			if expected := fmt.Sprintf("Run %d", tc.executions); len(entries) != 1 || entries[0].Name != expected {
+				t.Errorf("expected the entry %q, got %+v", expected, entries)
+			}
+		})
+	}
+}
+
+func TestFindByName(t *testing.T) {
+	src := script(`echo '[{"name": "License Activated", "timestamp": "2023-10-01T12:00:05Z", "comment": "seat 42"}, {"name": "License Agent Ready", "timestamp": "2023-10-01T12:00:09Z"}]'`)
+	// the plugin is executed by the FindFunc when it has not been read yet
+	lines, err := src.FindByName(ready.Name)(context.Background(), src, nil)
+	if err != nil {
+		t.Fatalf("unable to find %s: %v", ready.Name, err)
+	}
+	if expected := []string{encodeLine(ready)}; !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected lines %q, got %q", expected, lines)
+	}
+	if _, err := src.FindByName("License Revoked")(context.Background(), src, nil); err == nil {
+		t.Error("expected an error for an event the plugin did not emit")
+	}
+}
+
+func TestFind(t *testing.T) {
+	src := script(`echo '{"name": "License Agent Ready", "timestamp": "2023-10-01T12:00:09Z"}'; echo '{"name": "License Activated", "timestamp": "2023-10-01T12:00:05Z", "comment": "seat 42"}'`)
+	events, err := src.Events(context.Background())
+	if err != nil {
+		t.Fatalf("unable to read the events: %v", err)
+	}
+	if len(events) != 2 || events[0].Name != ready.Name || events[0].Metric != "license_agent_ready" || events[1].Metric != "license_activated" {
+		t.Fatalf("expected the events in the order they were emitted, got %+v", events)
+	}
+	results, err := src.Find(context.Background(), events[1])
+	if err != nil {
+		t.Fatalf("unable to find %s: %v", events[1].Name, err)
+	}
+	if len(results) != 1 || !results[0].Timestamp.Equal(activated.Timestamp) || results[0].Comment != activated.Comment {
+		t.Errorf("expected the %s result, got %+v", activated.Name, results)
+	}
+}
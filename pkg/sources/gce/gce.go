@@ -0,0 +1,186 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gce is a latency timing source for the Google Compute Engine (GCE) metadata server
+package gce
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	Name            = "GCE Metadata"
+	DefaultEndpoint = "http://metadata.google.internal"
+	ComputeEndpoint = "https://compute.googleapis.com/compute/v1"
+	// CreationTimestamp is a pseudo-path for the instance creation time which is retrieved from the Compute API
+	// using the instance's default service account since it is not available from the metadata server directly
+	CreationTimestamp = "instance/creationTimestamp"
+)
+
+// Source is the GCE metadata server http source
+type Source struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+// New instantiates a new instance of the GCE metadata source
+func New(endpoint string) *Source {
+	return &Source{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// ClearCache is a noop for the GCE Source since it is an http source, not a log file
+func (s Source) ClearCache() {}
+
+// String is a human readable string of the source
+func (s Source) String() string {
+	return s.endpoint
+}
+
+// Name is the name of the source
+func (s Source) Name() string {
+	return Name
+}
+
+// RemoteClock marks the timestamps of the GCE metadata server as from a remote clock, they are not skewed by steps of the node's clock
+func (s Source) RemoteClock() {}
+
+// FindByPath is a helper func that returns a FindFunc to query the metadata server for a specific path that can be used in an Event
+// The path must resolve to an RFC3339 timestamp
+func (s Source) FindByPath(path string) sources.FindFunc {
+	return func(ctx context.Context, _ sources.Source, _ []byte) ([]string, error) {
+		if path == CreationTimestamp {
+			ts, err := s.creationTimestamp(ctx)
+			return []string{ts}, err
+		}
+		result, err := s.GetMetadata(ctx, path)
+		return []string{result}, err
+	}
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search the source and return the result
+func (s Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	timestamps, err := event.FindFn(ctx, s, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, tsStr := range timestamps {
+		ts, err := time.Parse(time.RFC3339, tsStr)
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(tsStr)
+		}
+		results = append(results, sources.FindResult{
+			Line:      tsStr,
+			Timestamp: ts,
+			Comment:   comment,
+			Err:       err,
+		})
+	}
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
+
+// GetMetadata queries the GCE metadata server for a path relative to /computeMetadata/v1, i.e. "instance/zone"
+func (s Source) GetMetadata(ctx context.Context, metadataPath string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/computeMetadata/v1/%s", s.endpoint, metadataPath), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	return s.do(req, metadataPath)
+}
+
+// GetMetadataBase queries the GCE metadata server for a path and returns the last path element,
+// i.e. "instance/zone" returns "us-central1-a" rather than "projects/123/zones/us-central1-a"
+func (s Source) GetMetadataBase(ctx context.Context, metadataPath string) (string, error) {
+	value, err := s.GetMetadata(ctx, metadataPath)
+	if err != nil {
+		return "", err
+	}
+	return path.Base(value), nil
+}
+
+// creationTimestamp retrieves the instance creationTimestamp from the Compute API
+func (s Source) creationTimestamp(ctx context.Context) (string, error) {
+	project, err := s.GetMetadata(ctx, "project/project-id")
+	if err != nil {
+		return "", err
+	}
+	zone, err := s.GetMetadataBase(ctx, "instance/zone")
+	if err != nil {
+		return "", err
+	}
+	name, err := s.GetMetadata(ctx, "instance/name")
+	if err != nil {
+		return "", err
+	}
+	tokenJSON, err := s.GetMetadata(ctx, "instance/service-accounts/default/token")
+	if err != nil {
+		return "", err
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal([]byte(tokenJSON), &token); err != nil {
+		return "", fmt.Errorf("unable to parse service account token: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/projects/%s/zones/%s/instances/%s", ComputeEndpoint, project, zone, name), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
+	instanceJSON, err := s.do(req, "compute instances.get")
+	if err != nil {
+		return "", err
+	}
+	var instance struct {
+		CreationTimestamp string `json:"creationTimestamp"`
+	}
+	if err := json.Unmarshal([]byte(instanceJSON), &instance); err != nil {
+		return "", fmt.Errorf("unable to parse compute instance: %w", err)
+	}
+	if instance.CreationTimestamp == "" {
+		return "", fmt.Errorf("compute instance %s has no creationTimestamp", name)
+	}
+	return instance.CreationTimestamp, nil
+}
+
+// do executes the request and returns the body as a string
+func (s Source) do(req *http.Request, desc string) (string, error) {
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to query %s: %w", desc, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("unable to read response for %s: %w", desc, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to query %s: %s", desc, resp.Status)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
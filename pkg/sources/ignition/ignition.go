@@ -0,0 +1,106 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ignition is a latency timing source for Ignition, which provisions Flatcar, Fedora CoreOS, and Red Hat CoreOS
+// from the initramfs on first boot instead of cloud-init. Ignition only logs to the journal, so the source searches the
+// journal (or a recording of it) it is created with for Ignition's stage lines.
+package ignition
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/journal"
+)
+
+var (
+	Name = "Ignition"
+	// JournalArgs filter the journal to Ignition's entries
+	JournalArgs = []string{"--identifier=ignition"}
+	// lineStr prefixes Ignition's log lines, Flatcar's Ignition prefixes the message with its level, i.e. "INFO     : "
+	lineStr = `(?m).*ignition\[[0-9]+\]: (?:[A-Z]+ +: )?`
+	// Version matches the first line Ignition logs, with its version
+	Version = regexp.MustCompile(lineStr + `Ignition (?P<version>[0-9][0-9.]*).*$`)
+	// Complete matches systemd reaching the ignition-complete target after the last stage, it is logged by systemd so
+	// it is searched in the system journal rather than Ignition's entries
+	Complete = regexp.MustCompile(`.*Reached target (?:ignition-complete\.target - )?Ignition Complete.*`)
+)
+
+// Stages in the order Ignition runs them, each stage is a separate Ignition run in its own systemd unit
+const (
+	StageFetchOffline = "fetch-offline"
+	StageFetch        = "fetch"
+	StageKargs        = "kargs"
+	StageDisks        = "disks"
+	StageMount        = "mount"
+	StageFiles        = "files"
+)
+
+// Stage boundaries
+const (
+	BoundaryStart    = "start"
+	BoundaryFinished = "finished"
+)
+
+// Source is the Ignition source which searches the journal for Ignition's stage lines
+type Source struct {
+	log sources.RegexFinder
+}
+
+// New instantiates a new instance of the Ignition source which searches Ignition's entries of the journal
+func New() *Source {
+	return NewFromLog(journal.New(JournalArgs...).WithName(Name))
+}
+
+// NewFromLog instantiates a new instance of the Ignition source which searches the log, i.e. a recorded journal
+// The log is read under the Ignition source's lock, so it must not also be registered as a source.
+func NewFromLog(log sources.RegexFinder) *Source {
+	return &Source{log: log}
+}
+
+// ClearCache clears the cache of the searched log
+func (s *Source) ClearCache() {
+	s.log.ClearCache()
+}
+
+// String is a human readable string of the source, the searched log
+func (s *Source) String() string {
+	return s.log.String()
+}
+
+// Name is the name of the source
+func (s *Source) Name() string {
+	return Name
+}
+
+// FindByRegex is a helper func that returns a FindFunc to search for a regex in the log that can be used in an Event
+func (s *Source) FindByRegex(re *regexp.Regexp) sources.FindFunc {
+	return s.log.FindByRegex(re)
+}
+
+// FindByStage is a helper func that returns a FindFunc to find when an Ignition stage started or finished
+// boundary is either BoundaryStart or BoundaryFinished, a stage is finished when it logs that it passed.
+func (s *Source) FindByStage(stage string, boundary string) sources.FindFunc {
+	if boundary == BoundaryFinished {
+		return s.FindByRegex(regexp.MustCompile(fmt.Sprintf(`%s%s: %s passed$`, lineStr, regexp.QuoteMeta(stage), regexp.QuoteMeta(stage))))
+	}
+	return s.FindByRegex(regexp.MustCompile(fmt.Sprintf(`%sStage: %s$`, lineStr, regexp.QuoteMeta(stage))))
+}
+
+// Find searches the log with the Event's FindFunc, timestamps and comments are parsed by the log
+func (s *Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	return s.log.Find(ctx, event)
+}
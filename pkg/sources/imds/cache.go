@@ -0,0 +1,92 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imds
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Cache persists IMDS responses to a file, so they are reused across restarts and measurements instead of queried again
+// Only responses which do not change once retrieved are cached: the instance-identity document, the event times, and
+// the first time the instance role credentials were retrieved.
+type Cache struct {
+	path      string
+	mu        sync.Mutex
+	responses map[string]string
+}
+
+// LoadCache reads the cached responses from the file, the cache is empty if the file does not exist yet
+func LoadCache(path string) (*Cache, error) {
+	c := &Cache{path: path, responses: map[string]string{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read IMDS cache file %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &c.responses); err != nil {
+		return nil, fmt.Errorf("unable to decode IMDS cache file %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Len is the number of cached responses
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.responses)
+}
+
+// Get returns the cached response of the metadata path
+func (c *Cache) Get(path string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	response, ok := c.responses[path]
+	return response, ok
+}
+
+// Set caches the response of the metadata path and writes the cache file
+// The file is written to a temporary file and renamed so a crash mid-write does not corrupt the cache.
+func (c *Cache) Set(path string, response string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.responses[path]; ok && cached == response {
+		return nil
+	}
+	c.responses[path] = response
+	data, err := json.Marshal(c.responses)
+	if err != nil {
+		return fmt.Errorf("unable to marshal IMDS cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return fmt.Errorf("unable to create IMDS cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to write IMDS cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to write IMDS cache file: %w", err)
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
@@ -0,0 +1,105 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imds
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadCache(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		data      string
+		responses map[string]string
+		err       bool
+	}{
+		{name: "missing file", responses: map[string]string{}},
+		{name: "cached responses", data: `{"/dynamic/instance-identity/document/pendingTime":"1705226400000000"}`, responses: map[string]string{PendingTime: "1705226400000000"}},
+		{name: "corrupt file", data: `{"/dynamic`, err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "imds-cache.json")
+			if tc.data != "" {
+				if err := os.WriteFile(path, []byte(tc.data), 0o600); err != nil {
+					t.Fatalf("unable to write the cache file: %v", err)
+				}
+			}
+			cache, err := LoadCache(path)
+			if tc.err {
+				if err == nil {
+					t.Error("expected an error loading the cache")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to load the cache: %v", err)
+			}
+			if cache.Len() != len(tc.responses) {
+				t.Errorf("expected %d cached responses, got %d", len(tc.responses), cache.Len())
+			}
+			for path, expected := range tc.responses {
+				if response, ok := cache.Get(path); !ok || response != expected {
+					t.Errorf("expected %s to be cached as %q, got %q", path, expected, response)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheSet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "imds-cache.json")
+	cache, err := LoadCache(path)
+	if err != nil {
+		t.Fatalf("unable to load the cache: %v", err)
+	}
+	for _, tc := range []struct {
+		path     string
+		response string
+		len      int
+	}{
+		{path: PendingTime, response: "1705226400000000", len: 1},
+		{path: CredentialsLastUpdated, response: "1705226410000000", len: 2},
+		{path: PendingTime, response: "1705226400000000", len: 2},
+		{path: RebalanceRecommendation, response: "1705230000000000", len: 3},
+	} {
+		if err := cache.Set(tc.path, tc.response); err != nil {
+			t.Fatalf("unable to cache %s: %v", tc.path, err)
+		}
+		if cache.Len() != tc.len {
+			t.Errorf("expected %d cached responses after caching %s, got %d", tc.len, tc.path, cache.Len())
+		}
+		// the cache file is reloaded as written, so the responses are reused after a restart
+		reloaded, err := LoadCache(path)
+		if err != nil {
+			t.Fatalf("unable to reload the cache: %v", err)
+		}
+		if response, ok := reloaded.Get(tc.path); !ok || response != tc.response {
+			t.Errorf("expected %s to be reloaded as %q, got %q", tc.path, tc.response, response)
+		}
+	}
+	// the temporary files the cache is written to are renamed or removed
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("unable to read the cache directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only the cache file, got %d files", len(entries))
+	}
+	if _, ok := cache.Get(SpotInstanceAction); ok {
+		t.Errorf("expected %s not to be cached", SpotInstanceAction)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imds
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// ProbeTimeout bounds how long Probe waits for IMDS, including the SDK's retries
+var ProbeTimeout = 10 * time.Second
+
+// tokenTTLHeader is the header of the IMDSv2 session token request with the requested TTL in seconds
+const tokenTTLHeader = "X-Aws-Ec2-Metadata-Token-Ttl-Seconds"
+
+// ClientOptions tune the EC2 IMDS client, the SDK's defaults are used for zero values
+type ClientOptions struct {
+	// TokenTTL is the TTL requested for IMDSv2 session tokens, the SDK requests 6 hour tokens
+	TokenTTL time.Duration
+	// MaxAttempts is the number of attempts of each request, the SDK makes 3 attempts with a jittered backoff of up to 1s
+	MaxAttempts int
+	// V2Only disables the SDK's fallback to IMDSv1 when an IMDSv2 session token cannot be retrieved
+	V2Only bool
+}
+
+// NewClient instantiates an EC2 IMDS client from the AWS SDK config tuned by the ClientOptions
+func NewClient(cfg aws.Config, opts ClientOptions) *imds.Client {
+	return imds.NewFromConfig(cfg, func(o *imds.Options) {
+		if opts.TokenTTL > 0 {
+			o.APIOptions = append(o.APIOptions, withTokenTTL(opts.TokenTTL))
+		}
+		if opts.MaxAttempts > 0 {
+			o.Retryer = retry.NewStandard(func(so *retry.StandardOptions) {
+				so.MaxAttempts = opts.MaxAttempts
+			})
+		}
+		if opts.V2Only {
+			o.EnableFallback = aws.FalseTernary
+		}
+	})
+}
+
+// withTokenTTL overrides the TTL of the IMDSv2 session token requests, the IMDS client does not expose it
+func withTokenTTL(ttl time.Duration) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Build.Add(middleware.BuildMiddlewareFunc("TokenTTL", func(ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata, error) {
+			if req, ok := in.Request.(*smithyhttp.Request); ok && req.Header.Get(tokenTTLHeader) != "" {
+				req.Header.Set(tokenTTLHeader, strconv.Itoa(int(ttl.Seconds())))
+			}
+			return next.HandleBuild(ctx, in)
+		}), middleware.After)
+	}
+}
+
+// Probe checks that IMDS can be reached by retrieving the instance-id
+// IMDS is unreachable when it is disabled on the instance, or from a pod that is not on the host network when the
+// instance's IMDSv2 hop limit is 1, which drops the session token responses.
+func Probe(ctx context.Context, client *imds.Client) error {
+	ctx, cancel := context.WithTimeout(ctx, ProbeTimeout)
+	defer cancel()
+	if _, err := client.GetMetadata(ctx, &imds.GetMetadataInput{Path: "instance-id"}); err != nil {
+		return fmt.Errorf("unable to reach EC2 IMDS, it may be disabled or the IMDSv2 hop limit may be too low for a pod which is not on the host network: %w", err)
+	}
+	return nil
+}
@@ -17,8 +17,11 @@ package imds
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
@@ -30,11 +33,21 @@ var (
 	Name             = "EC2 IMDS"
 	DynamicDocPrefix = "/dynamic/instance-identity/document"
 	PendingTime      = fmt.Sprintf("%s/%s", DynamicDocPrefix, "pendingTime")
+	IAMInfoPrefix    = "/meta-data/iam/info"
+	// CredentialsLastUpdated is when the instance role credentials were last retrieved by IMDS, the first time during boot
+	CredentialsLastUpdated = fmt.Sprintf("%s/%s", IAMInfoPrefix, "LastUpdated")
+	// SpotInstanceAction is the time a spot interruption will stop, hibernate, or terminate the instance, it is only
+	// available once the interruption notice is issued two minutes before
+	SpotInstanceAction = "/meta-data/spot/instance-action"
+	// RebalanceRecommendation is the time a rebalance recommendation was issued for the spot instance, it is only
+	// available once the recommendation is issued
+	RebalanceRecommendation = "/meta-data/events/recommendations/rebalance"
 )
 
 // Source is the EC2 Instance Metadata Service (IMDS) http source
 type Source struct {
-	imds *imds.Client
+	imds  *imds.Client
+	cache *Cache
 }
 
 // New instantiates a new instance of the IMDS source
@@ -44,6 +57,13 @@ func New(imdsClient *imds.Client) *Source {
 	}
 }
 
+// WithCache is a builder func that serves the responses of the cache, and caches the responses queried from IMDS, so
+// they are not queried again after a restart or when IMDS becomes unreachable
+func (i *Source) WithCache(cache *Cache) *Source {
+	i.cache = cache
+	return i
+}
+
 // ClearCache is a noop for the IMDS Source since it is an http source, not a log file
 func (i Source) ClearCache() {}
 
@@ -57,29 +77,41 @@ func (i Source) Name() string {
 	return Name
 }
 
+// RemoteClock marks the timestamps of the IMDS as from a remote clock, they are not skewed by steps of the node's clock
+func (i Source) RemoteClock() {}
+
 // FindByPath is a helper func that returns a FindFunc to query IMDS for a specific HTTP path that can be used in an Event
 func (i Source) FindByPath(path string) sources.FindFunc {
-	return func(s sources.Source, log []byte) ([]string, error) {
-		result, err := i.GetMetadata(path)
+	return func(ctx context.Context, s sources.Source, log []byte) ([]string, error) {
+		result, err := i.GetMetadata(ctx, path)
 		return []string{result}, err
 	}
 }
 
+// CommentAction is a helper func that returns a CommentFunc which uses the action of a spot interruption, i.e. terminate
+func (i Source) CommentAction() func(matchedLine string) string {
+	return func(matchedLine string) string {
+		_, action, _ := strings.Cut(matchedLine, " ")
+		return action
+	}
+}
+
 // Find will use the Event's FindFunc and CommentFunc to search the source and return the result
-func (i Source) Find(event *sources.Event) ([]sources.FindResult, error) {
-	timestamps, err := event.FindFn(i, nil)
+func (i Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	timestamps, err := event.FindFn(ctx, i, nil)
 	if err != nil {
 		return nil, err
 	}
 	var results []sources.FindResult
-	for _, tsStr := range timestamps {
+	for _, line := range timestamps {
+		tsStr, _, _ := strings.Cut(line, " ")
 		tsMicros, err := strconv.ParseInt(tsStr, 10, 64)
 		comment := ""
 		if event.CommentFn != nil {
-			comment = event.CommentFn(tsStr)
+			comment = event.CommentFn(line)
 		}
 		results = append(results, sources.FindResult{
-			Line:      tsStr,
+			Line:      line,
 			Timestamp: time.UnixMicro(tsMicros),
 			Comment:   comment,
 			Err:       err,
@@ -88,15 +120,69 @@ func (i Source) Find(event *sources.Event) ([]sources.FindResult, error) {
 	return results, nil
 }
 
-// GetMetadata queries EC2 IMDS
-func (i Source) GetMetadata(path string) (string, error) {
-	ctx := context.TODO()
-	identityDoc, err := i.imds.GetInstanceIdentityDocument(ctx, &imds.GetInstanceIdentityDocumentInput{})
+// GetMetadata queries EC2 IMDS, or returns the cached response if the source has a cache
+func (i Source) GetMetadata(ctx context.Context, path string) (string, error) {
+	if i.cache == nil {
+		return i.queryMetadata(ctx, path)
+	}
+	if response, ok := i.cache.Get(path); ok {
+		return response, nil
+	}
+	response, err := i.queryMetadata(ctx, path)
 	if err != nil {
-		return "", fmt.Errorf("unable to retrieve instance-identity document: %w", err)
+		return "", err
+	}
+	if err := i.cache.Set(path, response); err != nil {
+		log.Printf("Unable to cache the IMDS response of %s: %s\n", path, err)
 	}
-	if path == PendingTime {
+	return response, nil
+}
+
+// queryMetadata queries EC2 IMDS
+func (i Source) queryMetadata(ctx context.Context, path string) (string, error) {
+	switch path {
+	case PendingTime:
+		identityDoc, err := i.imds.GetInstanceIdentityDocument(ctx, &imds.GetInstanceIdentityDocumentInput{})
+		if err != nil {
+			return "", fmt.Errorf("unable to retrieve instance-identity document: %w", err)
+		}
 		return strconv.FormatInt(identityDoc.PendingTime.UnixMicro(), 10), nil
+	case CredentialsLastUpdated:
+		iamInfo, err := i.imds.GetIAMInfo(ctx, &imds.GetIAMInfoInput{})
+		if err != nil {
+			return "", fmt.Errorf("unable to retrieve iam info: %w", err)
+		}
+		return strconv.FormatInt(iamInfo.LastUpdated.UnixMicro(), 10), nil
+	case SpotInstanceAction:
+		var instanceAction struct {
+			Action string    `json:"action"`
+			Time   time.Time `json:"time"`
+		}
+		if err := i.getJSON(ctx, path, &instanceAction); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d %s", instanceAction.Time.UnixMicro(), instanceAction.Action), nil
+	case RebalanceRecommendation:
+		var recommendation struct {
+			NoticeTime time.Time `json:"noticeTime"`
+		}
+		if err := i.getJSON(ctx, path, &recommendation); err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(recommendation.NoticeTime.UnixMicro(), 10), nil
 	}
 	return "", fmt.Errorf("metadata for path \"%s\" is not available", path)
 }
+
+// getJSON decodes the JSON document at the metadata path, the path is not found until EC2 issues the notice
+func (i Source) getJSON(ctx context.Context, path string, v any) error {
+	out, err := i.imds.GetMetadata(ctx, &imds.GetMetadataInput{Path: strings.TrimPrefix(path, "/meta-data")})
+	if err != nil {
+		return fmt.Errorf("unable to retrieve metadata path \"%s\": %w", path, err)
+	}
+	defer out.Content.Close()
+	if err := json.NewDecoder(out.Content).Decode(v); err != nil {
+		return fmt.Errorf("unable to decode metadata path \"%s\": %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package journal is a latency timing source for the systemd journal (journald)
+package journal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	Name            = "Journal"
+	Command         = "journalctl"
+	DefaultArgs     = []string{"--no-pager", "--quiet", "--output=short-precise"}
+	TimestampFormat = regexp.MustCompile(`[A-Z][a-z]+[ ]+[0-9][0-9]? [0-9]{2}:[0-9]{2}:[0-9]{2}\.[0-9]+`)
+	TimestampLayout = "Jan 2 15:04:05.999999 2006"
+)
+
+// WatchGlobs are the journal directories of the default system journals, journald writes to a machine-id subdirectory
+var WatchGlobs = []string{"/run/log/journal/*", "/var/log/journal/*"}
+
+// cursorPrefix prefixes the cursor line printed by journalctl --show-cursor
+const cursorPrefix = "-- cursor: "
+
+// Source is the systemd journal source which reads entries via journalctl
+type Source struct {
+	name string
+	args []string
+	logs []byte
+	// tail state, the logs accumulate the entries read after the cursor
+	tail    bool
+	stale   bool
+	cursor  string
+	matches sources.MatchCache
+}
+
+// New instantiates a new instance of the journal source
+// Any args passed are appended to the default journalctl args, i.e. "--directory=/var/log/journal" or "--unit=kubelet"
+func New(args ...string) *Source {
+	return &Source{
+		name: Name,
+		args: append(append([]string{}, DefaultArgs...), args...),
+	}
+}
+
+// WithName is a builder func that renames the source, so a journal filtered to units can be registered alongside the
+// default journal source
+func (s *Source) WithName(name string) *Source {
+	s.name = name
+	return s
+}
+
+// Available returns true if journalctl can be found on the PATH
+func Available() bool {
+	_, err := exec.LookPath(Command)
+	return err == nil
+}
+
+// ClearCache will clear the cached journal entries, a tailed journal is kept and only marked to read entries after the cursor
+func (s *Source) ClearCache() {
+	if s.tail {
+		s.stale = true
+		return
+	}
+	s.logs = nil
+}
+
+// Tail will incrementally read the journal entries after the cursor of the last read
+func (s *Source) Tail() {
+	s.tail = true
+}
+
+// WatchPaths are the existing default journal directories to watch for writes
+func (s *Source) WatchPaths() []string {
+	var watchPaths []string
+	for _, glob := range WatchGlobs {
+		matches, _ := filepath.Glob(glob)
+		watchPaths = append(watchPaths, matches...)
+	}
+	return watchPaths
+}
+
+// Checkpoint returns the cursor and matches of the tailed journal, or nil if it has not been read while tailing
+func (s *Source) Checkpoint() *sources.Checkpoint {
+	if !s.tail || s.cursor == "" {
+		return nil
+	}
+	return &sources.Checkpoint{Cursor: s.cursor, Matches: s.matches.Matches()}
+}
+
+// Restore tails the journal from the checkpoint's cursor
+func (s *Source) Restore(checkpoint *sources.Checkpoint) {
+	s.tail = true
+	s.stale = true
+	s.logs = []byte{}
+	s.cursor = checkpoint.Cursor
+	s.matches.Restore(checkpoint.Matches, 0)
+}
+
+// String is a human readable string of the source, the journalctl command line
+func (s *Source) String() string {
+	return fmt.Sprintf("%s %s", Command, strings.Join(s.args, " "))
+}
+
+// Name is the name of the source
+func (s *Source) Name() string {
+	return s.name
+}
+
+// Read executes journalctl and caches the output, journalctl is killed if the context is done
+// Any further calls to Read() will use the cached output until ClearCache() is called
+func (s *Source) Read(ctx context.Context) ([]byte, error) {
+	if s.logs != nil && !s.stale {
+		return s.logs, nil
+	}
+	args := s.args
+	if s.tail {
+		args = append(append([]string{}, args...), "--show-cursor")
+		if s.cursor != "" {
+			args = append(args, "--after-cursor="+s.cursor)
+		}
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, Command, args...) //nolint:gosec
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read journal with \"%s\": %w: %s", s.String(), err, strings.TrimSpace(stderr.String()))
+	}
+	if !s.tail {
+		s.logs = out
+		return out, nil
+	}
+	// the cursor line is printed after the entries
+	if i := bytes.LastIndex(out, []byte(cursorPrefix)); i >= 0 {
+		s.cursor = strings.TrimSpace(string(out[i+len(cursorPrefix):]))
+		out = out[:i]
+	}
+	if s.logs == nil {
+		s.logs = []byte{}
+	}
+	s.logs = append(s.logs, out...)
+	s.stale = false
+	return s.logs, nil
+}
+
+// FindByRegex is a helper func that returns a FindFunc to search for a regex in the journal that can be used in an Event
+func (s *Source) FindByRegex(re *regexp.Regexp) sources.FindFunc {
+	return func(_ context.Context, _ sources.Source, log []byte) ([]string, error) {
+		var lineStrs []string
+		if s.tail {
+			lineStrs = s.matches.FindAll(re, log)
+		} else {
+			for _, line := range re.FindAll(log, -1) {
+				lineStrs = append(lineStrs, string(line))
+			}
+		}
+		if len(lineStrs) == 0 {
+			return nil, fmt.Errorf("no matches in %s for regex \"%s\"", s.name, re.String())
+		}
+		return lineStrs, nil
+	}
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search the journal and return the results based on the Event's matcher
+func (s *Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	logBytes, err := s.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matchedLines, err := event.FindFn(ctx, s, logBytes)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, line := range matchedLines {
+		ts, err := sources.ParseTimestamp(TimestampFormat, TimestampLayout, line)
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(line)
+		}
+		results = append(results, sources.FindResult{
+			Line:      line,
+			Timestamp: ts,
+			Err:       err,
+			Comment:   comment,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Timestamp.UnixMicro() < results[j].Timestamp.UnixMicro()
+	})
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
@@ -0,0 +1,294 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package jsonlog is a latency timing source for JSON structured log files, i.e. kubelet or containerd with JSON logging
+// Events are matched by selectors on the fields of each entry rather than regexes on the raw line.
+package jsonlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	// DefaultTimestampField is the timestamp field of klog's JSON format, also used by the kubelet
+	DefaultTimestampField = "ts"
+	// anyEntry matches every line which may hold a JSON entry
+	anyEntry = regexp.MustCompile(`.*\{.*`)
+)
+
+// Selector matches a field of a JSON log entry, the Field is a dot separated path, i.e. "kubelet.node"
+// An entry matches when the field exists and every set condition holds, values which are not strings are compared as JSON.
+type Selector struct {
+	Field    string
+	Equals   string
+	Contains string
+	Regex    *regexp.Regexp
+}
+
+// Source is a JSON structured log file source
+type Source struct {
+	name           string
+	timestampField string
+	logReader      *sources.LogReader
+}
+
+// New instantiates a new instance of a JSON log file source
+// The path may be a glob, and more paths or globs may be passed, in which case the lines of every matching file are merged.
+// Lines may have a prefix before the JSON entry, i.e. the CRI log format. The timestamp field is parsed with the layout if
+// it is a string, or as fractional epoch seconds if it is a number. An empty layout is RFC 3339, and the "auto" layout detects syslog and ISO 8601 timestamps.
+func New(name string, path string, timestampField string, timestampLayout string, paths ...string) *Source {
+	if timestampField == "" {
+		timestampField = DefaultTimestampField
+	}
+	if timestampLayout == "" {
+		timestampLayout = time.RFC3339Nano
+	}
+	return &Source{
+		name:           name,
+		timestampField: timestampField,
+		logReader: &sources.LogReader{
+			Path:            path,
+			Paths:           paths,
+			Glob:            true,
+			TimestampLayout: timestampLayout,
+		},
+	}
+}
+
+// ClearCache will clear the log reader cache
+func (s Source) ClearCache() {
+	s.logReader.ClearCache()
+}
+
+// Tail will incrementally read the log as it is appended to
+func (s Source) Tail() {
+	s.logReader.Tail()
+}
+
+// WatchPaths are the directories of the log files to watch for writes
+func (s Source) WatchPaths() []string {
+	return s.logReader.WatchPaths()
+}
+
+// Checkpoint returns the read position and matches of the tailed log
+func (s Source) Checkpoint() *sources.Checkpoint {
+	return s.logReader.Checkpoint()
+}
+
+// Restore tails the log from the checkpoint
+func (s Source) Restore(checkpoint *sources.Checkpoint) {
+	s.logReader.Restore(checkpoint)
+}
+
+// String is a human readable string of the source, usually the log file path
+func (s Source) String() string {
+	return s.logReader.String()
+}
+
+// Name is the name of the source
+func (s Source) Name() string {
+	return s.name
+}
+
+// FindByRegex is a helper func that returns a FindFunc to search for a regex in the raw log lines that can be used in an Event
+func (s Source) FindByRegex(re *regexp.Regexp) sources.FindFunc {
+	return func(ctx context.Context, _ sources.Source, _ []byte) ([]string, error) {
+		return s.logReader.Find(ctx, re)
+	}
+}
+
+// FindByLine is a helper func that returns a FindFunc to search for the lines matched by a regex which the match func accepts,
+// i.e. an expression, that can be used in an Event
+func (s Source) FindByLine(re *regexp.Regexp, match func(line string) bool) sources.FindFunc {
+	return s.logReader.FindByLine(re, match)
+}
+
+// FindBySelectors is a helper func that returns a FindFunc to find the entries matching all selectors that can be used in an Event
+// The log is first searched for a literal value of the selectors, so only candidate lines are decoded.
+func (s Source) FindBySelectors(selectors ...Selector) sources.FindFunc {
+	prefilter := prefilterRegex(selectors)
+	return func(ctx context.Context, _ sources.Source, _ []byte) ([]string, error) {
+		candidates, err := s.logReader.Find(ctx, prefilter)
+		if err != nil {
+			return nil, err
+		}
+		var lines []string
+		for _, line := range candidates {
+			entry, err := Decode(line)
+			if err != nil {
+				continue
+			}
+			if matchesAll(entry, selectors) {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("no entries in %s match the selectors", s.logReader)
+		}
+		return lines, nil
+	}
+}
+
+// ParseTimestamp parses the timestamp field of the line's JSON entry
+func (s Source) ParseTimestamp(line string) (time.Time, error) {
+	entry, err := Decode(line)
+	if err != nil {
+		return time.Time{}, err
+	}
+	value, ok := field(entry, s.timestampField)
+	if !ok {
+		return time.Time{}, fmt.Errorf("unable to find timestamp field \"%s\" on log line \"%s\"", s.timestampField, line)
+	}
+	switch ts := value.(type) {
+	case json.Number:
+		secs, err := ts.Float64()
+		if err != nil {
+			return time.Time{}, err
+		}
+		whole, frac := math.Modf(secs)
+		return time.Unix(int64(whole), int64(frac*1e9)).UTC(), nil
+	case string:
+		return s.logReader.ParseRawTimestamp(ts)
+	}
+	return time.Time{}, fmt.Errorf("timestamp field \"%s\" is not a string or number on log line \"%s\"", s.timestampField, line)
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search the log source and return the results based on the Event's matcher
+func (s Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	matchedLines, err := s.logReader.FindEvent(ctx, s, event)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, line := range matchedLines {
+		ts, err := s.ParseTimestamp(line)
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(line)
+		}
+		results = append(results, sources.FindResult{
+			Line:      line,
+			Timestamp: ts,
+			Err:       err,
+			Comment:   comment,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Timestamp.UnixMicro() < results[j].Timestamp.UnixMicro()
+	})
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
+
+// CommentField is a helper func that returns a CommentFunc which uses the value of a field of the matched entry
+func CommentField(path string) func(matchedLine string) string {
+	return func(matchedLine string) string {
+		entry, err := Decode(matchedLine)
+		if err != nil {
+			return ""
+		}
+		value, ok := field(entry, path)
+		if !ok {
+			return ""
+		}
+		return stringify(value)
+	}
+}
+
+// prefilterRegex matches the lines holding a literal value of the selectors, or every line with a JSON entry if no value
+// is written verbatim in JSON, i.e. it has characters which are escaped
+func prefilterRegex(selectors []Selector) *regexp.Regexp {
+	for _, selector := range selectors {
+		for _, value := range []string{selector.Equals, selector.Contains} {
+			if value == "" {
+				continue
+			}
+			var encoded bytes.Buffer
+			encoder := json.NewEncoder(&encoded)
+			encoder.SetEscapeHTML(false)
+			if err := encoder.Encode(value); err == nil && strings.TrimSpace(encoded.String()) == `"`+value+`"` {
+				return regexp.MustCompile(fmt.Sprintf(`.*\{.*%s.*`, regexp.QuoteMeta(value)))
+			}
+		}
+	}
+	return anyEntry
+}
+
+// matchesAll checks if the entry matches every selector
+func matchesAll(entry map[string]any, selectors []Selector) bool {
+	for _, selector := range selectors {
+		value, ok := field(entry, selector.Field)
+		if !ok {
+			return false
+		}
+		str := stringify(value)
+		if (selector.Equals != "" && str != selector.Equals) ||
+			(selector.Contains != "" && !strings.Contains(str, selector.Contains)) ||
+			(selector.Regex != nil && !selector.Regex.MatchString(str)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Decode decodes the JSON entry of a log line, skipping any prefix before the entry, numbers are decoded as json.Number
+func Decode(line string) (map[string]any, error) {
+	start := strings.IndexByte(line, '{')
+	if start < 0 {
+		return nil, fmt.Errorf("no JSON entry on log line \"%s\"", line)
+	}
+	decoder := json.NewDecoder(strings.NewReader(line[start:]))
+	decoder.UseNumber()
+	var entry map[string]any
+	if err := decoder.Decode(&entry); err != nil {
+		return nil, fmt.Errorf("unable to decode JSON entry on log line \"%s\": %w", line, err)
+	}
+	return entry, nil
+}
+
+// field looks up the value at the dot separated path of the entry
+func field(entry map[string]any, path string) (any, bool) {
+	var value any = entry
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = obj[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// stringify returns strings as is and other values as JSON
+func stringify(value any) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
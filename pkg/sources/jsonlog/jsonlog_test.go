@@ -0,0 +1,186 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsonlog
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+const (
+	agentStarting = `{"ts":"2024-01-14T10:00:01Z","msg":"starting","agent":{"version":"1.2.0"}}`
+	agentReady    = `2024-01-14T10:00:09.000000000Z stdout F {"ts":"2024-01-14T10:00:09Z","msg":"ready","state":"ready","attempt":1}`
+	agentRetried  = `{"ts":1705226415.5,"msg":"ready after retry","state":"ready","attempt":2}`
+	agentPlain    = `agent ready`
+)
+
+func TestDecode(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		line  string
+		entry map[string]any
+		err   bool
+	}{
+		{name: "entry", line: `{"msg":"ready"}`, entry: map[string]any{"msg": "ready"}},
+		{name: "prefix", line: `2024-01-14T10:00:09Z stdout F {"msg":"ready"}`, entry: map[string]any{"msg": "ready"}},
+		{name: "numbers", line: `{"attempt":2,"ts":1705226415.5}`, entry: map[string]any{"attempt": json.Number("2"), "ts": json.Number("1705226415.5")}},
+		{name: "nested", line: `{"agent":{"version":"1.2.0"}}`, entry: map[string]any{"agent": map[string]any{"version": "1.2.0"}}},
+		{name: "no entry", line: agentPlain, err: true},
+		{name: "truncated entry", line: `{"msg":"rea`, err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			entry, err := Decode(tc.line)
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected an error decoding %q, got %v", tc.line, entry)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to decode %q: %v", tc.line, err)
+			}
+			if !reflect.DeepEqual(entry, tc.entry) {
+				t.Errorf("expected entry %v, got %v", tc.entry, entry)
+			}
+		})
+	}
+}
+
+func TestMatchesAll(t *testing.T) {
+	entry, err := Decode(agentStarting)
+	if err != nil {
+		t.Fatalf("unable to decode the entry: %v", err)
+	}
+	for _, tc := range []struct {
+		name      string
+		selectors []Selector
+		matches   bool
+	}{
+		{name: "no selectors", matches: true},
+		{name: "field exists", selectors: []Selector{{Field: "msg"}}, matches: true},
+		{name: "missing field", selectors: []Selector{{Field: "state"}}},
+		{name: "equals", selectors: []Selector{{Field: "msg", Equals: "starting"}}, matches: true},
+		{name: "not equals", selectors: []Selector{{Field: "msg", Equals: "start"}}},
+		{name: "contains", selectors: []Selector{{Field: "msg", Contains: "start"}}, matches: true},
+		{name: "regex", selectors: []Selector{{Field: "agent.version", Regex: regexp.MustCompile(`^1\.`)}}, matches: true},
+		{name: "nested object as JSON", selectors: []Selector{{Field: "agent", Equals: `{"version":"1.2.0"}`}}, matches: true},
+		{name: "path through a string", selectors: []Selector{{Field: "msg.version"}}},
+		{name: "every selector", selectors: []Selector{{Field: "msg", Equals: "starting"}, {Field: "agent.version", Equals: "1.3.0"}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if matches := matchesAll(entry, tc.selectors); matches != tc.matches {
+				t.Errorf("expected matchesAll to be %t, got %t", tc.matches, matches)
+			}
+		})
+	}
+}
+
+func TestPrefilterRegex(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		selectors []Selector
+		expected  string
+	}{
+		{name: "no values", selectors: []Selector{{Field: "msg"}}, expected: anyEntry.String()},
+		{name: "equals", selectors: []Selector{{Field: "msg", Equals: "ready"}}, expected: `.*\{.*ready.*`},
+		{name: "contains is quoted", selectors: []Selector{{Field: "msg", Contains: "1.2"}}, expected: `.*\{.*1\.2.*`},
+		{name: "escaped value", selectors: []Selector{{Field: "msg", Equals: `say "hi"`}}, expected: anyEntry.String()},
+		{name: "first verbatim value", selectors: []Selector{{Field: "msg", Equals: "a\tb"}, {Field: "state", Equals: "ready"}}, expected: `.*\{.*ready.*`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if re := prefilterRegex(tc.selectors); re.String() != tc.expected {
+				t.Errorf("expected prefilter %s, got %s", tc.expected, re)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.log")
+	if err := os.WriteFile(path, []byte(strings.Join([]string{agentStarting, agentPlain, agentReady, agentRetried}, "\n")+"\n"), 0o600); err != nil {
+		t.Fatalf("unable to write the log: %v", err)
+	}
+	src := New("agent", path, "", "")
+	for _, tc := range []struct {
+		name       string
+		event      *sources.Event
+		timestamps []time.Time
+		comments   []string
+		err        bool
+	}{
+		{
+			name:       "first entry",
+			event:      &sources.Event{FindFn: src.FindBySelectors(Selector{Field: "state", Equals: "ready"}), MatchSelector: sources.EventMatchSelectorFirst},
+			timestamps: []time.Time{time.Date(2024, 1, 14, 10, 0, 9, 0, time.UTC)},
+		},
+		{
+			name:       "numeric timestamp",
+			event:      &sources.Event{FindFn: src.FindBySelectors(Selector{Field: "state", Equals: "ready"}), MatchSelector: sources.EventMatchSelectorLast},
+			timestamps: []time.Time{time.Date(2024, 1, 14, 10, 0, 15, 500000000, time.UTC)},
+		},
+		{
+			name:       "comment field",
+			event:      &sources.Event{FindFn: src.FindBySelectors(Selector{Field: "attempt"}), MatchSelector: sources.EventMatchSelectorAll, CommentFn: CommentField("attempt")},
+			timestamps: []time.Time{time.Date(2024, 1, 14, 10, 0, 9, 0, time.UTC), time.Date(2024, 1, 14, 10, 0, 15, 500000000, time.UTC)},
+			comments:   []string{"1", "2"},
+		},
+		{
+			name:       "raw line regex",
+			event:      &sources.Event{FindFn: src.FindByRegex(regexp.MustCompile(`.*"msg":"starting".*`)), MatchSelector: sources.EventMatchSelectorFirst},
+			timestamps: []time.Time{time.Date(2024, 1, 14, 10, 0, 1, 0, time.UTC)},
+		},
+		{
+			name:  "no match",
+			event: &sources.Event{FindFn: src.FindBySelectors(Selector{Field: "state", Equals: "failed"}), MatchSelector: sources.EventMatchSelectorFirst},
+			err:   true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			results, err := src.Find(context.Background(), tc.event)
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected no matches, got %v", results)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to find the event: %v", err)
+			}
+			if len(results) != len(tc.timestamps) {
+				t.Fatalf("expected %d results, got %v", len(tc.timestamps), results)
+			}
+			for i, result := range results {
+				if result.Err != nil {
+					t.Errorf("unable to parse the timestamp of %q: %v", result.Line, result.Err)
+				}
+				if !result.Timestamp.Equal(tc.timestamps[i]) {
+					t.Errorf("expected timestamp %s, got %s", tc.timestamps[i], result.Timestamp)
+				}
+				if tc.comments != nil && result.Comment != tc.comments[i] {
+					t.Errorf("expected comment %q, got %q", tc.comments[i], result.Comment)
+				}
+			}
+		})
+	}
+}
@@ -63,10 +63,12 @@ func (s Source) Name() string {
 	return Name
 }
 
+// RemoteClock marks the timestamps of the Kubernetes API as from a remote clock, they are not skewed by steps of the node's clock
+func (s Source) RemoteClock() {}
+
 // FindPodCreationTime retrieves the Pod creation time
 func (s *Source) FindPodCreationTime() sources.FindFunc {
-	return func(_ sources.Source, _ []byte) ([]string, error) {
-		ctx := context.Background()
+	return func(ctx context.Context, _ sources.Source, _ []byte) ([]string, error) {
 		pods, err := s.clientset.CoreV1().Pods(s.podNamespace).List(ctx, v1.ListOptions{FieldSelector: fmt.Sprintf("spec.nodeName=%s", s.nodeName)})
 		if err != nil {
 			return nil, err
@@ -92,8 +94,8 @@ func (s *Source) ParseTimeFor(event []byte) (time.Time, error) {
 }
 
 // Find will use the Event's FindFunc and CommentFunc to search the source and return the result
-func (s *Source) Find(event *sources.Event) ([]sources.FindResult, error) {
-	k8sEvents, err := event.FindFn(s, nil)
+func (s *Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	k8sEvents, err := event.FindFn(ctx, s, nil)
 	if err != nil {
 		return nil, err
 	}
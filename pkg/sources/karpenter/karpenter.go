@@ -0,0 +1,172 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package karpenter is a latency timing source for the lifecycle of the Karpenter NodeClaim (or Machine) that launched the node
+package karpenter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	Name = "Karpenter"
+	// NodeClaimsPath is the NodeClaim API path for Karpenter v0.32+
+	NodeClaimsPath = "/apis/karpenter.sh/v1beta1/nodeclaims"
+	// MachinesPath is the Machine API path for Karpenter v0.28 - v0.31 which is used when NodeClaims are not available
+	MachinesPath = "/apis/karpenter.sh/v1alpha5/machines"
+)
+
+// NodeClaim status condition types
+const (
+	ConditionLaunched    = "Launched"
+	ConditionRegistered  = "Registered"
+	ConditionInitialized = "Initialized"
+)
+
+// Source is the Karpenter NodeClaim API source
+type Source struct {
+	clientset *kubernetes.Clientset
+	nodeName  string
+}
+
+// nodeClaim is the subset of a NodeClaim or Machine used for timings
+type nodeClaim struct {
+	Metadata struct {
+		Name              string    `json:"name"`
+		CreationTimestamp time.Time `json:"creationTimestamp"`
+	} `json:"metadata"`
+	Status struct {
+		NodeName   string `json:"nodeName"`
+		Conditions []struct {
+			Type               string    `json:"type"`
+			Status             string    `json:"status"`
+			LastTransitionTime time.Time `json:"lastTransitionTime"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+// New instantiates a new instance of the Karpenter source for the node
+func New(clientset *kubernetes.Clientset, nodeName string) *Source {
+	return &Source{
+		clientset: clientset,
+		nodeName:  nodeName,
+	}
+}
+
+// ClearCache is a noop for the Karpenter Source since it is an http source, not a log file
+func (s Source) ClearCache() {}
+
+// String is a human readable string of the source
+func (s Source) String() string {
+	return Name
+}
+
+// Name is the name of the source
+func (s Source) Name() string {
+	return Name
+}
+
+// RemoteClock marks the timestamps of the Kubernetes API as from a remote clock, they are not skewed by steps of the node's clock
+func (s Source) RemoteClock() {}
+
+// FindNodeClaimCreationTime is a helper func that returns a FindFunc for when Karpenter decided to provision the node and created its NodeClaim
+func (s *Source) FindNodeClaimCreationTime() sources.FindFunc {
+	return func(ctx context.Context, _ sources.Source, _ []byte) ([]string, error) {
+		nc, err := s.getNodeClaim(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("%s %s", nc.Metadata.CreationTimestamp.Format(time.RFC3339), nc.Metadata.Name)}, nil
+	}
+}
+
+// FindNodeClaimCondition is a helper func that returns a FindFunc for when a NodeClaim status condition (i.e. ConditionInitialized) became true
+func (s *Source) FindNodeClaimCondition(conditionType string) sources.FindFunc {
+	return func(ctx context.Context, _ sources.Source, _ []byte) ([]string, error) {
+		nc, err := s.getNodeClaim(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, condition := range nc.Status.Conditions {
+			if condition.Type == conditionType && condition.Status == "True" {
+				return []string{fmt.Sprintf("%s %s", condition.LastTransitionTime.Format(time.RFC3339), nc.Metadata.Name)}, nil
+			}
+		}
+		return nil, fmt.Errorf("nodeclaim %s is not %s", nc.Metadata.Name, conditionType)
+	}
+}
+
+// CommentNodeClaimName is a helper func that returns a CommentFunc which comments the name of the node's NodeClaim
+func (s *Source) CommentNodeClaimName() func(matchedLine string) string {
+	return func(matchedLine string) string {
+		_, name, _ := strings.Cut(matchedLine, " ")
+		return name
+	}
+}
+
+// getNodeClaim lists NodeClaims, or Machines on older Karpenter versions, to find the one for the node
+func (s *Source) getNodeClaim(ctx context.Context) (*nodeClaim, error) {
+	raw, err := s.clientset.Discovery().RESTClient().Get().AbsPath(NodeClaimsPath).DoRaw(ctx)
+	if apierrors.IsNotFound(err) {
+		raw, err = s.clientset.Discovery().RESTClient().Get().AbsPath(MachinesPath).DoRaw(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to list Karpenter nodeclaims: %w", err)
+	}
+	var list struct {
+		Items []nodeClaim `json:"items"`
+	}
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("unable to parse Karpenter nodeclaims: %w", err)
+	}
+	for _, nc := range list.Items {
+		if nc.Status.NodeName == s.nodeName {
+			return &nc, nil
+		}
+	}
+	return nil, fmt.Errorf("no Karpenter nodeclaim found for node %s", s.nodeName)
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search the source and return the result
+func (s *Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	lines, err := event.FindFn(ctx, s, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, line := range lines {
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(line)
+		}
+		tsStr, _, _ := strings.Cut(line, " ")
+		ts, err := time.Parse(time.RFC3339, tsStr)
+		results = append(results, sources.FindResult{
+			Line:      line,
+			Timestamp: ts,
+			Comment:   comment,
+			Err:       err,
+		})
+	}
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
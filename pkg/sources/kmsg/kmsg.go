@@ -0,0 +1,197 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kmsg is a latency timing source for the kernel ring buffer (/dev/kmsg or a dmesg formatted log)
+// Kernel timestamps are monotonic since boot, so they are converted to wall clock time using the boot time.
+package kmsg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	Name            = "kmsg"
+	DefaultPath     = "/dev/kmsg"
+	UptimePath      = "/proc/uptime"
+	TimestampFormat = regexp.MustCompile(`^\[\s*([0-9]+\.[0-9]+)\]`)
+)
+
+// Source is the kernel ring buffer source
+type Source struct {
+	path     string
+	logs     []byte
+	bootTime time.Time
+}
+
+// New instantiates a new instance of the kmsg source
+// The path may be /dev/kmsg or a file in dmesg format, i.e. "[    5.140900] message", from the current boot
+func New(path string) *Source {
+	return &Source{
+		path: path,
+	}
+}
+
+// ClearCache will clear the cached kernel messages
+func (s *Source) ClearCache() {
+	s.logs = nil
+}
+
+// String is a human readable string of the source, the kernel log path
+func (s *Source) String() string {
+	return s.path
+}
+
+// Name is the name of the source
+func (s *Source) Name() string {
+	return Name
+}
+
+// Read reads the kernel messages in dmesg format and caches them along with the boot time
+// Any further calls to Read() will use the cached messages until ClearCache() is called
+func (s *Source) Read() ([]byte, error) {
+	if s.logs != nil {
+		return s.logs, nil
+	}
+	bootTime, err := BootTime()
+	if err != nil {
+		return nil, err
+	}
+	var logs []byte
+	if s.path == DefaultPath {
+		logs, err = readKmsg(s.path)
+	} else {
+		logs, err = os.ReadFile(s.path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read kernel messages from %s: %w", s.path, err)
+	}
+	s.logs = logs
+	s.bootTime = bootTime
+	return logs, nil
+}
+
+// BootTime returns the wall clock time the system booted calculated from /proc/uptime
+func BootTime() (time.Time, error) {
+	uptime, err := os.ReadFile(UptimePath)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to read uptime: %w", err)
+	}
+	fields := strings.Fields(string(uptime))
+	if len(fields) == 0 {
+		return time.Time{}, fmt.Errorf("unable to parse uptime \"%s\"", string(uptime))
+	}
+	uptimeSecs, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to parse uptime: %w", err)
+	}
+	return time.Now().Add(-time.Duration(uptimeSecs * float64(time.Second))), nil
+}
+
+// toDmesgFormat converts /dev/kmsg records ("priority,sequence,usec,flags;message") to dmesg format lines
+// Continuation lines of a record (prefixed with a space) are dropped.
+func toDmesgFormat(records []string) []byte {
+	var buf bytes.Buffer
+	for _, record := range records {
+		header, msg, ok := strings.Cut(record, ";")
+		if !ok {
+			continue
+		}
+		fields := strings.Split(header, ",")
+		if len(fields) < 3 {
+			continue
+		}
+		usec, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		msg, _, _ = strings.Cut(msg, "\n")
+		fmt.Fprintf(&buf, "[%5d.%06d] %s\n", usec/1e6, usec%1e6, msg)
+	}
+	return buf.Bytes()
+}
+
+// FindByRegex is a helper func that returns a FindFunc to search for a regex in the kernel messages that can be used in an Event
+func (s *Source) FindByRegex(re *regexp.Regexp) sources.FindFunc {
+	return func(_ context.Context, _ sources.Source, log []byte) ([]string, error) {
+		var lines []string
+		scanner := bufio.NewScanner(bytes.NewReader(log))
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			if re.Match(scanner.Bytes()) {
+				lines = append(lines, scanner.Text())
+			}
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("no matches in %s for regex \"%s\"", s.path, re.String())
+		}
+		return lines, nil
+	}
+}
+
+// ParseTimestamp converts the monotonic timestamp of a dmesg formatted line to wall clock time
+func (s *Source) ParseTimestamp(line string) (time.Time, error) {
+	match := TimestampFormat.FindStringSubmatch(line)
+	if len(match) != 2 {
+		return time.Time{}, fmt.Errorf("unable to find timestamp on kernel message matching regex: \"%s\" \"%s\"", TimestampFormat.String(), line)
+	}
+	secs, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return s.bootTime.Add(time.Duration(secs * float64(time.Second))).UTC(), nil
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search the kernel messages and return the results based on the Event's matcher
+func (s *Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	logBytes, err := s.Read()
+	if err != nil {
+		return nil, err
+	}
+	matchedLines, err := event.FindFn(ctx, s, logBytes)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, line := range matchedLines {
+		ts, err := s.ParseTimestamp(line)
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(line)
+		}
+		results = append(results, sources.FindResult{
+			Line:      line,
+			Timestamp: ts,
+			Err:       err,
+			Comment:   comment,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Timestamp.UnixMicro() < results[j].Timestamp.UnixMicro()
+	})
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
@@ -0,0 +1,119 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package logfile is a generic latency timing source for any log file with a configurable name and timestamp format
+package logfile
+
+import (
+	"context"
+	"regexp"
+	"sort"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// Source is a generic log file source
+type Source struct {
+	name      string
+	logReader *sources.LogReader
+}
+
+// New instantiates a new instance of a generic log file source
+// The path may be a glob, and more paths or globs may be passed, in which case the lines of every matching file are merged
+func New(name string, path string, timestampRegex *regexp.Regexp, timestampLayout string, paths ...string) *Source {
+	return &Source{
+		name: name,
+		logReader: &sources.LogReader{
+			Path:            path,
+			Paths:           paths,
+			Glob:            true,
+			TimestampRegex:  timestampRegex,
+			TimestampLayout: timestampLayout,
+		},
+	}
+}
+
+// ClearCache will clear the log reader cache
+func (s Source) ClearCache() {
+	s.logReader.ClearCache()
+}
+
+// Tail will incrementally read the log as it is appended to
+func (s Source) Tail() {
+	s.logReader.Tail()
+}
+
+// WatchPaths are the directories of the log files to watch for writes
+func (s Source) WatchPaths() []string {
+	return s.logReader.WatchPaths()
+}
+
+// Checkpoint returns the read position and matches of the tailed log
+func (s Source) Checkpoint() *sources.Checkpoint {
+	return s.logReader.Checkpoint()
+}
+
+// Restore tails the log from the checkpoint
+func (s Source) Restore(checkpoint *sources.Checkpoint) {
+	s.logReader.Restore(checkpoint)
+}
+
+// String is a human readable string of the source, usually the log file path
+func (s Source) String() string {
+	return s.logReader.String()
+}
+
+// Name is the name of the source
+func (s Source) Name() string {
+	return s.name
+}
+
+// FindByRegex is a helper func that returns a FindFunc to search for a regex in a log source that can be used in an Event
+func (s Source) FindByRegex(re *regexp.Regexp) sources.FindFunc {
+	return func(ctx context.Context, _ sources.Source, log []byte) ([]string, error) {
+		return s.logReader.Find(ctx, re)
+	}
+}
+
+// FindByLine is a helper func that returns a FindFunc to search for the lines matched by a regex which the match func accepts,
+// i.e. an expression, that can be used in an Event
+func (s Source) FindByLine(re *regexp.Regexp, match func(line string) bool) sources.FindFunc {
+	return s.logReader.FindByLine(re, match)
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search the log source and return the results based on the Event's matcher
+func (s Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	matchedLines, err := s.logReader.FindEvent(ctx, s, event)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, line := range matchedLines {
+		ts, err := s.logReader.ParseTimestamp(line)
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(line)
+		}
+		results = append(results, sources.FindResult{
+			Line:      line,
+			Timestamp: ts,
+			Err:       err,
+			Comment:   comment,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Timestamp.UnixMicro() < results[j].Timestamp.UnixMicro()
+	})
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
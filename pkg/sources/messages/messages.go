@@ -16,6 +16,7 @@ limitations under the License.
 package messages
 
 import (
+	"context"
 	"regexp"
 	"sort"
 
@@ -25,8 +26,9 @@ import (
 var (
 	Name            = "Messages"
 	DefaultPath     = "/var/log/messages*"
-	TimestampFormat = regexp.MustCompile(`[A-Z][a-z]+[ ]+[0-9][0-9]? [0-9]{2}:[0-9]{2}:[0-9]{2}`)
-	TimestampLayout = "Jan 2 15:04:05 2006"
+	SyslogPath      = "/var/log/syslog*"
+	TimestampFormat = sources.SyslogTimestampFormat
+	TimestampLayout = sources.TimestampLayoutAuto
 )
 
 // Source is the /var/log/messages log source
@@ -35,10 +37,12 @@ type Source struct {
 }
 
 // New instantiates a new instance of messages source
-func New(path string) *Source {
+// The paths are more paths, or globs, of the log on other distros, the lines of every matched log are merged.
+func New(path string, paths ...string) *Source {
 	return &Source{
 		logReader: &sources.LogReader{
 			Path:            path,
+			Paths:           paths,
 			Glob:            true,
 			TimestampRegex:  TimestampFormat,
 			TimestampLayout: TimestampLayout,
@@ -51,9 +55,29 @@ func (s Source) ClearCache() {
 	s.logReader.ClearCache()
 }
 
+// Tail will incrementally read the log as it is appended to
+func (s Source) Tail() {
+	s.logReader.Tail()
+}
+
+// WatchPaths are the directories of the log files to watch for writes
+func (s Source) WatchPaths() []string {
+	return s.logReader.WatchPaths()
+}
+
+// Checkpoint returns the read position and matches of the tailed log
+func (s Source) Checkpoint() *sources.Checkpoint {
+	return s.logReader.Checkpoint()
+}
+
+// Restore tails the log from the checkpoint
+func (s Source) Restore(checkpoint *sources.Checkpoint) {
+	s.logReader.Restore(checkpoint)
+}
+
 // String is a human readable string of the source, usually the log file path
 func (s Source) String() string {
-	return s.logReader.Path
+	return s.logReader.String()
 }
 
 // Name is the name of the source
@@ -63,18 +87,20 @@ func (s Source) Name() string {
 
 // FindByRegex is a helper func that returns a FindFunc to search for a regex in a log source that can be used in an Event
 func (s Source) FindByRegex(re *regexp.Regexp) sources.FindFunc {
-	return func(_ sources.Source, log []byte) ([]string, error) {
-		return s.logReader.Find(re)
+	return func(ctx context.Context, _ sources.Source, log []byte) ([]string, error) {
+		return s.logReader.Find(ctx, re)
 	}
 }
 
+// FindByLine is a helper func that returns a FindFunc to search for the lines matched by a regex which the match func accepts,
+// i.e. an expression, that can be used in an Event
+func (s Source) FindByLine(re *regexp.Regexp, match func(line string) bool) sources.FindFunc {
+	return s.logReader.FindByLine(re, match)
+}
+
 // Find will use the Event's FindFunc and CommentFunc to search the log source and return the results based on the Event's matcher
-func (s Source) Find(event *sources.Event) ([]sources.FindResult, error) {
-	logBytes, err := s.logReader.Read()
-	if err != nil {
-		return nil, err
-	}
-	matchedLines, err := event.FindFn(s, logBytes)
+func (s Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	matchedLines, err := s.logReader.FindEvent(ctx, s, event)
 	if err != nil {
 		return nil, err
 	}
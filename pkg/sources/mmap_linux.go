@@ -0,0 +1,41 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// mmapFile maps the file read-only, the returned unmap func must be called once the bytes are no longer referenced
+// Files that cannot be mapped, i.e. pipes or procfs files, are read into memory.
+func mmapFile(file *os.File) ([]byte, func() error, error) {
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	size := stat.Size()
+	if !stat.Mode().IsRegular() || size == 0 || int64(int(size)) != size {
+		return readFile(file)
+	}
+	data, err := unix.Mmap(int(file.Fd()), 0, int(size), unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		return readFile(file)
+	}
+	// logs are searched front to back, so read ahead aggressively and drop pages behind
+	_ = unix.Madvise(data, unix.MADV_SEQUENTIAL)
+	return data, func() error { return unix.Munmap(data) }, nil
+}
@@ -0,0 +1,161 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package npd is a latency timing source for the problems Node Problem Detector (NPD) reports on the node
+// NPD reports temporary problems as Node events and permanent problems as Node conditions.
+package npd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	Name = "Node Problem Detector"
+	// ReporterSuffix is the suffix of the sources of NPD's monitors, i.e. "kernel-monitor" or "systemd-monitor"
+	ReporterSuffix = "-monitor"
+	// Reporters are the sources of NPD's problem daemons which are not monitors
+	Reporters = []string{"abrt-adaptor", "health-checker"}
+	// kubeletConditions are the Node conditions set by the kubelet rather than NPD
+	kubeletConditions = []corev1.NodeConditionType{corev1.NodeReady, corev1.NodeMemoryPressure, corev1.NodeDiskPressure, corev1.NodePIDPressure, corev1.NodeNetworkUnavailable}
+)
+
+// Source is the Node Problem Detector source, which reads NPD's Node events and conditions from the K8s API
+type Source struct {
+	clientset *kubernetes.Clientset
+	nodeName  string
+}
+
+// New instantiates a new instance of the Node Problem Detector source for the node
+func New(clientset *kubernetes.Clientset, nodeName string) *Source {
+	return &Source{
+		clientset: clientset,
+		nodeName:  nodeName,
+	}
+}
+
+// ClearCache is a noop for the Node Problem Detector Source since it is an http source, not a log file
+func (s Source) ClearCache() {}
+
+// String is a human readable string of the source
+func (s Source) String() string {
+	return Name
+}
+
+// Name is the name of the source
+func (s Source) Name() string {
+	return Name
+}
+
+// RemoteClock marks the timestamps of the Kubernetes API as from a remote clock, they are not skewed by steps of the node's clock
+func (s Source) RemoteClock() {}
+
+// FindProblemEvents is a helper func that returns a FindFunc for the temporary problems NPD reported as Node events, i.e. KernelOops
+func (s *Source) FindProblemEvents() sources.FindFunc {
+	return func(ctx context.Context, _ sources.Source, _ []byte) ([]string, error) {
+		events, err := s.clientset.CoreV1().Events(v1.NamespaceAll).List(ctx, v1.ListOptions{
+			FieldSelector: fmt.Sprintf("involvedObject.kind=Node,involvedObject.name=%s", s.nodeName),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to list node events: %w", err)
+		}
+		var lines []string
+		for _, event := range events.Items {
+			if !isReporter(event.Source.Component) && !isReporter(event.ReportingController) {
+				continue
+			}
+			ts := lo.Ternary(event.FirstTimestamp.IsZero(), event.EventTime.Time, event.FirstTimestamp.Time)
+			lines = append(lines, fmt.Sprintf("%s %s: %s", ts.Format(time.RFC3339), event.Reason, oneLine(event.Message)))
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("no node problem events found for node %s", s.nodeName)
+		}
+		sort.Strings(lines)
+		return lines, nil
+	}
+}
+
+// FindProblemConditions is a helper func that returns a FindFunc for the permanent problems NPD reported as true Node conditions, i.e. KernelDeadlock
+func (s *Source) FindProblemConditions() sources.FindFunc {
+	return func(ctx context.Context, _ sources.Source, _ []byte) ([]string, error) {
+		node, err := s.clientset.CoreV1().Nodes().Get(ctx, s.nodeName, v1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("unable to get node %s: %w", s.nodeName, err)
+		}
+		var lines []string
+		for _, condition := range node.Status.Conditions {
+			if lo.Contains(kubeletConditions, condition.Type) || condition.Status != corev1.ConditionTrue {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%s %s: %s", condition.LastTransitionTime.Format(time.RFC3339), condition.Type, condition.Reason))
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("no node problem conditions found for node %s", s.nodeName)
+		}
+		sort.Strings(lines)
+		return lines, nil
+	}
+}
+
+// CommentProblem is a helper func that returns a CommentFunc which comments the problem's reason and message, or condition type and reason
+func (s *Source) CommentProblem() func(matchedLine string) string {
+	return func(matchedLine string) string {
+		_, problem, _ := strings.Cut(matchedLine, " ")
+		return problem
+	}
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search the source and return the result
+func (s *Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	lines, err := event.FindFn(ctx, s, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, line := range lines {
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(line)
+		}
+		tsStr, _, _ := strings.Cut(line, " ")
+		ts, err := time.Parse(time.RFC3339, tsStr)
+		results = append(results, sources.FindResult{
+			Line:      line,
+			Timestamp: ts,
+			Comment:   comment,
+			Err:       err,
+		})
+	}
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
+
+// isReporter checks if the event source is one of NPD's problem daemons
+func isReporter(component string) bool {
+	return strings.HasSuffix(component, ReporterSuffix) || lo.Contains(Reporters, component)
+}
+
+// oneLine collapses a multi-line message, i.e. a kernel oops, so it fits a timeline comment
+func oneLine(message string) string {
+	return strings.Join(strings.Fields(message), " ")
+}
@@ -0,0 +1,137 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package procfs is a latency timing source for the boot time and process start times of /proc
+// It is readable by unprivileged containers without host log mounts, i.e. in Fargate and Firecracker microVMs where
+// each pod runs in its own VM, so the boot time is the microVM's.
+package procfs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/kmsg"
+)
+
+var (
+	Name        = "proc"
+	DefaultPath = "/proc"
+	// ClockTicks is USER_HZ, the unit of process start times in /proc/<pid>/stat, which is 100 on all Linux architectures
+	ClockTicks = 100
+)
+
+// Source is the /proc source
+type Source struct {
+	path string
+}
+
+// New instantiates a new instance of the /proc source
+func New(path string) *Source {
+	return &Source{
+		path: path,
+	}
+}
+
+// ClearCache is a noop for the /proc source since /proc is read on every Find
+func (s *Source) ClearCache() {}
+
+// String is a human readable string of the source, the /proc path
+func (s *Source) String() string {
+	return s.path
+}
+
+// Name is the name of the source
+func (s *Source) Name() string {
+	return Name
+}
+
+// FindBootTime is a helper func that returns a FindFunc to find when the system booted that can be used in an Event
+func (s *Source) FindBootTime() sources.FindFunc {
+	return func(_ context.Context, _ sources.Source, _ []byte) ([]string, error) {
+		bootTime, err := kmsg.BootTime()
+		if err != nil {
+			return nil, err
+		}
+		return []string{strconv.FormatInt(bootTime.UnixMicro(), 10)}, nil
+	}
+}
+
+// FindProcessStart is a helper func that returns a FindFunc to find when a process started that can be used in an Event
+// PID 1 is the first process of the container's PID namespace, so its start is when the container started.
+func (s *Source) FindProcessStart(pid int) sources.FindFunc {
+	return func(_ context.Context, _ sources.Source, _ []byte) ([]string, error) {
+		bootTime, err := kmsg.BootTime()
+		if err != nil {
+			return nil, err
+		}
+		ticks, err := s.processStartTicks(pid)
+		if err != nil {
+			return nil, err
+		}
+		startTime := bootTime.Add(time.Duration(ticks) * time.Second / time.Duration(ClockTicks))
+		return []string{strconv.FormatInt(startTime.UnixMicro(), 10)}, nil
+	}
+}
+
+// processStartTicks returns the start time of a process in clock ticks since boot, the 22nd field of /proc/<pid>/stat
+func (s *Source) processStartTicks(pid int) (int64, error) {
+	statPath := filepath.Join(s.path, strconv.Itoa(pid), "stat")
+	stat, err := os.ReadFile(statPath)
+	if err != nil {
+		return 0, fmt.Errorf("unable to read %s: %w", statPath, err)
+	}
+	// the command (2nd field) is in parentheses and may contain spaces, so fields are counted after its closing parenthesis
+	commEnd := strings.LastIndex(string(stat), ")")
+	if commEnd == -1 {
+		return 0, fmt.Errorf("unable to parse %s", statPath)
+	}
+	fields := strings.Fields(string(stat)[commEnd+1:])
+	if len(fields) < 20 {
+		return 0, fmt.Errorf("unable to parse %s, expected at least 22 fields", statPath)
+	}
+	ticks, err := strconv.ParseInt(fields[19], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse the start time of %s: %w", statPath, err)
+	}
+	return ticks, nil
+}
+
+// Find will use the Event's FindFunc and CommentFunc to read /proc and return the result
+func (s *Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	timestamps, err := event.FindFn(ctx, s, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, tsStr := range timestamps {
+		tsMicros, err := strconv.ParseInt(tsStr, 10, 64)
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(tsStr)
+		}
+		results = append(results, sources.FindResult{
+			Line:      tsStr,
+			Timestamp: time.UnixMicro(tsMicros),
+			Comment:   comment,
+			Err:       err,
+		})
+	}
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
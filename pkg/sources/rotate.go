@@ -0,0 +1,176 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// rotatedSuffix matches the suffixes logrotate and the kubelet add to rotated logs,
+// i.e. "messages.1", "messages-20240101.gz", or "0.log.20240101-123456.gz"
+var rotatedSuffix = regexp.MustCompile(`^[.-][0-9]+(?:-[0-9]+)?(?:\.gz)?$`)
+
+// String is the log's path, or its paths joined by commas
+func (l *LogReader) String() string {
+	return strings.Join(l.patterns(), ",")
+}
+
+// patterns are the log's path and more paths
+func (l *LogReader) patterns() []string {
+	return append([]string{l.Path}, l.Paths...)
+}
+
+// Files are the log files which are read, the paths or their glob matches and their rotated siblings, from the oldest to the newest modified
+func (l *LogReader) Files() ([]string, error) {
+	return l.logPaths()
+}
+
+// logPaths resolves the log's paths, or their glob matches, and their rotated siblings ordered from the oldest to the newest modified
+// Paths which do not exist are skipped as long as one of them does.
+func (l *LogReader) logPaths() ([]string, error) {
+	var matches []string
+	matchedPatterns := 0
+	for _, pattern := range l.patterns() {
+		patternMatches := []string{pattern}
+		if l.Glob {
+			var err error
+			if patternMatches, err = filepath.Glob(pattern); err != nil {
+				return nil, fmt.Errorf("unable to find log file %s: %w", pattern, err)
+			}
+		} else if _, err := os.Stat(pattern); err != nil {
+			patternMatches = nil
+		}
+		if len(patternMatches) > 0 {
+			matchedPatterns++
+		}
+		matches = append(matches, patternMatches...)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("unable to find log file %s", l)
+	}
+	l.interleaved = matchedPatterns > 1
+	modTimes := map[string]time.Time{}
+	for _, match := range matches {
+		for _, path := range append(rotatedSiblings(match), match) {
+			if _, ok := modTimes[path]; ok {
+				continue
+			}
+			modTimes[path] = time.Time{}
+			if stat, err := os.Stat(path); err == nil {
+				modTimes[path] = stat.ModTime()
+			}
+		}
+	}
+	paths := make([]string, 0, len(modTimes))
+	for path := range modTimes {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if !modTimes[paths[i]].Equal(modTimes[paths[j]]) {
+			return modTimes[paths[i]].Before(modTimes[paths[j]])
+		}
+		return paths[i] < paths[j]
+	})
+	return paths, nil
+}
+
+// rotatedSiblings finds the rotated, and possibly gzipped, copies of the log file in its directory
+func rotatedSiblings(path string) []string {
+	dir, base := filepath.Split(path)
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		return nil
+	}
+	var siblings []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, base) && rotatedSuffix.MatchString(strings.TrimPrefix(name, base)) {
+			siblings = append(siblings, filepath.Join(dir, name))
+		}
+	}
+	return siblings
+}
+
+// mapRotated maps the rotated log files in order, each is kept apart so they are searched in turn rather than copied together
+func mapRotated(paths []string) ([][]byte, []func() error, error) {
+	var rotated [][]byte
+	var unmaps []func() error
+	for _, path := range paths {
+		fileBytes, unmap, err := mapLogFile(path)
+		if err != nil {
+			for _, unmap := range unmaps {
+				_ = unmap()
+			}
+			return nil, nil, err
+		}
+		rotated = append(rotated, fileBytes)
+		if unmap != nil {
+			unmaps = append(unmaps, unmap)
+		}
+	}
+	return rotated, unmaps, nil
+}
+
+// mapLogFile maps the log file, a gzipped log is decompressed to an unlinked temporary file which is mapped instead so the
+// decompressed log is held by the page cache rather than the heap. It is decompressed into memory if no temporary file can be written.
+func mapLogFile(path string) ([]byte, func() error, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to open log file %s: %w", path, err)
+	}
+	defer file.Close()
+	if !strings.HasSuffix(path, ".gz") {
+		fileBytes, unmap, err := mmapFile(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read file %s: %w", path, err)
+		}
+		return fileBytes, unmap, nil
+	}
+	gzReader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create gzip reader for file %s: %w", path, err)
+	}
+	defer gzReader.Close()
+	tmp, err := os.CreateTemp("", "node-latency-*.log")
+	if err != nil {
+		fileBytes, err := io.ReadAll(gzReader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read file %s: %w", path, err)
+		}
+		return fileBytes, nil, nil
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	if _, err := io.Copy(tmp, gzReader); err != nil {
+		return nil, nil, fmt.Errorf("unable to decompress file %s: %w", path, err)
+	}
+	// files which are not mapped are read from the start
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, fmt.Errorf("unable to read decompressed file %s: %w", path, err)
+	}
+	fileBytes, unmap, err := mmapFile(tmp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read decompressed file %s: %w", path, err)
+	}
+	return fileBytes, unmap, nil
+}
@@ -0,0 +1,88 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRotatedSiblings(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"messages", "messages.1", "messages-20240114.gz", "messages.bak", "messages-old", "0.log", "0.log.20240114-100000.gz", "0.log.20240114-100000"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatalf("unable to write %s: %v", name, err)
+		}
+	}
+	for _, tc := range []struct {
+		name     string
+		siblings []string
+	}{
+		{name: "messages", siblings: []string{"messages-20240114.gz", "messages.1"}},
+		{name: "0.log", siblings: []string{"0.log.20240114-100000", "0.log.20240114-100000.gz"}},
+		{name: "syslog"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var expected []string
+			for _, sibling := range tc.siblings {
+				expected = append(expected, filepath.Join(dir, sibling))
+			}
+			if siblings := rotatedSiblings(filepath.Join(dir, tc.name)); !reflect.DeepEqual(siblings, expected) {
+				t.Errorf("expected siblings %v, got %v", expected, siblings)
+			}
+		})
+	}
+}
+
+func TestMapLogFile(t *testing.T) {
+	rotated, err := os.ReadFile(filepath.Join("testdata", "rotated", "messages.1"))
+	if err != nil {
+		t.Fatalf("unable to read the rotated log: %v", err)
+	}
+	for _, tc := range []struct {
+		name string
+		path string
+		log  string
+	}{
+		{name: "plain", path: filepath.Join("testdata", "rotated", "messages.1"), log: string(rotated)},
+		{
+			name: "gzipped",
+			path: filepath.Join("testdata", "rotated", "messages-20240114.gz"),
+			log: "Jan 14 09:59:58 ip-192-168-23-248 kernel: Linux version 6.1.66-91.160.amzn2023.x86_64\n" +
+				"Jan 14 10:00:01 ip-192-168-23-248 systemd[1]: Starting containerd.service - containerd container runtime...\n" +
+				containerdStarted + "\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			logBytes, unmap, err := mapLogFile(tc.path)
+			if err != nil {
+				t.Fatalf("unable to map %s: %v", tc.path, err)
+			}
+			if string(logBytes) != tc.log {
+				t.Errorf("expected log %q, got %q", tc.log, logBytes)
+			}
+			if unmap != nil {
+				if err := unmap(); err != nil {
+					t.Errorf("unable to unmap %s: %v", tc.path, err)
+				}
+			}
+		})
+	}
+	if _, _, err := mapLogFile(filepath.Join("testdata", "rotated", "messages.2")); err == nil {
+		t.Error("expected an error mapping a missing log")
+	}
+}
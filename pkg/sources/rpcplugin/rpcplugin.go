@@ -0,0 +1,283 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rpcplugin loads Go Sources shipped as separate plugin binaries at runtime and talks to them over gRPC
+// The plugin binary calls Serve with its Source and events, and the agent executes it and proxies Find calls to it.
+// A magic cookie env var guards against executing the binary directly and the plugin writes a handshake line with the
+// protocol version and the address of its gRPC server to stdout. The protocol borrows the handshake of HashiCorp's
+// go-plugin but it is not go-plugin, which is not a dependency of the module: plugins are built with Serve and
+// go-plugin binaries cannot be loaded.
+package rpcplugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+const (
+	// ServiceName is the fully qualified gRPC service name of a plugin
+	ServiceName = "nodelatency.plugin.v1.Source"
+	// ProtocolVersion is the version of the handshake and service, plugins of another version are rejected
+	ProtocolVersion = "1"
+	// MagicCookieKey and MagicCookieValue are set in the plugin's environment so Serve can tell it was executed by the agent
+	MagicCookieKey   = "NODE_LATENCY_PLUGIN_MAGIC_COOKIE"
+	MagicCookieValue = "5c1e2e4e-node-latency-for-k8s-plugin"
+)
+
+// HandshakeTimeout is how long a plugin may take to start serving
+var HandshakeTimeout = 10 * time.Second
+
+// EventsRequest retrieves the events the plugin serves
+type EventsRequest struct{}
+
+// EventsResponse are the events the plugin serves, without their funcs
+type EventsResponse struct {
+	Events []*sources.Event `json:"events"`
+}
+
+// FindRequest finds the timings of an event by name
+type FindRequest struct {
+	Event string `json:"event"`
+}
+
+// FindResponse are the results of a Find, the Error is the error returned by the plugin's Source
+type FindResponse struct {
+	Results []Result `json:"results"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Result is a sources.FindResult with the error as a string
+type Result struct {
+	Line      string    `json:"line"`
+	Timestamp time.Time `json:"timestamp"`
+	Comment   string    `json:"comment"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// ClearCacheRequest clears the cache of the plugin's Source
+type ClearCacheRequest struct{}
+
+// ClearCacheResponse is returned once the cache is cleared
+type ClearCacheResponse struct{}
+
+// jsonCodec encodes gRPC messages as JSON, it is forced on both ends rather than registered
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Name() string                       { return "json" }
+
+// Source is a plugin binary which is executed on first use and serves a Source over gRPC
+// The plugin is restarted if it exits or its binary is replaced, so plugins can be upgraded without restarting the agent.
+type Source struct {
+	name    string
+	command string
+	args    []string
+	// plugin process state
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	conn    *grpc.ClientConn
+	modTime time.Time
+}
+
+// New instantiates a new instance of a plugin source which executes the command with the args
+func New(name string, command string, args ...string) *Source {
+	return &Source{
+		name:    name,
+		command: command,
+		args:    args,
+	}
+}
+
+// Name is the name of the source
+func (s *Source) Name() string {
+	return s.name
+}
+
+// String is a human readable string of the source, the plugin command line
+func (s *Source) String() string {
+	return strings.Join(append([]string{s.command}, s.args...), " ")
+}
+
+// ClearCache clears the cache of the plugin's Source, a plugin whose binary was replaced is stopped and restarted on next use
+func (s *Source) ClearCache() {
+	if s.conn == nil {
+		return
+	}
+	if modTime, err := s.binaryModTime(); err != nil || !modTime.Equal(s.modTime) {
+		s.Close()
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), HandshakeTimeout)
+	defer cancel()
+	if err := s.invoke(ctx, "ClearCache", &ClearCacheRequest{}, &ClearCacheResponse{}); err != nil {
+		s.Close()
+	}
+}
+
+// Events returns the events served by the plugin
+func (s *Source) Events(ctx context.Context) ([]*sources.Event, error) {
+	resp := &EventsResponse{}
+	if err := s.invoke(ctx, "Events", &EventsRequest{}, resp); err != nil {
+		return nil, err
+	}
+	for _, event := range resp.Events {
+		event.SrcName = s.name
+		event.Src = s
+	}
+	return resp.Events, nil
+}
+
+// Find finds the timings of the event in the plugin's Source
+func (s *Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	resp := &FindResponse{}
+	if err := s.invoke(ctx, "Find", &FindRequest{Event: event.Name}, resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	var results []sources.FindResult
+	for _, r := range resp.Results {
+		result := sources.FindResult{Line: r.Line, Timestamp: r.Timestamp, Comment: r.Comment}
+		if r.Error != "" {
+			result.Err = errors.New(r.Error)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Close stops the plugin process
+func (s *Source) Close() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+	}
+	// the plugin exits once its stdin is closed, it is killed in case it does not
+	if s.stdin != nil {
+		_ = s.stdin.Close()
+	}
+	if s.cmd != nil && s.cmd.Process != nil {
+		_ = s.cmd.Process.Kill()
+		go s.cmd.Wait() //nolint:errcheck
+	}
+	s.cmd, s.stdin, s.conn = nil, nil, nil
+}
+
+// invoke calls a method of the plugin, starting the plugin first if it is not running
+// The plugin is stopped if it is unreachable, i.e. it crashed, so it is restarted on the next call.
+func (s *Source) invoke(ctx context.Context, method string, req any, resp any) error {
+	if err := s.start(ctx); err != nil {
+		return err
+	}
+	err := s.conn.Invoke(ctx, "/"+ServiceName+"/"+method, req, resp)
+	if status.Code(err) == codes.Unavailable {
+		s.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("unable to call %s of plugin \"%s\": %w", method, s.String(), err)
+	}
+	return nil
+}
+
+// start executes the plugin and connects to the address in its handshake line if it is not running
+func (s *Source) start(ctx context.Context) error {
+	if s.conn != nil {
+		return nil
+	}
+	modTime, err := s.binaryModTime()
+	if err != nil {
+		return fmt.Errorf("unable to find plugin \"%s\": %w", s.command, err)
+	}
+	cmd := exec.Command(s.command, s.args...) //nolint:gosec
+	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", MagicCookieKey, MagicCookieValue))
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("unable to start plugin \"%s\": %w", s.String(), err)
+	}
+	s.cmd, s.stdin, s.modTime = cmd, stdin, modTime
+	handshake := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(stdout).ReadString('\n')
+		handshake <- strings.TrimSpace(line)
+		// drain anything else the plugin writes so it does not block on a full pipe
+		_, _ = io.Copy(io.Discard, stdout)
+	}()
+	var line string
+	select {
+	case line = <-handshake:
+	case <-time.After(HandshakeTimeout):
+		s.Close()
+		return fmt.Errorf("plugin \"%s\" did not complete the handshake within %s", s.String(), HandshakeTimeout)
+	case <-ctx.Done():
+		s.Close()
+		return ctx.Err()
+	}
+	// the handshake line is <protocol version>|<network>|<address>, the network is unix or tcp
+	parts := strings.Split(line, "|")
+	if len(parts) != 3 || parts[0] != ProtocolVersion || (parts[1] != "unix" && parts[1] != "tcp") {
+		s.Close()
+		return fmt.Errorf("plugin \"%s\" wrote an invalid handshake \"%s\", expected protocol version %s", s.String(), line, ProtocolVersion)
+	}
+	target := parts[2]
+	if parts[1] == "unix" {
+		target = "unix:" + target
+	}
+	conn, err := grpc.DialContext(ctx, target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(jsonCodec{})),
+	)
+	if err != nil {
+		s.Close()
+		return fmt.Errorf("unable to connect to plugin \"%s\": %w", s.String(), err)
+	}
+	s.conn = conn
+	return nil
+}
+
+// binaryModTime is the modification time of the plugin binary, used to detect when it was replaced
+func (s *Source) binaryModTime() (time.Time, error) {
+	path, err := exec.LookPath(s.command)
+	if err != nil {
+		return time.Time{}, err
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return stat.ModTime(), nil
+}
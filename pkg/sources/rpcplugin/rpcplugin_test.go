@@ -0,0 +1,182 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rpcplugin_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency/latencytest"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/rpcplugin"
+)
+
+var ready = &sources.Event{Name: "Ready", Metric: "ready", Terminal: true}
+
+// TestMain runs the test binary as a plugin when it is executed with a plugin mode as its first arg
+// "serve" serves a fake source whose Ready line is the plugin's pid, "handshake" writes the handshake line in the second
+// arg, and "silent" never writes a handshake.
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "serve":
+			src := latencytest.NewSource("plugin").WithResult("ready", time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC), strconv.Itoa(os.Getpid()))
+			if err := rpcplugin.Serve(src, ready); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "handshake":
+			fmt.Println(os.Args[2])
+			_, _ = io.Copy(io.Discard, os.Stdin)
+			os.Exit(0)
+		case "silent":
+			_, _ = io.Copy(io.Discard, os.Stdin)
+			os.Exit(0)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+// newSource is a plugin source which executes the test binary in the plugin mode
+func newSource(t *testing.T, command string, args ...string) *rpcplugin.Source {
+	src := rpcplugin.New("plugin", command, args...)
+	t.Cleanup(src.Close)
+	return src
+}
+
+// testBinary is the path of the test binary
+func testBinary(t *testing.T) string {
+	path, err := os.Executable()
+	if err != nil {
+		t.Fatalf("unable to find the test binary: %v", err)
+	}
+	return path
+}
+
+func TestServe(t *testing.T) {
+	t.Setenv(rpcplugin.MagicCookieKey, "")
+	if err := rpcplugin.Serve(latencytest.NewSource("plugin"), ready); err == nil {
+		t.Error("expected Serve to fail when not executed by the agent")
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	handshakeTimeout := rpcplugin.HandshakeTimeout
+	rpcplugin.HandshakeTimeout = time.Second
+	t.Cleanup(func() { rpcplugin.HandshakeTimeout = handshakeTimeout })
+	for _, tc := range []struct {
+		name    string
+		command string
+		args    []string
+		err     string
+	}{
+		{name: "served", args: []string{"serve"}},
+		{name: "protocol version mismatch", args: []string{"handshake", "2|unix|/tmp/plugin.sock"}, err: "expected protocol version 1"},
+		{name: "unsupported network", args: []string{"handshake", "1|udp|127.0.0.1:8080"}, err: "invalid handshake"},
+		{name: "malformed", args: []string{"handshake", "ready"}, err: "invalid handshake"},
+		{name: "no handshake", args: []string{"silent"}, err: "did not complete the handshake within 1s"},
+		{name: "missing binary", command: filepath.Join(t.TempDir(), "plugin"), err: "unable to find plugin"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			src := newSource(t, lo.Ternary(tc.command != "", tc.command, testBinary(t)), tc.args...)
+			events, err := src.Events(context.Background())
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("expected an error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to retrieve the events: %v", err)
+			}
+			if len(events) != 1 || events[0].Name != ready.Name || events[0].SrcName != "plugin" || events[0].Src != src {
+				t.Errorf("expected the Ready event of the plugin source, got %+v", events)
+			}
+		})
+	}
+}
+
+func TestRestart(t *testing.T) {
+	// the test binary is copied so that it can be replaced
+	binary, err := os.ReadFile(testBinary(t))
+	if err != nil {
+		t.Fatalf("unable to read the test binary: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "plugin")
+	if err := os.WriteFile(path, binary, 0o700); err != nil {
+		t.Fatalf("unable to copy the test binary: %v", err)
+	}
+	src := newSource(t, path, "serve")
+
+	pid := findPID(t, src)
+	src.ClearCache()
+	if restarted := findPID(t, src); restarted != pid {
+		t.Errorf("expected plugin %d to keep running when its binary is unchanged, got plugin %d", pid, restarted)
+	}
+
+	// a plugin which crashed is restarted on the next call after the one that failed
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		t.Fatalf("unable to find plugin %d: %v", pid, err)
+	}
+	if err := process.Kill(); err != nil {
+		t.Fatalf("unable to kill plugin %d: %v", pid, err)
+	}
+	_, _ = process.Wait()
+	if _, err := src.Find(context.Background(), ready); err == nil {
+		t.Fatalf("expected finding in the crashed plugin %d to fail", pid)
+	}
+	restarted := findPID(t, src)
+	if restarted == pid {
+		t.Fatalf("expected the crashed plugin %d to be restarted", pid)
+	}
+
+	// a plugin whose binary is replaced is restarted once the cache is cleared
+	modTime := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("unable to replace the plugin binary: %v", err)
+	}
+	src.ClearCache()
+	if replaced := findPID(t, src); replaced == restarted {
+		t.Errorf("expected plugin %d to be restarted once its binary was replaced", restarted)
+	}
+}
+
+// findPID finds the Ready event, whose line is the pid of the plugin
+func findPID(t *testing.T, src *rpcplugin.Source) int {
+	t.Helper()
+	results, err := src.Find(context.Background(), ready)
+	if err != nil {
+		t.Fatalf("unable to find %s: %v", ready.Name, err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected a single result, got %+v", results)
+	}
+	pid, err := strconv.Atoi(results[0].Line)
+	if err != nil {
+		t.Fatalf("expected the line to be the plugin's pid, got %s", results[0].Line)
+	}
+	return pid
+}
@@ -0,0 +1,140 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rpcplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/samber/lo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// server serves a plugin's Source and events, calls are serialized since sources are not safe for concurrent use
+type server struct {
+	mu     sync.Mutex
+	src    sources.Source
+	events []*sources.Event
+}
+
+// sourceServer is the gRPC service of a plugin
+type sourceServer interface {
+	Events(context.Context, *EventsRequest) (*EventsResponse, error)
+	Find(context.Context, *FindRequest) (*FindResponse, error)
+	ClearCache(context.Context, *ClearCacheRequest) (*ClearCacheResponse, error)
+}
+
+// serviceDesc is written by hand in place of protoc generated code
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*sourceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("Events", (*server).Events),
+		unaryMethod("Find", (*server).Find),
+		unaryMethod("ClearCache", (*server).ClearCache),
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+// unaryMethod adapts a server method to a grpc.MethodDesc
+func unaryMethod[Req any, Resp any](name string, call func(*server, context.Context, *Req) (*Resp, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+			req := new(Req)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return call(srv.(*server), ctx, req)
+		},
+	}
+}
+
+// Events returns the events without their funcs, which stay in the plugin
+func (s *server) Events(_ context.Context, _ *EventsRequest) (*EventsResponse, error) {
+	return &EventsResponse{Events: s.events}, nil
+}
+
+// Find finds the timings of the event with the plugin's Source
+func (s *server) Find(ctx context.Context, req *FindRequest) (*FindResponse, error) {
+	event, ok := lo.Find(s.events, func(e *sources.Event) bool { return e.Name == req.Event })
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "event \"%s\" is not served by the plugin", req.Event)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	results, err := s.src.Find(ctx, event)
+	resp := &FindResponse{Results: lo.Map(results, func(r sources.FindResult, _ int) Result {
+		result := Result{Line: r.Line, Timestamp: r.Timestamp, Comment: r.Comment}
+		if r.Err != nil {
+			result.Error = r.Err.Error()
+		}
+		return result
+	})}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	return resp, nil
+}
+
+// ClearCache clears the cache of the plugin's Source
+func (s *server) ClearCache(_ context.Context, _ *ClearCacheRequest) (*ClearCacheResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.ClearCache()
+	return &ClearCacheResponse{}, nil
+}
+
+// Serve serves the Source and its events to the agent, it is called from the main func of a plugin binary
+// Serve returns once the agent closes the plugin's stdin, i.e. when the agent exits or restarts the plugin.
+func Serve(src sources.Source, events ...*sources.Event) error {
+	if os.Getenv(MagicCookieKey) != MagicCookieValue {
+		return errors.New("this binary is a node-latency-for-k8s plugin and is executed by the agent, it should be declared as a source of type plugin in the config file")
+	}
+	for _, event := range events {
+		event.SrcName, event.Src = src.Name(), src
+	}
+	dir, err := os.MkdirTemp("", "node-latency-plugin")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "plugin.sock")
+	lis, err := net.Listen("unix", socket)
+	if err != nil {
+		return fmt.Errorf("unable to listen on %s: %w", socket, err)
+	}
+	srv := grpc.NewServer(grpc.ForceServerCodec(jsonCodec{}))
+	srv.RegisterService(&serviceDesc, &server{src: src, events: events})
+	go func() {
+		_, _ = io.Copy(io.Discard, os.Stdin)
+		srv.Stop()
+	}()
+	fmt.Printf("%s|unix|%s\n", ProtocolVersion, socket)
+	if err := srv.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	return nil
+}
@@ -16,13 +16,15 @@ package sources
 
 import (
 	"bufio"
-	"compress/gzip"
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
-	"sort"
+	"runtime/debug"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -35,8 +37,8 @@ var (
 // Most often source is a log file or an API.
 type Source interface {
 	// Find finds the string in the source using a source specific method (could be regex or HTTP path)
-	// If no time.Time could be found an error is returned
-	Find(event *Event) ([]FindResult, error)
+	// If no time.Time could be found an error is returned, the search is abandoned once the context is done
+	Find(ctx context.Context, event *Event) ([]FindResult, error)
 	// Name is the source name identifier
 	Name() string
 	// ClearCache clears any cached source data
@@ -53,8 +55,74 @@ type FindResult struct {
 	Err       error
 }
 
-type FindFunc func(s Source, log []byte) ([]string, error)
+type FindFunc func(ctx context.Context, s Source, log []byte) ([]string, error)
 type CommentFunc func(matchedLine string) string
+type LabelFunc func(matchedLine string) map[string]string
+
+// Tailer is a Source that can be tailed so that appended data is read incrementally instead of re-reading the whole source
+type Tailer interface {
+	Source
+	// Tail switches the source to incrementally read and match appended data after its cache is cleared
+	Tail()
+	// WatchPaths are the directories to watch for writes to the source
+	WatchPaths() []string
+}
+
+// EventProvider is a Source that declares its own events, i.e. a plugin, which are registered when the source is measured
+type EventProvider interface {
+	Source
+	// Events returns the events currently found in the source
+	Events(ctx context.Context) ([]*Event, error)
+}
+
+// RegexFinder is a Source that can search for a regular expression, usually a log source
+type RegexFinder interface {
+	Source
+	// FindByRegex returns a FindFunc that searches the source for the regex and can be used in an Event
+	FindByRegex(re *regexp.Regexp) FindFunc
+}
+
+// LineFinder is a RegexFinder that can also filter the lines matched by a regular expression, usually a log file source
+type LineFinder interface {
+	RegexFinder
+	// FindByLine returns a FindFunc that searches the source for the lines matched by the regex which the match func
+	// accepts, i.e. an expression, and can be used in an Event
+	FindByLine(re *regexp.Regexp, match func(line string) bool) FindFunc
+}
+
+// FindByLine is a helper func that returns a FindFunc to search the source for the lines matched by the regex which the
+// match func accepts that can be used in an Event. Sources which are not a LineFinder are searched for all of the lines
+// matched by the regex, which are then filtered, since their first match may not be accepted.
+func FindByLine(src RegexFinder, re *regexp.Regexp, match func(line string) bool) FindFunc {
+	if finder, ok := src.(LineFinder); ok {
+		return finder.FindByLine(re, match)
+	}
+	find := src.FindByRegex(re)
+	return func(ctx context.Context, s Source, log []byte) ([]string, error) {
+		lines, err := find(ctx, s, log)
+		if err != nil {
+			return nil, err
+		}
+		var matched []string
+		for _, line := range lines {
+			if match(line) {
+				matched = append(matched, line)
+			}
+		}
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("no lines in %s for regex \"%s\" match", src, re.String())
+		}
+		return matched, nil
+	}
+}
+
+// RemoteClock is a Source whose timestamps are from a remote clock, i.e. a cloud provider API, rather than the node's clock
+// Its timestamps are not corrected when the node's clock is stepped.
+type RemoteClock interface {
+	Source
+	// RemoteClock marks the source's timestamps as from a remote clock
+	RemoteClock()
+}
 
 // Event defines what is being timed from a specific source
 type Event struct {
@@ -66,6 +134,18 @@ type Event struct {
 	Src           Source      `json:"-"`
 	CommentFn     CommentFunc `json:"-"`
 	FindFn        FindFunc    `json:"-"`
+	// Labels are the names of the labels the LabelFn sets on the event's metric, i.e. regex capture groups
+	Labels  []string  `json:"labels,omitempty"`
+	LabelFn LabelFunc `json:"-"`
+	// StaticLabels are labels with fixed values on the event's metric, i.e. phase=bootstrap, they are added to Labels when
+	// the event is registered
+	StaticLabels map[string]string `json:"staticLabels,omitempty"`
+	// MaxLatency is the expected maximum latency (SLO) of the event, zero means the event has no SLO
+	MaxLatency time.Duration `json:"maxLatency"`
+	// After are the metrics of the events this event is expected to be timed after, i.e. kubelet_start for kubelet_registered
+	After []string `json:"after,omitempty"`
+	// Deadline is how long after the first timing the event must be observed by before it is reported absent, zero means no deadline
+	Deadline time.Duration `json:"deadline,omitempty"`
 }
 
 // Match Selector consts for an Event's MatchSelector
@@ -73,8 +153,34 @@ const (
 	EventMatchSelectorFirst = "first"
 	EventMatchSelectorLast  = "last"
 	EventMatchSelectorAll   = "all"
+	// EventMatchSelectorNthPrefix prefixes the MatchSelector of the nth match counting from 1, i.e. "nth:3"
+	EventMatchSelectorNthPrefix = "nth:"
 )
 
+// EventMatchSelectorNth returns the MatchSelector of the nth match counting from 1
+func EventMatchSelectorNth(n int) string {
+	return fmt.Sprintf("%s%d", EventMatchSelectorNthPrefix, n)
+}
+
+// ValidMatchSelector checks if the matchSelector is first, last, all, or the nth match
+func ValidMatchSelector(matchSelector string) bool {
+	switch matchSelector {
+	case EventMatchSelectorFirst, EventMatchSelectorLast, EventMatchSelectorAll:
+		return true
+	}
+	_, ok := nthMatch(matchSelector)
+	return ok
+}
+
+// nthMatch parses the n of an nth matchSelector
+func nthMatch(matchSelector string) (int, bool) {
+	if !strings.HasPrefix(matchSelector, EventMatchSelectorNthPrefix) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(matchSelector, EventMatchSelectorNthPrefix))
+	return n, err == nil && n > 0
+}
+
 // Timing is a specific instance of an Event timing
 type Timing struct {
 	Event     *Event        `json:"event"`
@@ -82,6 +188,27 @@ type Timing struct {
 	T         time.Duration `json:"seconds"`
 	Comment   string        `json:"comment"`
 	Error     error         `json:"error"`
+	// Line is the raw matched log line or API response the timing was parsed from
+	Line string `json:"-"`
+	// Labels are the values of the event's Labels found in the matched line
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// SLO status consts of a Timing
+const (
+	SLOStatusPass = "pass"
+	SLOStatusFail = "fail"
+)
+
+// SLOStatus returns whether the timing is within its event's MaxLatency, or an empty string if the event has no SLO or the timing failed
+func (t *Timing) SLOStatus() string {
+	if t.Event.MaxLatency <= 0 || t.Error != nil {
+		return ""
+	}
+	if t.T > t.Event.MaxLatency {
+		return SLOStatusFail
+	}
+	return SLOStatusPass
 }
 
 // SelectMaches will filter raw results based on the provided matchSelector
@@ -97,6 +224,12 @@ func SelectMatches(results []FindResult, matchSelector string) []FindResult {
 	case EventMatchSelectorAll:
 		return results
 	}
+	if n, ok := nthMatch(matchSelector); ok {
+		if n > len(results) {
+			return []FindResult{{Err: fmt.Errorf("only %d matches, the nth match %d was not found", len(results), n)}}
+		}
+		return []FindResult{results[n-1]}
+	}
 	return results
 }
 
@@ -108,119 +241,440 @@ func CommentMatchedLine() func(matchedLine string) string {
 	}
 }
 
+// CommentCaptureGroups is a helper func that returns a func that can be used as a CommentFunc in an Event
+// The func will use the values of the regex's named capture groups as the comment, i.e. "pod=inflate-7d8f9 wait=1.2s"
+func CommentCaptureGroups(re *regexp.Regexp) func(matchedLine string) string {
+	return func(matchedLine string) string {
+		groups := CaptureGroups(re, matchedLine)
+		var pairs []string
+		for _, name := range re.SubexpNames() {
+			if value, ok := groups[name]; ok {
+				pairs = append(pairs, fmt.Sprintf("%s=%s", name, value))
+				delete(groups, name)
+			}
+		}
+		return strings.Join(pairs, " ")
+	}
+}
+
+// LabelCaptureGroups is a helper func that returns a LabelFunc which uses the values of the regex's named capture groups as labels
+func LabelCaptureGroups(re *regexp.Regexp, names ...string) LabelFunc {
+	return func(matchedLine string) map[string]string {
+		groups := CaptureGroups(re, matchedLine)
+		labels := map[string]string{}
+		for _, name := range names {
+			if value, ok := groups[name]; ok {
+				labels[name] = value
+			}
+		}
+		return labels
+	}
+}
+
+// CaptureGroups returns the values of the regex's named capture groups which participated in the match of the line
+func CaptureGroups(re *regexp.Regexp, line string) map[string]string {
+	groups := map[string]string{}
+	match := re.FindStringSubmatchIndex(line)
+	if match == nil {
+		return groups
+	}
+	for i, name := range re.SubexpNames() {
+		if name != "" && match[2*i] >= 0 {
+			groups[name] = line[match[2*i]:match[2*i+1]]
+		}
+	}
+	return groups
+}
+
 // LogReader is a base Source helper that can Read file contents, cache, and support Glob file paths
 // Other Sources can be built on-top of the LogSrc
 type LogReader struct {
-	Path            string
+	Path string
+	// Paths are more paths, or globs, of the log which are read along with the Path, i.e. the log's paths on other distros
+	Paths           []string
 	Glob            bool
 	TimestampRegex  *regexp.Regexp
 	TimestampLayout string
-	file            []byte
+	// file is memory-mapped where supported, unmap releases the mapping
+	file  []byte
+	unmap func() error
+	// firstOnly stops regex searches at the first match, set while finding an Event selecting the first match
+	firstOnly bool
+	// segment is the only rotated log or log file searched while an Event's FindFn runs over it
+	segment   int
+	inSegment bool
+	// tail state, the file only holds complete lines up to the offset when tailing
+	tail         bool
+	stale        bool
+	resolvedPath string
+	offset       int64
+	matches      MatchCache
+	// rotated are the mapped rotated logs searched before the current log file, each is kept apart so none of them is copied
+	rotated        [][]byte
+	rotatedUnmaps  []func() error
+	rotatedMatches []MatchCache
+	// interleaved is set when logs were found at more than one of the paths
+	interleaved bool
+	// modTime is the modification time of the read log file, timestamps without a year are assumed to be logged before it
+	modTime time.Time
 }
 
-// ClearCache cleas the cached log
+// ClearCache cleas the cached log, a tailed log is kept and only marked to read appended lines
 func (l *LogReader) ClearCache() {
+	if l.tail {
+		l.stale = true
+		return
+	}
+	l.release()
+}
+
+// release unmaps the cached log file and rotated logs
+func (l *LogReader) release() {
+	l.releaseFile()
+	for _, unmap := range l.rotatedUnmaps {
+		_ = unmap()
+	}
+	l.rotated, l.rotatedUnmaps, l.rotatedMatches = nil, nil, nil
+}
+
+// releaseFile unmaps the cached log file, the rotated logs are kept
+func (l *LogReader) releaseFile() {
+	if l.unmap != nil {
+		_ = l.unmap()
+		l.unmap = nil
+	}
 	l.file = nil
 }
 
-// Read will open and read all the bytes of a log file into byte slice and then cache it
-// Any further calls to Read() will use the cached byte slice.
+// Tail switches the LogReader to incrementally read the lines appended to the log after the cache is cleared
+// Regex matches are also kept so that only appended lines are searched. Gzipped logs are always fully re-read.
+func (l *LogReader) Tail() {
+	l.tail = true
+}
+
+// Checkpoint returns the read position and matches of the tailed log file, or nil if it has not been read while tailing
+// The matches of the rotated logs come before the matches of the log file.
+func (l *LogReader) Checkpoint() *Checkpoint {
+	if !l.tail || l.resolvedPath == "" {
+		return nil
+	}
+	matches := map[string][]string{}
+	for i := range l.segments() {
+		for key, lines := range l.matchCache(i).Matches() {
+			matches[key] = append(matches[key], lines...)
+		}
+	}
+	return &Checkpoint{Path: l.resolvedPath, Offset: l.offset, Matches: matches}
+}
+
+// Restore tails the log file from the checkpoint so the lines before the offset are not re-read
+// The log file is re-read from the start if it was truncated, i.e. rotated.
+func (l *LogReader) Restore(checkpoint *Checkpoint) {
+	l.tail = true
+	l.stale = true
+	l.release()
+	l.file = []byte{}
+	// the matches of the rotated logs are restored, so only the current log is re-read
+	l.resolvedPath = checkpoint.Path
+	l.offset = checkpoint.Offset
+	l.matches.Restore(checkpoint.Matches, int(checkpoint.Offset))
+}
+
+// WatchPaths returns the directories of the log files matching the path, rotated or created files are written to the same directories
+func (l *LogReader) WatchPaths() []string {
+	var matches []string
+	for _, pattern := range l.patterns() {
+		patternMatches, err := filepath.Glob(pattern)
+		if err != nil {
+			continue
+		}
+		matches = append(matches, patternMatches...)
+	}
+	dirs := map[string]struct{}{}
+	var watchPaths []string
+	for _, match := range matches {
+		dir := filepath.Dir(match)
+		if _, ok := dirs[dir]; !ok {
+			dirs[dir] = struct{}{}
+			watchPaths = append(watchPaths, dir)
+		}
+	}
+	return watchPaths
+}
+
+// Read will open and map the log file into a byte slice and then cache it, gzipped files are decompressed and mapped
+// Mapping lets the kernel page in and evict a multi-GB log rather than copying it onto the heap.
+// Only the current log file is returned. Its rotated copies, i.e. messages-20240101.gz, are mapped one by one apart from it,
+// and FindEvent runs an Event's FindFn over them in turn from the oldest to the newest before it, so they are never copied together.
+// Any further calls to Read() will use the cached byte slices.
 // If the file is being updated and you need the updated contents,
 // you'll need to instantiate a new LogSrc and call Read() again
 func (l *LogReader) Read() ([]byte, error) {
-	if l.file != nil {
-		return l.file, nil
-	}
-	resolvedPath := l.Path
-	if l.Glob {
-		matches, err := filepath.Glob(l.Path)
-		if err != nil || len(matches) == 0 {
-			return nil, fmt.Errorf("unable to find log file %s: %w", l.Path, err)
-		}
-		// sort to find the oldest file for initial startup timings if the logs were rotated
-		sort.Slice(matches, func(i, j int) bool {
-			iFile, err := os.Open(matches[i])
-			if err != nil {
-				return matches[i] < matches[j]
-			}
-			defer iFile.Close()
-			jFile, err := os.Open(matches[j])
-			if err != nil {
-				return matches[i] < matches[j]
-			}
-			defer jFile.Close()
-			iStat, err := iFile.Stat()
-			if err != nil {
-				return matches[i] < matches[j]
-			}
-			jStat, err := jFile.Stat()
-			if err != nil {
-				return matches[i] < matches[j]
-			}
-			return iStat.ModTime().Unix() < jStat.ModTime().Unix()
-		})
-		resolvedPath = matches[0]
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+	return l.file, nil
+}
+
+// segments are the cached rotated logs and the current log file, in the order they were logged
+func (l *LogReader) segments() [][]byte {
+	return append(l.rotated[:len(l.rotated):len(l.rotated)], l.file)
+}
+
+// matchCache is the cache of the regex matches of the segment, the rotated logs and the log file each have their own
+func (l *LogReader) matchCache(segment int) *MatchCache {
+	if segment < len(l.rotated) {
+		return &l.rotatedMatches[segment]
+	}
+	return &l.matches
+}
+
+// load maps the rotated logs and the current log file unless they are cached
+func (l *LogReader) load() error {
+	if l.file != nil && !l.stale {
+		return nil
 	}
-	file, err := os.Open(resolvedPath)
+	// a truncated or rotated file is fully re-read
+	if l.file != nil && l.resolvedPath != "" && l.readAppended() == nil {
+		return nil
+	}
+	l.stale = false
+	l.resolvedPath = ""
+	l.matches.Reset()
+	// the newest file is the current log, rotated logs are searched before it in order so early boot events are not lost
+	paths, err := l.logPaths()
 	if err != nil {
-		return nil, fmt.Errorf("unable to open log file %s: %w", resolvedPath, err)
+		return err
 	}
-	defer file.Close()
-	var reader io.Reader
-	if strings.HasSuffix(resolvedPath, ".gz") {
-		gzReader, err := gzip.NewReader(file)
-		if err != nil {
-			return nil, fmt.Errorf("unable to create gzip reader for file %s: %w", file.Name(), err)
+	resolvedPath := paths[len(paths)-1]
+	rotated, rotatedUnmaps, err := mapRotated(paths[:len(paths)-1])
+	if err != nil {
+		return err
+	}
+	fileBytes, unmap, err := mapLogFile(resolvedPath)
+	if err != nil {
+		for _, unmap := range rotatedUnmaps {
+			_ = unmap()
 		}
-		defer gzReader.Close()
-		reader = gzReader
-	} else {
-		reader = bufio.NewReader(file)
+		return err
+	}
+	if stat, err := os.Stat(resolvedPath); err == nil {
+		l.modTime = stat.ModTime()
 	}
+	l.release()
+	// gzipped logs are not appended to, so they are always fully re-read
+	if l.tail && !strings.HasSuffix(resolvedPath, ".gz") {
+		fileBytes = completeLines(fileBytes)
+		l.resolvedPath = resolvedPath
+		l.offset = int64(len(fileBytes))
+	}
+	l.rotated, l.rotatedUnmaps, l.rotatedMatches = rotated, rotatedUnmaps, make([]MatchCache, len(rotated))
+	l.file, l.unmap = fileBytes, unmap
+	return nil
+}
 
-	fileBytes, err := io.ReadAll(reader)
+// readAppended re-maps the tailed log file up to the complete lines written since the last read
+// The previous matches are kept, so only the appended lines are searched.
+func (l *LogReader) readAppended() error {
+	file, err := os.Open(l.resolvedPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
 	if err != nil {
-		return fileBytes, fmt.Errorf("unable to read file %s: %w", file.Name(), err)
+		return err
 	}
-	l.file = fileBytes
-	return fileBytes, nil
+	if stat.Size() < l.offset {
+		return fmt.Errorf("log file %s was truncated", l.resolvedPath)
+	}
+	l.modTime = stat.ModTime()
+	fileBytes, unmap, err := mmapFile(file)
+	if err != nil {
+		return err
+	}
+	l.releaseFile()
+	fileBytes = completeLines(fileBytes)
+	l.offset = int64(len(fileBytes))
+	l.file, l.unmap = fileBytes, unmap
+	l.stale = false
+	return nil
 }
 
-// Find searches for the passed in regexp from the log references in the LogReader
-func (l *LogReader) Find(re *regexp.Regexp) ([]string, error) {
-	// Read the log file
-	messages, err := l.Read()
-	if err != nil {
+// readFile reads the whole file into memory, used where the file cannot be mapped
+func readFile(file *os.File) ([]byte, func() error, error) {
+	fileBytes, err := io.ReadAll(bufio.NewReader(file))
+	return fileBytes, nil, err
+}
+
+// completeLines trims a partially written last line which is read once it is complete
+func completeLines(b []byte) []byte {
+	return b[:bytes.LastIndexByte(b, '\n')+1]
+}
+
+// FindEvent reads the log and runs the Event's FindFn over each rotated log and then the current log file
+// Each run is passed the segment and its searches only cover it, so a FindFn parsing the log bytes itself sees the rotated logs too.
+// Regex searches of an Event selecting the first match stop at the first segment with a matched line instead of scanning the rest of the log.
+func (l *LogReader) FindEvent(ctx context.Context, src Source, event *Event) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := l.load(); err != nil {
 		return nil, err
 	}
-	// Find all occurrences of the regex in the log file
-	lines := re.FindAll(messages, -1)
-	if len(lines) == 0 {
+	// the first match of logs found at more than one path is not necessarily the earliest since their lines interleave
+	l.firstOnly = event.MatchSelector == EventMatchSelectorFirst && !l.interleaved
+	defer func() { l.firstOnly, l.segment, l.inSegment = false, 0, false }()
+	var lines []string
+	var lastErr error
+	for i, segment := range l.segments() {
+		l.segment, l.inSegment = i, true
+		segmentLines, err := event.FindFn(ctx, src, segment)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		// a FindFn errors on a segment without a match, the error is only returned when no segment matched
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		lines = append(lines, segmentLines...)
+		if l.firstOnly && len(lines) > 0 {
+			break
+		}
+	}
+	if len(lines) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return lines, nil
+}
+
+// Find searches for the passed in regexp from the log references in the LogReader
+func (l *LogReader) Find(ctx context.Context, re *regexp.Regexp) ([]string, error) {
+	lineStrs, err := l.FindLines(ctx, re, nil)
+	if err == nil && len(lineStrs) == 0 {
 		return nil, fmt.Errorf("no matches in %s for regex \"%s\"", l.Path, re.String())
 	}
-	var lineStrs []string
-	for _, line := range lines {
-		lineStrs = append(lineStrs, string(line))
+	return lineStrs, err
+}
+
+// FindByLine is a helper func that returns a FindFunc to search the log for the lines matched by the regex which the match
+// func accepts, a LineFinder's FindByLine
+func (l *LogReader) FindByLine(re *regexp.Regexp, match func(line string) bool) FindFunc {
+	return func(ctx context.Context, _ Source, _ []byte) ([]string, error) {
+		lineStrs, err := l.FindLines(ctx, re, match)
+		if err == nil && len(lineStrs) == 0 {
+			return nil, fmt.Errorf("no lines in %s for regex \"%s\" match", l.Path, re.String())
+		}
+		return lineStrs, err
+	}
+}
+
+// FindLines searches for the lines matched by the regexp which the match func accepts, i.e. an expression, every matched
+// line is accepted if the match func is nil. A search for the first match stops at the first accepted line.
+func (l *LogReader) FindLines(ctx context.Context, re *regexp.Regexp, match func(line string) bool) (lineStrs []string, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// Read the rotated logs and the log file, which are searched in turn
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+	segments := l.segments()
+	// only the segment passed to an Event's FindFn is searched while it runs
+	first := 0
+	if l.inSegment {
+		first, segments = l.segment, segments[l.segment:l.segment+1]
+	}
+	// a mapped log file that is truncated while it is searched faults, which is recovered as an error and the file is re-read on the next search
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		if r := recover(); r != nil {
+			l.release()
+			lineStrs, err = nil, fmt.Errorf("unable to search log file %s: %v", l.Path, r)
+		}
+	}()
+	// Find all occurrences of the regex in the log file, or only in the lines appended since the last search when tailing
+	switch {
+	case l.tail:
+		for i, segment := range segments {
+			for _, line := range l.matchCache(first+i).FindAll(re, segment) {
+				if match == nil || match(line) {
+					lineStrs = append(lineStrs, line)
+				}
+			}
+		}
+	case l.firstOnly:
+		for _, segment := range segments {
+			if line, ok := findFirst(re, segment, match); ok {
+				return []string{line}, nil
+			}
+		}
+	default:
+		for _, segment := range segments {
+			for _, line := range re.FindAll(segment, -1) {
+				if match == nil || match(string(line)) {
+					lineStrs = append(lineStrs, string(line))
+				}
+			}
+		}
 	}
 	return lineStrs, nil
 }
 
+// findFirst finds the first line matched by the regexp which the match func accepts
+func findFirst(re *regexp.Regexp, segment []byte, match func(line string) bool) (string, bool) {
+	for offset := 0; offset <= len(segment); {
+		loc := re.FindIndex(segment[offset:])
+		if loc == nil {
+			return "", false
+		}
+		if line := string(segment[offset+loc[0] : offset+loc[1]]); match == nil || match(line) {
+			return line, true
+		}
+		// an empty match advances by a byte so the search ends
+		if loc[1] == loc[0] {
+			loc[1]++
+		}
+		offset += loc[1]
+	}
+	return "", false
+}
+
 // ParseTimestamp usese the configured timestamp regex to find a timestamp from the passed in log line and return as a time.Time
+// Timestamps without a year are assumed to be logged before the log file was last modified.
 func (l *LogReader) ParseTimestamp(line string) (time.Time, error) {
-	rawTS := l.TimestampRegex.FindString(line)
-	if rawTS == "" {
-		return time.Time{}, fmt.Errorf("unable to find timestamp on log line matching regex: \"%s\" \"%s\"", l.TimestampRegex.String(), line)
+	rawTS, err := findTimestamp(l.TimestampRegex, line)
+	if err != nil {
+		return time.Time{}, err
 	}
-	rawTS = spaceRE.ReplaceAllString(rawTS, " ")
+	return l.ParseRawTimestamp(rawTS)
+}
 
-	suffix := ""
-	// Convert timestamp to a time.Time type
-	if !strings.Contains(rawTS, fmt.Sprint(time.Now().Year())) {
-		suffix = fmt.Sprintf(" %d", time.Now().Year())
+// ParseRawTimestamp parses a raw timestamp with the configured timestamp layout
+func (l *LogReader) ParseRawTimestamp(rawTS string) (time.Time, error) {
+	reference := l.modTime
+	if reference.IsZero() {
+		reference = time.Now()
 	}
-	ts, err := time.Parse(l.TimestampLayout, fmt.Sprintf("%s%s", rawTS, suffix))
+	return ParseRawTimestamp(l.TimestampLayout, rawTS, reference)
+}
+
+// ParseTimestamp uses the timestamp regex to find a timestamp in the log line and parses it with the layout
+// If the raw timestamp does not include a year, it is assumed to be logged in the last year
+func ParseTimestamp(timestampRegex *regexp.Regexp, timestampLayout string, line string) (time.Time, error) {
+	rawTS, err := findTimestamp(timestampRegex, line)
 	if err != nil {
 		return time.Time{}, err
 	}
-	return ts, nil
+	return ParseRawTimestamp(timestampLayout, rawTS, time.Now())
+}
+
+// findTimestamp finds the raw timestamp in the log line with runs of whitespace collapsed
+func findTimestamp(timestampRegex *regexp.Regexp, line string) (string, error) {
+	rawTS := timestampRegex.FindString(line)
+	if rawTS == "" {
+		return "", fmt.Errorf("unable to find timestamp on log line matching regex: \"%s\" \"%s\"", timestampRegex.String(), line)
+	}
+	return spaceRE.ReplaceAllString(rawTS, " "), nil
 }
@@ -0,0 +1,267 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	containerdStarted = "Jan 14 10:00:02 ip-192-168-23-248 systemd[1]: Started containerd.service - containerd container runtime."
+	kubeletStarted    = "Jan 14 10:00:06 ip-192-168-23-248 systemd[1]: Started kubelet.service - Kubernetes Kubelet."
+	kubeletRestarted  = "Jan 14 10:00:31 ip-192-168-23-248 systemd[1]: Started kubelet.service - Kubernetes Kubelet."
+	kubeletAppended   = "Jan 14 10:01:15 ip-192-168-23-248 systemd[1]: Started kubelet.service - Kubernetes Kubelet."
+)
+
+var kubeletStartedRE = regexp.MustCompile(`.*Started kubelet.service.*`)
+
+// rotatedLogs copies the testdata logs rotated by logrotate to a temporary directory, the gzipped log is the oldest and
+// messages.1 the newest rotation, and returns the path of the current log
+func rotatedLogs(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	modTime := time.Date(2024, 1, 14, 10, 0, 0, 0, time.UTC)
+	for _, name := range []string{"messages-20240114.gz", "messages.1", "messages"} {
+		logBytes, err := os.ReadFile(filepath.Join("testdata", "rotated", name))
+		if err != nil {
+			t.Fatalf("unable to read fixture %s: %v", name, err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, logBytes, 0o600); err != nil {
+			t.Fatalf("unable to write fixture %s: %v", name, err)
+		}
+		modTime = modTime.Add(time.Minute)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("unable to set the modification time of fixture %s: %v", name, err)
+		}
+	}
+	return filepath.Join(dir, "messages")
+}
+
+func TestFiles(t *testing.T) {
+	path := rotatedLogs(t)
+	dir := filepath.Dir(path)
+	for _, tc := range []struct {
+		name   string
+		reader *LogReader
+		files  []string
+	}{
+		{
+			name:   "path",
+			reader: &LogReader{Path: path},
+			files:  []string{"messages-20240114.gz", "messages.1", "messages"},
+		},
+		{
+			name:   "glob",
+			reader: &LogReader{Path: filepath.Join(dir, "messages*"), Glob: true},
+			files:  []string{"messages-20240114.gz", "messages.1", "messages"},
+		},
+		{
+			name:   "missing paths are skipped",
+			reader: &LogReader{Path: filepath.Join(dir, "syslog*"), Paths: []string{filepath.Join(dir, "messages")}, Glob: true},
+			files:  []string{"messages-20240114.gz", "messages.1", "messages"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			files, err := tc.reader.Files()
+			if err != nil {
+				t.Fatalf("unable to find the log files: %v", err)
+			}
+			var expected []string
+			for _, name := range tc.files {
+				expected = append(expected, filepath.Join(dir, name))
+			}
+			if !reflect.DeepEqual(files, expected) {
+				t.Errorf("expected files %v, got %v", expected, files)
+			}
+		})
+	}
+	if _, err := (&LogReader{Path: filepath.Join(dir, "syslog")}).Files(); err == nil {
+		t.Error("expected an error when no log file exists")
+	}
+}
+
+func TestReadRotated(t *testing.T) {
+	path := rotatedLogs(t)
+	reader := &LogReader{Path: path}
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unable to read the current log: %v", err)
+	}
+	logBytes, err := reader.Read()
+	if err != nil {
+		t.Fatalf("unable to read the log: %v", err)
+	}
+	if string(logBytes) != string(current) {
+		t.Errorf("expected Read to only return the current log, got %q", logBytes)
+	}
+	for _, tc := range []struct {
+		name  string
+		re    *regexp.Regexp
+		lines []string
+	}{
+		{name: "gzipped rotation", re: regexp.MustCompile(`.*Started containerd.service.*`), lines: []string{containerdStarted}},
+		{name: "every log in order", re: kubeletStartedRE, lines: []string{kubeletStarted, kubeletRestarted}},
+		{name: "no match", re: regexp.MustCompile(`.*Started kube-proxy.*`)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			lines, err := reader.Find(context.Background(), tc.re)
+			if len(tc.lines) == 0 {
+				if err == nil {
+					t.Errorf("expected no matches, got %v", lines)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to find %s: %v", tc.re, err)
+			}
+			if !reflect.DeepEqual(lines, tc.lines) {
+				t.Errorf("expected lines %q, got %q", tc.lines, lines)
+			}
+		})
+	}
+}
+
+func TestFindEventRotated(t *testing.T) {
+	path := rotatedLogs(t)
+	reader := &LogReader{Path: path}
+	// findContaining parses the log bytes passed to the FindFn itself rather than searching with the LogReader
+	findContaining := func(substr string) FindFunc {
+		return func(_ context.Context, _ Source, log []byte) ([]string, error) {
+			var lines []string
+			scanner := bufio.NewScanner(bytes.NewReader(log))
+			for scanner.Scan() {
+				if strings.Contains(scanner.Text(), substr) {
+					lines = append(lines, scanner.Text())
+				}
+			}
+			if len(lines) == 0 {
+				return nil, errors.New("no matches")
+			}
+			return lines, nil
+		}
+	}
+	findRegex := func(ctx context.Context, _ Source, _ []byte) ([]string, error) {
+		return reader.Find(ctx, kubeletStartedRE)
+	}
+	for _, tc := range []struct {
+		name  string
+		event *Event
+		lines []string
+	}{
+		{
+			name:  "custom FindFn over the gzipped rotation",
+			event: &Event{FindFn: findContaining("Started containerd.service"), MatchSelector: EventMatchSelectorAll},
+			lines: []string{containerdStarted},
+		},
+		{
+			name:  "custom FindFn over every log in order",
+			event: &Event{FindFn: findContaining("Started kubelet.service"), MatchSelector: EventMatchSelectorAll},
+			lines: []string{kubeletStarted, kubeletRestarted},
+		},
+		{
+			name:  "first match stops at the first matching log",
+			event: &Event{FindFn: findContaining("Started kubelet.service"), MatchSelector: EventMatchSelectorFirst},
+			lines: []string{kubeletStarted},
+		},
+		{
+			name:  "regex searches only cover the passed log",
+			event: &Event{FindFn: findRegex, MatchSelector: EventMatchSelectorLast},
+			lines: []string{kubeletStarted, kubeletRestarted},
+		},
+		{
+			name:  "no match",
+			event: &Event{FindFn: findContaining("Started kube-proxy"), MatchSelector: EventMatchSelectorAll},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			lines, err := reader.FindEvent(context.Background(), nil, tc.event)
+			if len(tc.lines) == 0 {
+				if err == nil {
+					t.Errorf("expected no matches, got %v", lines)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to find the event: %v", err)
+			}
+			if !reflect.DeepEqual(lines, tc.lines) {
+				t.Errorf("expected lines %q, got %q", tc.lines, lines)
+			}
+		})
+	}
+}
+
+func TestTailRotated(t *testing.T) {
+	path := rotatedLogs(t)
+	reader := &LogReader{Path: path}
+	reader.Tail()
+	lines, err := reader.Find(context.Background(), kubeletStartedRE)
+	if err != nil {
+		t.Fatalf("unable to find %s: %v", kubeletStartedRE, err)
+	}
+	if expected := []string{kubeletStarted, kubeletRestarted}; !reflect.DeepEqual(lines, expected) {
+		t.Fatalf("expected lines %q, got %q", expected, lines)
+	}
+
+	// only the lines appended to the current log are searched once the cache is cleared, a partial line is read once complete
+	appendLog(t, path, kubeletAppended+"\n"+strings.TrimSuffix(kubeletAppended, "."))
+	reader.ClearCache()
+	expected := []string{kubeletStarted, kubeletRestarted, kubeletAppended}
+	if lines, err = reader.Find(context.Background(), kubeletStartedRE); err != nil || !reflect.DeepEqual(lines, expected) {
+		t.Fatalf("expected lines %q, got %q (%v)", expected, lines, err)
+	}
+
+	// the checkpoint holds the matches of the rotated logs before the matches of the current log
+	checkpoint := reader.Checkpoint()
+	if checkpoint == nil || checkpoint.Path != path {
+		t.Fatalf("expected a checkpoint of %s, got %+v", path, checkpoint)
+	}
+	if matches := checkpoint.Matches[kubeletStartedRE.String()]; !reflect.DeepEqual(matches, expected) {
+		t.Errorf("expected checkpoint matches %q, got %q", expected, matches)
+	}
+
+	// a restored reader only reads the current log past the checkpoint's offset
+	appendLog(t, path, ".\n")
+	restored := &LogReader{Path: path}
+	restored.Restore(checkpoint)
+	expected = append(expected, kubeletAppended)
+	if lines, err = restored.Find(context.Background(), kubeletStartedRE); err != nil || !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected restored lines %q, got %q (%v)", expected, lines, err)
+	}
+}
+
+// appendLog appends the data to the log file
+func appendLog(t *testing.T, path string, data string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("unable to open %s: %v", path, err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(data); err != nil {
+		t.Fatalf("unable to append to %s: %v", path, err)
+	}
+}
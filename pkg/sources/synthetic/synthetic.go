@@ -0,0 +1,188 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package synthetic fabricates the logs and IMDS responses of a realistic EKS node boot, so output formats, dashboards,
+// and sinks can be tried on a laptop without an EC2 instance. The boot is written to a directory laid out like the node's
+// filesystem, with the IMDS responses in its "imds" directory, which is measured like a recording.
+package synthetic
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Paths of the fabricated files under the directory
+var (
+	MessagesPath = "/var/log/messages"
+	AWSNodePath  = "/var/log/pods/kube-system_aws-node-synthetic_00000000-0000-0000-0000-000000000000/aws-node/0.log"
+	IdentityPath = "/imds/dynamic/instance-identity/document"
+	IAMInfoPath  = "/imds/meta-data/iam/info"
+)
+
+// Fabricated node identity
+const (
+	InstanceID = "i-0123456789abcdef0"
+	hostname   = "ip-192-168-1-10"
+	privateIP  = "192.168.1.10"
+)
+
+// Step is an event of the fabricated boot, logged the delay after the previous step
+type Step struct {
+	Metric string
+	Delay  time.Duration
+	// File is the path of the file the line is logged to, the line is formatted with the step's timestamp
+	File string
+	Line func(ts time.Time) string
+}
+
+// DefaultSteps are the events of an EKS Optimized Amazon Linux node boot with typical delays, keyed by the default event metrics
+var DefaultSteps = []Step{
+	{Metric: "vm_initialized", Delay: 8 * time.Second, File: MessagesPath, Line: syslog("kernel", "Linux version 5.10.205-195.807.amzn2.x86_64 (mockbuild@ip-10-0-48-1) (gcc10-gcc (GCC) 10.5.0) #1 SMP")},
+	{Metric: "network_start", Delay: 4 * time.Second, File: MessagesPath, Line: syslog("systemd[1]", "Reached target Network (Pre).")},
+	{Metric: "network_ready", Delay: time.Second, File: MessagesPath, Line: syslog("systemd[1]", "Reached target Network.")},
+	{Metric: "cloudinit_initial_start", Delay: time.Second, File: MessagesPath, Line: cloudInit("init")},
+	{Metric: "credentials_available", Delay: time.Second, File: IAMInfoPath, Line: iamInfo},
+	{Metric: "cloudinit_config_start", Delay: 3 * time.Second, File: MessagesPath, Line: cloudInit("modules:config")},
+	{Metric: "cloudinit_final_start", Delay: time.Second, File: MessagesPath, Line: cloudInit("modules:final")},
+	{Metric: "conatinerd_start", Delay: 5 * time.Second, File: MessagesPath, Line: syslog("systemd[1]", "Starting containerd container runtime...")},
+	{Metric: "conatinerd_initialized", Delay: time.Second, File: MessagesPath, Line: syslog("systemd[1]", "Started containerd container runtime.")},
+	{Metric: "kubelet_start", Delay: time.Second, File: MessagesPath, Line: syslog("systemd[1]", "Starting Kubernetes Kubelet...")},
+	{Metric: "kubelet_initialized", Delay: time.Second, File: MessagesPath, Line: syslog("systemd[1]", "Started kubelet.")},
+	{Metric: "cloudinit_final_finish", Delay: time.Second, File: MessagesPath, Line: func(ts time.Time) string {
+		return syslog("cloud-init[3274]", fmt.Sprintf("Cloud-init v. 19.3-46.amzn2.0.2 finished at %s. Datasource DataSourceEc2.  Up 32.41 seconds", ts.Format(time.RFC1123Z)))(ts)
+	}},
+	{Metric: "kubelet_authenticated", Delay: 2 * time.Second, File: MessagesPath, Line: kubelet("reflector.go:351] Caches populated for *v1.Service from k8s.io/client-go/informers/factory.go:150")},
+	{Metric: "kubelet_registered", Delay: time.Second, File: MessagesPath, Line: kubelet(fmt.Sprintf(`kubelet_node_status.go:76] "Successfully registered node" node="%s.ec2.internal"`, hostname))},
+	{Metric: "kube_proxy_start", Delay: 4 * time.Second, File: MessagesPath, Line: createContainer("kube-proxy")},
+	{Metric: "vpc_cni_init_start", Delay: time.Second, File: MessagesPath, Line: createContainer("aws-vpc-cni-init")},
+	{Metric: "aws_node_start", Delay: 3 * time.Second, File: MessagesPath, Line: createContainer("aws-node")},
+	{Metric: "vpc_cni_plugin_initialized", Delay: 2 * time.Second, File: AWSNodePath, Line: func(ts time.Time) string {
+		return fmt.Sprintf(`{"level":"info","ts":"%s","caller":"entrypoint.sh","msg":"Successfully copied CNI plugin binary and config file."}`, ts.UTC().Format("2006-01-02T15:04:05.000Z"))
+	}},
+	{Metric: "node_ready", Delay: 4 * time.Second, File: MessagesPath, Line: kubelet(fmt.Sprintf(`event.go:307] "Event occurred" object="%s.ec2.internal" fieldPath="" kind="Node" apiVersion="v1" type="Normal" reason="NodeReady" message="Node %[1]s.ec2.internal status is now: NodeReady" event="NodeReady"`, hostname))},
+	{Metric: "pod_ready", Delay: 10 * time.Second, File: MessagesPath, Line: kubelet(`kubelet.go:2457] "SyncLoop (PLEG): event for pod" pod="default/inflate-6b88c9fb68-x2j9p" event=&{ID:4c2b9e0e-7c56-4d4e-b1f5-0c1a4e3c9f11 Type:ContainerStarted Data:9a7e5c}`)},
+}
+
+// Options control the fabricated boot
+type Options struct {
+	// Delays override the delays of the default steps by metric, i.e. a slow "node_ready"
+	Delays map[string]time.Duration
+	// End is when the last step is logged, the instance pending time is the sum of the delays before it, defaults to now
+	End time.Time
+}
+
+// Write fabricates the boot's files in the directory
+func Write(dir string, opts Options) error {
+	for metric := range opts.Delays {
+		if !hasStep(metric) {
+			return fmt.Errorf("unable to delay \"%s\", it is not a synthetic event", metric)
+		}
+	}
+	end := opts.End
+	if end.IsZero() {
+		end = time.Now()
+	}
+	var total time.Duration
+	for _, step := range DefaultSteps {
+		total += delay(step, opts.Delays)
+	}
+	pending := end.Add(-total).UTC().Truncate(time.Second)
+	files := map[string][]string{}
+	ts := pending
+	for _, step := range DefaultSteps {
+		ts = ts.Add(delay(step, opts.Delays))
+		files[step.File] = append(files[step.File], step.Line(ts))
+	}
+	identity, err := json.Marshal(map[string]any{
+		"accountId":        "123456789012",
+		"architecture":     "x86_64",
+		"availabilityZone": "us-west-2a",
+		"imageId":          "ami-0123456789abcdef0",
+		"instanceId":       InstanceID,
+		"instanceType":     "m5.large",
+		"pendingTime":      pending.Format(time.RFC3339),
+		"privateIp":        privateIP,
+		"region":           "us-west-2",
+		"version":          "2017-09-30",
+	})
+	if err != nil {
+		return fmt.Errorf("unable to marshal the instance-identity document: %w", err)
+	}
+	files[IdentityPath] = []string{string(identity)}
+	for file, lines := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("unable to create directory for %s: %w", file, err)
+		}
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+			return fmt.Errorf("unable to write %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// delay is the step's delay, or its override
+func delay(step Step, delays map[string]time.Duration) time.Duration {
+	if d, ok := delays[step.Metric]; ok {
+		return d
+	}
+	return step.Delay
+}
+
+// hasStep checks if a default step has the metric
+func hasStep(metric string) bool {
+	for _, step := range DefaultSteps {
+		if step.Metric == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// syslog formats a syslog line of the process with an RFC 5424 timestamp
+func syslog(process string, msg string) func(ts time.Time) string {
+	return func(ts time.Time) string {
+		return fmt.Sprintf("%s %s %s: %s", ts.UTC().Format("2006-01-02T15:04:05.000000-07:00"), hostname, process, msg)
+	}
+}
+
+// kubelet formats a klog line of the kubelet
+func kubelet(msg string) func(ts time.Time) string {
+	return func(ts time.Time) string {
+		return syslog("kubelet[3402]", fmt.Sprintf("I%s 3402 %s", ts.UTC().Format("0102 15:04:05.000000"), msg))(ts)
+	}
+}
+
+// cloudInit formats cloud-init's log line of starting the stage
+func cloudInit(stage string) func(ts time.Time) string {
+	return func(ts time.Time) string {
+		return syslog("cloud-init[2962]", fmt.Sprintf("Cloud-init v. 19.3-46.amzn2.0.2 running '%s' at %s. Up 12.34 seconds.", stage, ts.Format(time.RFC1123Z)))(ts)
+	}
+}
+
+// createContainer formats containerd's log line of creating the container
+func createContainer(name string) func(ts time.Time) string {
+	return func(ts time.Time) string {
+		return syslog("containerd[3125]", fmt.Sprintf(`time="%s" level=info msg="CreateContainer within sandbox \"5d3c1f\" for &ContainerMetadata{Name:%s,Attempt:0,} returns container id \"8e2a4b\""`, ts.UTC().Format(time.RFC3339Nano), name))(ts)
+	}
+}
+
+// iamInfo formats the IMDS IAM info response, the instance role credentials were last updated at the timestamp
+func iamInfo(ts time.Time) string {
+	return fmt.Sprintf(`{"Code":"Success","LastUpdated":"%s","InstanceProfileArn":"arn:aws:iam::123456789012:instance-profile/synthetic","InstanceProfileId":"AIPAEXAMPLE"}`, ts.UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,125 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package systemd is a latency timing source for systemd unit activation timestamps read over D-Bus
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/dbus"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	Name = "systemd"
+)
+
+// Unit timestamp properties, all are microseconds since the epoch
+const (
+	// InactiveExitTimestamp is when the unit started activating
+	InactiveExitTimestamp = "InactiveExitTimestamp"
+	// ExecMainStartTimestamp is when the main process of a service was started
+	ExecMainStartTimestamp = "ExecMainStartTimestamp"
+	// ActiveEnterTimestamp is when the unit finished activating, i.e. a Type=notify service signaled readiness
+	ActiveEnterTimestamp = "ActiveEnterTimestamp"
+)
+
+// Source is the systemd D-Bus source
+// The system bus socket (/run/dbus/system_bus_socket) must be accessible, i.e. mounted into the pod
+type Source struct{}
+
+// New instantiates a new instance of the systemd source
+func New() *Source {
+	return &Source{}
+}
+
+// ClearCache is a noop for the systemd Source since properties are queried on every Find
+func (s Source) ClearCache() {}
+
+// String is a human readable string of the source
+func (s Source) String() string {
+	return "systemd D-Bus"
+}
+
+// Name is the name of the source
+func (s Source) Name() string {
+	return Name
+}
+
+// FindByUnitProperty is a helper func that returns a FindFunc to query a unit's timestamp property that can be used in an Event
+func (s Source) FindByUnitProperty(unit string, property string) sources.FindFunc {
+	return func(ctx context.Context, _ sources.Source, _ []byte) ([]string, error) {
+		usec, err := s.GetTimestampProperty(context.Background(), unit, property)
+		if err != nil {
+			return nil, err
+		}
+		return []string{strconv.FormatUint(usec, 10)}, nil
+	}
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search the source and return the result
+func (s Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	timestamps, err := event.FindFn(ctx, s, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, tsStr := range timestamps {
+		tsMicros, err := strconv.ParseInt(tsStr, 10, 64)
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(tsStr)
+		}
+		results = append(results, sources.FindResult{
+			Line:      tsStr,
+			Timestamp: time.UnixMicro(tsMicros),
+			Comment:   comment,
+			Err:       err,
+		})
+	}
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
+
+// GetTimestampProperty queries a unit's timestamp property over D-Bus and returns microseconds since the epoch
+func (s Source) GetTimestampProperty(ctx context.Context, unit string, property string) (uint64, error) {
+	conn, err := dbus.NewSystemConnectionContext(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("unable to connect to the systemd D-Bus: %w", err)
+	}
+	defer conn.Close()
+	var prop *dbus.Property
+	// Exec* properties are on the Service interface rather than the Unit interface
+	if strings.HasPrefix(property, "Exec") {
+		prop, err = conn.GetServicePropertyContext(ctx, unit, property)
+	} else {
+		prop, err = conn.GetUnitPropertyContext(ctx, unit, property)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("unable to get property %s of unit %s: %w", property, unit, err)
+	}
+	usec, ok := prop.Value.Value().(uint64)
+	if !ok {
+		return 0, fmt.Errorf("property %s of unit %s is not a timestamp", property, unit)
+	}
+	if usec == 0 {
+		return 0, fmt.Errorf("unit %s has not reached %s", unit, property)
+	}
+	return usec, nil
+}
@@ -0,0 +1,148 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package talos is a latency timing source for the Talos Linux machine API
+// Talos has no shell or log files, machined logs its boot sequence and service states to the kernel log which is read
+// from the machine API with talosctl, authenticated by the talosconfig, i.e. $TALOSCONFIG or the Talos API access from
+// Kubernetes secret.
+package talos
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	Name    = "Talos"
+	Command = "talosctl"
+	// TimestampFormat matches the RFC 3339 timestamps of talosctl dmesg lines, i.e. "[2024-03-05T10:00:00.123456789Z]:"
+	TimestampFormat = regexp.MustCompile(`[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}(?:\.[0-9]+)?Z`)
+	TimestampLayout = time.RFC3339Nano
+)
+
+// Source is the Talos machine API source which reads the kernel log with talosctl dmesg
+type Source struct {
+	node string
+	args []string
+	logs []byte
+}
+
+// New instantiates a new instance of the Talos source which reads the node's kernel log, the node is the address of
+// its machine API, or talosctl's default nodes of the talosconfig are read if it is empty
+// Any args passed are passed to talosctl before the dmesg command, i.e. "--talosconfig=/var/run/secrets/talos.dev/config"
+func New(node string, args ...string) *Source {
+	return &Source{
+		node: node,
+		args: args,
+	}
+}
+
+// Available returns true if talosctl can be found on the PATH
+func Available() bool {
+	_, err := exec.LookPath(Command)
+	return err == nil
+}
+
+// ClearCache will clear the cached kernel log
+func (s *Source) ClearCache() {
+	s.logs = nil
+}
+
+// String is a human readable string of the source, the talosctl command line
+func (s *Source) String() string {
+	return fmt.Sprintf("%s %s", Command, strings.Join(s.commandArgs(), " "))
+}
+
+// Name is the name of the source
+func (s *Source) Name() string {
+	return Name
+}
+
+// commandArgs are the talosctl args which read the node's kernel log
+func (s *Source) commandArgs() []string {
+	var args []string
+	if s.node != "" {
+		args = append(args, "--nodes", s.node)
+	}
+	return append(append(args, s.args...), "dmesg")
+}
+
+// Read executes talosctl dmesg and caches the output, talosctl is killed if the context is done
+// Any further calls to Read() will use the cached output until ClearCache() is called
+func (s *Source) Read(ctx context.Context) ([]byte, error) {
+	if s.logs != nil {
+		return s.logs, nil
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, Command, s.commandArgs()...) //nolint:gosec
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the Talos kernel log with \"%s\": %w: %s", s.String(), err, strings.TrimSpace(stderr.String()))
+	}
+	s.logs = out
+	return s.logs, nil
+}
+
+// FindByRegex is a helper func that returns a FindFunc to search for a regex in the kernel log that can be used in an Event
+func (s *Source) FindByRegex(re *regexp.Regexp) sources.FindFunc {
+	return func(_ context.Context, _ sources.Source, log []byte) ([]string, error) {
+		var lineStrs []string
+		for _, line := range re.FindAll(log, -1) {
+			lineStrs = append(lineStrs, string(line))
+		}
+		if len(lineStrs) == 0 {
+			return nil, fmt.Errorf("no matches in %s for regex \"%s\"", Name, re.String())
+		}
+		return lineStrs, nil
+	}
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search the kernel log and return the results based on the Event's matcher
+func (s *Source) Find(ctx context.Context, event *sources.Event) ([]sources.FindResult, error) {
+	logBytes, err := s.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matchedLines, err := event.FindFn(ctx, s, logBytes)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, line := range matchedLines {
+		ts, err := sources.ParseTimestamp(TimestampFormat, TimestampLayout, line)
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(line)
+		}
+		results = append(results, sources.FindResult{
+			Line:      line,
+			Timestamp: ts,
+			Err:       err,
+			Comment:   comment,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Timestamp.UnixMicro() < results[j].Timestamp.UnixMicro()
+	})
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
@@ -0,0 +1,87 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TimestampLayoutAuto is a timestamp layout which detects RFC 3164 syslog, RFC 5424 syslog, and ISO 8601 timestamps
+// Timestamps without a zone are in the local time zone.
+const TimestampLayoutAuto = "auto"
+
+var (
+	// SyslogTimestampFormat matches the timestamps detected by TimestampLayoutAuto, i.e. "Jan 30 19:03:10" or "2023-01-30T19:03:10.123456+00:00"
+	SyslogTimestampFormat = regexp.MustCompile(`[0-9]{4}-[0-9]{2}-[0-9]{2}[T ][0-9]{2}:[0-9]{2}:[0-9]{2}(?:[.,][0-9]+)?(?:Z|[+-][0-9]{2}:?[0-9]{2})?|[A-Z][a-z]{2}[ ]+[0-9]{1,2} [0-9]{2}:[0-9]{2}:[0-9]{2}(?:\.[0-9]+)?`)
+	// autoTimestampLayouts are tried in order by TimestampLayoutAuto, ISO 8601 dates are separated from the time by a "T"
+	autoTimestampLayouts = []string{
+		"2006-01-02T15:04:05.999999999Z07:00",
+		"2006-01-02T15:04:05.999999999Z0700",
+		"2006-01-02T15:04:05.999999999",
+		"Jan 2 15:04:05.999999999",
+	}
+	// yearSkew is how far a timestamp without a year may be after the reference before it is assumed to be from the year before
+	yearSkew = 24 * time.Hour
+)
+
+// ParseRawTimestamp parses a raw timestamp with the layout, or detects its layout if the layout is TimestampLayoutAuto
+// Timestamps without a year are assumed to be logged before the reference time, usually the log's modification time,
+// so they are in the reference's year unless that is after the reference, i.e. a December line of a log modified in January.
+func ParseRawTimestamp(layout string, rawTS string, reference time.Time) (time.Time, error) {
+	if layout == TimestampLayoutAuto {
+		return parseAutoTimestamp(rawTS, reference)
+	}
+	if ts, err := time.Parse(layout, rawTS); err == nil {
+		return inferYear(ts, reference), nil
+	}
+	ts, err := time.Parse(layout, fmt.Sprintf("%s %d", rawTS, reference.Year()))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return priorYear(ts, reference), nil
+}
+
+// parseAutoTimestamp parses the raw timestamp with the first auto layout which matches it
+func parseAutoTimestamp(rawTS string, reference time.Time) (time.Time, error) {
+	if len(rawTS) > 10 && rawTS[4] == '-' && rawTS[10] == ' ' {
+		rawTS = rawTS[:10] + "T" + rawTS[11:]
+	}
+	rawTS = strings.Replace(rawTS, ",", ".", 1)
+	for _, layout := range autoTimestampLayouts {
+		if ts, err := time.ParseInLocation(layout, rawTS, time.Local); err == nil {
+			return inferYear(ts, reference), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unable to parse timestamp \"%s\" as RFC 3164, RFC 5424, or ISO 8601", rawTS)
+}
+
+// inferYear sets the year of a timestamp parsed without a year
+func inferYear(ts time.Time, reference time.Time) time.Time {
+	if ts.Year() != 0 {
+		return ts
+	}
+	return priorYear(ts.AddDate(reference.Year(), 0, 0), reference)
+}
+
+// priorYear moves a timestamp in the reference's year to the year before if it is after the reference
+func priorYear(ts time.Time, reference time.Time) time.Time {
+	if ts.After(reference.Add(yearSkew)) {
+		return ts.AddDate(-1, 0, 0)
+	}
+	return ts
+}
@@ -0,0 +1,149 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRawTimestamp(t *testing.T) {
+	reference := time.Date(2024, 1, 20, 10, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name      string
+		layout    string
+		rawTS     string
+		reference time.Time
+		expected  time.Time
+		err       bool
+	}{
+		{
+			name:     "layout with a year",
+			layout:   "2006-01-02 15:04:05",
+			rawTS:    "2023-06-14 10:00:02",
+			expected: time.Date(2023, 6, 14, 10, 0, 2, 0, time.UTC),
+		},
+		{
+			name:     "layout without a year",
+			layout:   "Jan 2 15:04:05",
+			rawTS:    "Jan 14 10:00:02",
+			expected: time.Date(2024, 1, 14, 10, 0, 2, 0, time.UTC),
+		},
+		{
+			name:     "layout with a year the timestamp is missing",
+			layout:   "Jan _2 15:04:05 2006",
+			rawTS:    "Jan 14 10:00:02",
+			expected: time.Date(2024, 1, 14, 10, 0, 2, 0, time.UTC),
+		},
+		{
+			name:      "December line of a log modified in January",
+			layout:    "Jan 2 15:04:05",
+			rawTS:     "Dec 31 23:59:58",
+			reference: time.Date(2024, 1, 1, 0, 5, 0, 0, time.UTC),
+			expected:  time.Date(2023, 12, 31, 23, 59, 58, 0, time.UTC),
+		},
+		{
+			name:      "after the reference within the skew",
+			layout:    "Jan 2 15:04:05",
+			rawTS:     "Jan 1 20:00:00",
+			reference: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+			expected:  time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "layout mismatch",
+			layout: "2006-01-02 15:04:05",
+			rawTS:  "Jan 14 10:00:02",
+			err:    true,
+		},
+		{
+			name:     "auto RFC 3164",
+			layout:   TimestampLayoutAuto,
+			rawTS:    "Jan 14 10:00:02",
+			expected: time.Date(2024, 1, 14, 10, 0, 2, 0, time.Local),
+		},
+		{
+			name:     "auto RFC 3164 with fractional seconds",
+			layout:   TimestampLayoutAuto,
+			rawTS:    "Jan 14 10:00:02.250",
+			expected: time.Date(2024, 1, 14, 10, 0, 2, 250000000, time.Local),
+		},
+		{
+			name:     "auto RFC 5424",
+			layout:   TimestampLayoutAuto,
+			rawTS:    "2024-01-14T10:00:02.123456+00:00",
+			expected: time.Date(2024, 1, 14, 10, 0, 2, 123456000, time.UTC),
+		},
+		{
+			name:     "auto ISO 8601 with a zone without a colon",
+			layout:   TimestampLayoutAuto,
+			rawTS:    "2024-01-14T12:00:02+0200",
+			expected: time.Date(2024, 1, 14, 10, 0, 2, 0, time.UTC),
+		},
+		{
+			name:     "auto ISO 8601 in UTC",
+			layout:   TimestampLayoutAuto,
+			rawTS:    "2024-01-14T10:00:02Z",
+			expected: time.Date(2024, 1, 14, 10, 0, 2, 0, time.UTC),
+		},
+		{
+			name:     "auto ISO 8601 with a space and a decimal comma",
+			layout:   TimestampLayoutAuto,
+			rawTS:    "2024-01-14 10:00:02,5",
+			expected: time.Date(2024, 1, 14, 10, 0, 2, 500000000, time.Local),
+		},
+		{
+			name:   "auto unknown layout",
+			layout: TimestampLayoutAuto,
+			rawTS:  "14/01/2024 10:00:02",
+			err:    true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.reference.IsZero() {
+				tc.reference = reference
+			}
+			ts, err := ParseRawTimestamp(tc.layout, tc.rawTS, tc.reference)
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected an error parsing %q, got %s", tc.rawTS, ts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to parse %q: %v", tc.rawTS, err)
+			}
+			if !ts.Equal(tc.expected) {
+				t.Errorf("expected %s, got %s", tc.expected, ts)
+			}
+		})
+	}
+}
+
+func TestSyslogTimestampFormat(t *testing.T) {
+	for _, tc := range []struct {
+		line     string
+		expected string
+	}{
+		{line: "Jan 14 10:00:02 ip-192-168-23-248 systemd[1]: Started kubelet.service", expected: "Jan 14 10:00:02"},
+		{line: "Jan  4 10:00:02 ip-192-168-23-248 systemd[1]: Started kubelet.service", expected: "Jan  4 10:00:02"},
+		{line: "<30>1 2024-01-14T10:00:02.123456+00:00 ip-192-168-23-248 systemd 1 - - Started", expected: "2024-01-14T10:00:02.123456+00:00"},
+		{line: "2024-01-14 10:00:02,5 INFO agent ready", expected: "2024-01-14 10:00:02,5"},
+		{line: "agent ready"},
+	} {
+		if rawTS := SyslogTimestampFormat.FindString(tc.line); rawTS != tc.expected {
+			t.Errorf("expected timestamp %q in %q, got %q", tc.expected, tc.line, rawTS)
+		}
+	}
+}
@@ -10,4 +10,4 @@ aws cloudformation deploy \
   --stack-name "${CLUSTER_NAME}-node-latency-for-k8s" \
   --template-file "${SCRIPTPATH}/cloudformation.yaml" \
   --capabilities CAPABILITY_NAMED_IAM \
-  --parameter-overrides "ClusterName=${CLUSTER_NAME}"
+  --parameter-overrides "ClusterName=${CLUSTER_NAME}" "S3Bucket=${S3_BUCKET}" "S3Prefix=${S3_PREFIX}" "EMFLogGroup=${CLOUDWATCH_EMF_LOG_GROUP}"